@@ -11,15 +11,22 @@ cd /d "%ROOT_DIR%"
 echo.
 echo Cleaning up running processes...
 taskkill /F /IM OlicanaPlot.exe /T >nul 2>&1
+taskkill /F /IM arma_simulator.exe /T >nul 2>&1
+taskkill /F /IM batch_csv_exporter.exe /T >nul 2>&1
 taskkill /F /IM csv_reader.exe /T >nul 2>&1
+taskkill /F /IM csv_writer.exe /T >nul 2>&1
+taskkill /F /IM hdf5_reader.exe /T >nul 2>&1
+taskkill /F /IM json_reader.exe /T >nul 2>&1
 taskkill /F /IM model_selector.exe /T >nul 2>&1
 taskkill /F /IM olicanaplot_reader.exe /T >nul 2>&1
+taskkill /F /IM proc_monitor.exe /T >nul 2>&1
 taskkill /F /IM random_walk_generator.exe /T >nul 2>&1
+taskkill /F /IM signal_generator.exe /T >nul 2>&1
 taskkill /F /IM synthetic_data_generator.exe /T >nul 2>&1
 echo Done.
 
 echo.
-echo [1/6] Building Main Application...
+echo [1/13] Building Main Application...
 call wails3 build
 if %errorlevel% neq 0 (
     echo Error building main application.
@@ -27,7 +34,7 @@ if %errorlevel% neq 0 (
 )
 
 echo.
-echo [2/6] Building Random Walk Generator (C++ Plugin)...
+echo [2/13] Building Random Walk Generator (C++ Plugin)...
 cd /d "%ROOT_DIR%plugins\random_walk_generator"
 if exist build.bat (
     call build.bat
@@ -36,7 +43,7 @@ if exist build.bat (
 )
 
 echo.
-echo [3/6] Building CSV IPC (Go Plugin)...
+echo [3/13] Building CSV IPC (Go Plugin)...
 cd /d "%ROOT_DIR%plugins\csv_reader"
 if exist build.bat (
     call build.bat
@@ -45,7 +52,7 @@ if exist build.bat (
 )
 
 echo.
-echo [4/6] Building Synthetic Data Generator (Wails Plugin)...
+echo [4/13] Building Synthetic Data Generator (Wails Plugin)...
 cd /d "%ROOT_DIR%plugins\synthetic_data_generator"
 call wails3 build
 if %errorlevel% neq 0 (
@@ -53,7 +60,7 @@ if %errorlevel% neq 0 (
 )
 
 echo.
-echo [5/6] Building Model Selector (Go IPC Plugin)...
+echo [5/13] Building Model Selector (Go IPC Plugin)...
 cd /d "%ROOT_DIR%plugins\model_selector"
 go build -o model_selector.exe main.go
 if %errorlevel% neq 0 (
@@ -61,13 +68,76 @@ if %errorlevel% neq 0 (
 )
 
 echo.
-echo [6/6] Building OlicanaPlot Reader (Go IPC Plugin)...
+echo [6/13] Building OlicanaPlot Reader (Go IPC Plugin)...
 cd /d "%ROOT_DIR%plugins\olicanaplot_reader"
 go build -o olicanaplot_reader.exe main.go
 if %errorlevel% neq 0 (
     echo Warning: Error building olicanaplot_reader.
 )
 
+echo.
+echo [7/13] Building JSON IPC (Go Plugin)...
+cd /d "%ROOT_DIR%plugins\json_reader"
+if exist build.bat (
+    call build.bat
+) else (
+    echo Warning: json_reader\build.bat not found.
+)
+
+echo.
+echo [8/13] Building ARMA Simulator (Go IPC Plugin)...
+cd /d "%ROOT_DIR%plugins\arma_simulator"
+if exist build.bat (
+    call build.bat
+) else (
+    echo Warning: arma_simulator\build.bat not found.
+)
+
+echo.
+echo [9/13] Building Signal Generator (Go IPC Plugin)...
+cd /d "%ROOT_DIR%plugins\signal_generator"
+if exist build.bat (
+    call build.bat
+) else (
+    echo Warning: signal_generator\build.bat not found.
+)
+
+echo.
+echo [10/13] Building Process Monitor (Go IPC Plugin)...
+cd /d "%ROOT_DIR%plugins\proc_monitor"
+if exist build.bat (
+    call build.bat
+) else (
+    echo Warning: proc_monitor\build.bat not found.
+)
+
+echo.
+echo [11/13] Building HDF5 Reader (Go IPC Plugin, requires CGO)...
+cd /d "%ROOT_DIR%plugins\hdf5_reader"
+if exist build.bat (
+    call build.bat
+) else (
+    echo Warning: hdf5_reader\build.bat not found.
+)
+
+echo.
+echo [12/13] Building Batch CSV Exporter (Go IPC Plugin)...
+cd /d "%ROOT_DIR%plugins\batch_csv_exporter"
+if exist build.bat (
+    call build.bat
+) else (
+    echo Warning: batch_csv_exporter\build.bat not found.
+)
+
+echo.
+echo [13/13] Building CSV Writer (Go IPC Plugin)...
+cd /d "%ROOT_DIR%plugins\csv_writer"
+if exist build.bat (
+    call build.bat
+) else (
+    echo Warning: csv_writer\build.bat not found.
+)
+
 echo.
 echo Running Synchronization Tests...
 cd /d "%ROOT_DIR%"
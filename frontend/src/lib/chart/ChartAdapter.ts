@@ -18,6 +18,8 @@ export interface AxisConfig {
   unit?: string;
   min?: number;
   max?: number;
+  scale_factor?: number; // Values are displayed as value * scale_factor + scale_offset
+  scale_offset?: number;
 }
 
 // AxisGroupConfig describes all axes and series for one subplot cell.
@@ -35,6 +37,9 @@ export interface ChartConfig {
   axes: AxisGroupConfig[];
   link_x?: boolean;
   link_y?: boolean;
+  line_width_default?: number;
+  subplot_titles?: string[];
+  colorscale?: string; // e.g. "viridis", for series with color_values
 }
 
 // Define the structure for a single data series to be plotted, including its
@@ -53,6 +58,12 @@ export interface SeriesConfig {
   visible: boolean;
   unit?: string;
   y_axis?: string; // references Y axis title
+  opacity?: number; // 0.0-1.0, defaults to 1.0
+  description?: string; // Shown in the series tooltip
+  load_priority?: number; // Higher priorities are fetched first
+  refresh_interval_ms?: number; // Polling interval of /ws/series_stream
+  color_scale_column?: string; // Series whose Y values color the points
+  color_values?: Float64Array; // From /api/colorscale_data, one per point
 }
 
 // Define the standardized structure for context menu events across chart
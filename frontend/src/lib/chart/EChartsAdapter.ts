@@ -8,6 +8,7 @@ import {
   type ChartConfig,
   getCSSVar,
 } from "./ChartAdapter.ts";
+import { colorAt, valueRange } from "./colorscale.ts";
 
 // ECharts implementation of ChartAdapter. Implements true subplots by using
 // multiple grid objects stacked vertically.
@@ -233,27 +234,42 @@ export class EChartsAdapter extends ChartAdapter {
     const series = seriesArr.map((s, i) => {
       const cellId = `${s.subplot.row},${s.subplot.col}`;
       const cellIdx = cellToIndexMap[cellId];
-      const echartSymbol = (s.marker_type === "square" ? "rect" : s.marker_type) || "circle";
+
+      // Points colored by a color scale need symbols to show it, and are
+      // drawn one by one, as large mode and sampling would lose their colors
+      const values = s.color_values;
+      const hasColors = !!values && values.length === s.data.length / 2;
+      const [lo, hi] = hasColors ? valueRange(values!) : [0, 1];
+
+      const markerType = hasColors && s.marker_type === "none" ? "circle" : s.marker_type;
+      const echartSymbol = (markerType === "square" ? "rect" : markerType) || "circle";
       const finalSymbol = s.marker_fill === "empty" ? `empty${echartSymbol.charAt(0).toUpperCase() + echartSymbol.slice(1)}` : echartSymbol;
 
       return {
         name: s.name,
         type: "line" as const,
-        showSymbol: !!s.marker_type && s.marker_type !== "none",
+        showSymbol: hasColors || (!!s.marker_type && s.marker_type !== "none"),
         symbol: finalSymbol,
         symbolSize: s.marker_size || 8,
         datasetIndex: i,
         xAxisIndex: cellIdx,
         yAxisIndex: cellIdx,
         encode: { x: "x", y: "y" },
-        large: true,
+        large: !hasColors,
         emphasis: { disabled: true },
         color: s.color,
         lineStyle: {
           width: s.line_width,
           type: s.line_type,
+          opacity: s.opacity ?? 1,
         },
-        sampling: "lttb" as const,
+        itemStyle: {
+          opacity: s.opacity ?? 1,
+          ...(hasColors && {
+            color: (p: any) => colorAt(config.colorscale, values![p.dataIndex], lo, hi),
+          }),
+        },
+        ...(!hasColors && { sampling: "lttb" as const }),
       };
     });
 
@@ -321,6 +337,11 @@ export class EChartsAdapter extends ChartAdapter {
         textStyle: { color: textColor },
         type: "scroll" as const,
         triggerEvent: true,
+        tooltip: {
+          show: seriesArr.some((s) => s.description),
+          formatter: (p: any) =>
+            seriesArr.find((s) => s.name === p.name)?.description || p.name,
+        },
       },
       dataset: datasets,
       grid: grids,
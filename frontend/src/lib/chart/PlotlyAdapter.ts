@@ -8,6 +8,7 @@ import {
   type ChartConfig,
   getCSSVar,
 } from "./ChartAdapter.ts";
+import { plotlyColorscale } from "./colorscale.ts";
 
 // Plotly.js implementation of ChartAdapter using WebGL (scattergl).
 // Implements true subplots by dynamically partitioning the Y domain.
@@ -68,7 +69,7 @@ export class PlotlyAdapter extends ChartAdapter {
       grid.cols,
     );
 
-    const traces = this.createTraces(seriesArr, cellToAxisMap, xAxisTypes, yAxisTypes);
+    const traces = this.createTraces(seriesArr, cellToAxisMap, xAxisTypes, yAxisTypes, config.colorscale);
     const layout = this.createBaseLayout(
       title,
       getGridRight(seriesArr),
@@ -136,7 +137,8 @@ export class PlotlyAdapter extends ChartAdapter {
     seriesArr: SeriesConfig[],
     cellToAxisMap: Record<string, any>,
     xAxisTypes: Record<string, string>,
-    yAxisTypes: Record<string, string>
+    yAxisTypes: Record<string, string>,
+    colorscale?: string,
   ) {
     return seriesArr.map((s) => {
       const pointCount = s.data.length / 2;
@@ -158,14 +160,16 @@ export class PlotlyAdapter extends ChartAdapter {
         for (let j = 0; j < pointCount; j++) yData[j] = s.data[pointCount + j] * 1000;
       }
 
-      const hasMarker = !!(s.marker_type && s.marker_type !== "none");
+      // Points colored by a color scale need markers to show it
+      const hasColors = !!s.color_values && s.color_values.length === pointCount;
+      const hasMarker = hasColors || !!(s.marker_type && s.marker_type !== "none");
       const mode = hasMarker ? "lines+markers" : "lines";
 
       // Map marker types to Plotly symbols
       const markerSymbol = s.marker_type === "square" ? "square" :
         s.marker_type === "triangle" ? "triangle-up" :
           s.marker_type === "x" ? "x" :
-            (s.marker_type || "circle");
+            ((s.marker_type !== "none" && s.marker_type) || "circle");
 
       return {
         x: xData,
@@ -184,12 +188,15 @@ export class PlotlyAdapter extends ChartAdapter {
         // This prevents Plotly's WebGL scatter from choking on undefined/null objects.
         ...(hasMarker && {
           marker: {
-            color: s.color,
+            color: hasColors ? s.color_values : s.color,
+            ...(hasColors && { colorscale: plotlyColorscale(colorscale), showscale: true }),
             size: s.marker_size,
             symbol: s.marker_fill === "empty" ? `${markerSymbol}-open` : markerSymbol,
           }
         }),
-        hoverinfo: "x+y+name",
+        opacity: s.opacity ?? 1,
+        hoverinfo: s.description ? "x+y+name+text" : "x+y+name",
+        ...(s.description && { text: s.description }),
       };
     });
   }
@@ -0,0 +1,49 @@
+// Continuous color scales named by ChartConfig.colorscale, as evenly spaced
+// color stops from the lowest value to the highest.
+const colorscales: Record<string, string[]> = {
+  viridis: ["#440154", "#482878", "#3e4989", "#31688e", "#26828e", "#1f9e89", "#35b779", "#6ece58", "#b5de2b", "#fde725"],
+  plasma: ["#0d0887", "#46039f", "#7201a8", "#9c179e", "#bd3786", "#d8576b", "#ed7953", "#fb9f3a", "#fdca26", "#f0f921"],
+  hot: ["#0b0000", "#4d0000", "#900000", "#d20000", "#ff1700", "#ff5a00", "#ff9d00", "#ffe000", "#ffff50", "#ffffff"],
+};
+
+// Return the color stops of the named scale, viridis for unknown names.
+function stops(name?: string): string[] {
+  return colorscales[(name || "").toLowerCase()] ?? colorscales.viridis;
+}
+
+// Return the named scale as Plotly colorscale pairs of position and color.
+export function plotlyColorscale(name?: string): [number, string][] {
+  const s = stops(name);
+  return s.map((c, i) => [i / (s.length - 1), c]);
+}
+
+// Return the lowest and highest finite values, or 0 and 1 if there are none.
+export function valueRange(values: Float64Array): [number, number] {
+  let lo = Infinity, hi = -Infinity;
+  for (const v of values) {
+    if (Number.isFinite(v)) {
+      lo = Math.min(lo, v);
+      hi = Math.max(hi, v);
+    }
+  }
+  return lo <= hi ? [lo, hi] : [0, 1];
+}
+
+// Color of values that aren't finite, such as the NaN of a gap.
+const neutralColor = "rgb(128, 128, 128)";
+
+// Return the color of value on the named scale, between lo and hi, or a
+// neutral grey if value isn't finite.
+export function colorAt(name: string | undefined, value: number, lo: number, hi: number): string {
+  if (!Number.isFinite(value)) {
+    return neutralColor;
+  }
+  const s = stops(name);
+  const t = hi > lo ? Math.min(Math.max((value - lo) / (hi - lo), 0), 1) : 0;
+  const pos = t * (s.length - 1);
+  const i = Math.min(Math.floor(pos), s.length - 2);
+  const f = pos - i;
+  const a = parseInt(s[i].slice(1), 16), b = parseInt(s[i + 1].slice(1), 16);
+  const mix = (shift: number) => Math.round(((a >> shift) & 255) * (1 - f) + ((b >> shift) & 255) * f);
+  return `rgb(${mix(16)}, ${mix(8)}, ${mix(0)})`;
+}
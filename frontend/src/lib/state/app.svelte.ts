@@ -16,12 +16,27 @@ export interface AppPlugin {
     enabled: boolean;
 }
 
+// maxAgeSeconds returns the max-age of a Cache-Control header, or 0.
+function maxAgeSeconds(cacheControl: string | null): number {
+    const match = /max-age=(\d+)/.exec(cacheControl ?? "");
+    return match ? Number(match[1]) : 0;
+}
+
+// colorValues fetches the values that color a series' points, if it has a
+// color_scale_column.
+async function colorValues(series: any): Promise<Float64Array | undefined> {
+    if (!series.color_scale_column) return undefined;
+    const res = await fetch(`/api/colorscale_data?series=${series.id}`, { cache: "no-cache" });
+    return res.ok ? new Float64Array(await res.arrayBuffer()) : undefined;
+}
+
 class AppState {
     // Reactive State
     chartContainer = $state<HTMLElement | null>(null);
     chartAdapter = $state<ChartAdapter | null>(null);
     chartLibrary = $state<string>("echarts");
     loading = $state(true);
+    initProgress = $state<{ pct: number; message: string } | null>(null); // From a plugin's initialize
     error = $state<string | null>(null);
     dataSource = $state("function_generator");
     linkX = $state(true); // Default to true as it was the previous behavior
@@ -34,9 +49,11 @@ class AppState {
     currentTitle = $state("");
     axes = $state<AxisGroupConfig[]>([]);
     gridConfig = $state<GridConfig>({ rows: 1, cols: 1 });
+    colorscale = $state(""); // Of the chart config, for series with color values
     allPlugins = $state<AppPlugin[]>([]);
     showGeneratorsMenu = $state(true);
     defaultLineWidth = $state(2.0);
+    colorPalette = $state<string[]>(["#636EFA", "#EF553B", "#00CC96", "#AB63FA", "#FFA15A", "#19D3F3", "#FF6692", "#B6E880", "#FF97FF", "#FECB52"]);
 
     get hasSubplots(): boolean {
         const cells = new Set(
@@ -74,6 +91,7 @@ class AppState {
     private unsubs: (() => void)[] = [];
     private initialized = false;
     private initPromise: Promise<void> | null = null;
+    private refreshTimer: ReturnType<typeof setTimeout> | null = null;
 
     constructor() {
         this.initPromise = this.init();
@@ -87,6 +105,7 @@ class AppState {
             PluginService.LogDebug("AppState", `Config loaded, library: ${this.chartLibrary}`, "");
             this.showGeneratorsMenu = await ConfigService.GetShowGeneratorsMenu();
             this.defaultLineWidth = await ConfigService.GetDefaultLineWidth();
+            this.colorPalette = await ConfigService.GetColorPalette();
 
             const plugins = await PluginService.ListPlugins();
             this.allPlugins = plugins || [];
@@ -124,10 +143,52 @@ class AppState {
             this.defaultLineWidth = (Array.isArray(val.data) ? val.data[0] : val.data) as number;
             this.updateChart();
         }));
+        this.unsubs.push(Events.On("colorPaletteChanged", (val: any) => {
+            // Applies to series loaded from now on
+            this.colorPalette = (Array.isArray(val.data) && typeof val.data[0] !== "string" ? val.data[0] : val.data) as string[];
+        }));
+        this.unsubs.push(Events.On("configChanged", async (val: any) => {
+            // config.json was edited outside the app
+            const keys = ((Array.isArray(val.data) ? val.data[0] : val.data)?.keys ?? []) as string[];
+            if (keys.includes("theme")) {
+                this.isDarkMode = (await ConfigService.GetTheme()) === "dark";
+            }
+            if (keys.includes("showGeneratorsMenu")) {
+                this.showGeneratorsMenu = await ConfigService.GetShowGeneratorsMenu();
+            }
+            if (keys.includes("defaultLineWidth")) {
+                this.defaultLineWidth = await ConfigService.GetDefaultLineWidth();
+                this.updateChart();
+            }
+            if (keys.includes("colorPalette")) {
+                this.colorPalette = await ConfigService.GetColorPalette();
+            }
+        }));
+        this.unsubs.push(Events.On("pluginRefreshed", async (val: any) => {
+            // A file plugin reloaded its file, so refetch if it is still active
+            const name = (Array.isArray(val.data) ? val.data[0] : val.data) as string;
+            if (await PluginService.GetActivePlugin() === name) {
+                await this.loadData(this.dataSource);
+            }
+        }));
+        this.unsubs.push(Events.On("ipc-plugin-init-progress", (val: any) => {
+            const { pct, message } = (Array.isArray(val.data) ? val.data[0] : val.data) as any;
+            this.initProgress = { pct, message };
+        }));
+        this.unsubs.push(Events.On("seriesConfigChanged", (val: any) => {
+            // Restyle loaded series in place, keeping their data
+            const patched = new Map((val.data as any[]).map((s: any) => [s.id, s]));
+            this.currentSeriesData = this.currentSeriesData.map(s => {
+                const p = patched.get(s.id);
+                return p ? { ...s, ...p, data: s.data } : s;
+            });
+            this.updateChart();
+        }));
     }
 
     destroy() {
         this.unsubs.forEach(u => u());
+        if (this.refreshTimer) clearTimeout(this.refreshTimer);
     }
 
     // Chart Lifecycle
@@ -232,12 +293,12 @@ class AppState {
                 const res = await fetch(`/api/series_data?series=${series.id}&storage=${storage}`);
                 const buffer = await res.arrayBuffer();
                 const data = new Float64Array(buffer);
-                return { ...series, data };
+                return { ...series, data, color_values: await colorValues(series) };
             });
 
             const newSeriesData: SeriesConfig[] = await Promise.all(dataPromises);
 
-            const colors = ["#636EFA", "#EF553B", "#00CC96", "#AB63FA", "#FFA15A", "#19D3F3", "#FF6692", "#B6E880", "#FF97FF", "#FECB52"];
+            const colors = this.colorPalette;
             newSeriesData.forEach((s, i) => {
                 // Determine color based on existing series in this specific cell
                 const countInCell = this.currentSeriesData.filter(ser => ser.subplot.row === targetCell.row && ser.subplot.col === targetCell.col).length;
@@ -348,21 +409,30 @@ class AppState {
         if (!this.initialized && this.initPromise) {
             await this.initPromise;
         }
+        if (this.refreshTimer) {
+            clearTimeout(this.refreshTimer);
+            this.refreshTimer = null;
+        }
         this.loading = true;
         try {
             const seriesResponse = await fetch("/api/series_config");
             const seriesConfig = await seriesResponse.json();
             const storage = this.chartLibrary === "plotly" ? "arrays" : "interleaved";
 
+            // With auto-refresh on, series data carries a Cache-Control
+            // max-age after which it is fetched again. no-cache keeps the
+            // browser from answering the refresh with the old data.
+            let maxAge = 0;
             const dataPromises = seriesConfig.map(async (series: any) => {
-                const res = await fetch(`/api/series_data?series=${series.id}&storage=${storage}`);
+                const res = await fetch(`/api/series_data?series=${series.id}&storage=${storage}`, { cache: "no-cache" });
+                maxAge = maxAge || maxAgeSeconds(res.headers.get("Cache-Control"));
                 const buffer = await res.arrayBuffer();
                 const data = new Float64Array(buffer);
-                return { ...series, data };
+                return { ...series, data, color_values: await colorValues(series) };
             });
 
             const seriesData: SeriesConfig[] = await Promise.all(dataPromises);
-            const defaultColors = ["#636EFA", "#EF553B", "#00CC96", "#AB63FA", "#FFA15A", "#19D3F3", "#FF6692", "#B6E880", "#FF97FF", "#FECB52"];
+            const defaultColors = this.colorPalette;
 
             seriesData.forEach((s: any, i) => {
                 if (!s.subplot) {
@@ -382,6 +452,10 @@ class AppState {
 
             await this.fetchPluginConfig();
             this.updateChart();
+
+            if (maxAge > 0) {
+                this.refreshTimer = setTimeout(() => this.loadData(source), maxAge * 1000);
+            }
         } catch (e: any) {
             console.error("Failed to fetch data:", e);
             this.error = e.message;
@@ -411,6 +485,7 @@ class AppState {
                 if (config.link_y !== undefined && config.link_y !== null) {
                     this.linkY = config.link_y;
                 }
+                this.colorscale = config.colorscale || "";
             }
         } catch (e) {
             console.error("Failed to fetch plugin config:", e);
@@ -421,7 +496,7 @@ class AppState {
         if (!this.chartAdapter || !this.currentSeriesData) return;
 
         this.chartAdapter.update(
-            this.currentSeriesData,
+            this.scaledSeriesData(),
             this.getGridRight.bind(this),
             {
                 title: this.currentTitle,
@@ -429,10 +504,35 @@ class AppState {
                 axes: this.axes,
                 link_x: this.linkX,
                 link_y: this.linkY,
+                colorscale: this.colorscale,
             }
         );
     }
 
+    // scaledSeriesData returns the series with the scale_factor and
+    // scale_offset of their axes applied. Series on unscaled axes are
+    // returned as they are, so the raw data is never changed.
+    scaledSeriesData(): SeriesConfig[] {
+        const isArrays = this.chartLibrary === "plotly";
+        return this.currentSeriesData.map((s) => {
+            const group = this.axes.find(a => a.subplot.row === s.subplot?.row && a.subplot.col === s.subplot?.col);
+            const xAxis = group?.x_axes?.[0];
+            const yAxis = group?.y_axes?.find(a => a.title === s.y_axis) ?? group?.y_axes?.[0];
+            const [xFactor, xOffset] = [xAxis?.scale_factor || 1, xAxis?.scale_offset || 0];
+            const [yFactor, yOffset] = [yAxis?.scale_factor || 1, yAxis?.scale_offset || 0];
+            if (!s.data || (xFactor === 1 && xOffset === 0 && yFactor === 1 && yOffset === 0)) return s;
+
+            const n = s.data.length / 2;
+            const data = new Float64Array(s.data.length);
+            for (let i = 0; i < n; i++) {
+                const [xi, yi] = isArrays ? [i, n + i] : [i * 2, i * 2 + 1];
+                data[xi] = s.data[xi] * xFactor + xOffset;
+                data[yi] = s.data[yi] * yFactor + yOffset;
+            }
+            return { ...s, data };
+        });
+    }
+
     toggleLinkX() {
         this.linkX = !this.linkX;
         this.updateChart();
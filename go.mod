@@ -5,6 +5,10 @@ go 1.25
 require (
 	github.com/expr-lang/expr v1.17.7
 	github.com/wailsapp/wails/v3 v3.0.0-alpha.61
+	golang.org/x/net v0.37.0
+	golang.org/x/sys v0.33.0
+	golang.org/x/text v0.23.0
+	gonum.org/v1/gonum v0.16.0
 )
 
 require (
@@ -41,8 +45,5 @@ require (
 	github.com/wailsapp/go-webview2 v1.0.22 // indirect
 	github.com/xanzy/ssh-agent v0.3.3 // indirect
 	golang.org/x/crypto v0.36.0 // indirect
-	golang.org/x/net v0.37.0 // indirect
-	golang.org/x/sys v0.33.0 // indirect
-	golang.org/x/text v0.23.0 // indirect
 	gopkg.in/warnings.v0 v0.1.2 // indirect
 )
@@ -0,0 +1,8 @@
+//go:build !windows
+
+package appconfig
+
+// systemDPIScale returns 1.0; only Windows reports a scale at startup.
+func systemDPIScale() float64 {
+	return 1.0
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package appconfig
+
+import "golang.org/x/sys/windows"
+
+// systemDPIScale returns the system DPI relative to the 96 DPI baseline.
+// GetDpiForSystem needs Windows 10 1607 or later; older versions get 1.0.
+func systemDPIScale() float64 {
+	proc := windows.NewLazySystemDLL("user32.dll").NewProc("GetDpiForSystem")
+	if proc.Find() != nil {
+		return 1.0
+	}
+	dpi, _, _ := proc.Call()
+	if dpi == 0 {
+		return 1.0
+	}
+	return float64(dpi) / 96
+}
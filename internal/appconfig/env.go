@@ -0,0 +1,105 @@
+package appconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+
+	"olicanaplot/internal/logging"
+)
+
+// envOverride is an environment variable that overrides a setting, named by
+// its configData field.
+type envOverride struct {
+	name  string
+	field string
+}
+
+// envOverrideTable lists the supported environment variables. Lists, such as
+// OLICANA_PLUGIN_SEARCH_DIRS, are separated like PATH.
+var envOverrideTable = []envOverride{
+	{"OLICANA_LOG_PATH", "LogPath"},
+	{"OLICANA_CHART_LIBRARY", "ChartLibrary"},
+	{"OLICANA_THEME", "Theme"},
+	{"OLICANA_LOG_LEVEL", "LogLevel"},
+	{"OLICANA_SHOW_GENERATORS_MENU", "ShowGeneratorsMenu"},
+	{"OLICANA_DEFAULT_LINE_WIDTH", "DefaultLineWidth"},
+	{"OLICANA_PLUGIN_SEARCH_DIRS", "PluginSearchDirs"},
+	{"OLICANA_CSV_PARSE_MODE", "CSVParseMode"},
+	{"OLICANA_SANDBOX_IPC", "SandboxIPC"},
+	{"OLICANA_DIALOG_TIMEOUT_SECONDS", "DialogTimeoutSeconds"},
+	{"OLICANA_AUTO_REFRESH_SECONDS", "AutoRefreshSeconds"},
+	{"OLICANA_IPC_BUFFER_SIZE", "IPCBufferSize"},
+	{"OLICANA_LOCALE", "Locale"},
+}
+
+// set parses value into the overridden field of cfg.
+func (o envOverride) set(cfg *configData, value string) error {
+	f := reflect.ValueOf(cfg).Elem().FieldByName(o.field)
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		f.SetInt(int64(n))
+	case reflect.Float64:
+		x, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		f.SetFloat(x)
+	case reflect.Slice:
+		f.Set(reflect.ValueOf(filepath.SplitList(value)))
+	default:
+		return fmt.Errorf("unsupported setting type %s", f.Type())
+	}
+	return nil
+}
+
+// restore copies the overridden field from src to dst.
+func (o envOverride) restore(dst, src *configData) {
+	reflect.ValueOf(dst).Elem().FieldByName(o.field).Set(reflect.ValueOf(src).Elem().FieldByName(o.field))
+}
+
+// loadConfigFromEnv overrides the loaded settings with the environment
+// variables in envOverrideTable that are set. Invalid values are logged and
+// ignored.
+func (s *ConfigService) loadConfigFromEnv() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.applyEnvOverridesLocked()
+}
+
+// applyEnvOverridesLocked implements loadConfigFromEnv. Callers must hold
+// s.mu.
+func (s *ConfigService) applyEnvOverridesLocked() {
+	s.fileConfig = s.currentConfig()
+	s.envOverrides = nil
+
+	cfg := s.fileConfig
+	for _, o := range envOverrideTable {
+		value := os.Getenv(o.name)
+		if value == "" {
+			continue
+		}
+		if err := o.set(&cfg, value); err != nil {
+			logging.NewLogger("Config").Warn("Ignoring invalid environment variable", "name", o.name, "value", value, "error", err)
+			continue
+		}
+		s.envOverrides = append(s.envOverrides, o)
+	}
+	if len(s.envOverrides) > 0 {
+		s.applyConfig(cfg)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package appconfig
+
+import "os"
+
+// systemLocale returns the locale from the environment, e.g. "fr_FR.UTF-8",
+// in the order of precedence POSIX gives LC_ALL, LC_MESSAGES and LANG.
+func systemLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,26 @@
+//go:build windows
+
+package appconfig
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// localeNameMaxLength is LOCALE_NAME_MAX_LENGTH, including the terminator.
+const localeNameMaxLength = 85
+
+// systemLocale returns the user's default locale name, e.g. "fr-FR".
+func systemLocale() string {
+	proc := windows.NewLazySystemDLL("kernel32.dll").NewProc("GetUserDefaultLocaleName")
+	if proc.Find() != nil {
+		return ""
+	}
+	buf := make([]uint16, localeNameMaxLength)
+	n, _, _ := proc.Call(uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	if n == 0 {
+		return ""
+	}
+	return windows.UTF16ToString(buf)
+}
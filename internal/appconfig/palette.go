@@ -0,0 +1,52 @@
+package appconfig
+
+import "slices"
+
+// defaultColorPalette names the palette used until the user picks another.
+const defaultColorPalette = "plotly"
+
+// colorPalettes are the built-in series color palettes, offered by name in
+// the options window.
+var colorPalettes = map[string][]string{
+	// Plotly's default qualitative palette
+	"plotly": {"#636EFA", "#EF553B", "#00CC96", "#AB63FA", "#FFA15A", "#19D3F3", "#FF6692", "#B6E880", "#FF97FF", "#FECB52"},
+	// Okabe and Ito's palette, distinguishable with all common forms of
+	// colour blindness
+	"okabe-ito": {"#E69F00", "#56B4E9", "#009E73", "#F0E442", "#0072B2", "#D55E00", "#CC79A7", "#000000"},
+	// Paul Tol's bright palette, colour-blind safe and readable in print
+	"accessible": {"#4477AA", "#EE6677", "#228833", "#CCBB44", "#66CCEE", "#AA3377", "#BBBBBB"},
+}
+
+// GetColorPalettes returns the built-in color palettes by name.
+func (s *ConfigService) GetColorPalettes() map[string][]string {
+	palettes := make(map[string][]string, len(colorPalettes))
+	for name, colors := range colorPalettes {
+		palettes[name] = slices.Clone(colors)
+	}
+	return palettes
+}
+
+// GetColorPalette returns the colors given in turn to series that don't set
+// their own.
+func (s *ConfigService) GetColorPalette() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.colorPalette) == 0 {
+		return slices.Clone(colorPalettes[defaultColorPalette])
+	}
+	return slices.Clone(s.colorPalette)
+}
+
+// SetColorPalette updates the series color palette and notifies listeners.
+// An empty palette restores the default.
+func (s *ConfigService) SetColorPalette(colors []string) {
+	s.mu.Lock()
+	s.colorPalette = slices.Clone(colors)
+	app := s.app
+	s.mu.Unlock()
+	s.saveConfig()
+
+	if app != nil {
+		app.Event.Emit("colorPaletteChanged", s.GetColorPalette())
+	}
+}
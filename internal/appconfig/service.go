@@ -7,8 +7,11 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strings"
 	"sync"
 
+	"olicanaplot/internal/funceval"
 	"olicanaplot/internal/logging"
 
 	"github.com/wailsapp/wails/v3/pkg/application"
@@ -16,19 +19,42 @@ import (
 
 // ConfigService handles application configuration and settings.
 type ConfigService struct {
-	mu                 sync.RWMutex
-	app                *application.App
-	optionsWindow      *application.WebviewWindow
-	configPath         string
-	logPath            string
-	chartLibrary       string
-	theme              string
-	logLevel           string
-	disabledPlugins    []string
-	showGeneratorsMenu bool
-	defaultLineWidth   float64
-	functionPresets    []FunctionPreset
-	pluginSearchDirs   []string
+	mu                   sync.RWMutex
+	app                  *application.App
+	optionsWindow        *application.WebviewWindow
+	configPath           string
+	logPath              string
+	chartLibrary         string
+	theme                string
+	logLevel             string
+	disabledPlugins      []string
+	showGeneratorsMenu   bool
+	defaultLineWidth     float64
+	colorPalette         []string // Series colors, the default palette if empty
+	functionPresets      []FunctionPreset
+	pluginSearchDirs     []string
+	discoverPlugins      func(dir string) error // Registers the plugins of an added search directory
+	csvParseMode         string
+	sandboxIPC           bool
+	dialogTimeoutSeconds int     // How long IPC plugin dialogs wait for the user
+	autoRefreshSeconds   int     // How often file plugins re-read a changed file and series are re-fetched, 0 disables
+	ipcBufferSize        int     // Bytes buffered when reading IPC plugin output
+	dpiScale             float64 // System DPI relative to 96, read at startup
+	locale               string  // User's choice, e.g. "fr-FR", or "" to follow the OS
+	systemLocale         string  // OS locale read at startup, "" if unknown
+
+	// envOverrides are the settings overridden by environment variables.
+	// They are saved with their values from before, kept in fileConfig.
+	envOverrides []envOverride
+	fileConfig   configData
+
+	stopWatch chan struct{} // Closed to stop WatchConfig, nil if not watching
+
+	// saveMu serializes saves, and WatchConfig's checks against them, so
+	// that the watcher never sees a save half done. lastSaved is what the
+	// last save wrote to config.json, and is guarded by saveMu.
+	saveMu    sync.Mutex
+	lastSaved []byte
 }
 
 // FunctionPreset represents a user-saved function configuration
@@ -42,15 +68,22 @@ type FunctionPreset struct {
 
 // configData is the structure we save to disk
 type configData struct {
-	LogPath            string           `json:"logPath"`
-	ChartLibrary       string           `json:"chartLibrary"`
-	Theme              string           `json:"theme"`
-	LogLevel           string           `json:"logLevel"`
-	DisabledPlugins    []string         `json:"disabledPlugins"`
-	ShowGeneratorsMenu bool             `json:"showGeneratorsMenu"`
-	DefaultLineWidth   float64          `json:"defaultLineWidth"`
-	FunctionPresets    []FunctionPreset `json:"functionPresets"`
-	PluginSearchDirs   []string         `json:"pluginSearchDirs"`
+	LogPath              string           `json:"logPath"`
+	ChartLibrary         string           `json:"chartLibrary"`
+	Theme                string           `json:"theme"`
+	LogLevel             string           `json:"logLevel"`
+	DisabledPlugins      []string         `json:"disabledPlugins"`
+	ShowGeneratorsMenu   bool             `json:"showGeneratorsMenu"`
+	DefaultLineWidth     float64          `json:"defaultLineWidth"`
+	ColorPalette         []string         `json:"colorPalette,omitempty"`
+	FunctionPresets      []FunctionPreset `json:"functionPresets"`
+	PluginSearchDirs     []string         `json:"pluginSearchDirs"`
+	CSVParseMode         string           `json:"csvParseMode"`
+	SandboxIPC           bool             `json:"sandboxIPC"`
+	DialogTimeoutSeconds int              `json:"dialogTimeoutSeconds"`
+	AutoRefreshSeconds   int              `json:"autoRefreshSeconds"`
+	IPCBufferSize        int              `json:"ipcBufferSize"`
+	Locale               string           `json:"locale,omitempty"`
 }
 
 // NewConfigService creates a new config service with default values.
@@ -67,16 +100,22 @@ func NewConfigService() *ConfigService {
 	os.MkdirAll(appDir, 0755)
 
 	s := &ConfigService{
-		configPath:         filepath.Join(appDir, "config.json"),
-		logPath:            filepath.Join(appDir, "olicana.log"),
-		chartLibrary:       "echarts", // Default to ECharts
-		theme:              "light",   // Default to light
-		logLevel:           "info",    // Default to info
-		showGeneratorsMenu: true,      // Default to true
-		defaultLineWidth:   2.0,       // Default to 2.0
+		configPath:           filepath.Join(appDir, "config.json"),
+		logPath:              filepath.Join(appDir, "olicana.log"),
+		chartLibrary:         "echarts", // Default to ECharts
+		theme:                "light",   // Default to light
+		logLevel:             "info",    // Default to info
+		showGeneratorsMenu:   true,      // Default to true
+		defaultLineWidth:     2.0,       // Default to 2.0
+		csvParseMode:         "full",    // Default to reading the whole file
+		dialogTimeoutSeconds: 300,       // Default to 5 minutes
+		ipcBufferSize:        64 << 10,  // Default to 64 KB
+		dpiScale:             systemDPIScale(),
+		systemLocale:         normalizeLocale(systemLocale()),
 	}
 
 	s.loadConfig()
+	s.loadConfigFromEnv()
 	return s
 }
 
@@ -106,17 +145,39 @@ func (s *ConfigService) OpenOptions() {
 	})
 }
 
-func (s *ConfigService) loadConfig() {
-	data, err := os.ReadFile(s.configPath)
-	if err != nil {
-		return // File might not exist yet, use defaults
-	}
+// backupPath returns the location of the previous good config file.
+func (s *ConfigService) backupPath() string {
+	return s.configPath + ".bak"
+}
 
+// readConfigFile reads and parses a config file.
+func readConfigFile(path string) (configData, error) {
 	var cfg configData
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
 	if err := json.Unmarshal(data, &cfg); err != nil {
-		return
+		return cfg, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// loadConfig reads config.json, falling back to config.json.bak if it is
+// missing or corrupt.
+func (s *ConfigService) loadConfig() {
+	cfg, err := readConfigFile(s.configPath)
+	if err != nil {
+		cfg, err = readConfigFile(s.backupPath())
+		if err != nil {
+			return // File might not exist yet, use defaults
+		}
 	}
+	s.applyConfig(cfg)
+}
 
+// applyConfig copies loaded settings into the service.
+func (s *ConfigService) applyConfig(cfg configData) {
 	if cfg.LogPath != "" {
 		s.logPath = cfg.LogPath
 	}
@@ -136,25 +197,60 @@ func (s *ConfigService) loadConfig() {
 	} else {
 		s.defaultLineWidth = 2.0
 	}
+	s.colorPalette = cfg.ColorPalette
 
 	// Apply log level
 	logging.SetLevel(s.logLevel)
 	s.functionPresets = cfg.FunctionPresets
 	s.pluginSearchDirs = cfg.PluginSearchDirs
+	if cfg.CSVParseMode != "" {
+		s.csvParseMode = cfg.CSVParseMode
+	}
+	s.sandboxIPC = cfg.SandboxIPC
+	if cfg.DialogTimeoutSeconds > 0 {
+		s.dialogTimeoutSeconds = cfg.DialogTimeoutSeconds
+	}
+	s.autoRefreshSeconds = max(cfg.AutoRefreshSeconds, 0)
+	if cfg.IPCBufferSize > 0 {
+		s.ipcBufferSize = cfg.IPCBufferSize
+	}
+	s.locale = normalizeLocale(cfg.Locale)
+}
+
+// currentConfig returns the settings to save. Callers must hold s.mu.
+func (s *ConfigService) currentConfig() configData {
+	return configData{
+		LogPath:              s.logPath,
+		ChartLibrary:         s.chartLibrary,
+		Theme:                s.theme,
+		LogLevel:             s.logLevel,
+		DisabledPlugins:      s.disabledPlugins,
+		ShowGeneratorsMenu:   s.showGeneratorsMenu,
+		DefaultLineWidth:     s.defaultLineWidth,
+		ColorPalette:         s.colorPalette,
+		FunctionPresets:      s.functionPresets,
+		PluginSearchDirs:     s.pluginSearchDirs,
+		CSVParseMode:         s.csvParseMode,
+		SandboxIPC:           s.sandboxIPC,
+		DialogTimeoutSeconds: s.dialogTimeoutSeconds,
+		AutoRefreshSeconds:   s.autoRefreshSeconds,
+		IPCBufferSize:        s.ipcBufferSize,
+		Locale:               s.locale,
+	}
 }
 
+// saveConfig writes the config to config.json.tmp and renames it over
+// config.json, so a crash mid-write never leaves a truncated config. The
+// previous config.json is kept as config.json.bak. Settings overridden by
+// environment variables keep their previous values.
 func (s *ConfigService) saveConfig() {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
 	s.mu.RLock()
-	cfg := configData{
-		LogPath:            s.logPath,
-		ChartLibrary:       s.chartLibrary,
-		Theme:              s.theme,
-		LogLevel:           s.logLevel,
-		DisabledPlugins:    s.disabledPlugins,
-		ShowGeneratorsMenu: s.showGeneratorsMenu,
-		DefaultLineWidth:   s.defaultLineWidth,
-		FunctionPresets:    s.functionPresets,
-		PluginSearchDirs:   s.pluginSearchDirs,
+	cfg := s.currentConfig()
+	for _, o := range s.envOverrides {
+		o.restore(&cfg, &s.fileConfig)
 	}
 	s.mu.RUnlock()
 
@@ -163,7 +259,47 @@ func (s *ConfigService) saveConfig() {
 		return
 	}
 
-	os.WriteFile(s.configPath, data, 0644)
+	tmpPath := s.configPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return
+	}
+	// Only a config that still parses is worth keeping as the backup
+	if _, err := readConfigFile(s.configPath); err == nil {
+		os.Rename(s.configPath, s.backupPath())
+	}
+	if os.Rename(tmpPath, s.configPath) == nil {
+		// So that WatchConfig does not reload the app's own save
+		s.lastSaved = data
+	}
+}
+
+// RestoreBackup replaces config.json with config.json.bak and reloads it.
+func (s *ConfigService) RestoreBackup() error {
+	data, err := os.ReadFile(s.backupPath())
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+	var cfg configData
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("failed to parse backup: %w", err)
+	}
+
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+	tmpPath := s.configPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.configPath); err != nil {
+		return fmt.Errorf("failed to restore config: %w", err)
+	}
+	s.lastSaved = data
+
+	s.mu.Lock()
+	s.applyConfig(cfg)
+	s.applyEnvOverridesLocked()
+	s.mu.Unlock()
+	return nil
 }
 
 // GetLogPath returns the current log path.
@@ -306,7 +442,6 @@ func (s *ConfigService) GetFunctionPresets() []FunctionPreset {
 // AddFunctionPreset adds or updates a function preset.
 func (s *ConfigService) AddFunctionPreset(preset FunctionPreset) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 
 	// Update existing if name matches
 	found := false
@@ -321,6 +456,7 @@ func (s *ConfigService) AddFunctionPreset(preset FunctionPreset) {
 	if !found {
 		s.functionPresets = append(s.functionPresets, preset)
 	}
+	s.mu.Unlock()
 
 	s.saveConfig()
 }
@@ -328,7 +464,6 @@ func (s *ConfigService) AddFunctionPreset(preset FunctionPreset) {
 // RemoveFunctionPreset deletes a preset by name.
 func (s *ConfigService) RemoveFunctionPreset(name string) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 
 	newPresets := make([]FunctionPreset, 0, len(s.functionPresets))
 	for _, p := range s.functionPresets {
@@ -337,7 +472,42 @@ func (s *ConfigService) RemoveFunctionPreset(name string) {
 		}
 	}
 	s.functionPresets = newPresets
+	s.mu.Unlock()
+	s.saveConfig()
+}
+
+// ImportPresets adds or updates presets exported from elsewhere, e.g. an
+// engineering notebook. Expressions may use numpy syntax and are converted
+// with funceval.ImportNumpyExpression. Nothing is imported if any expression
+// fails to convert.
+func (s *ConfigService) ImportPresets(presets []FunctionPreset) error {
+	converted := make([]FunctionPreset, len(presets))
+	for i, preset := range presets {
+		expr, err := funceval.ImportNumpyExpression(preset.Expression)
+		if err != nil {
+			return fmt.Errorf("preset %q: %w", preset.Name, err)
+		}
+		preset.Expression = expr
+		converted[i] = preset
+	}
+
+	s.mu.Lock()
+	for _, preset := range converted {
+		found := false
+		for i, p := range s.functionPresets {
+			if p.Name == preset.Name {
+				s.functionPresets[i] = preset
+				found = true
+				break
+			}
+		}
+		if !found {
+			s.functionPresets = append(s.functionPresets, preset)
+		}
+	}
+	s.mu.Unlock()
 	s.saveConfig()
+	return nil
 }
 
 // OpenLogFile opens the current log file in the OS default text editor.
@@ -401,3 +571,187 @@ func (s *ConfigService) SetPluginSearchDirs(dirs []string) {
 		app.Event.Emit("pluginSearchDirsChanged", dirs)
 	}
 }
+
+// SetPluginDiscoverer sets the function AddPluginSearchDir calls to register
+// the plugins of a new directory, which discovers IPC plugins in it. The
+// loader and manager live in packages that import this one, so they are
+// wired in by the caller.
+func (s *ConfigService) SetPluginDiscoverer(discover func(dir string) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.discoverPlugins = discover
+}
+
+// AddPluginSearchDir adds a directory to search for plugins and registers the
+// plugins found in it, without restarting. Adding a directory that is already
+// searched only looks for new plugins in it again.
+func (s *ConfigService) AddPluginSearchDir(dir string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("invalid plugin directory %q: %w", dir, err)
+	}
+	if info, err := os.Stat(abs); err != nil {
+		return fmt.Errorf("invalid plugin directory: %w", err)
+	} else if !info.IsDir() {
+		return fmt.Errorf("invalid plugin directory: %s is not a directory", abs)
+	}
+
+	s.mu.Lock()
+	added := !slices.Contains(s.pluginSearchDirs, abs)
+	if added {
+		s.pluginSearchDirs = append(slices.Clone(s.pluginSearchDirs), abs)
+	}
+	dirs := slices.Clone(s.pluginSearchDirs)
+	app, discover := s.app, s.discoverPlugins
+	s.mu.Unlock()
+
+	if added {
+		s.saveConfig()
+		if app != nil {
+			app.Event.Emit("pluginSearchDirsChanged", dirs)
+		}
+	}
+	if discover == nil {
+		return nil
+	}
+	if err := discover(abs); err != nil {
+		return fmt.Errorf("failed to load plugins from %s: %w", abs, err)
+	}
+	return nil
+}
+
+// GetCSVParseMode returns how the CSV connector parses files ("full" or "stream").
+func (s *ConfigService) GetCSVParseMode() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.csvParseMode
+}
+
+// SetCSVParseMode sets how the CSV connector parses files ("full" or "stream").
+func (s *ConfigService) SetCSVParseMode(mode string) {
+	s.mu.Lock()
+	s.csvParseMode = mode
+	s.mu.Unlock()
+	s.saveConfig()
+}
+
+// GetSandboxIPC returns whether IPC plugins run in an OS-level sandbox.
+func (s *ConfigService) GetSandboxIPC() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sandboxIPC
+}
+
+// SetSandboxIPC enables or disables the IPC plugin sandbox (Linux only).
+// Takes effect after an application restart.
+func (s *ConfigService) SetSandboxIPC(enabled bool) {
+	s.mu.Lock()
+	s.sandboxIPC = enabled
+	s.mu.Unlock()
+	s.saveConfig()
+}
+
+// GetDialogTimeoutSeconds returns how long IPC plugin dialogs wait for the user.
+func (s *ConfigService) GetDialogTimeoutSeconds() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.dialogTimeoutSeconds
+}
+
+// SetDialogTimeoutSeconds sets how long IPC plugin dialogs wait for the user.
+// Takes effect after an application restart.
+func (s *ConfigService) SetDialogTimeoutSeconds(seconds int) {
+	s.mu.Lock()
+	s.dialogTimeoutSeconds = seconds
+	s.mu.Unlock()
+	s.saveConfig()
+}
+
+// GetAutoRefreshSeconds returns how often file plugins check their file for
+// changes and reload it, and the frontend re-fetches series data. 0 means
+// auto-refresh is off.
+func (s *ConfigService) GetAutoRefreshSeconds() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.autoRefreshSeconds
+}
+
+// SetAutoRefreshSeconds sets the auto-refresh interval. 0 turns it off.
+func (s *ConfigService) SetAutoRefreshSeconds(seconds int) {
+	s.mu.Lock()
+	s.autoRefreshSeconds = max(seconds, 0)
+	s.mu.Unlock()
+	s.saveConfig()
+}
+
+// GetIPCBufferSize returns how many bytes of IPC plugin output are read at a
+// time. Larger buffers read binary series data in fewer system calls.
+func (s *ConfigService) GetIPCBufferSize() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.ipcBufferSize
+}
+
+// SetIPCBufferSize sets the IPC read buffer size in bytes. Sizes that are not
+// positive are ignored. Takes effect after an application restart.
+func (s *ConfigService) SetIPCBufferSize(size int) {
+	if size <= 0 {
+		return
+	}
+	s.mu.Lock()
+	s.ipcBufferSize = size
+	s.mu.Unlock()
+	s.saveConfig()
+}
+
+// GetDPIScale returns the display scaling factor read from the OS at startup,
+// e.g. 1.5 at 144 DPI. It is 1.0 on platforms other than Windows.
+func (s *ConfigService) GetDPIScale() float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.dpiScale
+}
+
+// defaultLocale is used when neither the user nor the OS sets a locale.
+const defaultLocale = "en-US"
+
+// GetLocale returns the user's locale as a BCP 47 tag, e.g. "fr-FR": the one
+// set with SetLocale, else the OS locale read at startup.
+func (s *ConfigService) GetLocale() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	switch {
+	case s.locale != "":
+		return s.locale
+	case s.systemLocale != "":
+		return s.systemLocale
+	}
+	return defaultLocale
+}
+
+// SetLocale overrides the OS locale, e.g. with "fr-FR" or "fr_FR". An empty
+// locale follows the OS again.
+func (s *ConfigService) SetLocale(locale string) {
+	s.mu.Lock()
+	s.locale = normalizeLocale(locale)
+	app := s.app
+	s.mu.Unlock()
+	s.saveConfig()
+
+	if app != nil {
+		app.Event.Emit("localeChanged", s.GetLocale())
+	}
+}
+
+// normalizeLocale converts a POSIX locale such as "fr_FR.UTF-8@euro" to a
+// BCP 47 tag such as "fr-FR". It returns "" for the "C" and "POSIX" locales.
+func normalizeLocale(locale string) string {
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	locale = strings.ReplaceAll(strings.TrimSpace(locale), "_", "-")
+	if locale == "C" || locale == "POSIX" {
+		return ""
+	}
+	return locale
+}
@@ -0,0 +1,282 @@
+package appconfig
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestService(t *testing.T) *ConfigService {
+	t.Helper()
+	s := &ConfigService{
+		configPath:   filepath.Join(t.TempDir(), "config.json"),
+		chartLibrary: "echarts",
+		theme:        "light",
+		logLevel:     "info",
+	}
+	s.loadConfig()
+	return s
+}
+
+func TestSaveConfigKeepsBackup(t *testing.T) {
+	s := newTestService(t)
+	s.SetTheme("dark")
+	s.SetTheme("light")
+
+	if _, err := os.Stat(s.configPath + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+	cfg, err := readConfigFile(s.configPath)
+	if err != nil || cfg.Theme != "light" {
+		t.Errorf("config theme = %q (%v), want light", cfg.Theme, err)
+	}
+	bak, err := readConfigFile(s.backupPath())
+	if err != nil || bak.Theme != "dark" {
+		t.Errorf("backup theme = %q (%v), want dark", bak.Theme, err)
+	}
+}
+
+func TestLoadConfigRecoversFromPartialWrite(t *testing.T) {
+	s := newTestService(t)
+	s.SetTheme("dark")
+	s.SetChartLibrary("plotly")
+
+	// Simulate a crash halfway through writing config.json
+	if err := os.WriteFile(s.configPath, []byte(`{"theme": "da`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := &ConfigService{configPath: s.configPath}
+	reloaded.loadConfig()
+	if reloaded.GetTheme() != "dark" {
+		t.Errorf("recovered theme = %q, want dark", reloaded.GetTheme())
+	}
+
+	// A corrupt config must not replace the good backup on the next save
+	reloaded.SetLogLevel("debug")
+	if _, err := readConfigFile(reloaded.backupPath()); err != nil {
+		t.Errorf("backup corrupted: %v", err)
+	}
+}
+
+func TestRestoreBackup(t *testing.T) {
+	s := newTestService(t)
+	s.SetTheme("dark")
+	s.SetTheme("light")
+
+	if err := s.RestoreBackup(); err != nil {
+		t.Fatalf("RestoreBackup failed: %v", err)
+	}
+	if s.GetTheme() != "dark" {
+		t.Errorf("theme = %q, want dark", s.GetTheme())
+	}
+	cfg, err := readConfigFile(s.configPath)
+	if err != nil || cfg.Theme != "dark" {
+		t.Errorf("config theme = %q (%v), want dark", cfg.Theme, err)
+	}
+}
+
+func TestFunctionPresetsSave(t *testing.T) {
+	s := newTestService(t)
+	s.AddFunctionPreset(FunctionPreset{Name: "wave", Expression: "sin(x)"})
+	s.RemoveFunctionPreset("wave")
+	if got := len(s.GetFunctionPresets()); got != 0 {
+		t.Errorf("presets = %d, want 0", got)
+	}
+}
+
+func TestGetLocale(t *testing.T) {
+	s := newTestService(t)
+	if got := s.GetLocale(); got != defaultLocale {
+		t.Errorf("locale without OS locale = %q, want %s", got, defaultLocale)
+	}
+
+	s.systemLocale = normalizeLocale("de_DE.UTF-8")
+	if got := s.GetLocale(); got != "de-DE" {
+		t.Errorf("OS locale = %q, want de-DE", got)
+	}
+
+	s.SetLocale("fr_FR")
+	reloaded := &ConfigService{configPath: s.configPath, systemLocale: "de-DE"}
+	reloaded.loadConfig()
+	if got := reloaded.GetLocale(); got != "fr-FR" {
+		t.Errorf("user locale = %q, want fr-FR", got)
+	}
+
+	reloaded.SetLocale("")
+	if got := reloaded.GetLocale(); got != "de-DE" {
+		t.Errorf("locale after reset = %q, want the OS locale de-DE", got)
+	}
+}
+
+func TestNormalizeLocale(t *testing.T) {
+	for in, want := range map[string]string{
+		"fr_FR.UTF-8":      "fr-FR",
+		"de_DE@euro":       "de-DE",
+		"en-GB":            "en-GB",
+		"C":                "",
+		"POSIX":            "",
+		"C.UTF-8":          "",
+		"sr_RS.UTF-8@latn": "sr-RS",
+	} {
+		if got := normalizeLocale(in); got != want {
+			t.Errorf("normalizeLocale(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestEnvOverridesConfig(t *testing.T) {
+	s := newTestService(t)
+	s.SetTheme("light")
+	s.SetDialogTimeoutSeconds(60)
+
+	t.Setenv("OLICANA_THEME", "dark")
+	t.Setenv("OLICANA_PLUGIN_SEARCH_DIRS", "a"+string(os.PathListSeparator)+"b")
+	t.Setenv("OLICANA_DIALOG_TIMEOUT_SECONDS", "soon") // Invalid, ignored
+
+	reloaded := &ConfigService{configPath: s.configPath}
+	reloaded.loadConfig()
+	reloaded.loadConfigFromEnv()
+	if got := reloaded.GetTheme(); got != "dark" {
+		t.Errorf("theme = %q, want dark from the environment", got)
+	}
+	if got := reloaded.GetPluginSearchDirs(); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("plugin search dirs = %v, want [a b]", got)
+	}
+	if got := reloaded.GetDialogTimeoutSeconds(); got != 60 {
+		t.Errorf("dialog timeout = %d, want 60 from the file", got)
+	}
+
+	// Overrides are not saved, other changes are
+	reloaded.SetChartLibrary("plotly")
+	cfg, err := readConfigFile(s.configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Theme != "light" || len(cfg.PluginSearchDirs) != 0 || cfg.ChartLibrary != "plotly" {
+		t.Errorf("saved theme %q, dirs %v, library %q, want light, none, plotly", cfg.Theme, cfg.PluginSearchDirs, cfg.ChartLibrary)
+	}
+}
+
+func TestEnvOverrideTableFields(t *testing.T) {
+	for _, o := range envOverrideTable {
+		var cfg configData
+		if !reflect.ValueOf(&cfg).Elem().FieldByName(o.field).IsValid() {
+			t.Errorf("%s overrides unknown field %s", o.name, o.field)
+		}
+	}
+}
+
+func TestWatchConfigReloadsExternalEdits(t *testing.T) {
+	s := newTestService(t)
+	s.SetTheme("light")
+	if err := s.WatchConfig(); err != nil {
+		t.Fatalf("WatchConfig failed: %v", err)
+	}
+	t.Cleanup(s.stopWatchingConfig)
+
+	// The app's own saves are not reloaded
+	s.SetChartLibrary("plotly")
+
+	if err := os.WriteFile(s.configPath, []byte(`{"theme": "dark", "chartLibrary": "plotly"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for s.GetTheme() != "dark" {
+		if time.Now().After(deadline) {
+			t.Fatal("config was not reloaded within a second")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := s.GetChartLibrary(); got != "plotly" {
+		t.Errorf("chart library = %q, want plotly", got)
+	}
+}
+
+func TestPollConfigSkipsOwnSaves(t *testing.T) {
+	s := newTestService(t)
+	s.SetTheme("light")
+
+	// A setting changed in memory but not saved yet, as while a save is
+	// under way, is not reverted by the app's previous save
+	s.mu.Lock()
+	s.theme = "dark"
+	s.mu.Unlock()
+	if _, changed, err := s.pollConfig(time.Time{}); err != nil || len(changed) != 0 {
+		t.Errorf("pollConfig() = %v, %v, want no changes", changed, err)
+	}
+	if got := s.GetTheme(); got != "dark" {
+		t.Errorf("theme = %q, want dark", got)
+	}
+}
+
+func TestChangedSettings(t *testing.T) {
+	a := configData{Theme: "light", DisabledPlugins: []string{"CSV"}, Locale: "fr-FR"}
+	b := a
+	b.Theme = "dark"
+	b.DisabledPlugins = []string{"CSV", "JSON"}
+	if got := changedSettings(a, b); !reflect.DeepEqual(got, []string{"theme", "disabledPlugins"}) {
+		t.Errorf("changed = %v, want theme and disabledPlugins", got)
+	}
+	if got := changedSettings(a, a); len(got) != 0 {
+		t.Errorf("changed = %v, want none", got)
+	}
+}
+
+func TestColorPalette(t *testing.T) {
+	s := newTestService(t)
+	if got := s.GetColorPalette(); !reflect.DeepEqual(got, colorPalettes["plotly"]) {
+		t.Errorf("default palette = %v, want plotly", got)
+	}
+
+	okabeIto := s.GetColorPalettes()["okabe-ito"]
+	s.SetColorPalette(okabeIto)
+	okabeIto[0] = "#FFFFFF" // Callers' slices are not shared
+	reloaded := &ConfigService{configPath: s.configPath}
+	reloaded.loadConfig()
+	if got := reloaded.GetColorPalette(); !reflect.DeepEqual(got, colorPalettes["okabe-ito"]) {
+		t.Errorf("reloaded palette = %v, want okabe-ito", got)
+	}
+
+	s.SetColorPalette(nil)
+	if got := s.GetColorPalette(); !reflect.DeepEqual(got, colorPalettes["plotly"]) {
+		t.Errorf("palette after reset = %v, want plotly", got)
+	}
+}
+
+func TestAddPluginSearchDir(t *testing.T) {
+	s := newTestService(t)
+	var discovered []string
+	s.SetPluginDiscoverer(func(dir string) error {
+		discovered = append(discovered, dir)
+		return nil
+	})
+
+	dir := t.TempDir()
+	if err := s.AddPluginSearchDir(dir); err != nil {
+		t.Fatalf("AddPluginSearchDir failed: %v", err)
+	}
+	// Adding it again only scans it again
+	if err := s.AddPluginSearchDir(dir); err != nil {
+		t.Fatalf("AddPluginSearchDir failed the second time: %v", err)
+	}
+	if got := s.GetPluginSearchDirs(); !reflect.DeepEqual(got, []string{dir}) {
+		t.Errorf("search dirs = %v, want [%s]", got, dir)
+	}
+	if !reflect.DeepEqual(discovered, []string{dir, dir}) {
+		t.Errorf("discovered %v, want %s twice", discovered, dir)
+	}
+	cfg, err := readConfigFile(s.configPath)
+	if err != nil || !reflect.DeepEqual(cfg.PluginSearchDirs, []string{dir}) {
+		t.Errorf("saved dirs = %v (%v), want [%s]", cfg.PluginSearchDirs, err, dir)
+	}
+
+	if err := s.AddPluginSearchDir(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected error for a missing directory")
+	}
+	if len(discovered) != 2 {
+		t.Errorf("missing directory was scanned")
+	}
+}
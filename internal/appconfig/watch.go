@@ -0,0 +1,138 @@
+package appconfig
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"olicanaplot/internal/logging"
+)
+
+// configPollInterval is how often WatchConfig checks config.json for changes.
+var configPollInterval = 250 * time.Millisecond
+
+// WatchConfig reloads config.json whenever another program, such as a text
+// editor, changes it, so the edits apply without a restart. It polls the
+// file's modification time; the app's own saves are recognised by their
+// contents and skipped. After a reload that changed settings, a
+// "configChanged" event is emitted with the JSON names of those settings.
+func (s *ConfigService) WatchConfig() error {
+	if _, err := os.Stat(filepath.Dir(s.configPath)); err != nil {
+		return fmt.Errorf("cannot watch config: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopWatch != nil {
+		return fmt.Errorf("config is already watched")
+	}
+	stop := make(chan struct{})
+	s.stopWatch = stop
+	go s.watchConfig(stop, configModTime(s.configPath))
+	return nil
+}
+
+// stopWatchingConfig stops the watcher started by WatchConfig, if any.
+func (s *ConfigService) stopWatchingConfig() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopWatch != nil {
+		close(s.stopWatch)
+		s.stopWatch = nil
+	}
+}
+
+// watchConfig reloads the config when its modification time moves on from
+// seen, until stop is closed.
+func (s *ConfigService) watchConfig(stop <-chan struct{}, seen time.Time) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(configPollInterval):
+		}
+
+		var changed []string
+		var err error
+		seen, changed, err = s.pollConfig(seen)
+		if err != nil {
+			// Most likely saved half way; the rest arrives with the next change
+			logging.NewLogger("Config").Warn("Ignoring unreadable config file", "path", s.configPath, "error", err)
+			continue
+		}
+		if len(changed) == 0 {
+			continue
+		}
+		logging.NewLogger("Config").Info("Config file changed, reloaded", "settings", changed)
+
+		s.mu.RLock()
+		app := s.app
+		s.mu.RUnlock()
+		if app != nil {
+			app.Event.Emit("configChanged", map[string]interface{}{"keys": changed})
+		}
+	}
+}
+
+// pollConfig reloads config.json if its modification time has moved on from
+// seen and it isn't the app's own save. It returns the new modification
+// time and the JSON names of the settings that changed.
+func (s *ConfigService) pollConfig(seen time.Time) (time.Time, []string, error) {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	modTime := configModTime(s.configPath)
+	if modTime.Equal(seen) {
+		return seen, nil, nil
+	}
+	if data, err := os.ReadFile(s.configPath); err == nil && bytes.Equal(data, s.lastSaved) {
+		return modTime, nil, nil
+	}
+	changed, err := s.reloadConfig()
+	return modTime, changed, err
+}
+
+// reloadConfig reads config.json and applies it, keeping the settings
+// overridden by environment variables. It returns the JSON names of the
+// settings whose values changed.
+func (s *ConfigService) reloadConfig() ([]string, error) {
+	cfg, err := readConfigFile(s.configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	before := s.currentConfig()
+	s.applyConfig(cfg)
+	s.applyEnvOverridesLocked()
+	return changedSettings(before, s.currentConfig()), nil
+}
+
+// changedSettings returns the JSON names of the fields that differ between
+// a and b, in field order.
+func changedSettings(a, b configData) []string {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	var changed []string
+	for i := 0; i < va.NumField(); i++ {
+		if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			name, _, _ := strings.Cut(va.Type().Field(i).Tag.Get("json"), ",")
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+// configModTime returns the modification time of path, or the zero time if
+// it does not exist.
+func configModTime(path string) time.Time {
+	st, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return st.ModTime()
+}
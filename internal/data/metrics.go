@@ -0,0 +1,166 @@
+package data
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"olicanaplot/internal/logging"
+)
+
+// latencyWindow is the number of recent requests kept by the histogram.
+const latencyWindow = 100
+
+// LatencyHistogram keeps a rolling window of request latencies.
+type LatencyHistogram struct {
+	mu      sync.Mutex
+	samples []float64 // Ring buffer of latencies in milliseconds
+	next    int
+	total   int
+}
+
+// LatencySnapshot is the JSON view of a LatencyHistogram.
+type LatencySnapshot struct {
+	Count int     `json:"count"` // Samples in the window
+	Total int     `json:"total"` // Samples recorded since startup
+	P50   float64 `json:"p50_ms"`
+	P95   float64 `json:"p95_ms"`
+	P99   float64 `json:"p99_ms"`
+}
+
+// NewLatencyHistogram creates a histogram over the last size requests.
+func NewLatencyHistogram(size int) *LatencyHistogram {
+	return &LatencyHistogram{samples: make([]float64, 0, size)}
+}
+
+// Record adds a latency sample in milliseconds, evicting the oldest sample
+// once the window is full.
+func (h *LatencyHistogram) Record(ms float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.samples) < cap(h.samples) {
+		h.samples = append(h.samples, ms)
+	} else {
+		h.samples[h.next] = ms
+	}
+	h.next = (h.next + 1) % cap(h.samples)
+	h.total++
+}
+
+// Snapshot returns the P50/P95/P99 of the current window.
+func (h *LatencyHistogram) Snapshot() LatencySnapshot {
+	h.mu.Lock()
+	sorted := append([]float64(nil), h.samples...)
+	total := h.total
+	h.mu.Unlock()
+
+	sort.Float64s(sorted)
+	return LatencySnapshot{
+		Count: len(sorted),
+		Total: total,
+		P50:   percentile(sorted, 50),
+		P95:   percentile(sorted, 95),
+		P99:   percentile(sorted, 99),
+	}
+}
+
+// percentile returns the nearest-rank percentile p of sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// timingWriter records when the first byte is written and how many bytes
+// are written in total.
+type timingWriter struct {
+	http.ResponseWriter
+	firstByte    time.Time
+	bytesWritten int
+}
+
+func (tw *timingWriter) markFirstByte() {
+	if tw.firstByte.IsZero() {
+		tw.firstByte = time.Now()
+	}
+}
+
+func (tw *timingWriter) WriteHeader(status int) {
+	tw.markFirstByte()
+	tw.ResponseWriter.WriteHeader(status)
+}
+
+func (tw *timingWriter) Write(b []byte) (int, error) {
+	tw.markFirstByte()
+	n, err := tw.ResponseWriter.Write(b)
+	tw.bytesWritten += n
+	return n, err
+}
+
+// Hijack lets /ws/series_stream take over the connection for a WebSocket.
+func (tw *timingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(tw.ResponseWriter).Hijack()
+}
+
+// Unwrap lets /ws/series_stream check whether the writer underneath can
+// hijack, as Hijack always exists here.
+func (tw *timingWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
+
+// BenchmarkMiddleware logs the latency of every request at DEBUG level and
+// keeps a rolling histogram of /api/series_data time-to-first-byte, which it
+// serves as JSON on /api/metrics.
+func BenchmarkMiddleware(next http.Handler, logger logging.Logger) http.Handler {
+	seriesLatency := NewLatencyHistogram(latencyWindow)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/metrics" {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"series_data": seriesLatency.Snapshot(),
+			})
+			return
+		}
+
+		start := time.Now()
+		tw := &timingWriter{ResponseWriter: w}
+		next.ServeHTTP(tw, r)
+		elapsed := time.Since(start)
+
+		if tw.firstByte.IsZero() {
+			tw.firstByte = time.Now()
+		}
+		firstByteMs := float64(tw.firstByte.Sub(start).Microseconds()) / 1000
+
+		args := []any{
+			"path", r.URL.Path,
+			"request_latency_ms", float64(elapsed.Microseconds()) / 1000,
+			"first_byte_ms", firstByteMs,
+		}
+		if w.Header().Get("Content-Type") == "application/octet-stream" {
+			args = append(args,
+				"bytes_written", tw.bytesWritten,
+				"points_served", tw.bytesWritten/16, // Two float64 values per point
+			)
+		}
+		logger.Debug("Request served", args...)
+
+		if r.URL.Path == "/api/series_data" {
+			seriesLatency.Record(firstByteMs)
+		}
+	})
+}
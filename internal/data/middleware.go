@@ -2,17 +2,46 @@
 package data
 
 import (
+	"cmp"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"unsafe"
 
 	"olicanaplot/internal/logging"
 	"olicanaplot/internal/plugins"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
 )
 
+// MiddlewareOption configures the middleware created by Middleware.
+type MiddlewareOption func(*middlewareOptions)
+
+type middlewareOptions struct {
+	autoRefreshSeconds func() int // nil if not set
+}
+
+// WithAutoRefresh makes successful /api/series_data responses carry
+// "Cache-Control: max-age=<seconds()>", from which the frontend schedules
+// re-fetches. seconds is called per request, so changes apply straight away.
+// No header is sent while it returns 0.
+func WithAutoRefresh(seconds func() int) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.autoRefreshSeconds = seconds
+	}
+}
+
 // Middleware creates an HTTP middleware that intercepts chart data API requests.
-func Middleware(manager *plugins.Manager, logger logging.Logger) func(http.Handler) http.Handler {
+func Middleware(manager *plugins.Manager, logger logging.Logger, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	var options middlewareOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			switch r.URL.Path {
@@ -21,16 +50,56 @@ func Middleware(manager *plugins.Manager, logger logging.Logger) func(http.Handl
 				return
 
 			case "/api/series_config":
-				handleSeriesConfig(w, r, manager)
+				handleSeriesConfig(w, r, manager, logger)
+				return
+
+			case "/api/series_names":
+				handleSeriesNames(w, r, manager)
+				return
+
+			case "/api/series_metadata":
+				handleSeriesMetadata(w, r, manager, logger)
 				return
 
 			case "/api/series_data":
-				handleSeriesData(w, r, manager, logger)
+				maxAge := 0
+				if options.autoRefreshSeconds != nil {
+					maxAge = options.autoRefreshSeconds()
+				}
+				handleSeriesData(w, r, manager, logger, maxAge)
+				return
+
+			case "/api/colorscale_data":
+				handleColorscaleData(w, r, manager, logger)
+				return
+
+			case "/api/correlation":
+				handleCorrelation(w, r, manager, logger)
+				return
+
+			case "/api/refresh":
+				handleRefresh(w, r, manager, logger)
+				return
+
+			case "/api/file_info":
+				handleFileInfo(w, r, manager)
+				return
+
+			case "/api/save":
+				handleSave(w, r, manager, logger)
+				return
+
+			case "/api/column_stats":
+				handleColumnStats(w, r, manager)
 				return
 
 			case "/api/plugins":
 				handlePluginList(w, r, manager)
 				return
+
+			case "/ws/series_stream":
+				handleSeriesStream(w, r, manager, logger)
+				return
 			}
 
 			// Pass to default asset server
@@ -59,16 +128,28 @@ func handleChartConfig(w http.ResponseWriter, r *http.Request, manager *plugins.
 
 	// GET - return current plugin config
 	plugin := manager.GetActive()
-	if plugin == nil {
-		http.Error(w, "No active plugin", http.StatusNotFound)
+
+	body, etag, gen, ok := manager.CachedConfig("chart_config")
+	if ok {
+		writeCachedJSON(w, r, body, etag)
 		return
 	}
 
 	config, err := plugin.GetChartConfig("")
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), pluginErrorStatus(err))
 		return
 	}
+	plugins.ApplyTimeRange(plugin, config)
+	if _, ok := plugin.(plugins.YAxisLabeler); ok {
+		series, err := plugin.GetSeriesConfig()
+		if err != nil {
+			http.Error(w, err.Error(), pluginErrorStatus(err))
+			return
+		}
+		manager.ApplySeriesOverrides(series)
+		plugins.ApplyYAxisLabels(plugin, config, series)
+	}
 
 	response := map[string]interface{}{
 		"activePlugin": manager.ActiveName(),
@@ -76,53 +157,393 @@ func handleChartConfig(w http.ResponseWriter, r *http.Request, manager *plugins.
 		"config":       config,
 	}
 
+	body, err = json.Marshal(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeCachedJSON(w, r, body, manager.StoreConfig("chart_config", gen, body))
+}
+
+// pluginErrorStatus returns the HTTP status for an error from the active
+// plugin: 404 Not Found when there is none, else 500.
+func pluginErrorStatus(err error) int {
+	if errors.Is(err, plugins.ErrNoActivePlugin) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// writeCachedJSON writes a JSON body with its ETag, or only the status 304
+// Not Modified when the request's If-None-Match already names the ETag.
+func writeCachedJSON(w http.ResponseWriter, r *http.Request, body []byte, etag string) {
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	w.Write(body)
+}
+
+// etagMatches reports whether an If-None-Match header value, a list of ETags
+// or "*", matches etag. Weak ETags compare by their opaque tag.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// handleRefresh makes the active plugin re-read its data source on POST.
+func handleRefresh(w http.ResponseWriter, r *http.Request, manager *plugins.Manager, logger logging.Logger) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	plugin := manager.GetActive()
+	if _, ok := plugin.(plugins.Refresher); !ok {
+		http.Error(w, "Active plugin does not support refreshing", http.StatusBadRequest)
+		return
+	}
+
+	if err := manager.RefreshActive(); err != nil {
+		http.Error(w, err.Error(), pluginErrorStatus(err))
+		return
+	}
+	logger.Info("Plugin refreshed", "name", manager.ActiveName())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"status":"ok"}`))
 }
 
-// handleSeriesConfig returns the list of series from the active plugin
-func handleSeriesConfig(w http.ResponseWriter, r *http.Request, manager *plugins.Manager) {
+// handleFileInfo returns metadata about the file loaded by the active plugin.
+func handleFileInfo(w http.ResponseWriter, r *http.Request, manager *plugins.Manager) {
 	plugin := manager.GetActive()
-	if plugin == nil {
-		http.Error(w, "No active plugin", http.StatusNotFound)
+
+	fi, ok := plugin.(plugins.FileInformer)
+	// IPC plugins all have GetFileInfo, so they must list it too
+	if cp, isProvider := plugin.(plugins.CapabilityProvider); isProvider && !slices.Contains(cp.Capabilities(), "get_file_info") {
+		ok = false
+	}
+	if !ok {
+		http.Error(w, "Active plugin does not report file info", http.StatusNotFound)
+		return
+	}
+
+	info, err := fi.GetFileInfo()
+	if err != nil {
+		http.Error(w, err.Error(), pluginErrorStatus(err))
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
+
+// handleSave sends the series of the active plugin, on POST, to a plugin
+// that saves them, e.g. the CSV Writer. The plugin parameter picks the saver,
+// the first registered one by default, and path is passed to it as the
+// output path, overriding the one it was configured with.
+func handleSave(w http.ResponseWriter, r *http.Request, manager *plugins.Manager, logger logging.Logger) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	saver, name, err := manager.FindSaver(query.Get("plugin"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	series, err := manager.ActiveSeriesPoints()
+	if err != nil {
+		http.Error(w, err.Error(), pluginErrorStatus(err))
+		return
+	}
+	if err := saver.Save(query.Get("path"), series); err != nil {
+		logger.Error("Failed to save series", "saver", name, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	logger.Info("Series saved", "saver", name, "source", manager.ActiveName(), "series", len(series))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "plugin": name, "series": len(series)})
+}
+
+// columnStats is the /api/column_stats response.
+type columnStats struct {
+	Column   string  `json:"column"`
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"`
+	Mean     float64 `json:"mean"`
+	NaNCount int     `json:"nan_count"`
+}
+
+// handleColumnStats returns the statistics of a column of the table loaded
+// by the active plugin, given by the column parameter.
+func handleColumnStats(w http.ResponseWriter, r *http.Request, manager *plugins.Manager) {
+	plugin := manager.GetActive()
+
+	sp, ok := plugin.(plugins.ColumnStatsProvider)
+	if !ok {
+		http.Error(w, "Active plugin does not report column statistics", http.StatusNotFound)
+		return
+	}
+	column := r.URL.Query().Get("column")
+	if column == "" {
+		http.Error(w, "Missing column parameter", http.StatusBadRequest)
+		return
+	}
+
+	min, max, mean, nanCount, err := sp.GetColumnStats(column)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(columnStats{Column: column, Min: min, Max: max, Mean: mean, NaNCount: nanCount})
+}
+
+// defaultSeriesPageSize is the page size of /api/series_config requests that
+// give a page but no page_size.
+const defaultSeriesPageSize = 100
+
+// seriesConfigPage is the /api/series_config response to a paged request.
+type seriesConfigPage struct {
+	Total    int                    `json:"total"`
+	Page     int                    `json:"page"`
+	PageSize int                    `json:"page_size"`
+	Series   []plugins.SeriesConfig `json:"series"`
+}
+
+// handleSeriesConfig returns the list of series from the active plugin. A
+// PATCH with a JSON array of partial series configs, each identified by its
+// id, overrides their appearance until another plugin is activated. With a
+// page or page_size parameter, a GET returns one page of the list instead,
+// see handleSeriesConfigPage.
+func handleSeriesConfig(w http.ResponseWriter, r *http.Request, manager *plugins.Manager, logger logging.Logger) {
+	plugin := manager.GetActive()
+
+	// The host default line width is applied below, so it is part of the key
+	cacheKey := fmt.Sprintf("series_config:%g", plugins.HostLineWidthDefault())
+	query := r.URL.Query()
+	if r.Method != http.MethodPatch && (query.Has("page") || query.Has("page_size")) {
+		handleSeriesConfigPage(w, r, manager, logger, cacheKey)
+		return
+	}
+
+	var gen uint64
+	if r.Method != http.MethodPatch {
+		body, etag, g, ok := manager.CachedConfig(cacheKey)
+		if ok {
+			writeCachedJSON(w, r, body, etag)
+			return
+		}
+		gen = g
+	}
+
 	series, err := plugin.GetSeriesConfig()
+	if err != nil {
+		http.Error(w, err.Error(), pluginErrorStatus(err))
+		return
+	}
+
+	if r.Method == http.MethodPatch {
+		var patches []plugins.SeriesConfig
+		if err := json.NewDecoder(r.Body).Decode(&patches); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid series patch: %v", err), http.StatusBadRequest)
+			return
+		}
+		known := make(map[string]bool, len(series))
+		for _, s := range series {
+			known[s.ID] = true
+		}
+		for _, p := range patches {
+			if !known[p.ID] {
+				http.Error(w, fmt.Sprintf("Unknown series: %q", p.ID), http.StatusBadRequest)
+				return
+			}
+		}
+		if err := manager.PatchSeries(patches); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		logger.Info("Series config patched", "series", len(patches))
+	}
+
+	prepareSeriesConfig(series, plugin, manager, logger)
+
+	if r.Method == http.MethodPatch {
+		if app := application.Get(); app != nil {
+			app.Event.Emit("seriesConfigChanged", series)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(series)
+		return
+	}
+
+	body, err := json.Marshal(series)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	writeCachedJSON(w, r, body, manager.StoreConfig(cacheKey, gen, body))
+}
+
+// prepareSeriesConfig turns the plugin's series configs into those served:
+// with overrides, axis labels and defaults applied, and in load order.
+func prepareSeriesConfig(series []plugins.SeriesConfig, plugin plugins.Plugin, manager *plugins.Manager, logger logging.Logger) {
+	manager.ApplySeriesOverrides(series)
+	plugins.ApplyYAxisLabels(plugin, nil, series)
 
-	// Ensure all series have defaults set
+	// Series without their own line width take the plugin's default, then
+	// the host's
+	config, err := plugin.GetChartConfig("")
+	if err != nil {
+		logger.Warn("Failed to get chart config for series defaults", "error", err)
+	}
 	for i := range series {
+		series[i].ApplyLineWidthDefault(config)
 		series[i].SetDefaults()
 	}
+	// The frontend fetches data in response order
+	slices.SortStableFunc(series, func(a, b plugins.SeriesConfig) int {
+		return cmp.Compare(b.LoadPriority, a.LoadPriority)
+	})
+}
+
+// handleSeriesConfigPage returns page number page, from 0, of page_size
+// series, defaultSeriesPageSize if not given, with the total number of
+// series, also sent as the X-Total-Series header. The series are fetched
+// from the plugin once and cached in the manager until it invalidates its
+// config cache, e.g. on activating another plugin.
+func handleSeriesConfigPage(w http.ResponseWriter, r *http.Request, manager *plugins.Manager, logger logging.Logger, cacheKey string) {
+	page, pageSize := 0, defaultSeriesPageSize
+	for name, v := range map[string]*int{"page": &page, "page_size": &pageSize} {
+		s := r.URL.Query().Get(name)
+		if s == "" {
+			continue
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || (name == "page_size" && n == 0) {
+			http.Error(w, fmt.Sprintf("Invalid %s: %q", name, s), http.StatusBadRequest)
+			return
+		}
+		*v = n
+	}
+
+	plugin := manager.GetActive()
+	series, gen, ok := manager.CachedSeries(cacheKey)
+	if !ok {
+		var err error
+		series, err = plugin.GetSeriesConfig()
+		if err != nil {
+			http.Error(w, err.Error(), pluginErrorStatus(err))
+			return
+		}
+		prepareSeriesConfig(series, plugin, manager, logger)
+		manager.StoreSeries(cacheKey, gen, series)
+	}
+
+	// Pages past the end are empty rather than an error. The bounds are
+	// worked out so that huge parameters can't overflow.
+	start := len(series)
+	if page <= len(series)/pageSize {
+		start = page * pageSize
+	}
+	end := start + min(pageSize, len(series)-start)
+
+	body, err := json.Marshal(seriesConfigPage{
+		Total:    len(series),
+		Page:     page,
+		PageSize: pageSize,
+		Series:   series[start:end],
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("X-Total-Series", strconv.Itoa(len(series)))
+	writeCachedJSON(w, r, body, plugins.ConfigETag(plugin.Name(), plugin.Version(), body))
+}
+
+// seriesName is an entry of the /api/series_names response.
+type seriesName struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// handleSeriesNames returns only the IDs and names of the active plugin's
+// series, which is much smaller than the full config for large files. The
+// filter parameter keeps the series whose name contains it, ignoring case.
+func handleSeriesNames(w http.ResponseWriter, r *http.Request, manager *plugins.Manager) {
+	plugin := manager.GetActive()
+
+	series, err := plugin.GetSeriesConfig()
+	if err != nil {
+		http.Error(w, err.Error(), pluginErrorStatus(err))
+		return
+	}
+	manager.ApplySeriesOverrides(series)
+
+	filter := strings.ToLower(r.URL.Query().Get("filter"))
+	names := make([]seriesName, 0, len(series))
+	for _, s := range series {
+		if filter != "" && !strings.Contains(strings.ToLower(s.Name), filter) {
+			continue
+		}
+		names = append(names, seriesName{ID: s.ID, Name: s.Name})
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(series)
+	json.NewEncoder(w).Encode(names)
 }
 
-// handleSeriesData returns binary Float64 data for a specific series
-func handleSeriesData(w http.ResponseWriter, r *http.Request, manager *plugins.Manager, logger logging.Logger) {
+// handleSeriesMetadata returns the point count and X and Y ranges of a
+// series, so the frontend can learn its size without fetching the data.
+func handleSeriesMetadata(w http.ResponseWriter, r *http.Request, manager *plugins.Manager, logger logging.Logger) {
 	seriesID := r.URL.Query().Get("series")
 	if seriesID == "" {
 		http.Error(w, "Missing series parameter", http.StatusBadRequest)
 		return
 	}
 
-	storage := r.URL.Query().Get("storage") // interleaved or arrays
+	meta, err := manager.SeriesMetadata(seriesID)
+	if err != nil {
+		logger.Error("Error getting series metadata", "series", seriesID, "error", err)
+		http.Error(w, err.Error(), pluginErrorStatus(err))
+		return
+	}
 
-	plugin := manager.GetActive()
-	if plugin == nil {
-		http.Error(w, "No active plugin", http.StatusNotFound)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(meta)
+}
+
+// handleSeriesData serves a series as binary Float64 data. A positive maxAge
+// is sent as Cache-Control max-age, in seconds.
+func handleSeriesData(w http.ResponseWriter, r *http.Request, manager *plugins.Manager, logger logging.Logger, maxAge int) {
+	seriesID := r.URL.Query().Get("series")
+	if seriesID == "" {
+		http.Error(w, "Missing series parameter", http.StatusBadRequest)
 		return
 	}
 
+	storage := r.URL.Query().Get("storage") // interleaved or arrays
+	logger = logger.With("series", seriesID)
+
+	plugin := manager.GetActive()
+
 	data, actualStorage, err := plugin.GetSeriesData(seriesID, storage)
 	if err != nil {
-		logger.Error("Error getting series data", "series", seriesID, "error", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logger.Error("Error getting series data", "error", err)
+		http.Error(w, err.Error(), pluginErrorStatus(err))
 		return
 	}
 
@@ -134,25 +555,121 @@ func handleSeriesData(w http.ResponseWriter, r *http.Request, manager *plugins.M
 
 	// Set actual storage header so frontend knows what it got (should now match requested)
 	w.Header().Set("X-Data-Storage", actualStorage)
+	if maxAge > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", maxAge))
+	}
 
 	numPoints := len(data) / 2
-	logger.Info("Serving series data", "series", seriesID, "points", numPoints)
+	logger.Info("Serving series data", "points", numPoints)
+
+	// Create a byte slice view of the float64 data without copying
+	var byteData []byte
+	if len(data) > 0 {
+		byteData = unsafe.Slice((*byte)(unsafe.Pointer(&data[0])), len(data)*8)
+	}
+
+	w.Header().Set("Accept-Ranges", "bytes")
+
+	// Serve partial content when the browser re-requests part of the data
+	if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
+		ranges, err := parseByteRanges(rangeHeader, int64(len(byteData)))
+		if err != nil {
+			logger.Warn("Rejecting series data range", "range", rangeHeader, "error", err)
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", len(byteData)))
+			http.Error(w, err.Error(), http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		writeRanges(w, byteData, ranges)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/octet-stream")
 	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)*8))
+	w.Write(byteData)
+}
 
-	// Create a byte slice view of the float64 data without copying
-	if len(data) > 0 {
-		byteData := unsafe.Slice((*byte)(unsafe.Pointer(&data[0])), len(data)*8)
-		w.Write(byteData)
+// handleColorscaleData serves the values that color a series' points as
+// binary Float64 data, one per point: the Y values of the series named by its
+// ColorScaleColumn.
+func handleColorscaleData(w http.ResponseWriter, r *http.Request, manager *plugins.Manager, logger logging.Logger) {
+	seriesID := r.URL.Query().Get("series")
+	if seriesID == "" {
+		http.Error(w, "Missing series parameter", http.StatusBadRequest)
+		return
+	}
+	logger = logger.With("series", seriesID)
+
+	plugin := manager.GetActive()
+	series, err := plugin.GetSeriesConfig()
+	if err != nil {
+		http.Error(w, err.Error(), pluginErrorStatus(err))
+		return
+	}
+	manager.ApplySeriesOverrides(series)
+
+	i := slices.IndexFunc(series, func(s plugins.SeriesConfig) bool { return s.ID == seriesID })
+	if i < 0 {
+		http.Error(w, fmt.Sprintf("series not found: %s", seriesID), http.StatusNotFound)
+		return
+	}
+	column := series[i].ColorScaleColumn
+	if column == "" {
+		http.Error(w, fmt.Sprintf("series %s has no color scale column", seriesID), http.StatusNotFound)
+		return
+	}
+
+	data, storage, err := plugin.GetSeriesData(column, "arrays")
+	if err != nil {
+		logger.Error("Error getting color scale data", "column", column, "error", err)
+		http.Error(w, err.Error(), pluginErrorStatus(err))
+		return
+	}
+	if storage != "arrays" {
+		data = convertStorage(data, "interleaved", "arrays")
+	}
+	values := data[len(data)/2:]
+
+	var byteData []byte
+	if len(values) > 0 {
+		byteData = unsafe.Slice((*byte)(unsafe.Pointer(&values[0])), len(values)*8)
 	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(byteData)))
+	w.Write(byteData)
+}
+
+// handleCorrelation returns the Pearson correlation between two series
+func handleCorrelation(w http.ResponseWriter, r *http.Request, manager *plugins.Manager, logger logging.Logger) {
+	seriesA := r.URL.Query().Get("a")
+	seriesB := r.URL.Query().Get("b")
+	if seriesA == "" || seriesB == "" {
+		http.Error(w, "Missing a or b parameter", http.StatusBadRequest)
+		return
+	}
+
+	corr, err := manager.ComputeCorrelation(seriesA, seriesB)
+	if err != nil {
+		logger.Error("Error computing correlation", "a", seriesA, "b", seriesB, "error", err)
+		http.Error(w, err.Error(), pluginErrorStatus(err))
+		return
+	}
+
+	response := map[string]interface{}{
+		"a": seriesA,
+		"b": seriesB,
+		"r": corr,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
 }
 
 // handlePluginList returns the list of available plugins
 func handlePluginList(w http.ResponseWriter, r *http.Request, manager *plugins.Manager) {
+	// A snapshot, so the active plugin is always one of those listed
+	snapshot := manager.Clone()
 	response := map[string]interface{}{
-		"active":  manager.ActiveName(),
-		"plugins": manager.List(),
+		"active":  snapshot.ActiveName(),
+		"plugins": snapshot.List(),
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
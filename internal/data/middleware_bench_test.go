@@ -0,0 +1,67 @@
+package data
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"olicanaplot/internal/logging"
+	"olicanaplot/internal/plugins"
+)
+
+func TestLatencyHistogram(t *testing.T) {
+	h := NewLatencyHistogram(100)
+	// Record 0..149 so that the window holds 50..149
+	for i := 0; i < 150; i++ {
+		h.Record(float64(i))
+	}
+
+	snap := h.Snapshot()
+	if snap.Count != 100 || snap.Total != 150 {
+		t.Fatalf("count = %d, total = %d, want 100, 150", snap.Count, snap.Total)
+	}
+	if snap.P50 != 99 || snap.P95 != 144 || snap.P99 != 148 {
+		t.Errorf("percentiles = %v/%v/%v, want 99/144/148", snap.P50, snap.P95, snap.P99)
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	logger := logging.NewLogger("Test")
+	manager := plugins.NewManager(logger)
+	manager.Register(&stubPlugin{points: 8}, true)
+	handler := BenchmarkMiddleware(Middleware(manager, logger)(http.NotFoundHandler()), logger)
+
+	for i := 0; i < 3; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/series_data?series=s1", nil))
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/series_config", nil))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/metrics", nil))
+
+	var metrics map[string]LatencySnapshot
+	if err := json.Unmarshal(rec.Body.Bytes(), &metrics); err != nil {
+		t.Fatalf("failed to decode metrics: %v", err)
+	}
+	if got := metrics["series_data"].Count; got != 3 {
+		t.Errorf("series_data count = %d, want 3", got)
+	}
+}
+
+func BenchmarkSeriesData(b *testing.B) {
+	logging.SetLevel("error")
+	defer logging.SetLevel("debug")
+
+	logger := logging.NewLogger("Bench")
+	manager := plugins.NewManager(logger)
+	manager.Register(&stubPlugin{points: 100000}, true)
+	handler := BenchmarkMiddleware(Middleware(manager, logger)(http.NotFoundHandler()), logger)
+
+	req := httptest.NewRequest("GET", "/api/series_data?series=s1", nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
@@ -0,0 +1,908 @@
+package data
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"olicanaplot/internal/logging"
+	"olicanaplot/internal/plugins"
+
+	"golang.org/x/net/websocket"
+)
+
+// stubPlugin is the plugin of these tests. By default it is named Stub and
+// serves a single interleaved series s1 with y = x * 10; the fields set the
+// rest of what it reports. It implements every optional plugin interface,
+// so tests of a plugin without them wrap it with bare.
+type stubPlugin struct {
+	name         string
+	points       int
+	chart        plugins.ChartConfig
+	series       []plugins.SeriesConfig
+	data         map[string][]float64 // Interleaved data of series other than s1
+	capabilities []string
+	labels       map[string]string
+	fileInfo     *plugins.FileInfo
+	stats        map[string]columnStats
+	metadata     *plugins.SeriesMetadata
+
+	mu          sync.Mutex
+	configCalls int
+	fetches     int
+	refreshes   int
+	saveArgs    string
+	saved       []plugins.SeriesPoints
+}
+
+// bare hides the optional interfaces of p, leaving only plugins.Plugin.
+func bare(p plugins.Plugin) plugins.Plugin {
+	return struct{ plugins.Plugin }{p}
+}
+
+func (p *stubPlugin) Name() string {
+	if p.name == "" {
+		return "Stub"
+	}
+	return p.name
+}
+
+func (p *stubPlugin) Version() uint32                        { return plugins.PluginAPIVersion }
+func (p *stubPlugin) Path() string                           { return "" }
+func (p *stubPlugin) GetFilePatterns() []plugins.FilePattern { return nil }
+func (p *stubPlugin) Close() error                           { return nil }
+func (p *stubPlugin) Capabilities() []string                 { return p.capabilities }
+func (p *stubPlugin) GetYAxisLabel(seriesID string) string   { return p.labels[seriesID] }
+
+func (p *stubPlugin) Initialize(ctx interface{}, initStr string, logger logging.Logger) (string, error) {
+	return "{}", nil
+}
+
+// GetChartConfig returns a copy of chart, as the host modifies the config
+// it is given.
+func (p *stubPlugin) GetChartConfig(args string) (*plugins.ChartConfig, error) {
+	var c plugins.ChartConfig
+	b, _ := json.Marshal(p.chart)
+	return &c, json.Unmarshal(b, &c)
+}
+
+func (p *stubPlugin) GetSeriesConfig() ([]plugins.SeriesConfig, error) {
+	p.mu.Lock()
+	p.configCalls++
+	p.mu.Unlock()
+	if p.series == nil {
+		return []plugins.SeriesConfig{{ID: "s1", Name: "Series 1"}}, nil
+	}
+	var series []plugins.SeriesConfig
+	b, _ := json.Marshal(p.series)
+	return series, json.Unmarshal(b, &series)
+}
+
+func (p *stubPlugin) GetSeriesData(seriesID string, preferredStorage string) ([]float64, string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fetches++
+	if d, ok := p.data[seriesID]; ok {
+		return d, "interleaved", nil
+	}
+	if seriesID != "s1" {
+		return nil, "", fmt.Errorf("series not found: %s", seriesID)
+	}
+	data := make([]float64, p.points*2)
+	for i := 0; i < p.points; i++ {
+		data[i*2] = float64(i)
+		data[i*2+1] = float64(i) * 10
+	}
+	return data, "interleaved", nil
+}
+
+// setPoints changes the point count of s1, as a file growing on disk would.
+func (p *stubPlugin) setPoints(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.points = n
+}
+
+func (p *stubPlugin) GetFileInfo() (*plugins.FileInfo, error) {
+	if p.fileInfo == nil {
+		return nil, fmt.Errorf("no file loaded")
+	}
+	return p.fileInfo, nil
+}
+
+func (p *stubPlugin) GetColumnStats(column string) (float64, float64, float64, int, error) {
+	s, ok := p.stats[column]
+	if !ok {
+		return 0, 0, 0, 0, fmt.Errorf("column not found: %s", column)
+	}
+	return s.Min, s.Max, s.Mean, s.NaNCount, nil
+}
+
+func (p *stubPlugin) GetMetadata(seriesID string) (plugins.SeriesMetadata, error) {
+	if p.metadata == nil {
+		return plugins.SeriesMetadata{}, fmt.Errorf("no metadata for %s", seriesID)
+	}
+	meta := *p.metadata
+	meta.ID = seriesID
+	return meta, nil
+}
+
+func (p *stubPlugin) Refresh() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.refreshes++
+	return nil
+}
+
+func (p *stubPlugin) Save(args string, series []plugins.SeriesPoints) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.saveArgs, p.saved = args, series
+	return nil
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	logger := logging.NewLogger("Test")
+	manager := plugins.NewManager(logger)
+	if err := manager.Register(&stubPlugin{points: 8}, true); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	srv := httptest.NewServer(Middleware(manager, logger)(http.NotFoundHandler()))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func getSeriesData(t *testing.T, srv *httptest.Server, rangeHeader string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest("GET", srv.URL+"/api/series_data?series=s1", nil)
+	if rangeHeader != "" {
+		req.Header.Set("Range", rangeHeader)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func decodeFloats(b []byte) []float64 {
+	values := make([]float64, len(b)/8)
+	for i := range values {
+		values[i] = math.Float64frombits(binary.LittleEndian.Uint64(b[i*8:]))
+	}
+	return values
+}
+
+func floatsEqual(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSeriesDataFullResponse(t *testing.T) {
+	resp := getSeriesData(t, newTestServer(t), "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Accept-Ranges"); got != "bytes" {
+		t.Errorf("Accept-Ranges = %q, want bytes", got)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if len(body) != 8*2*8 {
+		t.Errorf("body length = %d, want %d", len(body), 8*2*8)
+	}
+}
+
+func TestSeriesDataAutoRefresh(t *testing.T) {
+	if got := getSeriesData(t, newTestServer(t), "").Header.Get("Cache-Control"); got != "" {
+		t.Errorf("Cache-Control without auto-refresh = %q, want none", got)
+	}
+
+	logger := logging.NewLogger("Test")
+	manager := plugins.NewManager(logger)
+	manager.Register(&stubPlugin{points: 8}, true)
+	seconds := 5
+	srv := httptest.NewServer(Middleware(manager, logger, WithAutoRefresh(func() int { return seconds }))(http.NotFoundHandler()))
+	defer srv.Close()
+
+	if got := getSeriesData(t, srv, "").Header.Get("Cache-Control"); got != "max-age=5" {
+		t.Errorf("Cache-Control = %q, want max-age=5", got)
+	}
+	seconds = 0
+	if got := getSeriesData(t, srv, "").Header.Get("Cache-Control"); got != "" {
+		t.Errorf("Cache-Control with auto-refresh turned off = %q, want none", got)
+	}
+}
+
+func TestSeriesDataSingleRange(t *testing.T) {
+	// Bytes 16-31 are the second point (x=1, y=10)
+	resp := getSeriesData(t, newTestServer(t), "bytes=16-31")
+	if resp.StatusCode != http.StatusPartialContent {
+		t.Fatalf("status = %d, want 206", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Content-Range"); got != "bytes 16-31/128" {
+		t.Errorf("Content-Range = %q", got)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if got := decodeFloats(body); !floatsEqual(got, []float64{1, 10}) {
+		t.Errorf("range data = %v, want [1 10]", got)
+	}
+}
+
+func TestSeriesDataMultiRange(t *testing.T) {
+	resp := getSeriesData(t, newTestServer(t), "bytes=0-15, 48-63, -16")
+	if resp.StatusCode != http.StatusPartialContent {
+		t.Fatalf("status = %d, want 206", resp.StatusCode)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/byteranges" {
+		t.Fatalf("Content-Type = %q", resp.Header.Get("Content-Type"))
+	}
+
+	expected := []struct {
+		contentRange string
+		values       []float64
+	}{
+		{"bytes 0-15/128", []float64{0, 0}},
+		{"bytes 48-63/128", []float64{3, 30}},
+		{"bytes 112-127/128", []float64{7, 70}},
+	}
+
+	mr := multipart.NewReader(resp.Body, params["boundary"])
+	for i, want := range expected {
+		part, err := mr.NextPart()
+		if err != nil {
+			t.Fatalf("part %d: %v", i, err)
+		}
+		if got := part.Header.Get("Content-Range"); got != want.contentRange {
+			t.Errorf("part %d Content-Range = %q, want %q", i, got, want.contentRange)
+		}
+		body, _ := io.ReadAll(part)
+		if got := decodeFloats(body); !floatsEqual(got, want.values) {
+			t.Errorf("part %d data = %v, want %v", i, got, want.values)
+		}
+	}
+	if _, err := mr.NextPart(); err != io.EOF {
+		t.Errorf("expected exactly %d parts", len(expected))
+	}
+}
+
+func TestSeriesDataInvalidRanges(t *testing.T) {
+	srv := newTestServer(t)
+	for _, header := range []string{
+		"bytes=4-15",   // unaligned start
+		"bytes=0-12",   // unaligned end
+		"bytes=512-",   // past the end
+		"items=0-15",   // wrong unit
+		"bytes=16-8",   // reversed
+		"bytes=0-7,3-", // one bad range spoils the request
+	} {
+		resp := getSeriesData(t, srv, header)
+		if resp.StatusCode != http.StatusRequestedRangeNotSatisfiable {
+			t.Errorf("%s: status = %d, want 416", header, resp.StatusCode)
+		}
+		if got := resp.Header.Get("Content-Range"); !strings.HasSuffix(got, "/128") {
+			t.Errorf("%s: Content-Range = %q", header, got)
+		}
+	}
+}
+
+func TestSeriesConfigLineWidthDefaults(t *testing.T) {
+	orig := plugins.HostLineWidthDefault
+	plugins.HostLineWidthDefault = func() float64 { return 1.5 }
+	t.Cleanup(func() { plugins.HostLineWidthDefault = orig })
+
+	chartDefault := 0.8
+	for _, tt := range []struct {
+		name         string
+		chartDefault *float64
+		want         [2]float64
+	}{
+		{"host default", nil, [2]float64{1.5, 3}},
+		{"plugin default", &chartDefault, [2]float64{0.8, 3}},
+	} {
+		logger := logging.NewLogger("Test")
+		manager := plugins.NewManager(logger)
+		w := 3.0
+		manager.Register(&stubPlugin{
+			chart:  plugins.ChartConfig{LineWidthDefault: tt.chartDefault},
+			series: []plugins.SeriesConfig{{ID: "s1"}, {ID: "s2", LineWidth: &w}},
+		}, true)
+		handler := Middleware(manager, logger)(http.NotFoundHandler())
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/series_config", nil))
+		var series []plugins.SeriesConfig
+		if err := json.Unmarshal(rec.Body.Bytes(), &series); err != nil {
+			t.Fatalf("%s: failed to decode series: %v", tt.name, err)
+		}
+		for i, s := range series {
+			if *s.LineWidth != tt.want[i] {
+				t.Errorf("%s: %s line width = %v, want %v", tt.name, s.ID, *s.LineWidth, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestSeriesConfigPatch(t *testing.T) {
+	srv := newTestServer(t)
+
+	patch := func(body string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPatch, srv.URL+"/api/series_config", strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	resp := patch(`[{"id":"s1","color":"#ff0000","line_width":5}]`)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+
+	// The override sticks for later reads
+	resp, err := http.Get(srv.URL + "/api/series_config")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var series []plugins.SeriesConfig
+	json.NewDecoder(resp.Body).Decode(&series)
+	if len(series) != 1 || series[0].Color != "#ff0000" || *series[0].LineWidth != 5 || series[0].Name != "Series 1" {
+		t.Errorf("series = %+v, want patched color and width", series)
+	}
+
+	for _, body := range []string{`[{"id":"missing","color":"#ff0000"}]`, `{"id":"s1"}`} {
+		if resp := patch(body); resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, resp.StatusCode)
+		}
+	}
+}
+
+func TestFileInfo(t *testing.T) {
+	file := &plugins.FileInfo{Path: "data.csv", SizeBytes: 12345, RowCount: 1000, ModifiedUnix: 1700000000}
+	for _, tt := range []struct {
+		name   string
+		plugin plugins.Plugin
+		status int
+	}{
+		{"file plugin", &stubPlugin{fileInfo: file, capabilities: append(plugins.BaseCapabilities(), "get_file_info")}, http.StatusOK},
+		// A generator has the method but doesn't declare it
+		{"undeclared", &stubPlugin{fileInfo: file, capabilities: plugins.BaseCapabilities()}, http.StatusNotFound},
+		{"no file", bare(&stubPlugin{}), http.StatusNotFound},
+	} {
+		logger := logging.NewLogger("Test")
+		manager := plugins.NewManager(logger)
+		manager.Register(tt.plugin, true)
+		handler := Middleware(manager, logger)(http.NotFoundHandler())
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/file_info", nil))
+		if rec.Code != tt.status {
+			t.Fatalf("%s: status = %d, want %d", tt.name, rec.Code, tt.status)
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		var info plugins.FileInfo
+		if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+			t.Fatalf("%s: failed to decode file info: %v", tt.name, err)
+		}
+		if info.Path != "data.csv" || info.SizeBytes != 12345 || info.RowCount != 1000 {
+			t.Errorf("%s: info = %+v", tt.name, info)
+		}
+	}
+}
+
+func TestSave(t *testing.T) {
+	logger := logging.NewLogger("Test")
+	manager := plugins.NewManager(logger)
+	viewer := &stubPlugin{name: "Viewer", capabilities: plugins.BaseCapabilities()}
+	writer := &stubPlugin{name: "Writer", capabilities: append(plugins.BaseCapabilities(), "save")}
+	manager.Register(viewer, true)
+	manager.Register(writer, true)
+	manager.Register(&stubPlugin{points: 3}, true)
+	manager.SetActive("Stub")
+	handler := Middleware(manager, logger)(http.NotFoundHandler())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/api/save?path=out.csv", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if viewer.saved != nil {
+		t.Error("saved by a plugin without the save capability")
+	}
+	want := []plugins.SeriesPoints{{ID: "s1", X: []float64{0, 1, 2}, Y: []float64{0, 10, 20}}}
+	if writer.saveArgs != "out.csv" || !reflect.DeepEqual(writer.saved, want) {
+		t.Errorf("saved %+v to %q, want %+v to out.csv", writer.saved, writer.saveArgs, want)
+	}
+
+	for _, tt := range []struct {
+		method, path string
+		status       int
+	}{
+		{"GET", "/api/save", http.StatusMethodNotAllowed},
+		{"POST", "/api/save?plugin=Viewer", http.StatusNotFound},
+		{"POST", "/api/save?plugin=Missing", http.StatusNotFound},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.path, rec.Code, tt.status)
+		}
+	}
+}
+
+func TestColumnStats(t *testing.T) {
+	logger := logging.NewLogger("Test")
+	manager := plugins.NewManager(logger)
+	manager.Register(&stubPlugin{stats: map[string]columnStats{
+		"temp": {Min: -4.5, Max: 31, Mean: 12.25, NaNCount: 3},
+	}}, true)
+	handler := Middleware(manager, logger)(http.NotFoundHandler())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/column_stats?column=temp", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var stats columnStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("failed to decode column stats: %v", err)
+	}
+	if want := (columnStats{Column: "temp", Min: -4.5, Max: 31, Mean: 12.25, NaNCount: 3}); stats != want {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+
+	for _, tt := range []struct {
+		path   string
+		status int
+	}{
+		{"/api/column_stats", http.StatusBadRequest},
+		{"/api/column_stats?column=missing", http.StatusNotFound},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.path, rec.Code, tt.status)
+		}
+	}
+
+	// Plugins that don't load tables
+	manager = plugins.NewManager(logger)
+	manager.Register(bare(&stubPlugin{}), true)
+	handler = Middleware(manager, logger)(http.NotFoundHandler())
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/column_stats?column=temp", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("stub plugin: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	logger := logging.NewLogger("Test")
+	rp := &stubPlugin{}
+	manager := plugins.NewManager(logger)
+	manager.Register(rp, true)
+	handler := Middleware(manager, logger)(http.NotFoundHandler())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/api/refresh", nil))
+	if rec.Code != http.StatusOK || rp.refreshes != 1 {
+		t.Fatalf("status = %d, refreshes = %d, want 200 and 1", rec.Code, rp.refreshes)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/refresh", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d, want 405", rec.Code)
+	}
+
+	// Plugins without Refresher are rejected
+	manager = plugins.NewManager(logger)
+	manager.Register(bare(&stubPlugin{}), true)
+	rec = httptest.NewRecorder()
+	Middleware(manager, logger)(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest("POST", "/api/refresh", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+}
+
+func TestConfigETag(t *testing.T) {
+	logger := logging.NewLogger("Test")
+	manager := plugins.NewManager(logger)
+	manager.Register(&stubPlugin{}, true)
+	handler := Middleware(manager, logger)(http.NotFoundHandler())
+
+	get := func(path, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, path := range []string{"/api/chart_config", "/api/series_config"} {
+		first := get(path, "")
+		etag := first.Header().Get("ETag")
+		if first.Code != http.StatusOK || etag == "" {
+			t.Fatalf("%s: status = %d, ETag = %q", path, first.Code, etag)
+		}
+		if rec := get(path, etag); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+			t.Errorf("%s: status = %d, body = %q, want 304 and no body", path, rec.Code, rec.Body)
+		}
+		if rec := get(path, `"other", W/`+etag); rec.Code != http.StatusNotModified {
+			t.Errorf("%s: list status = %d, want 304", path, rec.Code)
+		}
+		if rec := get(path, `"other"`); rec.Code != http.StatusOK || rec.Body.String() != first.Body.String() {
+			t.Errorf("%s: stale status = %d, want 200 and the same body", path, rec.Code)
+		}
+	}
+
+	// Patching a series changes the series config ETag
+	etag := get("/api/series_config", "").Header().Get("ETag")
+	req := httptest.NewRequest(http.MethodPatch, "/api/series_config", strings.NewReader(`[{"id":"s1","color":"#00ff00"}]`))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if rec := get("/api/series_config", etag); rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Errorf("after patch: status = %d, ETag = %q, want 200 and a new ETag", rec.Code, rec.Header().Get("ETag"))
+	}
+}
+
+func TestSeriesConfigLoadPriority(t *testing.T) {
+	logger := logging.NewLogger("Test")
+	manager := plugins.NewManager(logger)
+	manager.Register(&stubPlugin{series: []plugins.SeriesConfig{
+		{ID: "hidden", LoadPriority: -1},
+		{ID: "a"},
+		{ID: "urgent", LoadPriority: 5},
+		{ID: "b"},
+	}}, true)
+	handler := Middleware(manager, logger)(http.NotFoundHandler())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/series_config", nil))
+	var series []plugins.SeriesConfig
+	if err := json.Unmarshal(rec.Body.Bytes(), &series); err != nil {
+		t.Fatalf("failed to decode series: %v", err)
+	}
+	var ids []string
+	for _, s := range series {
+		ids = append(ids, s.ID)
+	}
+	// Equal priorities keep the plugin's order
+	if got, want := strings.Join(ids, ","), "urgent,a,b,hidden"; got != want {
+		t.Errorf("series order = %s, want %s", got, want)
+	}
+}
+
+func TestSeriesNames(t *testing.T) {
+	logger := logging.NewLogger("Test")
+	manager := plugins.NewManager(logger)
+	var series []plugins.SeriesConfig
+	for i, name := range []string{"Pressure", "Temperature A", "temperature B"} {
+		series = append(series, plugins.SeriesConfig{ID: fmt.Sprintf("s%d", i), Name: name, Color: "#123456"})
+	}
+	manager.Register(&stubPlugin{series: series}, true)
+	handler := Middleware(manager, logger)(http.NotFoundHandler())
+
+	for _, tt := range []struct {
+		query string
+		want  string
+	}{
+		{"", `[{"id":"s0","name":"Pressure"},{"id":"s1","name":"Temperature A"},{"id":"s2","name":"temperature B"}]`},
+		{"?filter=TEMP", `[{"id":"s1","name":"Temperature A"},{"id":"s2","name":"temperature B"}]`},
+		{"?filter=none", `[]`},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/series_names"+tt.query, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%q: status %d", tt.query, rec.Code)
+		}
+		if got := strings.TrimSpace(rec.Body.String()); got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSeriesConfigPaging(t *testing.T) {
+	logger := logging.NewLogger("Test")
+	manager := plugins.NewManager(logger)
+	plugin := &stubPlugin{series: make([]plugins.SeriesConfig, 250)}
+	for i := range plugin.series {
+		plugin.series[i] = plugins.SeriesConfig{ID: fmt.Sprintf("s%d", i), Name: fmt.Sprintf("Series %d", i)}
+	}
+	manager.Register(plugin, true)
+	handler := Middleware(manager, logger)(http.NotFoundHandler())
+
+	for _, tt := range []struct {
+		query          string
+		page, pageSize int
+		first, count   int
+	}{
+		{"?page=0&page_size=100", 0, 100, 0, 100},
+		{"?page=1&page_size=100", 1, 100, 100, 100},
+		{"?page=2&page_size=100", 2, 100, 200, 50}, // The last, partial page
+		{"?page=3&page_size=100", 3, 100, 0, 0},    // Past the end
+		{"?page=1", 1, defaultSeriesPageSize, 100, 100},
+		{"?page_size=40", 0, 40, 0, 40},
+		{"?page=4&page_size=60", 4, 60, 240, 10},
+		{"?page=2&page_size=9223372036854775807", 2, math.MaxInt, 0, 0},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/series_config"+tt.query, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", tt.query, rec.Code, rec.Body)
+		}
+		if got := rec.Header().Get("X-Total-Series"); got != "250" {
+			t.Errorf("%s: X-Total-Series = %q, want 250", tt.query, got)
+		}
+		var resp seriesConfigPage
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: decode failed: %v", tt.query, err)
+		}
+		if resp.Total != 250 || resp.Page != tt.page || resp.PageSize != tt.pageSize || len(resp.Series) != tt.count {
+			t.Errorf("%s: total %d, page %d, page_size %d, %d series; want 250, %d, %d, %d",
+				tt.query, resp.Total, resp.Page, resp.PageSize, len(resp.Series), tt.page, tt.pageSize, tt.count)
+			continue
+		}
+		if tt.count > 0 && resp.Series[0].ID != fmt.Sprintf("s%d", tt.first) {
+			t.Errorf("%s: first series %s, want s%d", tt.query, resp.Series[0].ID, tt.first)
+		}
+	}
+	if n := plugin.configCalls; n != 1 {
+		t.Errorf("GetSeriesConfig called %d times, want once for all pages", n)
+	}
+
+	for _, query := range []string{"?page=-1", "?page_size=0", "?page=x"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/series_config"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", query, rec.Code)
+		}
+	}
+
+	// Without paging the whole list is returned as before
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/series_config", nil))
+	var all []plugins.SeriesConfig
+	if err := json.Unmarshal(rec.Body.Bytes(), &all); err != nil || len(all) != 250 {
+		t.Errorf("unpaged response: %d series, error %v", len(all), err)
+	}
+
+	// Activating a plugin again drops the cached series
+	manager.SetActive("Stub")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/series_config?page=0", nil))
+	if n := plugin.configCalls; n != 3 {
+		t.Errorf("GetSeriesConfig called %d times after reactivation, want 3", n)
+	}
+}
+
+func TestColorscaleData(t *testing.T) {
+	logger := logging.NewLogger("Test")
+	manager := plugins.NewManager(logger)
+	manager.Register(&stubPlugin{
+		points: 3,
+		series: []plugins.SeriesConfig{
+			{ID: "s1", Name: "Series 1", ColorScaleColumn: "density"},
+			{ID: "density", Name: "Density"},
+		},
+		data: map[string][]float64{"density": {0, 0.5, 1, 0.25, 2, 0.75}},
+	}, true)
+	handler := Middleware(manager, logger)(http.NotFoundHandler())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/colorscale_data?series=s1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	if got, want := decodeFloats(rec.Body.Bytes()), []float64{0.5, 0.25, 0.75}; !floatsEqual(got, want) {
+		t.Errorf("values = %v, want %v", got, want)
+	}
+
+	for _, tt := range []struct {
+		query string
+		code  int
+	}{
+		{"", http.StatusBadRequest},
+		{"?series=missing", http.StatusNotFound},
+		{"?series=density", http.StatusNotFound}, // No color scale column
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/colorscale_data"+tt.query, nil))
+		if rec.Code != tt.code {
+			t.Errorf("%q: status = %d, want %d", tt.query, rec.Code, tt.code)
+		}
+	}
+}
+
+func TestYAxisLabels(t *testing.T) {
+	logger := logging.NewLogger("Test")
+	manager := plugins.NewManager(logger)
+	// Series in different units, one already on a chosen axis
+	manager.Register(&stubPlugin{
+		chart:  plugins.ChartConfig{Axes: []plugins.AxisGroupConfig{{YAxes: []plugins.AxisConfig{{Title: "Value"}}}}},
+		series: []plugins.SeriesConfig{{ID: "p"}, {ID: "t"}, {ID: "u", YAxis: "Value"}},
+		labels: map[string]string{"p": "Pressure (Pa)", "t": "Temperature (K)", "u": "Unused"},
+	}, true)
+	handler := Middleware(manager, logger)(http.NotFoundHandler())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/chart_config", nil))
+	var chart struct {
+		Config plugins.ChartConfig `json:"config"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &chart); err != nil {
+		t.Fatalf("failed to decode chart config: %v", err)
+	}
+	// Value is still used by u, so isn't retitled
+	var titles []string
+	for _, a := range chart.Config.Axes[0].YAxes {
+		titles = append(titles, a.Title)
+	}
+	if want := []string{"Value", "Pressure (Pa)", "Temperature (K)"}; strings.Join(titles, "|") != strings.Join(want, "|") {
+		t.Errorf("Y axes = %q, want %q", titles, want)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/series_config", nil))
+	var series []plugins.SeriesConfig
+	if err := json.Unmarshal(rec.Body.Bytes(), &series); err != nil {
+		t.Fatalf("failed to decode series: %v", err)
+	}
+	for i, want := range []string{"Pressure (Pa)", "Temperature (K)", "Value"} {
+		if series[i].YAxis != want {
+			t.Errorf("%s: y_axis = %q, want %q", series[i].ID, series[i].YAxis, want)
+		}
+		if !slices.Contains(titles, series[i].YAxis) {
+			t.Errorf("%s: y_axis %q is not one of the chart's axes", series[i].ID, series[i].YAxis)
+		}
+	}
+}
+
+func TestNoActivePlugin(t *testing.T) {
+	logger := logging.NewLogger("Test")
+	handler := Middleware(plugins.NewManager(logger), logger)(http.NotFoundHandler())
+
+	for _, path := range []string{"/api/chart_config", "/api/series_config", "/api/series_data?series=s1", "/api/file_info"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s: status %d, want %d", path, rec.Code, http.StatusNotFound)
+		}
+	}
+}
+
+func TestSeriesMetadata(t *testing.T) {
+	logger := logging.NewLogger("Test")
+	cp := &stubPlugin{points: 8}
+	manager := plugins.NewManager(logger)
+	manager.Register(bare(cp), true)
+	handler := Middleware(manager, logger)(http.NotFoundHandler())
+
+	want := `{"id":"s1","count":8,"x_min":0,"x_max":7,"y_min":0,"y_max":70,"storage":"interleaved"}`
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/series_metadata?series=s1", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", rec.Code, rec.Body)
+		}
+		if got := strings.TrimSpace(rec.Body.String()); got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	}
+	if cp.fetches != 1 {
+		t.Errorf("data fetched %d times, want once and then cached", cp.fetches)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/series_metadata", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("without series: status %d, want 400", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/series_metadata?series=nope", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("unknown series: status %d, want 500", rec.Code)
+	}
+
+	// Plugins providing metadata are not asked for the data
+	ip := &stubPlugin{metadata: &plugins.SeriesMetadata{Count: 1000, XMax: 999, Storage: "arrays"}}
+	manager = plugins.NewManager(logger)
+	manager.Register(ip, true)
+	rec = httptest.NewRecorder()
+	Middleware(manager, logger)(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest("GET", "/api/series_metadata?series=s1", nil))
+	want = `{"id":"s1","count":1000,"x_min":0,"x_max":999,"y_min":0,"y_max":0,"storage":"arrays"}`
+	if got := strings.TrimSpace(rec.Body.String()); got != want || ip.fetches != 0 {
+		t.Errorf("got %s after %d fetches, want %s without fetching", got, ip.fetches, want)
+	}
+}
+
+func TestSeriesStream(t *testing.T) {
+	logger := logging.NewLogger("Test")
+	sp := &stubPlugin{points: 2, series: []plugins.SeriesConfig{{ID: "s1", Name: "Series 1", RefreshIntervalMs: 10}}}
+	manager := plugins.NewManager(logger)
+	manager.Register(sp, true)
+	srv := httptest.NewServer(BenchmarkMiddleware(Middleware(manager, logger)(http.NotFoundHandler()), logger))
+	defer srv.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/series_stream?series=s1"
+	ws, err := websocket.Dial(wsURL, "", srv.URL)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer ws.Close()
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	var frame []byte
+	if err := websocket.Message.Receive(ws, &frame); err != nil {
+		t.Fatalf("Receive failed: %v", err)
+	}
+	if got, want := decodeFloats(frame), []float64{0, 0, 1, 10}; !floatsEqual(got, want) {
+		t.Errorf("first frame = %v, want %v", got, want)
+	}
+
+	// Unchanged data is not sent again, so the next frame has the new points
+	sp.setPoints(3)
+	if err := websocket.Message.Receive(ws, &frame); err != nil {
+		t.Fatalf("Receive failed: %v", err)
+	}
+	if got, want := decodeFloats(frame), []float64{0, 0, 1, 10, 2, 20}; !floatsEqual(got, want) {
+		t.Errorf("second frame = %v, want %v", got, want)
+	}
+}
+
+func TestSeriesStreamWithoutHijack(t *testing.T) {
+	logger := logging.NewLogger("Test")
+	manager := plugins.NewManager(logger)
+	manager.Register(&stubPlugin{points: 2}, true)
+	handler := BenchmarkMiddleware(Middleware(manager, logger)(http.NotFoundHandler()), logger)
+
+	// Like the webview's asset server, a recorder can't hand over a connection
+	req := httptest.NewRequest("GET", "/ws/series_stream?series=s1", nil)
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotImplemented)
+	}
+}
+
+func TestSeriesStreamMissingSeries(t *testing.T) {
+	srv := newTestServer(t)
+	resp, err := http.Get(srv.URL + "/ws/series_stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,127 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// float64Size is the width in bytes of one value in the binary series data.
+const float64Size = 8
+
+// errUnsatisfiableRange is returned when no requested range can be served.
+var errUnsatisfiableRange = errors.New("unsatisfiable range")
+
+// byteRange is an inclusive byte range within a response body.
+type byteRange struct {
+	start, end int64
+}
+
+func (br byteRange) length() int64 {
+	return br.end - br.start + 1
+}
+
+func (br byteRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", br.start, br.end, size)
+}
+
+// parseByteRanges parses a "Range: bytes=..." header against a body of the
+// given size. Every resolved range must start and end on a float64 boundary
+// so that partial responses never split a value.
+func parseByteRanges(header string, size int64) ([]byteRange, error) {
+	const prefix = "bytes="
+	if !strings.HasPrefix(header, prefix) {
+		return nil, fmt.Errorf("invalid range unit: %q", header)
+	}
+
+	var ranges []byteRange
+	for _, spec := range strings.Split(header[len(prefix):], ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		startStr, endStr, ok := strings.Cut(spec, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid range: %q", spec)
+		}
+		startStr = strings.TrimSpace(startStr)
+		endStr = strings.TrimSpace(endStr)
+
+		var br byteRange
+		if startStr == "" {
+			// Suffix range: the last N bytes
+			n, err := strconv.ParseInt(endStr, 10, 64)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid suffix range: %q", spec)
+			}
+			if n > size {
+				n = size
+			}
+			br = byteRange{start: size - n, end: size - 1}
+		} else {
+			start, err := strconv.ParseInt(startStr, 10, 64)
+			if err != nil || start < 0 {
+				return nil, fmt.Errorf("invalid range start: %q", spec)
+			}
+			end := size - 1
+			if endStr != "" {
+				end, err = strconv.ParseInt(endStr, 10, 64)
+				if err != nil || end < start {
+					return nil, fmt.Errorf("invalid range end: %q", spec)
+				}
+				if end >= size {
+					end = size - 1
+				}
+			}
+			br = byteRange{start: start, end: end}
+		}
+
+		if br.start >= size || br.end < br.start {
+			continue
+		}
+		if br.start%float64Size != 0 || (br.end+1)%float64Size != 0 {
+			return nil, fmt.Errorf("range %d-%d is not aligned to %d bytes", br.start, br.end, float64Size)
+		}
+		ranges = append(ranges, br)
+	}
+
+	if len(ranges) == 0 {
+		return nil, errUnsatisfiableRange
+	}
+	return ranges, nil
+}
+
+// writeRanges writes a 206 Partial Content response for the requested ranges
+// of body. A single range is sent as-is; several use multipart/byteranges.
+func writeRanges(w http.ResponseWriter, body []byte, ranges []byteRange) {
+	size := int64(len(body))
+
+	if len(ranges) == 1 {
+		br := ranges[0]
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Range", br.contentRange(size))
+		w.Header().Set("Content-Length", strconv.FormatInt(br.length(), 10))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(body[br.start : br.end+1])
+		return
+	}
+
+	mw := multipart.NewWriter(w)
+	w.Header().Set("Content-Type", "multipart/byteranges; boundary="+mw.Boundary())
+	w.WriteHeader(http.StatusPartialContent)
+	for _, br := range ranges {
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":  {"application/octet-stream"},
+			"Content-Range": {br.contentRange(size)},
+		})
+		if err != nil {
+			return
+		}
+		part.Write(body[br.start : br.end+1])
+	}
+	mw.Close()
+}
@@ -0,0 +1,140 @@
+package data
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+	"unsafe"
+
+	"olicanaplot/internal/logging"
+	"olicanaplot/internal/plugins"
+
+	"golang.org/x/net/websocket"
+)
+
+// defaultStreamInterval is how often /ws/series_stream polls a series that
+// does not set RefreshIntervalMs.
+const defaultStreamInterval = time.Second
+
+// minStreamInterval keeps a short RefreshIntervalMs from busying the plugin.
+const minStreamInterval = 50 * time.Millisecond
+
+// handleSeriesStream upgrades the request to a WebSocket and pushes the
+// series' binary Float64 data, as served by /api/series_data, whenever it
+// changes. The data is polled at the series' RefreshIntervalMs. Without a
+// storage parameter the frames are interleaved.
+//
+// Upgrading takes over the request's connection, which only a net/http
+// server can hand over. The webview reaches the middleware through the Wails
+// asset server's scheme handler, which can't, so there the endpoint answers
+// 501 Not Implemented and the frontend keeps polling /api/series_data.
+// Streaming needs the middleware served by net/http, as in the tests.
+func handleSeriesStream(w http.ResponseWriter, r *http.Request, manager *plugins.Manager, logger logging.Logger) {
+	seriesID := r.URL.Query().Get("series")
+	if seriesID == "" {
+		http.Error(w, "Missing series parameter", http.StatusBadRequest)
+		return
+	}
+	storage := r.URL.Query().Get("storage")
+	if storage == "" {
+		storage = "interleaved"
+	}
+
+	// websocket.Server panics if the connection can't be taken over
+	if !hijackable(w) {
+		http.Error(w, "WebSocket streaming is not supported by this server", http.StatusNotImplemented)
+		return
+	}
+
+	// Like the other endpoints, the stream is served to any origin
+	server := websocket.Server{Handler: func(ws *websocket.Conn) {
+		streamSeries(ws, manager, logger, seriesID, storage)
+	}}
+	server.ServeHTTP(w, r)
+}
+
+// hijackable reports whether w can hand over its connection, looking through
+// wrappers with an Unwrap method as http.ResponseController does.
+func hijackable(w http.ResponseWriter) bool {
+	for {
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			_, ok := w.(http.Hijacker)
+			return ok
+		}
+		w = u.Unwrap()
+	}
+}
+
+// streamSeries sends a frame of the series' data each time it differs from
+// the last frame sent, until the client disconnects.
+func streamSeries(ws *websocket.Conn, manager *plugins.Manager, logger logging.Logger, seriesID, storage string) {
+	defer ws.Close()
+	logger = logger.With("series", seriesID)
+	ws.PayloadType = websocket.BinaryFrame
+
+	// Reading is how a close from the client is noticed
+	closed := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, ws)
+		close(closed)
+	}()
+
+	interval := streamInterval(manager, seriesID)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	logger.Info("Streaming series data", "interval_ms", interval.Milliseconds())
+
+	var last []byte
+	sent := false
+	for {
+		frame, err := seriesFrame(manager, seriesID, storage)
+		if err != nil {
+			logger.Warn("Error getting streamed series data", "error", err)
+		} else if !sent || !bytes.Equal(frame, last) {
+			if _, err := ws.Write(frame); err != nil {
+				logger.Debug("Series stream closed", "error", err)
+				return
+			}
+			last, sent = frame, true
+		}
+
+		select {
+		case <-closed:
+			logger.Debug("Series stream closed by client")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// streamInterval returns the polling interval for seriesID from its
+// RefreshIntervalMs.
+func streamInterval(manager *plugins.Manager, seriesID string) time.Duration {
+	series, err := manager.GetActive().GetSeriesConfig()
+	if err != nil {
+		return defaultStreamInterval
+	}
+	for _, s := range series {
+		if s.ID == seriesID && s.RefreshIntervalMs > 0 {
+			return max(time.Duration(s.RefreshIntervalMs)*time.Millisecond, minStreamInterval)
+		}
+	}
+	return defaultStreamInterval
+}
+
+// seriesFrame fetches the series from the active plugin and returns its
+// data in storage as bytes.
+func seriesFrame(manager *plugins.Manager, seriesID, storage string) ([]byte, error) {
+	data, actualStorage, err := manager.GetActive().GetSeriesData(seriesID, storage)
+	if err != nil {
+		return nil, err
+	}
+	data = convertStorage(data, actualStorage, storage)
+	if len(data) == 0 {
+		return []byte{}, nil
+	}
+	// Copied, as plugins may reuse the slice they return
+	return bytes.Clone(unsafe.Slice((*byte)(unsafe.Pointer(&data[0])), len(data)*float64Size)), nil
+}
@@ -0,0 +1,69 @@
+package funceval
+
+import (
+	"context"
+	"maps"
+
+	"github.com/expr-lang/expr/vm"
+)
+
+// CompileWithContext is Compile, returning ctx.Err() if ctx is done before
+// compilation finishes, e.g. because the user left the page that needs the
+// expression. Compilation carries on in the background and its program is
+// still cached.
+func CompileWithContext(ctx context.Context, expression string) (*Evaluator, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	type compiled struct {
+		e   *Evaluator
+		err error
+	}
+	done := make(chan compiled, 1)
+	go func() {
+		e, err := Compile(expression)
+		done <- compiled{e, err}
+	}()
+
+	select {
+	case c := <-done:
+		return c.e, c.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// EvalContext is Eval, returning ctx.Err() if ctx is done before evaluation
+// finishes. The evaluation runs on its own copy of the environment, so the
+// Evaluator can be used again while an abandoned one finishes.
+func (e *Evaluator) EvalContext(ctx context.Context, x float64) (float64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	env := maps.Clone(e.env)
+	env[e.variable] = x
+
+	type result struct {
+		y   float64
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		var machine vm.VM
+		output, err := machine.Run(e.program, env)
+		if err != nil {
+			done <- result{0, err}
+			return
+		}
+		done <- result{toFloat(output), nil}
+	}()
+
+	select {
+	case r := <-done:
+		return r.y, r.err
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
@@ -0,0 +1,55 @@
+package funceval
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/expr-lang/expr/vm"
+)
+
+func TestCompileWithContextCancelled(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	defer func(f func(string, string, map[string]interface{}) (*vm.Program, error)) { compileProgram = f }(compileProgram)
+	compileProgram = func(expression, variable string, env map[string]interface{}) (*vm.Program, error) {
+		close(started)
+		<-release
+		return cachedProgram(expression, variable, env)
+	}
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		// Cancel once compilation is under way
+		<-started
+		cancel()
+	}()
+	if _, err := CompileWithContext(ctx, "x * 3 + 1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("CompileWithContext error = %v, want context.Canceled", err)
+	}
+}
+
+func TestCompileWithContext(t *testing.T) {
+	e, err := CompileWithContext(context.Background(), "sin(x) + 1")
+	if err != nil {
+		t.Fatalf("CompileWithContext failed: %v", err)
+	}
+	y, err := e.EvalContext(context.Background(), math.Pi/2)
+	if err != nil || math.Abs(y-2) > 1e-12 {
+		t.Errorf("EvalContext(π/2) = %v, %v, want 2", y, err)
+	}
+
+	if _, err := CompileWithContext(context.Background(), "x +"); err == nil {
+		t.Error("expected error for an invalid expression")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := CompileWithContext(ctx, "x"); !errors.Is(err, context.Canceled) {
+		t.Errorf("CompileWithContext with a cancelled context = %v, want context.Canceled", err)
+	}
+	if _, err := e.EvalContext(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("EvalContext with a cancelled context = %v, want context.Canceled", err)
+	}
+}
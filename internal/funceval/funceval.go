@@ -1,7 +1,11 @@
 package funceval
 
 import (
+	"errors"
+	"fmt"
 	"math"
+	"runtime"
+	"sync"
 
 	"github.com/expr-lang/expr"
 	"github.com/expr-lang/expr/vm"
@@ -9,10 +13,20 @@ import (
 
 // Evaluator wraps a compiled expression.
 type Evaluator struct {
-	program *vm.Program
-	env     map[string]interface{}
+	program  *vm.Program
+	env      map[string]interface{}
+	variable string
 }
 
+// ErrPanicked is wrapped by the errors of SafeEval, SafeEvalBatch and
+// SafeEvalRange for points where evaluation panicked, e.g. on integer division by zero.
+// Other evaluation errors, such as a function returning an error, are not.
+var ErrPanicked = errors.New("expression evaluation panicked")
+
+// parallelThreshold is the batch size above which EvalBatch and EvalRange
+// split the work across goroutines.
+const parallelThreshold = 10000
+
 // Map of standard math functions to expose to expr
 var mathEnv = map[string]interface{}{
 	"sin":  math.Sin,
@@ -25,46 +39,385 @@ var mathEnv = map[string]interface{}{
 	"abs":  math.Abs,
 	"pi":   math.Pi,
 	"e":    math.E,
+
+	// expr has these built in too; listing them keeps every function
+	// of the help text in one place
+	"floor": math.Floor,
+	"ceil":  math.Ceil,
+	"round": math.Round,
+
+	"sign":  sign,
+	"clamp": clamp,
+	"lerp":  lerp,
+
+	"step":      step,
+	"heaviside": heaviside,
+	"pulse":     pulse,
+	"ramp":      ramp,
+
+	"mod": mod,
+
+	// Mixed-type overloads of the % operator, see compileWith
+	"modFloatInt": func(a float64, b int) float64 { return mod(a, float64(b)) },
+	"modIntFloat": func(a int, b float64) float64 { return mod(float64(a), b) },
+}
+
+// step returns 0 for x < threshold and 1 otherwise.
+func step(x, threshold float64) float64 {
+	if x < threshold {
+		return 0
+	}
+	return 1
+}
+
+// heaviside is the unit step at zero, with heaviside(0) = 1.
+func heaviside(x float64) float64 {
+	return step(x, 0)
+}
+
+// pulse returns 1 for x within [start, end] and 0 outside.
+func pulse(x, start, end float64) float64 {
+	if x < start || x > end {
+		return 0
+	}
+	return 1
+}
+
+// ramp returns max(0, x).
+func ramp(x float64) float64 {
+	return math.Max(0, x)
+}
+
+// sign returns -1 for negative x, 1 for positive x and x itself for zero
+// and NaN.
+func sign(x float64) float64 {
+	switch {
+	case x < 0:
+		return -1
+	case x > 0:
+		return 1
+	}
+	return x
+}
+
+// clamp limits x to [lo, hi], saturating like an actuator would.
+func clamp(x, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, x))
+}
+
+// lerp interpolates linearly from a at t = 0 to b at t = 1.
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
+
+// mod returns a modulo b with the sign of b, matching MATLAB's mod.
+// mod(a, 0) is a.
+func mod(a, b float64) float64 {
+	if b == 0 {
+		return a
+	}
+	return a - math.Floor(a/b)*b
 }
 
 // Compile parses and compiles an expression.
 // The expression can use 'x' as a variable and common math functions.
 func Compile(expression string) (*Evaluator, error) {
+	return compileWith(expression, "x")
+}
+
+// exprCache maps a variable name and expression, joined by cacheKey, to its
+// compiled *vm.Program. Programs are immutable, so Evaluators share them.
+// Entries are never evicted: there are few expressions and they are small.
+var exprCache sync.Map
+
+// cacheKey returns the exprCache key of an expression.
+func cacheKey(expression, variable string) string {
+	return variable + "\x00" + expression
+}
+
+// ClearCache empties the compiled expression cache.
+func ClearCache() {
+	exprCache.Clear()
+}
+
+// cachedProgram returns the compiled program of an expression from
+// exprCache, compiling it against env on a miss.
+func cachedProgram(expression, variable string, env map[string]interface{}) (*vm.Program, error) {
+	key := cacheKey(expression, variable)
+	if program, ok := exprCache.Load(key); ok {
+		return program.(*vm.Program), nil
+	}
+
+	// expr only defines % for integers, so route float operands to mod
+	program, err := expr.Compile(expression, expr.Env(env),
+		expr.Operator("%", "mod", "modFloatInt", "modIntFloat"))
+	if err != nil {
+		return nil, err
+	}
+	exprCache.Store(key, program)
+	return program, nil
+}
+
+// compileProgram compiles an expression for compileWith. Tests replace it.
+var compileProgram = cachedProgram
+
+// compileWith compiles an expression with a single free variable.
+func compileWith(expression string, variable string) (*Evaluator, error) {
 	// Create a combined environment for compilation
 	combinedEnv := make(map[string]interface{})
 	for k, v := range mathEnv {
 		combinedEnv[k] = v
 	}
-	combinedEnv["x"] = 0.0 // Placeholder for type inference
+	combinedEnv[variable] = 0.0 // Placeholder for type inference
 
-	program, err := expr.Compile(expression, expr.Env(combinedEnv))
+	program, err := compileProgram(expression, variable, combinedEnv)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Evaluator{
-		program: program,
-		env:     combinedEnv,
+		program:  program,
+		env:      combinedEnv,
+		variable: variable,
 	}, nil
 }
 
 // Eval evaluates the compiled expression for a given x.
 func (e *Evaluator) Eval(x float64) (float64, error) {
-	e.env["x"] = x
+	e.env[e.variable] = x
 	output, err := expr.Run(e.program, e.env)
 	if err != nil {
 		return 0, err
 	}
 
-	// Cast the result to float64. expr might return int if the result is an integer.
+	return toFloat(output), nil
+}
+
+// SafeEval evaluates the compiled expression for a given x like Eval, but
+// returns NaN and an error wrapping ErrPanicked where evaluation panics.
+// Other errors are returned as by Eval, with NaN.
+func (e *Evaluator) SafeEval(x float64) (float64, error) {
+	e.env[e.variable] = x
+	var machine vm.VM
+	output, err := e.safeRun(&machine, e.env)
+	if err != nil {
+		return math.NaN(), err
+	}
+	return toFloat(output), nil
+}
+
+// safeRun runs the program on machine with env. The VM recovers runtime
+// panics itself and returns them as errors wrapping the runtime.Error; any
+// that escape, e.g. from a function outside the VM, are recovered here. Only
+// those two wrap ErrPanicked.
+func (e *Evaluator) safeRun(machine *vm.VM, env map[string]interface{}) (output interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			output, err = nil, fmt.Errorf("%w at %s=%g: %v", ErrPanicked, e.variable, env[e.variable], r)
+		}
+	}()
+	output, err = machine.Run(e.program, env)
+	if err != nil {
+		var rerr runtime.Error
+		if errors.As(err, &rerr) {
+			return nil, fmt.Errorf("%w at %s=%g: %v", ErrPanicked, e.variable, env[e.variable], err)
+		}
+		return nil, fmt.Errorf("failed to evaluate at %s=%g: %w", e.variable, env[e.variable], err)
+	}
+	return output, nil
+}
+
+// EvalBatch evaluates the compiled expression for every x in xs. Batches
+// larger than parallelThreshold are split into one shard per CPU, each
+// with its own copy of the environment.
+func (e *Evaluator) EvalBatch(xs []float64) ([]float64, error) {
+	return e.evalBatch(xs, false)
+}
+
+// SafeEvalBatch is EvalBatch, except that the points where evaluation
+// panics are NaN instead of failing the batch. The error, wrapping
+// ErrPanicked, describes the first of them. Other errors fail the batch.
+func (e *Evaluator) SafeEvalBatch(xs []float64) ([]float64, error) {
+	return e.evalBatch(xs, true)
+}
+
+// evalBatch implements EvalBatch, or SafeEvalBatch if safe is true.
+func (e *Evaluator) evalBatch(xs []float64, safe bool) ([]float64, error) {
+	ys := make([]float64, len(xs))
+	err := e.shard(len(xs), func(env map[string]interface{}, start, end int) error {
+		return e.evalShard(env, xs[start:end], ys[start:end], safe)
+	})
+	if err != nil && (!safe || !errors.Is(err, ErrPanicked)) {
+		return nil, err
+	}
+	return ys, err
+}
+
+// EvalRange evaluates the compiled expression at n points evenly spaced over
+// [xMin, xMax] and writes them to out, which the caller allocates. If out
+// has length n it receives the y values only; if it has length 2n it
+// receives interleaved x, y pairs. Like EvalBatch, large ranges are split
+// across goroutines.
+func (e *Evaluator) EvalRange(xMin, xMax float64, n int, out []float64) error {
+	return e.evalRange(xMin, xMax, n, out, false)
+}
+
+// SafeEvalRange is EvalRange, except that the points where evaluation
+// panics are NaN instead of failing the range. The error, wrapping
+// ErrPanicked, describes the first of them. Other errors fail the range.
+func (e *Evaluator) SafeEvalRange(xMin, xMax float64, n int, out []float64) error {
+	return e.evalRange(xMin, xMax, n, out, true)
+}
+
+// evalRange implements EvalRange, or SafeEvalRange if safe is true.
+func (e *Evaluator) evalRange(xMin, xMax float64, n int, out []float64, safe bool) error {
+	if n < 2 {
+		return fmt.Errorf("need at least 2 points, got %d", n)
+	}
+	var stride int
+	switch len(out) {
+	case n:
+		stride = 1
+	case 2 * n:
+		stride = 2
+	default:
+		return fmt.Errorf("output length %d must be %d or %d", len(out), n, 2*n)
+	}
+
+	dx := (xMax - xMin) / float64(n-1)
+	return e.shard(n, func(env map[string]interface{}, start, end int) error {
+		return e.evalRangeShard(env, xMin, dx, start, end, out, stride, safe)
+	})
+}
+
+// shard calls run for the indices [0, n). Above parallelThreshold they are
+// split into one shard per CPU, each run with its own copy of the
+// environment. It returns the error of the first shard that failed.
+func (e *Evaluator) shard(n int, run func(env map[string]interface{}, start, end int) error) error {
+	if n <= parallelThreshold {
+		return run(e.env, 0, n)
+	}
+
+	shards := runtime.GOMAXPROCS(0)
+	shardSize := (n + shards - 1) / shards
+	errs := make([]error, shards)
+
+	var wg sync.WaitGroup
+	for i := 0; i < shards; i++ {
+		start := i * shardSize
+		if start >= n {
+			break
+		}
+		end := min(start+shardSize, n)
+
+		env := make(map[string]interface{}, len(e.env))
+		for k, v := range e.env {
+			env[k] = v
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = run(env, start, end)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// evalShard evaluates xs into ys using env, reusing a single VM. If safe is
+// true, points that fail are NaN and the first failure is returned at the end.
+func (e *Evaluator) evalShard(env map[string]interface{}, xs, ys []float64, safe bool) error {
+	var machine vm.VM
+	if safe {
+		var first error
+		for i, x := range xs {
+			env[e.variable] = x
+			output, err := e.safeRun(&machine, env)
+			if err != nil && !errors.Is(err, ErrPanicked) {
+				return err
+			}
+			if err != nil {
+				ys[i] = math.NaN()
+				if first == nil {
+					first = err
+				}
+				continue
+			}
+			ys[i] = toFloat(output)
+		}
+		return first
+	}
+
+	for i, x := range xs {
+		env[e.variable] = x
+		output, err := machine.Run(e.program, env)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate at %s=%g: %w", e.variable, x, err)
+		}
+		ys[i] = toFloat(output)
+	}
+	return nil
+}
+
+// evalRangeShard evaluates the points [start, end) of a range starting at
+// xMin with step dx into out, which holds y values if stride is 1 and
+// interleaved x, y pairs if it is 2. If safe is true, points that fail are
+// NaN and the first failure is returned at the end.
+func (e *Evaluator) evalRangeShard(env map[string]interface{}, xMin, dx float64, start, end int, out []float64, stride int, safe bool) error {
+	var machine vm.VM
+	var first error
+	for i := start; i < end; i++ {
+		x := xMin + float64(i)*dx
+		env[e.variable] = x
+
+		var output interface{}
+		var err error
+		if safe {
+			output, err = e.safeRun(&machine, env)
+		} else {
+			output, err = machine.Run(e.program, env)
+		}
+
+		y := toFloat(output)
+		if err != nil {
+			if !safe {
+				return fmt.Errorf("failed to evaluate at %s=%g: %w", e.variable, x, err)
+			}
+			if !errors.Is(err, ErrPanicked) {
+				return err
+			}
+			y = math.NaN()
+			if first == nil {
+				first = err
+			}
+		}
+		if stride == 2 {
+			out[2*i], out[2*i+1] = x, y
+		} else {
+			out[i] = y
+		}
+	}
+	return first
+}
+
+// toFloat casts an expression result to float64. expr might return int if
+// the result is an integer.
+func toFloat(output interface{}) float64 {
 	switch v := output.(type) {
 	case float64:
-		return v, nil
+		return v
 	case int:
-		return float64(v), nil
+		return float64(v)
 	case int64:
-		return float64(v), nil
+		return float64(v)
 	default:
-		return 0, nil // Or handle error
+		return 0 // Or handle error
 	}
 }
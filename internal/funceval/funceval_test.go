@@ -1,8 +1,12 @@
 package funceval
 
 import (
+	"errors"
+	"fmt"
 	"math"
 	"testing"
+
+	"github.com/expr-lang/expr"
 )
 
 func TestCompileAndEval(t *testing.T) {
@@ -36,3 +40,322 @@ func TestCompileAndEval(t *testing.T) {
 		})
 	}
 }
+
+func TestEvalBatchMatchesEval(t *testing.T) {
+	for _, n := range []int{100, parallelThreshold + 1234} {
+		eval, err := Compile("exp(-0.1 * x) * sin(x)")
+		if err != nil {
+			t.Fatalf("Compile failed: %v", err)
+		}
+
+		xs := make([]float64, n)
+		for i := range xs {
+			xs[i] = float64(i) * 0.01
+		}
+		ys, err := eval.EvalBatch(xs)
+		if err != nil {
+			t.Fatalf("EvalBatch failed: %v", err)
+		}
+		if len(ys) != n {
+			t.Fatalf("EvalBatch returned %d values, want %d", len(ys), n)
+		}
+		for i, x := range xs {
+			want, _ := eval.Eval(x)
+			if ys[i] != want {
+				t.Fatalf("n=%d: EvalBatch[%d] = %v, want %v", n, i, ys[i], want)
+			}
+		}
+	}
+}
+
+func benchmarkInputs(n int) []float64 {
+	xs := make([]float64, n)
+	for i := range xs {
+		xs[i] = float64(i) * 0.001
+	}
+	return xs
+}
+
+func BenchmarkEvalLoop(b *testing.B) {
+	for _, n := range []int{100000, 1000000} {
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			eval, _ := Compile("exp(-0.1 * x) * sin(x)")
+			xs := benchmarkInputs(n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				ys := make([]float64, n)
+				for j, x := range xs {
+					ys[j], _ = eval.Eval(x)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkEvalBatch(b *testing.B) {
+	for _, n := range []int{100000, 1000000} {
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			eval, _ := Compile("exp(-0.1 * x) * sin(x)")
+			xs := benchmarkInputs(n)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				eval.EvalBatch(xs)
+			}
+		})
+	}
+}
+
+func TestEvalRange(t *testing.T) {
+	eval, err := Compile("x * x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{11, parallelThreshold + 1} {
+		dx := 10 / float64(n-1)
+
+		ys := make([]float64, n)
+		if err := eval.EvalRange(0, 10, n, ys); err != nil {
+			t.Fatalf("EvalRange failed: %v", err)
+		}
+		pairs := make([]float64, 2*n)
+		if err := eval.EvalRange(0, 10, n, pairs); err != nil {
+			t.Fatalf("EvalRange failed: %v", err)
+		}
+		for i := 0; i < n; i++ {
+			x := float64(i) * dx
+			if ys[i] != x*x {
+				t.Fatalf("n=%d: y[%d] = %v, want %v", n, i, ys[i], x*x)
+			}
+			if pairs[2*i] != x || pairs[2*i+1] != x*x {
+				t.Fatalf("n=%d: pair %d = %v, %v, want %v, %v", n, i, pairs[2*i], pairs[2*i+1], x, x*x)
+			}
+		}
+	}
+
+	if err := eval.EvalRange(0, 10, 11, make([]float64, 12)); err == nil {
+		t.Error("EvalRange accepted an output of the wrong length")
+	}
+	if err := eval.EvalRange(0, 10, 1, make([]float64, 1)); err == nil {
+		t.Error("EvalRange accepted a single point")
+	}
+}
+
+func BenchmarkEvalRange(b *testing.B) {
+	for _, n := range []int{100000, 1000000} {
+		b.Run(fmt.Sprintf("n=%d", n), func(b *testing.B) {
+			eval, _ := Compile("exp(-0.1 * x) * sin(x)")
+			out := make([]float64, 2*n)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				eval.EvalRange(0, float64(n)*0.001, n, out)
+			}
+		})
+	}
+}
+
+func TestStepFunctions(t *testing.T) {
+	tests := []struct {
+		expression string
+		x          float64
+		expected   float64
+	}{
+		{"step(x, 2)", 1.999, 0},
+		{"step(x, 2)", 2, 1},
+		{"step(x, 2)", 5, 1},
+		{"heaviside(x)", -1e-12, 0},
+		{"heaviside(x)", 0, 1},
+		{"pulse(x, 1, 3)", 0.999, 0},
+		{"pulse(x, 1, 3)", 1, 1},
+		{"pulse(x, 1, 3)", 3, 1},
+		{"pulse(x, 1, 3)", 3.001, 0},
+		{"ramp(x)", -2, 0},
+		{"ramp(x)", 0, 0},
+		{"ramp(x)", 2.5, 2.5},
+		{"2 * step(x, 1) - step(x, 3)", 2, 2},
+	}
+
+	for _, tt := range tests {
+		eval, err := Compile(tt.expression)
+		if err != nil {
+			t.Fatalf("Compile(%q) failed: %v", tt.expression, err)
+		}
+		got, err := eval.Eval(tt.x)
+		if err != nil {
+			t.Fatalf("Eval(%q) failed: %v", tt.expression, err)
+		}
+		if got != tt.expected {
+			t.Errorf("%s at x=%v = %v, want %v", tt.expression, tt.x, got, tt.expected)
+		}
+	}
+}
+
+func TestRoundingAndLimitFunctions(t *testing.T) {
+	tests := []struct {
+		expression string
+		x          float64
+		expected   float64
+	}{
+		{"floor(x)", -2.5, -3},
+		{"ceil(x)", -2.5, -2},
+		{"round(x)", 2.5, 3},
+		{"round(x)", -2.5, -3},
+		{"clamp(5, 0, 3)", 0, 3},
+		{"clamp(x, 0, 3)", -1, 0},
+		{"clamp(x, 0, 3)", 1.5, 1.5},
+		{"sign(-2.5)", 0, -1},
+		{"sign(x)", 0, 0},
+		{"sign(x)", 0.1, 1},
+		{"lerp(0, 10, 0.5)", 0, 5},
+		{"lerp(2, 4, x)", 1, 4},
+	}
+
+	for _, tt := range tests {
+		eval, err := Compile(tt.expression)
+		if err != nil {
+			t.Fatalf("Compile(%q) failed: %v", tt.expression, err)
+		}
+		got, err := eval.Eval(tt.x)
+		if err != nil {
+			t.Fatalf("Eval(%q) failed: %v", tt.expression, err)
+		}
+		if got != tt.expected {
+			t.Errorf("%s at x=%v = %v, want %v", tt.expression, tt.x, got, tt.expected)
+		}
+	}
+}
+
+func TestCompileCache(t *testing.T) {
+	ClearCache()
+	a, err := Compile("sin(x) + 1")
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	b, _ := Compile("sin(x) + 1")
+	if a.program != b.program {
+		t.Error("second Compile did not reuse the cached program")
+	}
+
+	// Evaluators share the program but not the environment
+	ya, _ := a.Eval(0)
+	yb, _ := b.Eval(math.Pi / 2)
+	if ya != 1 || yb != 2 {
+		t.Errorf("Eval = %v, %v, want 1 and 2", ya, yb)
+	}
+
+	// The same text with another variable is a different program
+	if p, _ := compileWith("sin(x) + 1", "t"); p.program == a.program {
+		t.Error("program cached across variables")
+	}
+
+	ClearCache()
+	if c, _ := Compile("sin(x) + 1"); c.program == a.program {
+		t.Error("ClearCache kept the program")
+	}
+}
+
+func BenchmarkCompile(b *testing.B) {
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			Compile("sin(x)")
+		}
+	})
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ClearCache()
+			Compile("sin(x)")
+		}
+	})
+}
+
+func TestSafeEval(t *testing.T) {
+	// int(0.5) is 0, and integer % by zero panics inside the VM. Note that
+	// "1/int(x)" does not: expr's / divides as floats and gives +Inf.
+	eval, err := Compile("1 % int(x)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	y, err := eval.SafeEval(0.5)
+	if !errors.Is(err, ErrPanicked) {
+		t.Errorf("SafeEval(0.5) error = %v, want ErrPanicked", err)
+	}
+	if !math.IsNaN(y) {
+		t.Errorf("SafeEval(0.5) = %v, want NaN", y)
+	}
+
+	if y, err := eval.SafeEval(3); err != nil || y != 1 {
+		t.Errorf("SafeEval(3) = %v, %v, want 1", y, err)
+	}
+}
+
+func TestSafeEvalErrorNotPanic(t *testing.T) {
+	// A function that returns an error fails evaluation without panicking
+	errOutOfDomain := errors.New("out of domain")
+	env := map[string]interface{}{
+		"x": 0.0,
+		"checked": func(x float64) (float64, error) {
+			if x < 0 {
+				return 0, errOutOfDomain
+			}
+			return x, nil
+		},
+	}
+	program, err := expr.Compile("checked(x)", expr.Env(env))
+	if err != nil {
+		t.Fatal(err)
+	}
+	eval := &Evaluator{program: program, env: env, variable: "x"}
+
+	y, err := eval.SafeEval(-1)
+	if !errors.Is(err, errOutOfDomain) || errors.Is(err, ErrPanicked) {
+		t.Errorf("SafeEval(-1) error = %v, want the function's error, not ErrPanicked", err)
+	}
+	if !math.IsNaN(y) {
+		t.Errorf("SafeEval(-1) = %v, want NaN", y)
+	}
+	if _, err := eval.SafeEvalBatch([]float64{1, -1, 2}); errors.Is(err, ErrPanicked) || err == nil {
+		t.Errorf("SafeEvalBatch error = %v, want the batch to fail", err)
+	}
+	if err := eval.SafeEvalRange(-1, 1, 3, make([]float64, 3)); errors.Is(err, ErrPanicked) || err == nil {
+		t.Errorf("SafeEvalRange error = %v, want the range to fail", err)
+	}
+}
+
+func TestSafeEvalBatch(t *testing.T) {
+	eval, err := Compile("6 % int(x)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{5, parallelThreshold + 5} {
+		xs := make([]float64, n)
+		for i := range xs {
+			xs[i] = float64(i % 5) // 0 fails
+		}
+		ys, err := eval.SafeEvalBatch(xs)
+		if !errors.Is(err, ErrPanicked) {
+			t.Errorf("n=%d: error = %v, want ErrPanicked", n, err)
+		}
+		// Five points over [0, 4] are the same x values
+		if n == 5 {
+			out := make([]float64, n)
+			if err := eval.SafeEvalRange(0, 4, n, out); !errors.Is(err, ErrPanicked) {
+				t.Errorf("SafeEvalRange error = %v, want ErrPanicked", err)
+			}
+			if !math.IsNaN(out[0]) || out[4] != 2 {
+				t.Errorf("SafeEvalRange = %v, want NaN at x=0", out)
+			}
+		}
+		for i, x := range xs {
+			if x == 0 {
+				if !math.IsNaN(ys[i]) {
+					t.Fatalf("n=%d: ys[%d] = %v at x=0, want NaN", n, i, ys[i])
+				}
+			} else if want := float64(6 % int(x)); ys[i] != want {
+				t.Fatalf("n=%d: ys[%d] = %v, want %v", n, i, ys[i], want)
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package funceval
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/parser"
+)
+
+// LintWarning describes a sub-expression that is valid but may make
+// evaluation needlessly slow.
+type LintWarning struct {
+	Expression string `json:"expression"` // The sub-expression, as printed by expr
+	Message    string `json:"message"`
+}
+
+// maxCallDepth is the deepest nesting of function calls Lint accepts.
+const maxCallDepth = 5
+
+// largeExponent is the magnitude from which Lint warns about a literal
+// exponent.
+const largeExponent = 1000
+
+// Lint returns warnings about the expression's slow sub-expressions: those
+// that are constant but call a function, such as sin(pi / 2), and so could
+// be precomputed, literal exponents of largeExponent or more, and function
+// calls nested more than maxCallDepth deep. It never fails: expressions that
+// don't parse have no warnings, and Compile reports their errors.
+func Lint(expression string) []LintWarning {
+	tree, err := parser.Parse(expression)
+	if err != nil {
+		return nil
+	}
+	l := &linter{
+		constant: make(map[ast.Node]bool),
+		hasCall:  make(map[ast.Node]bool),
+		depth:    make(map[ast.Node]int),
+	}
+	ast.Walk(&tree.Node, l)
+
+	if d := l.depth[tree.Node]; d > maxCallDepth {
+		l.warn(tree.Node, fmt.Sprintf("function calls are nested %d deep, more than %d", d, maxCallDepth))
+	}
+	return l.warnings
+}
+
+// linter is the ast.Visitor of Lint. ast.Walk visits children before their
+// parent, so each node's properties are worked out from its children's.
+type linter struct {
+	constant map[ast.Node]bool // Whether the node's value is the same for any x
+	hasCall  map[ast.Node]bool // Whether the node contains a function call
+	depth    map[ast.Node]int  // Deepest nesting of function calls in the node
+	warnings []LintWarning
+}
+
+func (l *linter) Visit(node *ast.Node) {
+	n := *node
+	children := astChildren(n)
+
+	constant, hasCall, depth := true, false, 0
+	for _, c := range children {
+		constant = constant && l.constant[c]
+		hasCall = hasCall || l.hasCall[c]
+		depth = max(depth, l.depth[c])
+	}
+	switch n := n.(type) {
+	case *ast.IntegerNode, *ast.FloatNode, *ast.BoolNode, *ast.StringNode, *ast.ConstantNode, *ast.NilNode:
+	case *ast.IdentifierNode:
+		// pi and e, but not functions or the variable
+		v, ok := mathEnv[n.Value]
+		constant = ok && reflect.TypeOf(v).Kind() != reflect.Func
+	case *ast.UnaryNode, *ast.BinaryNode, *ast.ConditionalNode:
+		if b, ok := n.(*ast.BinaryNode); ok && (b.Operator == "**" || b.Operator == "^") {
+			l.checkExponent(b.Right)
+		}
+	case *ast.CallNode:
+		hasCall, depth = true, depth+1
+		if callee, ok := n.Callee.(*ast.IdentifierNode); ok && callee.Value == "pow" && len(n.Arguments) == 2 {
+			l.checkExponent(n.Arguments[1])
+		}
+	case *ast.BuiltinNode:
+		hasCall, depth = true, depth+1
+	default:
+		// Members, arrays, closures and the like aren't used in plotted
+		// functions, so aren't assumed to be constant
+		constant = false
+	}
+	l.constant[n], l.hasCall[n], l.depth[n] = constant, hasCall, depth
+
+	if !constant {
+		l.constantChildren(n)
+	}
+}
+
+// constantChildren warns about the children of the non-constant node n that
+// are constant and call a function. Only the largest such sub-expressions
+// are reported, and never the whole expression, which is a constant function
+// by intent.
+func (l *linter) constantChildren(n ast.Node) {
+	for _, c := range astChildren(n) {
+		if l.constant[c] && l.hasCall[c] {
+			l.warn(c, "constant sub-expression could be precomputed")
+		}
+	}
+}
+
+// checkExponent warns if exponent is a literal of largeExponent or more.
+func (l *linter) checkExponent(exponent ast.Node) {
+	var v float64
+	switch n := exponent.(type) {
+	case *ast.IntegerNode:
+		v = float64(n.Value)
+	case *ast.FloatNode:
+		v = n.Value
+	case *ast.UnaryNode:
+		if n.Operator != "-" {
+			return
+		}
+		l.checkExponent(n.Node)
+		return
+	default:
+		return
+	}
+	if math.Abs(v) >= largeExponent {
+		l.warn(exponent, fmt.Sprintf("exponent %g is very large", v))
+	}
+}
+
+func (l *linter) warn(n ast.Node, message string) {
+	l.warnings = append(l.warnings, LintWarning{Expression: n.String(), Message: message})
+}
+
+// astChildren returns the operands of the node types that appear in plotted
+// functions.
+func astChildren(n ast.Node) []ast.Node {
+	switch n := n.(type) {
+	case *ast.UnaryNode:
+		return []ast.Node{n.Node}
+	case *ast.BinaryNode:
+		return []ast.Node{n.Left, n.Right}
+	case *ast.ConditionalNode:
+		return []ast.Node{n.Cond, n.Exp1, n.Exp2}
+	case *ast.CallNode:
+		return n.Arguments
+	case *ast.BuiltinNode:
+		return n.Arguments
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package funceval
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		expression string
+		want       []LintWarning
+	}{
+		{"sin(x) * 2 + pi", nil},
+		{"sin(1)", nil}, // A constant function, not a sub-expression
+		{"x * sin(pi / 2)", []LintWarning{{"sin(pi / 2)", "constant sub-expression could be precomputed"}}},
+		{"pow(x, 1000000)", []LintWarning{{"1000000", "exponent 1e+06 is very large"}}},
+		{"x ** 5000", []LintWarning{{"5000", "exponent 5000 is very large"}}},
+		{"pow(x, 3)", nil},
+		{"sin(sin(sin(sin(sin(x)))))", nil},
+		{"sin(sin(sin(sin(sin(sin(x))))))", []LintWarning{{"sin(sin(sin(sin(sin(sin(x))))))", "function calls are nested 6 deep, more than 5"}}},
+		{"x +", nil}, // Compile reports the error
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			got := Lint(tt.expression)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Lint(%q) = %v, want %v", tt.expression, got, tt.want)
+			}
+			if tt.want != nil {
+				// Warnings don't stop an expression compiling
+				if _, err := Compile(tt.expression); err != nil {
+					t.Errorf("Compile(%q) failed: %v", tt.expression, err)
+				}
+			}
+		})
+	}
+}
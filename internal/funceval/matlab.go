@@ -0,0 +1,117 @@
+package funceval
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// matlabOperators rewrites MATLAB's element-wise operators to their scalar
+// forms, which is what Compile evaluates point by point anyway.
+var matlabOperators = strings.NewReplacer(
+	".^", "^",
+	".*", "*",
+	"./", "/",
+	".+", "+",
+	".-", "-",
+)
+
+// matlabMod matches the start of a mod(a, b) call.
+var matlabMod = regexp.MustCompile(`\bmod\s*\(`)
+
+// simpleOperand matches a name or number that needs no parentheses as an
+// operand of %.
+var simpleOperand = regexp.MustCompile(`^[A-Za-z0-9_.]+$`)
+
+// ImportMATLAB rewrites a MATLAB/Octave expression into the syntax accepted
+// by Compile.
+//
+// Supported subset:
+//   - the element-wise operators .^, .*, ./, .+ and .- (→ ^, *, /, +, -)
+//   - mod(a, b) (→ ((a % b) + b) % b, MATLAB's floored modulo, so the
+//     result takes the sign of b even for integer operands)
+//   - the constant pi, which needs no rewriting
+//
+// The result is not compiled, since the free variable depends on the caller
+// (x, or theta in polar mode).
+func ImportMATLAB(s string) (string, error) {
+	return rewriteMod(matlabOperators.Replace(s))
+}
+
+// rewriteMod replaces every mod(a, b) call in s, including nested ones, with
+// the parenthesised floored modulo ((a % b) + b) % b. A bare a % b would
+// truncate toward zero when both operands are integer literals.
+func rewriteMod(s string) (string, error) {
+	loc := matlabMod.FindStringIndex(s)
+	if loc == nil {
+		return s, nil
+	}
+
+	depth := 0
+	var args []string
+	argStart := loc[1]
+	end := -1
+	for i := loc[1] - 1; i < len(s) && end < 0; i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				args = append(args, s[argStart:i])
+				end = i
+			}
+		case ',':
+			if depth == 1 {
+				args = append(args, s[argStart:i])
+				argStart = i + 1
+			}
+		}
+	}
+	if end < 0 {
+		return "", fmt.Errorf("unbalanced parentheses in mod call at offset %d", loc[0])
+	}
+	if len(args) != 2 {
+		return "", fmt.Errorf("mod expects 2 arguments, got %d", len(args))
+	}
+
+	operands := make([]string, 2)
+	for i, arg := range args {
+		rewritten, err := rewriteMod(strings.TrimSpace(arg))
+		if err != nil {
+			return "", err
+		}
+		if !simpleOperand.MatchString(rewritten) && !parenthesised(rewritten) {
+			rewritten = "(" + rewritten + ")"
+		}
+		operands[i] = rewritten
+	}
+
+	rest, err := rewriteMod(s[end+1:])
+	if err != nil {
+		return "", err
+	}
+	a, b := operands[0], operands[1]
+	return s[:loc[0]] + "(((" + a + " % " + b + ") + " + b + ") % " + b + ")" + rest, nil
+}
+
+// parenthesised reports whether s is entirely enclosed by one pair of
+// parentheses, e.g. "(x % 5)" but not "(x) + (y)".
+func parenthesised(s string) bool {
+	if !strings.HasPrefix(s, "(") {
+		return false
+	}
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i == len(s)-1
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package funceval
+
+import (
+	"math"
+	"testing"
+)
+
+func TestImportMATLAB(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		x        float64
+		value    float64
+	}{
+		{"sin(x).^2 + cos(x)", "sin(x)^2 + cos(x)", 1, math.Pow(math.Sin(1), 2) + math.Cos(1)},
+		{"x.*3", "x*3", 2, 6},
+		{"1./x", "1/x", 4, 0.25},
+		{"x.+1", "x+1", 2, 3},
+		{"x.-1", "x-1", 2, 1},
+		{"pi*x", "pi*x", 2, 2 * math.Pi},
+		{"mod(x, 3)", "(((x % 3) + 3) % 3)", 7.5, 1.5},
+		{"mod(-x, 3)", "((((-x) % 3) + 3) % 3)", 1, 2},
+		{"2*mod(x+1, 2.5)", "2*((((x+1) % 2.5) + 2.5) % 2.5)", 2, 1},
+		{"mod(mod(x, 5), 2)", "((((((x % 5) + 5) % 5) % 2) + 2) % 2)", 8, 1},
+		{"mod(-7, 3)", "((((-7) % 3) + 3) % 3)", 0, 2},
+		{"mod(7, -3)", "(((7 % (-3)) + (-3)) % (-3))", 0, -2},
+		{"mod(x, -3)", "(((x % (-3)) + (-3)) % (-3))", 7.5, -1.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ImportMATLAB(tt.input)
+			if err != nil {
+				t.Fatalf("ImportMATLAB failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("ImportMATLAB() = %q, want %q", got, tt.expected)
+			}
+
+			eval, err := Compile(got)
+			if err != nil {
+				t.Fatalf("Compile failed: %v", err)
+			}
+			value, err := eval.Eval(tt.x)
+			if err != nil {
+				t.Fatalf("Eval failed: %v", err)
+			}
+			if math.Abs(value-tt.value) > 1e-9 {
+				t.Errorf("Eval(%v) = %v, want %v", tt.x, value, tt.value)
+			}
+		})
+	}
+}
+
+func TestImportMATLABRejects(t *testing.T) {
+	for _, input := range []string{"mod(x)", "mod(x, 2", "mod(x, 2, 3)"} {
+		if _, err := ImportMATLAB(input); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
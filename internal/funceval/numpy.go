@@ -0,0 +1,59 @@
+package funceval
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// numpyNames maps the supported numpy functions and constants to their
+// expr equivalents.
+var numpyNames = map[string]string{
+	"sin":      "sin",
+	"cos":      "cos",
+	"tan":      "tan",
+	"exp":      "exp",
+	"log":      "log",
+	"sqrt":     "sqrt",
+	"abs":      "abs",
+	"absolute": "abs",
+	"power":    "pow",
+	"pi":       "pi",
+	"e":        "e",
+}
+
+// numpyRef matches a qualified numpy name such as "np.sin" or "numpy.pi".
+var numpyRef = regexp.MustCompile(`\b(?:np|numpy)\.([A-Za-z_][A-Za-z0-9_]*)\b`)
+
+// ImportNumpyExpression rewrites a numpy expression string, as exported from
+// an engineering notebook, into the syntax accepted by Compile.
+//
+// Supported subset:
+//   - np.sin, np.cos, np.tan, np.exp, np.log, np.sqrt
+//   - np.abs and np.absolute (→ abs), np.power (→ pow)
+//   - the constants np.pi and np.e
+//   - the ** power operator (→ ^)
+//
+// The "numpy." prefix is accepted as well as "np.". Any other numpy
+// reference is rejected.
+func ImportNumpyExpression(s string) (string, error) {
+	var unsupported []string
+	result := numpyRef.ReplaceAllStringFunc(s, func(ref string) string {
+		name := numpyRef.FindStringSubmatch(ref)[1]
+		if replacement, ok := numpyNames[name]; ok {
+			return replacement
+		}
+		unsupported = append(unsupported, ref)
+		return ref
+	})
+	if len(unsupported) > 0 {
+		return "", fmt.Errorf("unsupported numpy reference: %s", strings.Join(unsupported, ", "))
+	}
+
+	result = strings.ReplaceAll(result, "**", "^")
+
+	if _, err := Compile(result); err != nil {
+		return "", fmt.Errorf("converted expression %q does not compile: %w", result, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,55 @@
+package funceval
+
+import (
+	"math"
+	"testing"
+)
+
+func TestImportNumpyExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		x        float64
+		value    float64
+	}{
+		{"np.sin(x) + np.exp(-0.1*x)", "sin(x) + exp(-0.1*x)", 1, math.Sin(1) + math.Exp(-0.1)},
+		{"x**2", "x^2", 3, 9},
+		{"np.pi * x", "pi * x", 2, 2 * math.Pi},
+		{"np.e ** x", "e ^ x", 2, math.E * math.E},
+		{"numpy.sqrt(np.abs(x))", "sqrt(abs(x))", -4, 2},
+		{"np.power(x, 3)", "pow(x, 3)", 2, 8},
+		{"sin(x)", "sin(x)", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ImportNumpyExpression(tt.input)
+			if err != nil {
+				t.Fatalf("ImportNumpyExpression failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("ImportNumpyExpression() = %q, want %q", got, tt.expected)
+			}
+
+			eval, err := Compile(got)
+			if err != nil {
+				t.Fatalf("Compile failed: %v", err)
+			}
+			value, err := eval.Eval(tt.x)
+			if err != nil {
+				t.Fatalf("Eval failed: %v", err)
+			}
+			if math.Abs(value-tt.value) > 1e-9 {
+				t.Errorf("Eval(%v) = %v, want %v", tt.x, value, tt.value)
+			}
+		})
+	}
+}
+
+func TestImportNumpyExpressionRejects(t *testing.T) {
+	for _, input := range []string{"np.arctan2(x, 1)", "np.sin(x", "np.linspace(0, 1)"} {
+		if _, err := ImportNumpyExpression(input); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package funceval
+
+import (
+	"fmt"
+	"math"
+)
+
+// PolarEvaluator evaluates a polar equation r = f(theta) and converts the
+// result to Cartesian coordinates.
+type PolarEvaluator struct {
+	eval *Evaluator
+}
+
+// CompilePolar parses and compiles a polar expression.
+// The expression can use 'theta' as a variable and common math functions.
+func CompilePolar(rExpr string) (*PolarEvaluator, error) {
+	eval, err := compileWith(rExpr, "theta")
+	if err != nil {
+		return nil, err
+	}
+	return &PolarEvaluator{eval: eval}, nil
+}
+
+// EvalAll samples n points with theta evenly spaced over [thetaMin, thetaMax]
+// and returns interleaved x, y pairs where x = r·cos(theta), y = r·sin(theta).
+func (p *PolarEvaluator) EvalAll(thetaMin, thetaMax float64, n int) ([]float64, error) {
+	if n < 2 {
+		return nil, fmt.Errorf("need at least 2 points, got %d", n)
+	}
+
+	result := make([]float64, n*2)
+	dTheta := (thetaMax - thetaMin) / float64(n-1)
+	for i := 0; i < n; i++ {
+		theta := thetaMin + float64(i)*dTheta
+		r, err := p.eval.Eval(theta)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate r at theta=%g: %w", theta, err)
+		}
+		result[i*2] = r * math.Cos(theta)
+		result[i*2+1] = r * math.Sin(theta)
+	}
+	return result, nil
+}
@@ -0,0 +1,59 @@
+package funceval
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPolarEvalAll(t *testing.T) {
+	tests := []struct {
+		name     string
+		rExpr    string
+		thetaMin float64
+		thetaMax float64
+		n        int
+		radius   func(theta float64) float64
+	}{
+		{"unit circle", "1", 0, 2 * math.Pi, 65, func(float64) float64 { return 1 }},
+		{"archimedean spiral", "theta", 0, 4 * math.Pi, 129, func(theta float64) float64 { return theta }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eval, err := CompilePolar(tt.rExpr)
+			if err != nil {
+				t.Fatalf("CompilePolar failed: %v", err)
+			}
+			points, err := eval.EvalAll(tt.thetaMin, tt.thetaMax, tt.n)
+			if err != nil {
+				t.Fatalf("EvalAll failed: %v", err)
+			}
+			if len(points) != tt.n*2 {
+				t.Fatalf("got %d values, want %d", len(points), tt.n*2)
+			}
+
+			dTheta := (tt.thetaMax - tt.thetaMin) / float64(tt.n-1)
+			for i := 0; i < tt.n; i++ {
+				theta := tt.thetaMin + float64(i)*dTheta
+				r := tt.radius(theta)
+				x, y := points[i*2], points[i*2+1]
+				if math.Abs(x-r*math.Cos(theta)) > 1e-9 || math.Abs(y-r*math.Sin(theta)) > 1e-9 {
+					t.Fatalf("point %d = (%v, %v), want (%v, %v)", i, x, y, r*math.Cos(theta), r*math.Sin(theta))
+				}
+				if math.Abs(math.Hypot(x, y)-math.Abs(r)) > 1e-9 {
+					t.Errorf("point %d has radius %v, want %v", i, math.Hypot(x, y), r)
+				}
+			}
+		})
+	}
+}
+
+func TestPolarEvalAllTooFewPoints(t *testing.T) {
+	eval, err := CompilePolar("1")
+	if err != nil {
+		t.Fatalf("CompilePolar failed: %v", err)
+	}
+	if _, err := eval.EvalAll(0, math.Pi, 1); err == nil {
+		t.Error("expected error for n < 2")
+	}
+}
@@ -0,0 +1,45 @@
+package funceval
+
+import "fmt"
+
+// VectorEvaluator evaluates a vector-valued function of one parameter, such
+// as a 3D trajectory [x(t), y(t), z(t)].
+type VectorEvaluator struct {
+	evals []*Evaluator
+}
+
+// CompileVector parses and compiles one expression per vector component.
+// The expressions can use param as a variable and common math functions.
+func CompileVector(exprs []string, param string) (*VectorEvaluator, error) {
+	if len(exprs) == 0 {
+		return nil, fmt.Errorf("need at least one component expression")
+	}
+
+	evals := make([]*Evaluator, len(exprs))
+	for i, e := range exprs {
+		eval, err := compileWith(e, param)
+		if err != nil {
+			return nil, fmt.Errorf("component %d: %w", i+1, err)
+		}
+		evals[i] = eval
+	}
+	return &VectorEvaluator{evals: evals}, nil
+}
+
+// Dim returns the number of components.
+func (v *VectorEvaluator) Dim() int {
+	return len(v.evals)
+}
+
+// Eval evaluates every component at t and returns one value per expression.
+func (v *VectorEvaluator) Eval(t float64) ([]float64, error) {
+	result := make([]float64, len(v.evals))
+	for i, eval := range v.evals {
+		value, err := eval.Eval(t)
+		if err != nil {
+			return nil, fmt.Errorf("component %d: %w", i+1, err)
+		}
+		result[i] = value
+	}
+	return result, nil
+}
@@ -0,0 +1,42 @@
+package funceval
+
+import (
+	"math"
+	"testing"
+)
+
+func TestVectorHelix(t *testing.T) {
+	eval, err := CompileVector([]string{"cos(t)", "sin(t)", "0.1 * t"}, "t")
+	if err != nil {
+		t.Fatalf("CompileVector failed: %v", err)
+	}
+	if eval.Dim() != 3 {
+		t.Fatalf("Dim() = %d, want 3", eval.Dim())
+	}
+
+	for _, tv := range []float64{0, 1, math.Pi, 10} {
+		p, err := eval.Eval(tv)
+		if err != nil {
+			t.Fatalf("Eval(%v) failed: %v", tv, err)
+		}
+		want := []float64{math.Cos(tv), math.Sin(tv), 0.1 * tv}
+		for i := range want {
+			if math.Abs(p[i]-want[i]) > 1e-12 {
+				t.Errorf("Eval(%v)[%d] = %v, want %v", tv, i, p[i], want[i])
+			}
+		}
+		// Every point lies on the unit cylinder
+		if r := math.Hypot(p[0], p[1]); math.Abs(r-1) > 1e-12 {
+			t.Errorf("Eval(%v) radius = %v, want 1", tv, r)
+		}
+	}
+}
+
+func TestCompileVectorErrors(t *testing.T) {
+	if _, err := CompileVector(nil, "t"); err == nil {
+		t.Error("expected error for no components")
+	}
+	if _, err := CompileVector([]string{"cos(t)", "sin(t", "t"}, "t"); err == nil {
+		t.Error("expected error for an invalid component")
+	}
+}
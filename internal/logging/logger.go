@@ -5,6 +5,7 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 )
@@ -13,6 +14,10 @@ var (
 	globalWriter io.Writer = os.Stderr
 	writerMu     sync.RWMutex
 	logLevel     = slog.LevelDebug
+
+	// exitFunc is called by Fatal. Tests replace it so that they can check
+	// Fatal without exiting the test binary.
+	exitFunc = os.Exit
 )
 
 // SafeMultiWriter is a writer that writes to multiple writers but doesn't
@@ -31,6 +36,25 @@ func (s *SafeMultiWriter) Write(p []byte) (n int, err error) {
 	return len(p), nil
 }
 
+// Sync flushes every writer that supports it, such as an *os.File.
+func (s *SafeMultiWriter) Sync() error {
+	for _, w := range s.Writers {
+		if f, ok := w.(interface{ Sync() error }); ok {
+			_ = f.Sync() // Stdout may not support syncing
+		}
+	}
+	return nil
+}
+
+// syncOutput flushes the global output if it supports syncing.
+func syncOutput() {
+	writerMu.RLock()
+	defer writerMu.RUnlock()
+	if f, ok := globalWriter.(interface{ Sync() error }); ok {
+		_ = f.Sync()
+	}
+}
+
 // SetOutput updates the global output for all loggers.
 func SetOutput(w ...io.Writer) {
 	writerMu.Lock()
@@ -68,11 +92,23 @@ type Logger interface {
 	Info(msg string, args ...any)
 	Warn(msg string, args ...any)
 	Error(msg string, args ...any)
+
+	// Fatal logs at ERROR level, flushes the output and exits with status 1.
+	Fatal(msg string, args ...any)
+
+	// WithTraceID returns a logger that adds trace_id to every log call.
+	WithTraceID(id string) Logger
+
+	// With returns a logger that adds the key-value pairs args to every log
+	// call, after those of the logger it was made from.
+	With(args ...any) Logger
 }
 
 // slogLogger wraps slog.Logger to implement our Logger interface.
 type slogLogger struct {
-	name string
+	name    string
+	traceID string
+	attrs   []any // Key-value pairs added by With
 }
 
 // NewLogger creates a new structured logger with the given name.
@@ -86,7 +122,22 @@ func (l *slogLogger) getLogger() *slog.Logger {
 	handler := slog.NewTextHandler(globalWriter, &slog.HandlerOptions{
 		Level: logLevel,
 	})
-	return slog.New(handler).With("component", l.name)
+	logger := slog.New(handler).With("component", l.name)
+	if l.traceID != "" {
+		logger = logger.With("trace_id", l.traceID)
+	}
+	if len(l.attrs) > 0 {
+		logger = logger.With(l.attrs...)
+	}
+	return logger
+}
+
+func (l *slogLogger) WithTraceID(id string) Logger {
+	return &slogLogger{name: l.name, traceID: id, attrs: l.attrs}
+}
+
+func (l *slogLogger) With(args ...any) Logger {
+	return &slogLogger{name: l.name, traceID: l.traceID, attrs: append(slices.Clip(l.attrs), args...)}
 }
 
 func (l *slogLogger) Debug(msg string, args ...any) {
@@ -105,6 +156,12 @@ func (l *slogLogger) Error(msg string, args ...any) {
 	l.getLogger().Error(msg, args...)
 }
 
+func (l *slogLogger) Fatal(msg string, args ...any) {
+	l.Error(msg, args...)
+	syncOutput()
+	exitFunc(1)
+}
+
 // Redirector implements io.Writer to redirect standard log calls to slog.
 type Redirector struct {
 	logger Logger
@@ -122,3 +179,27 @@ func (r *Redirector) Write(p []byte) (n int, err error) {
 func NewRedirector(l Logger) io.Writer {
 	return &Redirector{logger: l}
 }
+
+// NullLogger discards everything logged through it. It is for tests of code
+// that needs a Logger, and as an io.Writer can also be passed to
+// log.SetOutput. Unlike other loggers, Fatal does not exit.
+type NullLogger struct{}
+
+// NewNullLogger returns a logger that discards all messages.
+func NewNullLogger() Logger {
+	return NullLogger{}
+}
+
+func (NullLogger) Debug(msg string, args ...any) {}
+func (NullLogger) Info(msg string, args ...any)  {}
+func (NullLogger) Warn(msg string, args ...any)  {}
+func (NullLogger) Error(msg string, args ...any) {}
+func (NullLogger) Fatal(msg string, args ...any) {}
+func (l NullLogger) WithTraceID(string) Logger   { return l }
+func (l NullLogger) With(...any) Logger          { return l }
+
+// Write discards p. It reports p as written, as io.Writer requires of a
+// Write without error.
+func (NullLogger) Write(p []byte) (int, error) {
+	return len(p), nil
+}
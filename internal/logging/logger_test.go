@@ -0,0 +1,75 @@
+package logging
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFatal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create log file: %v", err)
+	}
+	defer f.Close()
+
+	SetOutput(&SafeMultiWriter{Writers: []io.Writer{f}})
+	defer SetOutput(os.Stderr)
+
+	exitCode := -1
+	exitFunc = func(code int) { exitCode = code }
+	defer func() { exitFunc = os.Exit }()
+
+	NewLogger("Test").Fatal("startup failed", "error", "boom")
+
+	if exitCode != 1 {
+		t.Errorf("exit code = %d, want 1", exitCode)
+	}
+	b, _ := os.ReadFile(path)
+	if line := string(b); !strings.Contains(line, "level=ERROR") || !strings.Contains(line, "startup failed") || !strings.Contains(line, "error=boom") {
+		t.Errorf("log = %q, want an ERROR line with the message", line)
+	}
+}
+
+func TestWith(t *testing.T) {
+	var buf strings.Builder
+	SetOutput(&buf)
+	defer SetOutput(os.Stderr)
+
+	base := NewLogger("Test")
+	fileLogger := base.With("file", "data.csv")
+	fileLogger.With("column", 3).WithTraceID("t1").Info("parsed", "rows", 10)
+	fileLogger.Warn("slow")
+	base.Info("plain")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %q", buf.String())
+	}
+	if !strings.Contains(lines[0], "component=Test trace_id=t1 file=data.csv column=3 rows=10") {
+		t.Errorf("nested fields missing or out of order: %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "file=data.csv") || strings.Contains(lines[1], "column=") {
+		t.Errorf("second line = %q, want only the file field", lines[1])
+	}
+	if strings.Contains(lines[2], "file=") {
+		t.Errorf("base logger gained fields: %q", lines[2])
+	}
+}
+
+func TestNullLogger(t *testing.T) {
+	exitFunc = func(int) { t.Error("NullLogger.Fatal exited") }
+	defer func() { exitFunc = os.Exit }()
+
+	l := NewNullLogger().With("key", "value").WithTraceID("trace")
+	l.Info("discarded")
+	l.Fatal("discarded")
+
+	var w io.Writer = NullLogger{}
+	if n, err := w.Write([]byte("line\n")); n != 5 || err != nil {
+		t.Errorf("Write() = %d, %v, want 5, nil", n, err)
+	}
+}
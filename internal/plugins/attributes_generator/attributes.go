@@ -122,22 +122,24 @@ func (p *Plugin) GetSeriesConfig() ([]plugins.SeriesConfig, error) {
 			ID:        "opacity_0",
 			Name:      "Full Opacity (100%)",
 			Subplot:   &plugins.SubPlot{Row: 1, Col: 1},
-			Color:     "rgba(255, 0, 0, 1.0)",
+			Color:     "#ff0000",
 			LineWidth: floatPtr(4.0),
 		},
 		{
 			ID:        "opacity_1",
 			Name:      "Medium Opacity (50%)",
 			Subplot:   &plugins.SubPlot{Row: 1, Col: 1},
-			Color:     "rgba(255, 0, 0, 0.5)",
+			Color:     "#ff0000",
 			LineWidth: floatPtr(4.0),
+			Opacity:   0.5,
 		},
 		{
 			ID:        "opacity_2",
 			Name:      "Low Opacity (25%)",
 			Subplot:   &plugins.SubPlot{Row: 1, Col: 1},
-			Color:     "rgba(255, 0, 0, 0.25)",
+			Color:     "#ff0000",
 			LineWidth: floatPtr(4.0),
+			Opacity:   0.25,
 		},
 	}, nil
 }
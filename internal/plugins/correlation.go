@@ -0,0 +1,126 @@
+package plugins
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// point is a single (x, y) sample of a series.
+type point struct {
+	x, y float64
+}
+
+// ComputeCorrelation returns the Pearson correlation coefficient between two
+// series of the active plugin. Series B is linearly interpolated onto the X
+// values of series A where their X ranges overlap.
+func (m *Manager) ComputeCorrelation(seriesA, seriesB string) (float64, error) {
+	active := m.GetActive()
+	a, err := fetchPoints(active, seriesA)
+	if err != nil {
+		return 0, err
+	}
+	b, err := fetchPoints(active, seriesB)
+	if err != nil {
+		return 0, err
+	}
+
+	xs, ys := alignSeries(a, b)
+	return pearson(xs, ys)
+}
+
+// fetchPoints loads a series and returns its points sorted by X.
+func fetchPoints(p Plugin, seriesID string) ([]point, error) {
+	data, storage, err := p.GetSeriesData(seriesID, "interleaved")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get series %s: %w", seriesID, err)
+	}
+
+	n := len(data) / 2
+	points := make([]point, 0, n)
+	for i := 0; i < n; i++ {
+		var pt point
+		if storage == "arrays" {
+			pt = point{data[i], data[n+i]}
+		} else {
+			pt = point{data[i*2], data[i*2+1]}
+		}
+		if math.IsNaN(pt.x) || math.IsNaN(pt.y) {
+			continue
+		}
+		points = append(points, pt)
+	}
+
+	sort.SliceStable(points, func(i, j int) bool { return points[i].x < points[j].x })
+	return points, nil
+}
+
+// alignSeries samples b at every X of a that falls inside b's X range and
+// returns the paired Y values.
+func alignSeries(a, b []point) ([]float64, []float64) {
+	if len(a) == 0 || len(b) == 0 {
+		return nil, nil
+	}
+
+	var ya, yb []float64
+	j := 0
+	for _, pa := range a {
+		if pa.x < b[0].x || pa.x > b[len(b)-1].x {
+			continue
+		}
+		// Advance to the segment of b containing pa.x
+		for j < len(b)-2 && b[j+1].x < pa.x {
+			j++
+		}
+
+		y := b[j].y
+		if j+1 < len(b) {
+			x0, x1 := b[j].x, b[j+1].x
+			if x1 > x0 {
+				t := (pa.x - x0) / (x1 - x0)
+				y = b[j].y + t*(b[j+1].y-b[j].y)
+			} else if pa.x == x1 {
+				y = b[j+1].y
+			}
+		}
+
+		ya = append(ya, pa.y)
+		yb = append(yb, y)
+	}
+	return ya, yb
+}
+
+// pearson computes r = Σ(xi-x̄)(yi-ȳ) / (n·σx·σy).
+func pearson(xs, ys []float64) (float64, error) {
+	n := len(xs)
+	if n != len(ys) {
+		return 0, fmt.Errorf("series length mismatch: %d vs %d", n, len(ys))
+	}
+	if n < 2 {
+		return 0, fmt.Errorf("not enough overlapping points: %d", n)
+	}
+
+	var meanX, meanY float64
+	for i := 0; i < n; i++ {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= float64(n)
+	meanY /= float64(n)
+
+	var cov, varX, varY float64
+	for i := 0; i < n; i++ {
+		dx := xs[i] - meanX
+		dy := ys[i] - meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+
+	sigmaX := math.Sqrt(varX / float64(n))
+	sigmaY := math.Sqrt(varY / float64(n))
+	if sigmaX == 0 || sigmaY == 0 {
+		return 0, fmt.Errorf("correlation is undefined for a constant series")
+	}
+	return cov / (float64(n) * sigmaX * sigmaY), nil
+}
@@ -0,0 +1,134 @@
+package plugins
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"olicanaplot/internal/logging"
+)
+
+// seriesPlugin is a minimal plugin serving fixed series in "arrays" storage.
+type seriesPlugin struct {
+	series map[string][]float64
+}
+
+func (p *seriesPlugin) Name() string                   { return "Series" }
+func (p *seriesPlugin) Version() uint32                { return PluginAPIVersion }
+func (p *seriesPlugin) Path() string                   { return "" }
+func (p *seriesPlugin) GetFilePatterns() []FilePattern { return nil }
+func (p *seriesPlugin) Close() error                   { return nil }
+
+func (p *seriesPlugin) Initialize(ctx interface{}, initStr string, logger logging.Logger) (string, error) {
+	return "{}", nil
+}
+
+func (p *seriesPlugin) GetChartConfig(args string) (*ChartConfig, error) {
+	return &ChartConfig{}, nil
+}
+
+func (p *seriesPlugin) GetSeriesConfig() ([]SeriesConfig, error) {
+	return nil, nil
+}
+
+func (p *seriesPlugin) GetSeriesData(seriesID string, preferredStorage string) ([]float64, string, error) {
+	data, ok := p.series[seriesID]
+	if !ok {
+		return nil, "", fmt.Errorf("series not found: %s", seriesID)
+	}
+	return data, "arrays", nil
+}
+
+// sampleArrays samples f at n points over [xMin, xMax] in "arrays" storage.
+func sampleArrays(f func(float64) float64, xMin, xMax float64, n int) []float64 {
+	data := make([]float64, n*2)
+	for i := 0; i < n; i++ {
+		x := xMin + float64(i)*(xMax-xMin)/float64(n-1)
+		data[i] = x
+		data[n+i] = f(x)
+	}
+	return data
+}
+
+func TestPearson(t *testing.T) {
+	tests := []struct {
+		name     string
+		xs, ys   []float64
+		expected float64
+	}{
+		{"perfect positive", []float64{1, 2, 3, 4}, []float64{3, 5, 7, 9}, 1},
+		{"perfect negative", []float64{1, 2, 3, 4}, []float64{8, 6, 4, 2}, -1},
+		{"partial", []float64{1, 2, 3, 4, 5}, []float64{2, 4, 5, 4, 5}, 6 / math.Sqrt(60)},
+		{"uncorrelated", []float64{-1, 0, 1}, []float64{1, 0, 1}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pearson(tt.xs, tt.ys)
+			if err != nil {
+				t.Fatalf("pearson failed: %v", err)
+			}
+			if math.Abs(got-tt.expected) > 1e-12 {
+				t.Errorf("pearson() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := pearson([]float64{1, 2, 3}, []float64{5, 5, 5}); err == nil {
+		t.Error("expected error for constant series")
+	}
+	if _, err := pearson([]float64{1}, []float64{2}); err == nil {
+		t.Error("expected error for a single point")
+	}
+}
+
+func TestComputeCorrelation(t *testing.T) {
+	// Series b and c use a coarser grid over a wider range than a, so they
+	// must be interpolated at a's X values.
+	plugin := &seriesPlugin{series: map[string][]float64{
+		"a": sampleArrays(func(x float64) float64 { return 3*x + 1 }, 0, 10, 101),
+		"b": sampleArrays(func(x float64) float64 { return 2 * x }, -5, 15, 21),
+		"c": sampleArrays(func(x float64) float64 { return 7 - x }, -5, 15, 21),
+	}}
+
+	m := NewManager(logging.NewLogger("Test"))
+	if err := m.Register(plugin, true); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	tests := []struct {
+		a, b     string
+		expected float64
+	}{
+		{"a", "b", 1},
+		{"a", "c", -1},
+		{"b", "c", -1},
+	}
+	for _, tt := range tests {
+		got, err := m.ComputeCorrelation(tt.a, tt.b)
+		if err != nil {
+			t.Fatalf("ComputeCorrelation(%s, %s) failed: %v", tt.a, tt.b, err)
+		}
+		if math.Abs(got-tt.expected) > 1e-9 {
+			t.Errorf("ComputeCorrelation(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.expected)
+		}
+	}
+
+	if _, err := m.ComputeCorrelation("a", "missing"); err == nil {
+		t.Error("expected error for missing series")
+	}
+}
+
+func TestComputeCorrelationNoOverlap(t *testing.T) {
+	plugin := &seriesPlugin{series: map[string][]float64{
+		"a": sampleArrays(math.Sin, 0, 1, 10),
+		"b": sampleArrays(math.Sin, 2, 3, 10),
+	}}
+
+	m := NewManager(logging.NewLogger("Test"))
+	m.Register(plugin, true)
+
+	if _, err := m.ComputeCorrelation("a", "b"); err == nil {
+		t.Error("expected error for series without overlapping X ranges")
+	}
+}
@@ -0,0 +1,78 @@
+package csv_reader
+
+import (
+	"encoding/csv"
+	"strings"
+)
+
+// metadataSection starts a block of rows, written by some logging software
+// before the data, that runs until the next section marker such as "[DATA]".
+const metadataSection = "[METADATA]"
+
+// SetSkipComments sets whether files loaded afterwards skip comment rows and
+// [METADATA] blocks. It is on by default.
+func (p *Plugin) SetSkipComments(skip bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.skipComments = skip
+}
+
+// recordReader reads the records of a CSV file, skipping comment rows and
+// metadata blocks if skipComments is set. Skipped rows may have any number
+// of fields; every other record must have as many as the header.
+type recordReader struct {
+	r            *csv.Reader
+	skipComments bool
+	inMetadata   bool
+	fields       int // Of the header, 0 until it is read
+}
+
+// newRecordReader wraps r, which must not have been read from.
+func newRecordReader(r *csv.Reader, skipComments bool) *recordReader {
+	r.FieldsPerRecord = -1
+	return &recordReader{r: r, skipComments: skipComments}
+}
+
+// Read returns the next record that is not skipped.
+func (rr *recordReader) Read() ([]string, error) {
+	for {
+		record, err := rr.r.Read()
+		if err != nil {
+			return nil, err
+		}
+		if rr.skipComments && rr.skip(record) {
+			continue
+		}
+
+		if rr.fields == 0 {
+			rr.fields = len(record)
+		} else if len(record) != rr.fields {
+			line, _ := rr.r.FieldPos(0)
+			return nil, &csv.ParseError{StartLine: line, Line: line, Column: 1, Err: csv.ErrFieldCount}
+		}
+		return record, nil
+	}
+}
+
+// skip reports whether record is a comment row or part of a metadata block.
+func (rr *recordReader) skip(record []string) bool {
+	first := strings.TrimSpace(record[0])
+	switch {
+	case strings.EqualFold(first, metadataSection):
+		rr.inMetadata = true
+		return true
+	case rr.inMetadata:
+		// The section marker ending the block is skipped with it
+		if len(record) == 1 && strings.HasPrefix(first, "[") && strings.HasSuffix(first, "]") {
+			rr.inMetadata = false
+		}
+		return true
+	}
+	return isComment(first)
+}
+
+// isComment reports whether a row whose first field is field, without
+// surrounding whitespace, is a comment.
+func isComment(field string) bool {
+	return strings.HasPrefix(field, "#") || strings.HasPrefix(field, "//")
+}
@@ -4,34 +4,57 @@ package csv_reader
 import (
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"math"
+	"olicanaplot/internal/appconfig"
 	"olicanaplot/internal/logging"
 	"olicanaplot/internal/plugins"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/wailsapp/wails/v3/pkg/application"
 )
 
 const pluginName = "CSV Connector"
 
+// Parse modes selectable via ConfigService.SetCSVParseMode.
+const (
+	ParseModeFull   = "full"   // Read all records into memory, then convert
+	ParseModeStream = "stream" // Convert records row by row as they are read
+)
+
 // Plugin implements the CSV file loading plugin.
 type Plugin struct {
-	mu          sync.Mutex
-	currentFile string
-	headers     []string
-	data        map[string][]float64
-	selectedY   []string
-	selectedX   string // Empty means use index
+	mu           sync.Mutex
+	config       *appconfig.ConfigService
+	currentFile  string
+	headers      []string
+	data         map[string][]float64
+	stats        map[string]ColumnStats // Of each column, computed while parsing
+	selectedY    []string
+	selectedX    string // Empty means use index
+	encoding     string // Requested encoding, detected per file when empty
+	fileEncoding string // Encoding the current file was read with
+	skipComments bool   // Skip comment rows and [METADATA] blocks
+	modTime      time.Time
+	logger       logging.Logger
+	stopWatch    chan struct{} // Closed to stop the auto-refresh watcher
+	onRefresh    func()        // Called after each auto-refresh
 }
 
 // New creates a new CSV plugin.
-func New() *Plugin {
+func New(config *appconfig.ConfigService) *Plugin {
 	return &Plugin{
-		data: make(map[string][]float64),
+		config:       config,
+		data:         make(map[string][]float64),
+		stats:        make(map[string]ColumnStats),
+		skipComments: true,
 	}
 }
 
@@ -62,6 +85,8 @@ func (p *Plugin) GetFilePatterns() []plugins.FilePattern {
 
 // Initialize sets up the plugin by opening a file dialog and then creating a configuration window.
 func (p *Plugin) Initialize(ctx interface{}, initStr string, logger logging.Logger) (string, error) {
+	p.logger = logger
+
 	// Cast context to Application
 	app, ok := ctx.(*application.App)
 	if !ok || app == nil {
@@ -90,17 +115,18 @@ func (p *Plugin) Initialize(ctx interface{}, initStr string, logger logging.Logg
 		}
 		logger.Info("File selected", "path", selectedFile)
 	}
+	fileLogger := logger.With("path", selectedFile)
 
 	// Load the file to get headers
 	headers, err := p.LoadFile(selectedFile)
 	if err != nil {
-		logger.Error("Failed to load CSV file", "path", selectedFile, "error", err)
+		fileLogger.Error("Failed to load CSV file", "error", err)
 		return "{}", fmt.Errorf("failed to load CSV file: %w", err)
 	}
-	logger.Info("CSV file loaded", "path", selectedFile, "columns", len(headers))
+	fileLogger.Info("CSV file loaded", "columns", len(headers))
 
 	// Create and show dialog
-	dialog := NewCsvDialog(app, selectedFile, headers)
+	dialog := NewCsvDialog(app, selectedFile, headers, p.allColumnStats(), p.FileEncoding())
 
 	// Register event listeners
 	unsubSubmit := app.Event.On("csv-config-submit", func(event *application.CustomEvent) {
@@ -114,7 +140,8 @@ func (p *Plugin) Initialize(ctx interface{}, initStr string, logger logging.Logg
 						yColumns = append(yColumns, colStr)
 					}
 				}
-				dialog.Submit(xColumn, yColumns)
+				encoding, _ := configMap["encoding"].(string)
+				dialog.Submit(xColumn, yColumns, encoding)
 			}
 		}
 	})
@@ -129,8 +156,16 @@ func (p *Plugin) Initialize(ctx interface{}, initStr string, logger logging.Logg
 	result := dialog.Show()
 
 	if result.Ok {
+		if result.Encoding != "" && result.Encoding != p.FileEncoding() {
+			result.YColumns, result.XColumn, err = p.reloadWithEncoding(result.Encoding, result.YColumns, result.XColumn)
+			if err != nil {
+				fileLogger.Error("Failed to reload CSV file", "encoding", result.Encoding, "error", err)
+				return "{}", fmt.Errorf("failed to reload CSV file: %w", err)
+			}
+		}
 		p.SetSelection(result.YColumns, result.XColumn)
 		logger.Info("CSV configuration complete", "xColumn", result.XColumn, "yColumns", result.YColumns)
+		p.startWatcher()
 	}
 
 	return "{}", nil
@@ -145,6 +180,12 @@ func encodeHeaders(headers []string) string {
 // LoadFile loads a CSV file from the given path and returns headers.
 // This method can be called from the frontend via the plugin service.
 func (p *Plugin) LoadFile(path string) ([]string, error) {
+	// An encoding chosen for one file says nothing about the next
+	p.mu.Lock()
+	if path != p.currentFile {
+		p.encoding = ""
+	}
+	p.mu.Unlock()
 	return p.loadCSVFile(path)
 }
 
@@ -158,6 +199,22 @@ func (p *Plugin) SetSelection(yColumns []string, xColumn string) error {
 	return nil
 }
 
+// Refresh re-reads the current file without showing any dialog, keeping the
+// column selection.
+func (p *Plugin) Refresh() error {
+	p.mu.Lock()
+	path, xColumn, yColumns := p.currentFile, p.selectedX, p.selectedY
+	p.mu.Unlock()
+	if path == "" {
+		return fmt.Errorf("no file loaded")
+	}
+
+	if _, err := p.loadCSVFile(path); err != nil {
+		return err
+	}
+	return p.SetSelection(yColumns, xColumn)
+}
+
 // loadCSVFile loads a CSV file from a path
 func (p *Plugin) loadCSVFile(path string) ([]string, error) {
 	file, err := os.Open(path)
@@ -166,8 +223,30 @@ func (p *Plugin) loadCSVFile(path string) ([]string, error) {
 	}
 	defer file.Close()
 
-	reader := csv.NewReader(file)
-	return p.processCSV(reader, path)
+	// Stat before reading so a write during the read is seen as a change
+	st, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	p.mu.Lock()
+	enc := p.encoding
+	p.mu.Unlock()
+	r, enc, err := decodingReader(file, enc)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(r)
+	headers, err := p.processCSV(reader, path)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.modTime = st.ModTime()
+	p.fileEncoding = enc
+	p.mu.Unlock()
+	return headers, nil
 }
 
 // loadCSVContent loads CSV data from a string
@@ -176,19 +255,42 @@ func (p *Plugin) loadCSVContent(content string, name string) ([]string, error) {
 	return p.processCSV(reader, name)
 }
 
+// ParseMode returns the configured parse mode, defaulting to ParseModeFull.
+func (p *Plugin) ParseMode() string {
+	if p.config != nil && p.config.GetCSVParseMode() == ParseModeStream {
+		return ParseModeStream
+	}
+	return ParseModeFull
+}
+
 // processCSV reads CSV data from a reader and updates the plugin state
 func (p *Plugin) processCSV(reader *csv.Reader, name string) ([]string, error) {
-	records, err := reader.ReadAll()
-	if err != nil {
-		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	p.mu.Lock()
+	records := newRecordReader(reader, p.skipComments)
+	p.mu.Unlock()
+
+	if p.ParseMode() == ParseModeStream {
+		return p.streamCSV(records, name)
 	}
 
-	if len(records) == 0 {
+	var rows [][]string
+	for {
+		row, err := records.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
 		return nil, fmt.Errorf("empty CSV file")
 	}
 
 	// First row is headers
-	headers := records[0]
+	headers := rows[0]
 	for i, h := range headers {
 		headers[i] = strings.TrimSpace(h)
 	}
@@ -196,33 +298,88 @@ func (p *Plugin) processCSV(reader *csv.Reader, name string) ([]string, error) {
 	// Initialize data map
 	data := make(map[string][]float64)
 	for _, h := range headers {
-		data[h] = make([]float64, 0, len(records)-1)
+		data[h] = make([]float64, 0, len(rows)-1)
 	}
+	stats := newColumnAccumulators(headers)
 
 	// Parse data rows
-	for rowIdx := 1; rowIdx < len(records); rowIdx++ {
-		row := records[rowIdx]
-		for colIdx, val := range row {
-			if colIdx < len(headers) {
-				header := headers[colIdx]
-				parsed, err := strconv.ParseFloat(val, 64)
-				if err != nil {
-					parsed = math.NaN()
-				}
-				data[header] = append(data[header], parsed)
+	for rowIdx := 1; rowIdx < len(rows); rowIdx++ {
+		appendRow(data, stats, headers, rows[rowIdx])
+	}
+
+	p.setData(name, headers, data, stats)
+	return headers, nil
+}
+
+// streamCSV parses records one at a time so the raw text of the whole file
+// is never held in memory, only the converted float columns.
+func (p *Plugin) streamCSV(records *recordReader, name string) ([]string, error) {
+	records.r.ReuseRecord = true
+
+	first, err := records.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("empty CSV file")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+
+	// The header record is reused by the reader, so copy it
+	headers := make([]string, len(first))
+	for i, h := range first {
+		headers[i] = strings.TrimSpace(h)
+	}
+
+	data := make(map[string][]float64)
+	for _, h := range headers {
+		data[h] = nil
+	}
+	stats := newColumnAccumulators(headers)
+
+	for {
+		row, err := records.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		appendRow(data, stats, headers, row)
+	}
+
+	p.setData(name, headers, data, stats)
+	return headers, nil
+}
+
+// appendRow parses one data row into the column map, using NaN for invalid
+// values, and adds them to the columns' statistics.
+func appendRow(data map[string][]float64, stats map[string]*columnAccumulator, headers []string, row []string) {
+	for colIdx, val := range row {
+		if colIdx < len(headers) {
+			header := headers[colIdx]
+			parsed, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				parsed = math.NaN()
 			}
+			data[header] = append(data[header], parsed)
+			stats[header].add(parsed)
 		}
 	}
+}
 
+// setData replaces the loaded file and clears the previous column selection.
+func (p *Plugin) setData(name string, headers []string, data map[string][]float64, stats map[string]*columnAccumulator) {
 	p.mu.Lock()
 	p.currentFile = name
 	p.headers = headers
 	p.data = data
+	p.stats = make(map[string]ColumnStats, len(stats))
+	for h, acc := range stats {
+		p.stats[h] = acc.stats()
+	}
 	p.selectedY = nil
 	p.selectedX = ""
 	p.mu.Unlock()
-
-	return headers, nil
 }
 
 // GetChartConfig returns chart display configuration.
@@ -271,6 +428,18 @@ func (p *Plugin) GetSeriesConfig() ([]plugins.SeriesConfig, error) {
 	return series, nil
 }
 
+// GetYAxisLabel labels each series' Y axis with its column header, which is
+// also its ID, as columns usually hold different quantities.
+func (p *Plugin) GetYAxisLabel(seriesID string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if slices.Contains(p.selectedY, seriesID) {
+		return seriesID
+	}
+	return ""
+}
+
 // GetSeriesData returns binary float64 data for the specified series ID.
 func (p *Plugin) GetSeriesData(seriesID string, preferredStorage string) ([]float64, string, error) {
 	p.mu.Lock()
@@ -314,7 +483,46 @@ func (p *Plugin) GetSeriesData(seriesID string, preferredStorage string) ([]floa
 	return result, storage, nil
 }
 
+// GetTimeRange returns the min and max of the selected X column, or of the
+// row index when no X column is selected.
+func (p *Plugin) GetTimeRange() (float64, float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.selectedX == "" || p.selectedX == "Index" {
+		count := 0
+		for _, y := range p.selectedY {
+			if n := len(p.data[y]); n > count {
+				count = n
+			}
+		}
+		if count == 0 {
+			return 0, 0, fmt.Errorf("no data loaded")
+		}
+		return 0, float64(count - 1), nil
+	}
+
+	xMin, xMax := math.Inf(1), math.Inf(-1)
+	for _, x := range p.data[p.selectedX] {
+		if math.IsNaN(x) {
+			continue
+		}
+		xMin = math.Min(xMin, x)
+		xMax = math.Max(xMax, x)
+	}
+	if xMin > xMax {
+		return 0, 0, fmt.Errorf("no numeric values in column %s", p.selectedX)
+	}
+	return xMin, xMax, nil
+}
+
+// Deactivate stops watching the file when another plugin is made active.
+func (p *Plugin) Deactivate() {
+	p.stopWatcher()
+}
+
 // Close cleans up plugin resources.
 func (p *Plugin) Close() error {
+	p.stopWatcher()
 	return nil
 }
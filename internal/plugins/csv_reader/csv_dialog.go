@@ -10,6 +10,7 @@ import (
 type ConfigResult struct {
 	XColumn  string
 	YColumns []string
+	Encoding string
 	Ok       bool
 }
 
@@ -20,8 +21,9 @@ type CsvDialog struct {
 	app    *application.App
 }
 
-// NewCsvDialog creates a new configuration dialog using the standardized SchemaForm
-func NewCsvDialog(app *application.App, file string, headers []string) *CsvDialog {
+// NewCsvDialog creates a new configuration dialog using the standardized
+// SchemaForm. Columns are listed with their range from stats.
+func NewCsvDialog(app *application.App, file string, headers []string, stats map[string]ColumnStats, encoding string) *CsvDialog {
 	d := &CsvDialog{
 		app:    app,
 		result: make(chan ConfigResult, 1),
@@ -33,12 +35,12 @@ func NewCsvDialog(app *application.App, file string, headers []string) *CsvDialo
 	var xOptions []map[string]interface{}
 	xOptions = append(xOptions, map[string]interface{}{"const": "Index", "title": "Index (0 to N)"})
 	for _, h := range headers {
-		xOptions = append(xOptions, map[string]interface{}{"const": h, "title": h})
+		xOptions = append(xOptions, map[string]interface{}{"const": h, "title": columnTitle(h, stats)})
 	}
 
 	var yOptions []map[string]interface{}
 	for _, h := range headers {
-		yOptions = append(yOptions, map[string]interface{}{"const": h, "title": h})
+		yOptions = append(yOptions, map[string]interface{}{"const": h, "title": columnTitle(h, stats)})
 	}
 
 	defaultX := "Index"
@@ -51,6 +53,11 @@ func NewCsvDialog(app *application.App, file string, headers []string) *CsvDialo
 		defaultY = headers
 	}
 
+	var encodingOptions []map[string]interface{}
+	for _, e := range encodingTitles {
+		encodingOptions = append(encodingOptions, map[string]interface{}{"const": e.name, "title": e.title})
+	}
+
 	schema := map[string]interface{}{
 		"type":  "object",
 		"title": "CSV Column Selection",
@@ -70,8 +77,18 @@ func NewCsvDialog(app *application.App, file string, headers []string) *CsvDialo
 				},
 				"default": defaultY,
 			},
+			"encoding": map[string]interface{}{
+				"title":       "Encoding (Advanced)",
+				"description": "Detected from the file. Change it if column names show garbled characters.",
+				"type":        "string",
+				"oneOf":       encodingOptions,
+				"default":     encoding,
+			},
 		},
 	}
+	uiSchema := map[string]interface{}{
+		"ui:order": []string{"xColumn", "yColumns", "encoding"},
+	}
 
 	// Listen for the result from the Svelte dialog
 	app.Event.On(fmt.Sprintf("ipc-form-result-%s", requestID), func(e *application.CustomEvent) {
@@ -89,7 +106,8 @@ func NewCsvDialog(app *application.App, file string, headers []string) *CsvDialo
 						yCols = append(yCols, s)
 					}
 				}
-				d.Submit(xCol, yCols)
+				enc, _ := data["encoding"].(string)
+				d.Submit(xCol, yCols, enc)
 			}
 		}
 	})
@@ -97,10 +115,12 @@ func NewCsvDialog(app *application.App, file string, headers []string) *CsvDialo
 	// Listen for the ready event to send data
 	app.Event.On(fmt.Sprintf("ipc-form-ready-%s", requestID), func(e *application.CustomEvent) {
 		app.Event.Emit(fmt.Sprintf("ipc-form-init-%s", requestID), map[string]interface{}{
-			"schema": schema,
+			"schema":   schema,
+			"uiSchema": uiSchema,
 			"data": map[string]interface{}{
 				"xColumn":  defaultX,
 				"yColumns": defaultY,
+				"encoding": encoding,
 			},
 			"handleFormChange": false,
 		})
@@ -140,10 +160,11 @@ func (d *CsvDialog) Show() ConfigResult {
 	return r
 }
 
-func (d *CsvDialog) Submit(xColumn string, yColumns []string) {
+func (d *CsvDialog) Submit(xColumn string, yColumns []string, encoding string) {
 	d.result <- ConfigResult{
 		XColumn:  xColumn,
 		YColumns: yColumns,
+		Encoding: encoding,
 		Ok:       true,
 	}
 	d.window.Close()
@@ -0,0 +1,360 @@
+package csv_reader
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"math"
+	"olicanaplot/internal/appconfig"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRefreshKeepsSelection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(path, []byte("t,a,b\n0,1,2\n1,3,4\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := New(nil)
+	if _, err := p.LoadFile(path); err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	p.SetSelection([]string{"b"}, "t")
+	if p.fileChanged() {
+		t.Error("file reported as changed right after loading")
+	}
+
+	// Another process appends a row
+	if err := os.WriteFile(path, []byte("t,a,b\n0,1,2\n1,3,4\n2,5,6\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Refresh(); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+
+	series, _ := p.GetSeriesConfig()
+	if len(series) != 1 || series[0].ID != "b" {
+		t.Fatalf("series = %+v, want the selected column b", series)
+	}
+	data, _, err := p.GetSeriesData("b", "interleaved")
+	if err != nil {
+		t.Fatalf("GetSeriesData failed: %v", err)
+	}
+	if len(data) != 6 || data[4] != 2 || data[5] != 6 {
+		t.Errorf("data = %v, want the appended row", data)
+	}
+
+	if err := New(nil).Refresh(); err == nil {
+		t.Error("expected error when no file is loaded")
+	}
+}
+
+func TestWatcherStops(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(path, []byte("t,a\n0,1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := New(newTestConfig(t))
+	if _, err := p.LoadFile(path); err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	watching := func() bool {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return p.stopWatch != nil
+	}
+
+	for _, stop := range []struct {
+		name string
+		fn   func()
+	}{
+		{"Deactivate", p.Deactivate},
+		{"Close", func() { p.Close() }},
+	} {
+		p.startWatcher()
+		if !watching() {
+			t.Fatal("watcher not started")
+		}
+		stop.fn()
+		if watching() {
+			t.Errorf("watcher still running after %s", stop.name)
+		}
+	}
+}
+
+func TestLoadLatin1Headers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "latin1.csv")
+	// "durée,température" in Latin-1
+	content := []byte("dur\xe9e,temp\xe9rature\n0,20.5\n1,21\n")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := New(nil)
+	headers, err := p.LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if len(headers) != 2 || headers[0] != "durée" || headers[1] != "température" {
+		t.Errorf("headers = %q, want durée and température", headers)
+	}
+	if enc := p.FileEncoding(); enc != EncodingLatin1 {
+		t.Errorf("FileEncoding() = %s, want %s", enc, EncodingLatin1)
+	}
+
+	p.SetSelection([]string{"température"}, "durée")
+	data, _, err := p.GetSeriesData("température", "interleaved")
+	if err != nil {
+		t.Fatalf("GetSeriesData failed: %v", err)
+	}
+	if len(data) != 4 || data[1] != 20.5 || data[3] != 21 {
+		t.Errorf("data = %v", data)
+	}
+
+	// Reading it as UTF-8 by mistake mangles the names; switching back
+	// maps the selection to the right columns
+	if err := p.SetEncoding(EncodingUTF8); err != nil {
+		t.Fatal(err)
+	}
+	headers, _ = p.LoadFile(path)
+	y, x, err := p.reloadWithEncoding(EncodingLatin1, []string{headers[1]}, headers[0])
+	if err != nil {
+		t.Fatalf("reloadWithEncoding failed: %v", err)
+	}
+	if x != "durée" || len(y) != 1 || y[0] != "température" {
+		t.Errorf("selection = %q, %q", x, y)
+	}
+
+	// The encoding chosen for one file isn't used for the next
+	p.SetEncoding(EncodingUTF8)
+	other := filepath.Join(t.TempDir(), "other.csv")
+	if err := os.WriteFile(other, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.LoadFile(other); err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if enc := p.FileEncoding(); enc != EncodingLatin1 {
+		t.Errorf("FileEncoding() of the next file = %s, want %s", enc, EncodingLatin1)
+	}
+}
+
+func TestDetectEncoding(t *testing.T) {
+	// A UTF-8 sample cut in the middle of "é"
+	cut := append(bytes.Repeat([]byte("a"), encodingSampleSize-1), 0xC3)
+
+	for _, tt := range []struct {
+		name   string
+		sample []byte
+		want   string
+	}{
+		{"ascii", []byte("t,a\n0,1\n"), EncodingUTF8},
+		{"utf-8", []byte("durée\n"), EncodingUTF8},
+		{"utf-8 bom", []byte("\xef\xbb\xbft,a\n"), EncodingUTF8},
+		{"utf-16le bom", []byte("\xff\xfet\x00,\x00"), EncodingUTF16LE},
+		{"utf-16be bom", []byte("\xfe\xff\x00t\x00,"), EncodingUTF16BE},
+		{"latin-1", []byte("dur\xe9e\n"), EncodingLatin1},
+		{"windows-1252", []byte("price \x80\n"), EncodingWindows1252},
+		{"truncated utf-8", cut, EncodingUTF8},
+		{"latin-1 at end", []byte("caf\xe9"), EncodingLatin1},
+	} {
+		if got := detectEncoding(tt.sample); got != tt.want {
+			t.Errorf("%s: detectEncoding = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoadUTF16(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "utf16.csv")
+	var content []byte
+	content = append(content, 0xFF, 0xFE)
+	for _, r := range "x,µ\n1,2\n" {
+		content = append(content, byte(r), byte(r>>8))
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	headers, err := New(nil).LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if len(headers) != 2 || headers[0] != "x" || headers[1] != "µ" {
+		t.Errorf("headers = %q, want x and µ", headers)
+	}
+}
+
+// newTestConfig returns a config service that reads and writes a config.json
+// in a temporary directory instead of the user's.
+func newTestConfig(t *testing.T) *appconfig.ConfigService {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("AppData", dir)
+	return appconfig.NewConfigService()
+}
+
+func TestStreamParseMode(t *testing.T) {
+	t.Setenv("OLICANA_CSV_PARSE_MODE", ParseModeStream)
+	p := New(newTestConfig(t))
+	if mode := p.ParseMode(); mode != ParseModeStream {
+		t.Fatalf("ParseMode() = %s, want %s", mode, ParseModeStream)
+	}
+
+	path := filepath.Join(t.TempDir(), "data.csv")
+	content := "# logged by bench 2\n t , volts,amps\n0,1,2\n1,,4\n# paused\n2,5,x\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	headers, err := p.LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	// The reader reuses its record buffer, which must not leak into the headers
+	if len(headers) != 3 || headers[0] != "t" || headers[1] != "volts" || headers[2] != "amps" {
+		t.Fatalf("headers = %q", headers)
+	}
+
+	// Streaming gives the same series as reading the whole file
+	full := New(nil)
+	if _, err := full.LoadFile(path); err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	for _, plugin := range []*Plugin{p, full} {
+		plugin.SetSelection([]string{"volts", "amps"}, "t")
+	}
+	for _, column := range []string{"volts", "amps"} {
+		got, _, err := p.GetSeriesData(column, "interleaved")
+		if err != nil {
+			t.Fatalf("GetSeriesData(%s) failed: %v", column, err)
+		}
+		want, _, _ := full.GetSeriesData(column, "interleaved")
+		if len(got) != 6 || len(got) != len(want) {
+			t.Fatalf("%s: data = %v, want %v", column, got, want)
+		}
+		for i := range got {
+			if got[i] != want[i] && !(math.IsNaN(got[i]) && math.IsNaN(want[i])) {
+				t.Errorf("%s: data = %v, want %v", column, got, want)
+				break
+			}
+		}
+	}
+
+	path = filepath.Join(t.TempDir(), "ragged.csv")
+	if err := os.WriteFile(path, []byte("t,a\n0,1\n1,2,3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.LoadFile(path); !errors.Is(err, csv.ErrFieldCount) {
+		t.Errorf("error = %v, want ErrFieldCount for a data row with extra fields", err)
+	}
+	empty := filepath.Join(t.TempDir(), "empty.csv")
+	if err := os.WriteFile(empty, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.LoadFile(empty); err == nil {
+		t.Error("expected error for an empty file")
+	}
+}
+
+func TestYAxisLabelIsColumn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.csv")
+	if err := os.WriteFile(path, []byte("t,volts,amps\n0,1,2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := New(nil)
+	if _, err := p.LoadFile(path); err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	p.SetSelection([]string{"volts", "amps"}, "t")
+
+	if got := p.GetYAxisLabel("amps"); got != "amps" {
+		t.Errorf("GetYAxisLabel(amps) = %q, want amps", got)
+	}
+	if got := p.GetYAxisLabel("t"); got != "" {
+		t.Errorf("GetYAxisLabel(t) = %q, want none for an unselected column", got)
+	}
+}
+
+func TestSkipComments(t *testing.T) {
+	p := New(nil)
+	headers, err := p.LoadFile("testdata/comments.csv")
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if len(headers) != 3 || headers[0] != "time" || headers[2] != "pressure" {
+		t.Fatalf("headers = %q, want the row after the comments", headers)
+	}
+	p.SetSelection([]string{"temp"}, "time")
+	data, _, err := p.GetSeriesData("temp", "interleaved")
+	if err != nil {
+		t.Fatalf("GetSeriesData failed: %v", err)
+	}
+	// The comment between data rows is skipped too
+	if len(data) != 6 || data[4] != 2 || data[5] != 20.9 {
+		t.Errorf("data = %v, want three rows", data)
+	}
+
+	// Without skipping, the first comment is the header
+	p.SetSkipComments(false)
+	if _, err := p.LoadFile("testdata/comments.csv"); err == nil {
+		t.Error("expected an error for comment rows with fewer fields")
+	}
+}
+
+func TestSkipMetadataBlock(t *testing.T) {
+	headers, err := New(nil).LoadFile("testdata/metadata.csv")
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if len(headers) != 2 || headers[0] != "time" || headers[1] != "temp" {
+		t.Errorf("headers = %q, want the row after [DATA]", headers)
+	}
+
+	path := filepath.Join(t.TempDir(), "ragged.csv")
+	if err := os.WriteFile(path, []byte("# note\nt,a\n0,1\n1,2,3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(nil).LoadFile(path); !errors.Is(err, csv.ErrFieldCount) {
+		t.Errorf("error = %v, want ErrFieldCount for a data row with extra fields", err)
+	}
+}
+
+func TestColumnStats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.csv")
+	content := "t,temp,label\n0,20,a\n1,,b\n2,NaN,c\n3,26,d\n4,x,e\n5,-1,f\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := New(nil)
+	if _, err := p.LoadFile(path); err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+
+	for _, tt := range []struct {
+		column         string
+		min, max, mean float64
+		nanCount       int
+	}{
+		{"t", 0, 5, 2.5, 0},
+		// Empty, NaN and text cells are counted
+		{"temp", -1, 26, 15, 3},
+		{"label", 0, 0, 0, 6},
+	} {
+		min, max, mean, nanCount, err := p.GetColumnStats(tt.column)
+		if err != nil {
+			t.Fatalf("GetColumnStats(%s) failed: %v", tt.column, err)
+		}
+		if min != tt.min || max != tt.max || mean != tt.mean || nanCount != tt.nanCount {
+			t.Errorf("%s: stats = %v, %v, %v, %d NaN, want %v, %v, %v, %d NaN",
+				tt.column, min, max, mean, nanCount, tt.min, tt.max, tt.mean, tt.nanCount)
+		}
+	}
+
+	if _, _, _, _, err := p.GetColumnStats("missing"); err == nil {
+		t.Error("expected error for a missing column")
+	}
+}
@@ -0,0 +1,175 @@
+package csv_reader
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Text encodings of CSV files. EncodingAuto detects the encoding from the
+// start of each file.
+const (
+	EncodingAuto        = "auto"
+	EncodingUTF8        = "utf-8"
+	EncodingUTF16LE     = "utf-16le"
+	EncodingUTF16BE     = "utf-16be"
+	EncodingLatin1      = "iso-8859-1"
+	EncodingWindows1252 = "windows-1252"
+)
+
+// encodingSampleSize is how many bytes of a file detectEncoding looks at.
+const encodingSampleSize = 1024
+
+// encodingTitles lists the selectable encodings in display order.
+var encodingTitles = []struct{ name, title string }{
+	{EncodingUTF8, "UTF-8"},
+	{EncodingUTF16LE, "UTF-16 (little endian)"},
+	{EncodingUTF16BE, "UTF-16 (big endian)"},
+	{EncodingLatin1, "Latin-1 (ISO-8859-1)"},
+	{EncodingWindows1252, "Windows-1252"},
+}
+
+// decoders maps encodings other than UTF-8 to their decoders. A BOM, if
+// present, overrides the UTF-16 byte order and is removed.
+var decoders = map[string]encoding.Encoding{
+	EncodingUTF16LE:     unicode.UTF16(unicode.LittleEndian, unicode.UseBOM),
+	EncodingUTF16BE:     unicode.UTF16(unicode.BigEndian, unicode.UseBOM),
+	EncodingLatin1:      charmap.ISO8859_1,
+	EncodingWindows1252: charmap.Windows1252,
+}
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// detectEncoding guesses the encoding of a file from its first
+// encodingSampleSize bytes: a BOM if there is one, else UTF-8 if the sample
+// is valid UTF-8, else Latin-1 or Windows-1252.
+func detectEncoding(sample []byte) string {
+	switch {
+	case bytes.HasPrefix(sample, utf8BOM):
+		return EncodingUTF8
+	case bytes.HasPrefix(sample, []byte{0xFF, 0xFE}):
+		return EncodingUTF16LE
+	case bytes.HasPrefix(sample, []byte{0xFE, 0xFF}):
+		return EncodingUTF16BE
+	}
+
+	valid := sample
+	if len(sample) == encodingSampleSize {
+		// The sample may end part way through a character
+		for i := 1; i < utf8.UTFMax && i <= len(sample); i++ {
+			if tail := sample[len(sample)-i:]; utf8.RuneStart(tail[0]) {
+				if !utf8.FullRune(tail) {
+					valid = sample[:len(sample)-i]
+				}
+				break
+			}
+		}
+	}
+	if utf8.Valid(valid) {
+		return EncodingUTF8
+	}
+
+	// Latin-1 has control characters at 0x80-0x9F, where Windows-1252 has
+	// printable ones such as € and curly quotes
+	for _, b := range sample {
+		if b >= 0x80 && b <= 0x9F {
+			return EncodingWindows1252
+		}
+	}
+	return EncodingLatin1
+}
+
+// decodingReader returns a reader of r's text converted from enc to UTF-8,
+// detecting the encoding when enc is EncodingAuto or empty. It also returns
+// the encoding used.
+func decodingReader(r io.Reader, enc string) (io.Reader, string, error) {
+	br := bufio.NewReader(r)
+	if enc == "" || enc == EncodingAuto {
+		// Peek returns what there is for files shorter than the sample
+		sample, _ := br.Peek(encodingSampleSize)
+		enc = detectEncoding(sample)
+	}
+
+	if enc == EncodingUTF8 {
+		// UTF-8 needs no conversion, only the BOM removed
+		if bom, _ := br.Peek(len(utf8BOM)); bytes.Equal(bom, utf8BOM) {
+			br.Discard(len(utf8BOM))
+		}
+		return br, enc, nil
+	}
+
+	dec, ok := decoders[enc]
+	if !ok {
+		return nil, "", fmt.Errorf("unsupported encoding: %q", enc)
+	}
+	return transform.NewReader(br, dec.NewDecoder()), enc, nil
+}
+
+// validEncoding reports whether enc can be passed to SetEncoding.
+func validEncoding(enc string) bool {
+	_, ok := decoders[enc]
+	return ok || enc == EncodingUTF8 || enc == EncodingAuto || enc == ""
+}
+
+// SetEncoding sets the encoding the current file is read with when it is
+// loaded again. EncodingAuto, or an empty string, detects it. Loading
+// another file with LoadFile detects that file's encoding afresh.
+func (p *Plugin) SetEncoding(enc string) error {
+	if !validEncoding(enc) {
+		return fmt.Errorf("unsupported encoding: %q", enc)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.encoding = enc
+	return nil
+}
+
+// FileEncoding returns the encoding the current file was read with.
+func (p *Plugin) FileEncoding() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.fileEncoding
+}
+
+// reloadWithEncoding re-reads the current file as enc and maps the selected
+// column names, which depend on the encoding, to the new headers.
+func (p *Plugin) reloadWithEncoding(enc string, yColumns []string, xColumn string) ([]string, string, error) {
+	p.mu.Lock()
+	path, oldHeaders := p.currentFile, p.headers
+	p.mu.Unlock()
+
+	if err := p.SetEncoding(enc); err != nil {
+		return nil, "", err
+	}
+	headers, err := p.loadCSVFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(headers) != len(oldHeaders) {
+		return nil, "", fmt.Errorf("file has %d columns as %s, %d before", len(headers), enc, len(oldHeaders))
+	}
+
+	renamed := make(map[string]string, len(headers))
+	for i, h := range oldHeaders {
+		renamed[h] = headers[i]
+	}
+	rename := func(col string) string {
+		if h, ok := renamed[col]; ok {
+			return h
+		}
+		return col // e.g. "Index"
+	}
+
+	newY := make([]string, len(yColumns))
+	for i, col := range yColumns {
+		newY[i] = rename(col)
+	}
+	return newY, rename(xColumn), nil
+}
@@ -0,0 +1,85 @@
+package csv_reader
+
+import (
+	"fmt"
+	"maps"
+	"math"
+)
+
+// ColumnStats summarizes a column of the loaded file, to help choose axis
+// ranges. Min, Max and Mean leave out NaN and infinite values and are 0 for a
+// column without finite values. Count is the number of finite values and
+// NaNCount the number of cells that were empty or not a number.
+type ColumnStats struct {
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"`
+	Mean     float64 `json:"mean"`
+	Count    int     `json:"count"`
+	NaNCount int     `json:"nan_count"`
+}
+
+// columnAccumulator gathers a column's statistics as its cells are parsed.
+type columnAccumulator struct {
+	min, max, sum float64
+	count, nans   int
+}
+
+// newColumnAccumulators returns an empty accumulator for each header.
+func newColumnAccumulators(headers []string) map[string]*columnAccumulator {
+	stats := make(map[string]*columnAccumulator, len(headers))
+	for _, h := range headers {
+		stats[h] = &columnAccumulator{min: math.Inf(1), max: math.Inf(-1)}
+	}
+	return stats
+}
+
+// add counts one parsed cell.
+func (a *columnAccumulator) add(v float64) {
+	switch {
+	case math.IsNaN(v):
+		a.nans++
+	case !math.IsInf(v, 0):
+		a.min = math.Min(a.min, v)
+		a.max = math.Max(a.max, v)
+		a.sum += v
+		a.count++
+	}
+}
+
+// stats returns the statistics of the cells added so far.
+func (a *columnAccumulator) stats() ColumnStats {
+	if a.count == 0 {
+		return ColumnStats{NaNCount: a.nans}
+	}
+	return ColumnStats{Min: a.min, Max: a.max, Mean: a.sum / float64(a.count), Count: a.count, NaNCount: a.nans}
+}
+
+// GetColumnStats returns the statistics of a column of the loaded file,
+// computed when it was read.
+func (p *Plugin) GetColumnStats(column string) (min, max, mean float64, nanCount int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s, ok := p.stats[column]
+	if !ok {
+		return 0, 0, 0, 0, fmt.Errorf("column not found: %s", column)
+	}
+	return s.Min, s.Max, s.Mean, s.NaNCount, nil
+}
+
+// allColumnStats returns the statistics of every column of the loaded file.
+func (p *Plugin) allColumnStats() map[string]ColumnStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return maps.Clone(p.stats)
+}
+
+// columnTitle labels a column in the selection dialog with its range, e.g.
+// "temp (12.5 to 31)".
+func columnTitle(column string, stats map[string]ColumnStats) string {
+	s, ok := stats[column]
+	if !ok || s.Count == 0 {
+		return column
+	}
+	return fmt.Sprintf("%s (%g to %g)", column, s.Min, s.Max)
+}
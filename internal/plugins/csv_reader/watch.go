@@ -0,0 +1,109 @@
+package csv_reader
+
+import (
+	"os"
+	"time"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// idlePollInterval is how often the watcher rechecks the setting while
+// auto-refresh is off.
+const idlePollInterval = 5 * time.Second
+
+// OnRefresh sets a callback run after each auto-refresh.
+func (p *Plugin) OnRefresh(callback func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onRefresh = callback
+}
+
+// startWatcher starts the auto-refresh watcher for the current file,
+// replacing any previous one.
+func (p *Plugin) startWatcher() {
+	if p.config == nil {
+		return
+	}
+	p.stopWatcher()
+
+	stop := make(chan struct{})
+	p.mu.Lock()
+	p.stopWatch = stop
+	p.mu.Unlock()
+	go p.watchFile(stop)
+}
+
+// stopWatcher stops the auto-refresh watcher if one is running.
+func (p *Plugin) stopWatcher() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopWatch != nil {
+		close(p.stopWatch)
+		p.stopWatch = nil
+	}
+}
+
+// watchFile checks the loaded file every ConfigService.AutoRefreshSeconds
+// and refreshes the plugin when the file's modification time has changed,
+// until stop is closed. The frontend is told with a "pluginRefreshed" event.
+func (p *Plugin) watchFile(stop <-chan struct{}) {
+	for {
+		interval := time.Duration(p.config.GetAutoRefreshSeconds()) * time.Second
+		wait := interval
+		if interval <= 0 {
+			wait = idlePollInterval
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(wait):
+		}
+
+		if interval <= 0 || !p.fileChanged() {
+			continue
+		}
+		if err := p.Refresh(); err != nil {
+			if p.logger != nil {
+				p.logger.Warn("Auto-refresh failed", "path", p.currentFileName(), "error", err)
+			}
+			continue
+		}
+		// Don't announce a refresh of a plugin stopped meanwhile
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		if p.logger != nil {
+			p.logger.Info("CSV file reloaded", "path", p.currentFileName())
+		}
+		p.mu.Lock()
+		onRefresh := p.onRefresh
+		p.mu.Unlock()
+		if onRefresh != nil {
+			onRefresh()
+		}
+		if app := application.Get(); app != nil {
+			app.Event.Emit("pluginRefreshed", pluginName)
+		}
+	}
+}
+
+// fileChanged reports whether the current file's modification time differs
+// from when it was last loaded.
+func (p *Plugin) fileChanged() bool {
+	p.mu.Lock()
+	path, modTime := p.currentFile, p.modTime
+	p.mu.Unlock()
+
+	st, err := os.Stat(path)
+	return err == nil && !st.ModTime().Equal(modTime)
+}
+
+// currentFileName returns the loaded file's path.
+func (p *Plugin) currentFileName() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.currentFile
+}
@@ -0,0 +1,88 @@
+package plugins
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// configCacheEntry is an encoded config response with its ETag.
+type configCacheEntry struct {
+	body []byte
+	etag string
+}
+
+// ConfigETag returns the strong ETag of a config response body served by the
+// named plugin.
+func ConfigETag(name string, version uint32, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s:v%d:", name, version)
+	h.Write(body)
+	return `"` + hex.EncodeToString(h.Sum(nil)) + `"`
+}
+
+// CachedConfig returns the cached response for key, e.g. "chart_config", and
+// its ETag. gen identifies the cache state and must be passed to StoreConfig
+// when the response has to be computed.
+func (m *Manager) CachedConfig(key string) (body []byte, etag string, gen uint64, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	entry, ok := m.configCache[key]
+	return entry.body, entry.etag, m.configGen, ok
+}
+
+// StoreConfig caches the active plugin's response body for key and returns
+// its ETag. The body is not cached if the cache was invalidated since gen was
+// returned by CachedConfig, as it may be stale.
+func (m *Manager) StoreConfig(key string, gen uint64, body []byte) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var etag string
+	if entry, ok := m.plugins[m.activePlugin]; ok {
+		etag = ConfigETag(m.activePlugin, entry.plugin.Version(), body)
+	} else {
+		etag = ConfigETag("", 0, body)
+	}
+	if gen == m.configGen {
+		m.configCache[key] = configCacheEntry{body: body, etag: etag}
+	}
+	return etag
+}
+
+// CachedSeries returns the series configs cached for key, which callers must
+// not modify, and gen as CachedConfig does.
+func (m *Manager) CachedSeries(key string) (series []SeriesConfig, gen uint64, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	series, ok = m.seriesCache[key]
+	return series, m.configGen, ok
+}
+
+// StoreSeries caches the active plugin's series configs for key, unless the
+// cache was invalidated since gen was returned.
+func (m *Manager) StoreSeries(key string, gen uint64, series []SeriesConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if gen == m.configGen {
+		m.seriesCache[key] = series
+	}
+}
+
+// InvalidateConfigCache drops all cached config responses, series configs
+// and series metadata. It is called whenever the active plugin, its
+// parameters or its data change.
+func (m *Manager) InvalidateConfigCache() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.invalidateConfigCacheLocked()
+}
+
+// invalidateConfigCacheLocked is InvalidateConfigCache for callers holding
+// m.mu.
+func (m *Manager) invalidateConfigCacheLocked() {
+	clear(m.configCache)
+	clear(m.seriesCache)
+	clear(m.metadataCache)
+	m.configGen++
+}
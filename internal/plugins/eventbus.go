@@ -0,0 +1,74 @@
+package plugins
+
+import "sync"
+
+// eventKey identifies the events a subscription receives.
+type eventKey struct {
+	plugin string
+	event  string
+}
+
+// eventSubscription is a registered handler. id tells subscriptions with the
+// same handler apart when cancelling.
+type eventSubscription struct {
+	id      uint64
+	handler func(data interface{})
+}
+
+// PluginEventBus delivers named events emitted by plugins, such as
+// "dataUpdated", to subscribers, separately from requests and responses.
+type PluginEventBus struct {
+	mu     sync.Mutex
+	subs   map[eventKey][]eventSubscription
+	nextID uint64
+}
+
+// NewPluginEventBus creates an event bus without subscribers.
+func NewPluginEventBus() *PluginEventBus {
+	return &PluginEventBus{subs: make(map[eventKey][]eventSubscription)}
+}
+
+// Emit calls the handlers subscribed to eventName of the named plugin, in
+// the order they subscribed. Handlers run on the caller's goroutine and may
+// subscribe or cancel.
+func (b *PluginEventBus) Emit(pluginName, eventName string, data interface{}) {
+	b.mu.Lock()
+	subs := b.subs[eventKey{pluginName, eventName}]
+	b.mu.Unlock()
+
+	// subs is never modified in place, so it is safe to range over unlocked
+	for _, s := range subs {
+		s.handler(data)
+	}
+}
+
+// Subscribe registers handler for eventName of the named plugin. The returned
+// function cancels the subscription and may be called more than once.
+func (b *PluginEventBus) Subscribe(pluginName, eventName string, handler func(data interface{})) (cancel func()) {
+	key := eventKey{pluginName, eventName}
+
+	b.mu.Lock()
+	b.nextID++
+	id := b.nextID
+	// Copy on write, as Emit may be ranging over the current slice
+	b.subs[key] = append(b.subs[key][:len(b.subs[key]):len(b.subs[key])], eventSubscription{id, handler})
+	b.mu.Unlock()
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		subs := b.subs[key]
+		for i, s := range subs {
+			if s.id == id {
+				rest := make([]eventSubscription, 0, len(subs)-1)
+				rest = append(append(rest, subs[:i]...), subs[i+1:]...)
+				if len(rest) == 0 {
+					delete(b.subs, key)
+				} else {
+					b.subs[key] = rest
+				}
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package plugins
+
+import "testing"
+
+func TestPluginEventBus(t *testing.T) {
+	bus := NewPluginEventBus()
+	var got []string
+	record := func(prefix string) func(interface{}) {
+		return func(data interface{}) { got = append(got, prefix+data.(string)) }
+	}
+
+	cancelA := bus.Subscribe("CSV", "dataUpdated", record("a:"))
+	bus.Subscribe("CSV", "dataUpdated", record("b:"))
+	bus.Subscribe("CSV", "error", record("err:"))
+	bus.Subscribe("JSON", "dataUpdated", record("json:"))
+
+	bus.Emit("CSV", "dataUpdated", "1")
+	cancelA()
+	cancelA() // No effect
+	bus.Emit("CSV", "dataUpdated", "2")
+	bus.Emit("Other", "dataUpdated", "3")
+
+	want := []string{"a:1", "b:1", "b:2"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestPluginEventBusCancelDuringEmit(t *testing.T) {
+	bus := NewPluginEventBus()
+	calls := 0
+	var cancel func()
+	cancel = bus.Subscribe("CSV", "dataUpdated", func(interface{}) {
+		calls++
+		cancel()
+	})
+	bus.Subscribe("CSV", "dataUpdated", func(interface{}) { calls++ })
+
+	bus.Emit("CSV", "dataUpdated", nil)
+	bus.Emit("CSV", "dataUpdated", nil)
+	if calls != 3 {
+		t.Errorf("handlers called %d times, want 3", calls)
+	}
+}
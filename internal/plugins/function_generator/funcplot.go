@@ -2,11 +2,15 @@ package function_generator
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"olicanaplot/internal/appconfig"
 	"olicanaplot/internal/funceval"
 	"olicanaplot/internal/logging"
 	"olicanaplot/internal/plugins"
+	"slices"
+	"strings"
 	"sync"
 
 	"github.com/wailsapp/wails/v3/pkg/application"
@@ -24,6 +28,10 @@ type Plugin struct {
 	xMin         float64
 	xMax         float64
 	numPoints    int
+	polarMode    bool
+	matlabMode   bool
+	thetaMin     float64
+	thetaMax     float64
 }
 
 type ConfigResult struct {
@@ -33,9 +41,21 @@ type ConfigResult struct {
 	XMin           float64 `json:"xMin"`
 	XMax           float64 `json:"xMax"`
 	NumPoints      int     `json:"numPoints"`
+	PolarMode      bool    `json:"polarMode"`
+	MATLABMode     bool    `json:"matlabMode"`
+	ThetaMin       float64 `json:"thetaMin"`
+	ThetaMax       float64 `json:"thetaMax"`
 	Cancelled      bool    `json:"-"`
 }
 
+// vectorParam is the curve parameter of a 3D function, which spans the
+// X Min to X Max range.
+const vectorParam = "t"
+
+// vectorComponents name the components of a 3D function, each plotted
+// against t as series "vec_<name>" in its own subplot.
+var vectorComponents = []string{"x", "y", "z"}
+
 // Built-in presets
 var builtinPresets = []appconfig.FunctionPreset{
 	{Name: "Damped Sine", Expression: "exp(-0.01*x) * sin(x * 0.1)", XMin: 0, XMax: 500, NumPoints: 1000},
@@ -54,6 +74,7 @@ func New(config *appconfig.ConfigService) *Plugin {
 		xMin:         builtinPresets[0].XMin,
 		xMax:         builtinPresets[0].XMax,
 		numPoints:    builtinPresets[0].NumPoints,
+		thetaMax:     2 * math.Pi,
 	}
 }
 
@@ -73,6 +94,25 @@ func (p *Plugin) GetFilePatterns() []plugins.FilePattern {
 	return nil
 }
 
+// InitSchema describes the JSON config accepted as initStr.
+func (p *Plugin) InitSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"functionName": map[string]interface{}{"type": "string"},
+			"expression":   map[string]interface{}{"type": "string"},
+			"xMin":         map[string]interface{}{"type": "number"},
+			"xMax":         map[string]interface{}{"type": "number"},
+			"numPoints":    map[string]interface{}{"type": "integer", "minimum": 2.0},
+			"polarMode":    map[string]interface{}{"type": "boolean"},
+			"matlabMode":   map[string]interface{}{"type": "boolean"},
+			"thetaMin":     map[string]interface{}{"type": "number"},
+			"thetaMax":     map[string]interface{}{"type": "number"},
+		},
+		"required": []interface{}{"expression"},
+	}
+}
+
 func (p *Plugin) Initialize(ctx interface{}, initStr string, logger logging.Logger) (string, error) {
 	p.logger = logger
 	logger.Debug("Initializing function plotter")
@@ -97,8 +137,10 @@ func (p *Plugin) Initialize(ctx interface{}, initStr string, logger logging.Logg
 
 	p.applyConfig(result)
 
-	// Save as user preset if a name is provided and it's not a direct built-in match
-	if result.FunctionName != "" {
+	// Save as user preset if a name is provided and it's not a direct built-in match.
+	// Presets only describe y = f(x) in native syntax, so polar and MATLAB
+	// functions are not saved.
+	if result.FunctionName != "" && !result.PolarMode && !result.MATLABMode {
 		isBuiltin := false
 		for _, b := range builtinPresets {
 			if b.Name == result.FunctionName && b.Expression == result.Expression {
@@ -128,6 +170,13 @@ func (p *Plugin) applyConfig(cfg ConfigResult) {
 	p.xMin = cfg.XMin
 	p.xMax = cfg.XMax
 	p.numPoints = cfg.NumPoints
+	p.polarMode = cfg.PolarMode
+	p.matlabMode = cfg.MATLABMode
+	p.thetaMin = cfg.ThetaMin
+	p.thetaMax = cfg.ThetaMax
+	if p.polarMode && p.thetaMin == p.thetaMax {
+		p.thetaMin, p.thetaMax = 0, 2*math.Pi
+	}
 }
 
 func (p *Plugin) showConfigDialog(app *application.App) ConfigResult {
@@ -167,9 +216,16 @@ func (p *Plugin) showConfigDialog(app *application.App) ConfigResult {
 				"default": builtinPresets[0].Name,
 			},
 			"expression": map[string]interface{}{
-				"title":   "Function Expression y = f(x)",
-				"type":    "string",
-				"default": builtinPresets[0].Expression,
+				"title":       "Function Expression y = f(x), r = f(theta) in polar mode, or x(t); y(t); z(t) for a 3D curve",
+				"description": "Functions: sin, cos, tan, exp, log, sqrt, pow, abs; step(x, threshold) is 0 below threshold and 1 from it on, heaviside(x) = step(x, 0), pulse(x, start, end) is 1 within [start, end], ramp(x) = max(0, x), mod(a, b) or a % b is the remainder with the sign of b; floor, ceil, round, sign(x) is -1, 0 or 1, clamp(x, lo, hi) limits x to [lo, hi], lerp(a, b, t) = a + (b - a) * t. Constants: pi, e.",
+				"type":        "string",
+				"default":     builtinPresets[0].Expression,
+			},
+			"matlabMode": map[string]interface{}{
+				"title":       "MATLAB Mode",
+				"description": "Accept MATLAB/Octave syntax: element-wise operators such as .^ and .*, and mod(a, b).",
+				"type":        "boolean",
+				"default":     false,
 			},
 			"xMin": map[string]interface{}{
 				"title":   "X Min",
@@ -188,34 +244,59 @@ func (p *Plugin) showConfigDialog(app *application.App) ConfigResult {
 				"maximum": 1000000,
 				"default": builtinPresets[0].NumPoints,
 			},
+			"polarMode": map[string]interface{}{
+				"title":   "Polar Mode",
+				"type":    "boolean",
+				"default": false,
+			},
+			"thetaMin": map[string]interface{}{
+				"title":   "θ Min",
+				"type":    "number",
+				"default": 0.0,
+			},
+			"thetaMax": map[string]interface{}{
+				"title":   "θ Max",
+				"type":    "number",
+				"default": 2 * math.Pi,
+			},
 		},
 	}
 
 	uiSchema := map[string]interface{}{
-		"ui:order": []string{"presetFunction", "functionName", "expression", "xMin", "xMax", "numPoints"},
+		"ui:order": []string{"presetFunction", "functionName", "expression", "matlabMode", "xMin", "xMax", "numPoints", "polarMode", "thetaMin", "thetaMax"},
 	}
 
-	// Handle form change for presets
+	// Handle form change for presets, and show lint warnings about the
+	// expression below it. They never stop the function being plotted.
 	lastPreset := enum[0]
 	app.Event.On(fmt.Sprintf("ipc-form-change-%s", requestID), func(e *application.CustomEvent) {
-		if data, ok := e.Data.(map[string]interface{}); ok {
-			if presetLabel, ok := data["presetFunction"].(string); ok {
-				if presetLabel != lastPreset {
-					lastPreset = presetLabel
-					if preset, ok := presetMap[presetLabel]; ok {
-						app.Event.Emit(fmt.Sprintf("ipc-form-update-%s", requestID), map[string]interface{}{
-							"data": map[string]interface{}{
-								"functionName": preset.Name,
-								"expression":   preset.Expression,
-								"xMin":         preset.XMin,
-								"xMax":         preset.XMax,
-								"numPoints":    preset.NumPoints,
-							},
-						})
+		data, ok := e.Data.(map[string]interface{})
+		if !ok {
+			return
+		}
+		expression, _ := data["expression"].(string)
+		matlabMode, _ := data["matlabMode"].(bool)
+		update := map[string]interface{}{}
+		if presetLabel, ok := data["presetFunction"].(string); ok {
+			if presetLabel != lastPreset {
+				lastPreset = presetLabel
+				if preset, ok := presetMap[presetLabel]; ok {
+					update["data"] = map[string]interface{}{
+						"functionName": preset.Name,
+						"expression":   preset.Expression,
+						"xMin":         preset.XMin,
+						"xMax":         preset.XMax,
+						"numPoints":    preset.NumPoints,
 					}
+					expression = preset.Expression
 				}
 			}
 		}
+		update["uiSchema"] = map[string]interface{}{
+			"ui:order":   uiSchema["ui:order"],
+			"expression": map[string]interface{}{"ui:warnings": expressionWarnings(expression, matlabMode)},
+		}
+		app.Event.Emit(fmt.Sprintf("ipc-form-update-%s", requestID), update)
 	})
 
 	app.Event.On(fmt.Sprintf("ipc-form-result-%s", requestID), func(e *application.CustomEvent) {
@@ -231,6 +312,10 @@ func (p *Plugin) showConfigDialog(app *application.App) ConfigResult {
 				XMax:         data["xMax"].(float64),
 				NumPoints:    int(data["numPoints"].(float64)),
 			}
+			res.PolarMode, _ = data["polarMode"].(bool)
+			res.MATLABMode, _ = data["matlabMode"].(bool)
+			res.ThetaMin, _ = data["thetaMin"].(float64)
+			res.ThetaMax, _ = data["thetaMax"].(float64)
 			resultChan <- res
 		}
 	})
@@ -275,6 +360,24 @@ func (p *Plugin) showConfigDialog(app *application.App) ConfigResult {
 func (p *Plugin) GetChartConfig(args string) (*plugins.ChartConfig, error) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
+	if p.is3D() {
+		// One subplot per component, stacked and sharing the t axis
+		linkX := true
+		axes := make([]plugins.AxisGroupConfig, len(vectorComponents))
+		for i, c := range vectorComponents {
+			axes[i] = plugins.AxisGroupConfig{
+				Subplot: &plugins.SubPlot{Row: i, Col: 0},
+				XAxes:   []plugins.AxisConfig{{Title: vectorParam}},
+				YAxes:   []plugins.AxisConfig{{Title: c}},
+			}
+		}
+		return &plugins.ChartConfig{
+			Title: p.functionName,
+			Grid:  &plugins.GridConfig{Rows: len(vectorComponents), Cols: 1},
+			Axes:  axes,
+			LinkX: &linkX,
+		}, nil
+	}
 	return &plugins.ChartConfig{
 		Title: p.functionName,
 	}, nil
@@ -283,6 +386,17 @@ func (p *Plugin) GetChartConfig(args string) (*plugins.ChartConfig, error) {
 func (p *Plugin) GetSeriesConfig() ([]plugins.SeriesConfig, error) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
+	if p.is3D() {
+		series := make([]plugins.SeriesConfig, len(vectorComponents))
+		for i, c := range vectorComponents {
+			series[i] = plugins.SeriesConfig{
+				ID:      "vec_" + c,
+				Name:    fmt.Sprintf("%s(%s)", c, vectorParam),
+				Subplot: &plugins.SubPlot{Row: i, Col: 0},
+			}
+		}
+		return series, nil
+	}
 	return []plugins.SeriesConfig{
 		{
 			ID:   "func_0",
@@ -297,40 +411,167 @@ func (p *Plugin) GetSeriesData(seriesID string, preferredStorage string) ([]floa
 	xMin := p.xMin
 	xMax := p.xMax
 	numPoints := p.numPoints
+	polarMode := p.polarMode
+	matlabMode := p.matlabMode
+	thetaMin := p.thetaMin
+	thetaMax := p.thetaMax
+	logger := p.logger
 	p.mu.RUnlock()
 
+	if matlabMode {
+		converted, err := funceval.ImportMATLAB(exprStr)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid MATLAB expression: %w", err)
+		}
+		exprStr = converted
+	}
+
+	if polarMode {
+		return polarSeriesData(exprStr, thetaMin, thetaMax, numPoints, preferredStorage)
+	}
+	if exprs := splitVector(exprStr); exprs != nil {
+		return vectorSeriesData(exprs, seriesID, xMin, xMax, numPoints, preferredStorage)
+	}
+
 	eval, err := funceval.Compile(exprStr)
 	if err != nil {
 		return nil, "", err
 	}
 
+	// Points where evaluation fails, e.g. "1 % int(x)" near zero, are left
+	// as gaps rather than failing the whole series
 	result := make([]float64, numPoints*2)
+	storage := "interleaved"
+	if preferredStorage == "arrays" {
+		storage = "arrays"
+		dx := (xMax - xMin) / float64(numPoints-1)
+		for i := 0; i < numPoints; i++ {
+			result[i] = xMin + float64(i)*dx
+		}
+		err = eval.SafeEvalRange(xMin, xMax, numPoints, result[numPoints:])
+	} else {
+		err = eval.SafeEvalRange(xMin, xMax, numPoints, result)
+	}
+	if err != nil && !errors.Is(err, funceval.ErrPanicked) {
+		return nil, "", err
+	}
+	if err != nil && logger != nil {
+		logger.Warn("Expression failed at some points", "expression", exprStr, "error", err)
+	}
+
+	return result, storage, nil
+}
+
+// polarSeriesData evaluates r = f(theta) and returns the Cartesian points in
+// the preferred storage format.
+func polarSeriesData(exprStr string, thetaMin, thetaMax float64, numPoints int, preferredStorage string) ([]float64, string, error) {
+	eval, err := funceval.CompilePolar(exprStr)
+	if err != nil {
+		return nil, "", err
+	}
+
+	points, err := eval.EvalAll(thetaMin, thetaMax, numPoints)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if preferredStorage != "arrays" {
+		return points, "interleaved", nil
+	}
+
+	result := make([]float64, len(points))
+	for i := 0; i < numPoints; i++ {
+		result[i] = points[i*2]
+		result[numPoints+i] = points[i*2+1]
+	}
+	return result, "arrays", nil
+}
+
+func (p *Plugin) Close() error {
+	return nil
+}
+
+// expressionWarnings returns funceval.Lint's warnings about the dialog's
+// expression, or about each component of a 3D function, as messages.
+func expressionWarnings(expression string, matlabMode bool) []string {
+	if matlabMode {
+		converted, err := funceval.ImportMATLAB(expression)
+		if err != nil {
+			return []string{}
+		}
+		expression = converted
+	}
+	exprs := splitVector(expression)
+	if exprs == nil {
+		exprs = []string{expression}
+	}
+	warnings := []string{}
+	for _, e := range exprs {
+		for _, w := range funceval.Lint(e) {
+			warnings = append(warnings, fmt.Sprintf("%s: %s", w.Expression, w.Message))
+		}
+	}
+	return warnings
+}
+
+// splitVector splits a 3D function "x(t); y(t); z(t)", optionally wrapped in
+// brackets, into its component expressions. It returns nil for any other
+// expression.
+func splitVector(expression string) []string {
+	s := strings.TrimSpace(expression)
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		s = s[1 : len(s)-1]
+	}
+	parts := strings.Split(s, ";")
+	if len(parts) != len(vectorComponents) {
+		return nil
+	}
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts
+}
+
+// is3D reports whether the current expression is a 3D function. Callers must
+// hold p.mu.
+func (p *Plugin) is3D() bool {
+	return !p.polarMode && splitVector(p.expression) != nil
+}
+
+// vectorSeriesData evaluates the 3D function for t over [tMin, tMax] and
+// returns the component selected by seriesID against t.
+func vectorSeriesData(exprs []string, seriesID string, tMin, tMax float64, numPoints int, preferredStorage string) ([]float64, string, error) {
+	component := slices.IndexFunc(vectorComponents, func(c string) bool { return "vec_"+c == seriesID })
+	if component < 0 {
+		return nil, "", fmt.Errorf("series not found: %s", seriesID)
+	}
+
+	eval, err := funceval.CompileVector(exprs, vectorParam)
+	if err != nil {
+		return nil, "", err
+	}
+
 	isArrays := preferredStorage == "arrays"
 	storage := "interleaved"
 	if isArrays {
 		storage = "arrays"
 	}
 
-	dx := (xMax - xMin) / float64(numPoints-1)
+	result := make([]float64, numPoints*2)
+	dt := (tMax - tMin) / float64(numPoints-1)
 	for i := 0; i < numPoints; i++ {
-		x := xMin + float64(i)*dx
-		y, err := eval.Eval(x)
+		t := tMin + float64(i)*dt
+		v, err := eval.Eval(t)
 		if err != nil {
-			y = 0 // Or handle error
+			return nil, "", fmt.Errorf("failed to evaluate at %s=%g: %w", vectorParam, t, err)
 		}
-
 		if isArrays {
-			result[i] = x
-			result[numPoints+i] = y
+			result[i] = t
+			result[numPoints+i] = v[component]
 		} else {
-			result[i*2] = x
-			result[i*2+1] = y
+			result[i*2] = t
+			result[i*2+1] = v[component]
 		}
 	}
-
 	return result, storage, nil
 }
-
-func (p *Plugin) Close() error {
-	return nil
-}
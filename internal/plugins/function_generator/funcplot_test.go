@@ -0,0 +1,83 @@
+package function_generator
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHelixSeries(t *testing.T) {
+	p := New(nil)
+	p.applyConfig(ConfigResult{
+		FunctionName: "Helix",
+		Expression:   "[cos(t); sin(t); 0.1 * t]",
+		XMin:         0,
+		XMax:         4 * math.Pi,
+		NumPoints:    101,
+	})
+
+	config, _ := p.GetChartConfig("")
+	if config.Grid == nil || config.Grid.Rows != 3 || len(config.Axes) != 3 {
+		t.Fatalf("chart config = %+v, want 3 stacked subplots", config)
+	}
+
+	series, _ := p.GetSeriesConfig()
+	want := []func(float64) float64{math.Cos, math.Sin, func(t float64) float64 { return 0.1 * t }}
+	if len(series) != len(want) {
+		t.Fatalf("got %d series, want %d", len(series), len(want))
+	}
+	for i, s := range series {
+		if s.ID != "vec_"+vectorComponents[i] || s.Subplot == nil || s.Subplot.Row != i {
+			t.Errorf("series %d = %+v", i, s)
+		}
+		data, storage, err := p.GetSeriesData(s.ID, "interleaved")
+		if err != nil {
+			t.Fatalf("GetSeriesData(%s) failed: %v", s.ID, err)
+		}
+		if storage != "interleaved" || len(data) != 202 {
+			t.Fatalf("%s: storage = %s, len = %d", s.ID, storage, len(data))
+		}
+		for k := 0; k < 101; k++ {
+			tv, v := data[k*2], data[k*2+1]
+			if math.Abs(v-want[i](tv)) > 1e-12 {
+				t.Fatalf("%s(%v) = %v, want %v", s.ID, tv, v, want[i](tv))
+			}
+		}
+	}
+
+	if _, _, err := p.GetSeriesData("func_0", "interleaved"); err == nil {
+		t.Error("expected error for a 2D series ID in 3D mode")
+	}
+}
+
+func TestSplitVector(t *testing.T) {
+	for _, tt := range []struct {
+		expr string
+		want int
+	}{
+		{"cos(t); sin(t); t", 3},
+		{"[cos(t); sin(t); t]", 3},
+		{"sin(x)", 0},
+		{"cos(t); sin(t)", 0},
+	} {
+		if got := len(splitVector(tt.expr)); got != tt.want {
+			t.Errorf("splitVector(%q) has %d parts, want %d", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestExpressionWarnings(t *testing.T) {
+	for _, tt := range []struct {
+		expr   string
+		matlab bool
+		want   int
+	}{
+		{"sin(x)", false, 0},
+		{"x * sin(pi / 2)", false, 1},
+		{"cos(t) * sqrt(2); sin(t); t ** 5000", false, 2},
+		{"x.^5000", true, 1},
+	} {
+		if got := len(expressionWarnings(tt.expr, tt.matlab)); got != tt.want {
+			t.Errorf("expressionWarnings(%q) has %d warnings, want %d", tt.expr, got, tt.want)
+		}
+	}
+}
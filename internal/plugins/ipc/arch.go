@@ -0,0 +1,108 @@
+package ipc
+
+import (
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// checkArch warns when the plugin executable is not built for the host's
+// architecture. Such plugins may still run, e.g. x86-64 ones under Rosetta 2
+// on Apple Silicon, but emulated and so slowly. Scripts and executables of
+// unknown format are not checked.
+func (p *Plugin) checkArch() {
+	archs := executableArchs(p.execPath)
+	if len(archs) == 0 || slices.Contains(archs, runtime.GOARCH) || p.logger == nil {
+		return
+	}
+	p.logger.Warn("IPC plugin is built for another architecture and may run emulated",
+		"name", p.name, "plugin_arch", strings.Join(archs, ","), "host_arch", runtime.GOARCH)
+}
+
+// executableArchs returns the GOARCH names of the architectures in the ELF,
+// PE or Mach-O executable at path. Universal Mach-O binaries contain several.
+// It returns nil if the file is not an executable of those formats, and
+// "unknown" for architectures without a GOARCH name.
+func executableArchs(path string) []string {
+	if f, err := elf.Open(path); err == nil {
+		defer f.Close()
+		return []string{elfArch(f)}
+	}
+	if f, err := macho.OpenFat(path); err == nil {
+		defer f.Close()
+		var archs []string
+		for _, a := range f.Arches {
+			archs = append(archs, machoArch(a.Cpu))
+		}
+		return archs
+	}
+	if f, err := macho.Open(path); err == nil {
+		defer f.Close()
+		return []string{machoArch(f.Cpu)}
+	}
+	if f, err := pe.Open(path); err == nil {
+		defer f.Close()
+		return []string{peArch(f.Machine)}
+	}
+	return nil
+}
+
+func elfArch(f *elf.File) string {
+	switch f.Machine {
+	case elf.EM_X86_64:
+		return "amd64"
+	case elf.EM_386:
+		return "386"
+	case elf.EM_AARCH64:
+		return "arm64"
+	case elf.EM_ARM:
+		return "arm"
+	case elf.EM_RISCV:
+		if f.Class == elf.ELFCLASS64 {
+			return "riscv64"
+		}
+	case elf.EM_PPC64:
+		if f.Data == elf.ELFDATA2LSB {
+			return "ppc64le"
+		}
+		return "ppc64"
+	case elf.EM_S390:
+		return "s390x"
+	case elf.EM_LOONGARCH:
+		return "loong64"
+	}
+	return "unknown"
+}
+
+func machoArch(cpu macho.Cpu) string {
+	switch cpu {
+	case macho.CpuAmd64:
+		return "amd64"
+	case macho.Cpu386:
+		return "386"
+	case macho.CpuArm64:
+		return "arm64"
+	case macho.CpuArm:
+		return "arm"
+	case macho.CpuPpc64:
+		return "ppc64"
+	}
+	return "unknown"
+}
+
+func peArch(machine uint16) string {
+	switch machine {
+	case pe.IMAGE_FILE_MACHINE_AMD64:
+		return "amd64"
+	case pe.IMAGE_FILE_MACHINE_I386:
+		return "386"
+	case pe.IMAGE_FILE_MACHINE_ARM64:
+		return "arm64"
+	case pe.IMAGE_FILE_MACHINE_ARMNT:
+		return "arm"
+	}
+	return "unknown"
+}
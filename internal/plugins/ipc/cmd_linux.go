@@ -0,0 +1,80 @@
+//go:build linux
+
+package ipc
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// sandboxShimArg marks a re-exec of the host binary that installs the seccomp
+// filter and then execs the real plugin. Go cannot run code between fork and
+// exec, so the filter has to be installed by this intermediate process.
+const sandboxShimArg = "--olicana-sandbox-exec"
+
+func configureCommand(cmd *exec.Cmd, hide bool) {
+	// No special configuration needed on Linux
+}
+
+// interruptProcess is only implemented on Windows, where Close uses it
+// before killing a plugin. Elsewhere the plugin is killed straight away.
+func interruptProcess(cmd *exec.Cmd) error {
+	return errors.ErrUnsupported
+}
+
+// sandboxCommand runs the plugin in new network and PID namespaces behind a
+// seccomp filter. A user namespace is created as well so that unprivileged
+// users can create the other namespaces.
+func sandboxCommand(cmd *exec.Cmd) error {
+	if cmd.Err != nil {
+		return cmd.Err
+	}
+	if seccompArch == 0 {
+		return fmt.Errorf("seccomp filtering is not supported on %s", runtime.GOARCH)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate host executable: %w", err)
+	}
+
+	// Route the launch through the shim: <host> --olicana-sandbox-exec <path> <argv...>
+	cmd.Args = append([]string{exe, sandboxShimArg, cmd.Path}, cmd.Args...)
+	cmd.Path = exe
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Cloneflags = syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET | syscall.CLONE_NEWPID
+	cmd.SysProcAttr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}}
+	cmd.SysProcAttr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}}
+	cmd.SysProcAttr.GidMappingsEnableSetgroups = false
+	return nil
+}
+
+// RunSandboxShim must be called at the start of main. When the process was
+// launched by sandboxCommand it installs the seccomp filter and replaces
+// itself with the plugin, so it never returns. Otherwise it does nothing.
+func RunSandboxShim() {
+	if len(os.Args) < 4 || os.Args[1] != sandboxShimArg {
+		return
+	}
+
+	// Seccomp filters and no_new_privs apply per thread, so the filter must
+	// be installed on the same thread that calls execve.
+	runtime.LockOSThread()
+
+	if err := installSeccompFilter(); err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+		os.Exit(1)
+	}
+	err := unix.Exec(os.Args[2], os.Args[3:], os.Environ())
+	fmt.Fprintf(os.Stderr, "sandbox: failed to exec %s: %v\n", os.Args[2], err)
+	os.Exit(1)
+}
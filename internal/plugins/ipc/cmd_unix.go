@@ -1,9 +1,26 @@
-//go:build !windows
+//go:build !windows && !linux
 
 package ipc
 
-import "os/exec"
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+)
 
 func configureCommand(cmd *exec.Cmd, hide bool) {
 	// No special configuration needed for other platforms
 }
+
+// interruptProcess is only implemented on Windows, where Close uses it
+// before killing a plugin. Elsewhere the plugin is killed straight away.
+func interruptProcess(cmd *exec.Cmd) error {
+	return errors.ErrUnsupported
+}
+
+func sandboxCommand(cmd *exec.Cmd) error {
+	return fmt.Errorf("IPC plugin sandboxing is only supported on Linux")
+}
+
+// RunSandboxShim is a no-op on platforms without sandbox support.
+func RunSandboxShim() {}
@@ -3,16 +3,45 @@
 package ipc
 
 import (
+	"errors"
+	"fmt"
 	"os/exec"
 	"syscall"
+
+	"golang.org/x/sys/windows"
 )
 
 func configureCommand(cmd *exec.Cmd, hide bool) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
 	if hide {
-		if cmd.SysProcAttr == nil {
-			cmd.SysProcAttr = &syscall.SysProcAttr{}
-		}
 		// CREATE_NO_WINDOW (0x08000000) hides the console window without affecting GUI windows.
 		cmd.SysProcAttr.CreationFlags = 0x08000000
+	} else {
+		// CREATE_NEW_PROCESS_GROUP (0x00000200) lets interruptProcess send
+		// Ctrl+Break to the plugin alone. It shares the host's console, so
+		// the event reaches it, but not the host.
+		cmd.SysProcAttr.CreationFlags = syscall.CREATE_NEW_PROCESS_GROUP
 	}
 }
+
+// interruptProcess sends CTRL_BREAK_EVENT to a plugin started in its own
+// process group, which Go plugins receive as os.Interrupt. Hidden plugins
+// have their own console and can't be reached.
+func interruptProcess(cmd *exec.Cmd) error {
+	if cmd.SysProcAttr == nil || cmd.SysProcAttr.CreationFlags&syscall.CREATE_NEW_PROCESS_GROUP == 0 {
+		return errors.New("plugin is not in its own process group")
+	}
+	if err := windows.GenerateConsoleCtrlEvent(windows.CTRL_BREAK_EVENT, uint32(cmd.Process.Pid)); err != nil {
+		return fmt.Errorf("failed to send Ctrl+Break: %w", err)
+	}
+	return nil
+}
+
+func sandboxCommand(cmd *exec.Cmd) error {
+	return fmt.Errorf("IPC plugin sandboxing is only supported on Linux")
+}
+
+// RunSandboxShim is a no-op on platforms without sandbox support.
+func RunSandboxShim() {}
@@ -0,0 +1,65 @@
+//go:build windows
+
+package ipc
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCtrlBreakHelper is not a real test. TestInterruptProcess runs the test
+// binary with OLICANA_CTRL_BREAK_HELPER=1 as a plugin that reports the
+// interrupt it receives.
+func TestCtrlBreakHelper(t *testing.T) {
+	if os.Getenv("OLICANA_CTRL_BREAK_HELPER") != "1" {
+		return
+	}
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	fmt.Println("ready")
+	select {
+	case <-interrupts:
+		fmt.Println("interrupted")
+	case <-time.After(10 * time.Second):
+	}
+	os.Exit(0)
+}
+
+func TestInterruptProcess(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestCtrlBreakHelper$")
+	cmd.Env = append(os.Environ(), "OLICANA_CTRL_BREAK_HELPER=1")
+	configureCommand(cmd, false)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start helper: %v", err)
+	}
+	defer cmd.Process.Kill()
+
+	lines := bufio.NewScanner(stdout)
+	if !lines.Scan() || strings.TrimSpace(lines.Text()) != "ready" {
+		t.Fatalf("helper did not start: %q", lines.Text())
+	}
+	if err := interruptProcess(cmd); err != nil {
+		t.Fatalf("interruptProcess failed: %v", err)
+	}
+	if !lines.Scan() || strings.TrimSpace(lines.Text()) != "interrupted" {
+		t.Errorf("helper did not receive Ctrl+Break, got %q", lines.Text())
+	}
+	cmd.Wait()
+
+	// Hidden plugins have their own console, so aren't interrupted
+	hidden := exec.Command(os.Args[0])
+	configureCommand(hidden, true)
+	if err := interruptProcess(hidden); err == nil {
+		t.Error("expected an error interrupting a hidden plugin")
+	}
+}
@@ -0,0 +1,80 @@
+package ipc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// defaultCPUTimeLimit is how long a plugin may take to answer a request
+// unless SetCPUTimeLimit says otherwise.
+const defaultCPUTimeLimit = 30 * time.Second
+
+// ErrCPUTimeExceeded is returned by requests the plugin did not answer
+// within its CPU time limit, such as a get_series_data stuck in a loop. The
+// plugin's process is killed and restarted by the watchdog, or by the next
+// request if that comes first.
+var ErrCPUTimeExceeded = errors.New("plugin exceeded its CPU time limit")
+
+// unlimitedMethods are the requests exempt from the CPU time limit.
+// initialize may wait on windows of the plugin's own, which the host can't
+// tell apart from a hang, or load a large file.
+var unlimitedMethods = map[string]bool{"initialize": true}
+
+// SetCPUTimeLimit sets how long the plugin may take to answer a request
+// other than initialize before its process is killed. Time spent waiting for
+// the user in dialogs doesn't count, and each progress message starts the
+// time again. Zero restores the default of 30 seconds, and a negative d
+// removes the limit. It waits for any request in flight to finish first.
+func (p *Plugin) SetCPUTimeLimit(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cpuTimeLimit = d
+}
+
+// cpuTimeLimitLocked returns the limit in force, negative if there is none.
+// Callers must hold p.mu.
+func (p *Plugin) cpuTimeLimitLocked() time.Duration {
+	if p.cpuTimeLimit == 0 {
+		return defaultCPUTimeLimit
+	}
+	return p.cpuTimeLimit
+}
+
+// limitRequest returns a context that expires after the CPU time limit for
+// method, or when parent is done, and kills the process when it does,
+// unblocking the pending read. release stops the timer without killing the
+// process, and must be called once the reply is read. Callers must hold p.mu.
+func (p *Plugin) limitRequest(parent context.Context, method string) (ctx context.Context, release func()) {
+	if p.cmd == nil || p.cmd.Process == nil {
+		return parent, func() {}
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	if limit := p.cpuTimeLimitLocked(); limit >= 0 && !unlimitedMethods[method] {
+		ctx, cancel = context.WithTimeout(parent, limit)
+	}
+	proc := p.cmd.Process
+	stop := context.AfterFunc(ctx, func() { proc.Kill() })
+	return ctx, func() {
+		// Stop before cancelling, which would otherwise run the kill
+		stop()
+		cancel()
+	}
+}
+
+// cpuTimeError returns ErrCPUTimeExceeded in place of err if ctx, from
+// limitRequest, expired while method was in flight, and marks the killed
+// process for a restart. Otherwise it returns err. Callers must hold p.mu.
+func (p *Plugin) cpuTimeError(ctx context.Context, method string, err error) error {
+	if err == nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	p.running = false
+	p.cpuTimedOut = true
+	if p.logger != nil {
+		p.logger.Warn("IPC plugin exceeded its CPU time limit, killed it", "name", p.name, "method", method, "limit", p.cpuTimeLimitLocked())
+	}
+	return fmt.Errorf("%w: no reply to %s within %v", ErrCPUTimeExceeded, method, p.cpuTimeLimitLocked())
+}
@@ -4,15 +4,25 @@ package ipc
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
@@ -22,13 +32,45 @@ import (
 	"github.com/wailsapp/wails/v3/pkg/application"
 )
 
+// defaultDialogTimeout is how long a show_form dialog waits for the user.
+const defaultDialogTimeout = 5 * time.Minute
+
+// defaultBufferSize is the size of the buffer for reading plugin stdout.
+// Binary series data follows its header directly, so a buffer much larger
+// than bufio's default 4 KB saves many small reads.
+const defaultBufferSize = 64 << 10
+
+// autocompleteTimeout is how long a form_autocomplete waits for the plugin's
+// candidates before the field shows none.
+const autocompleteTimeout = 200 * time.Millisecond
+
+// ErrChecksumMismatch is returned by GetSeriesData when the binary data does
+// not match the CRC-32 checksum in its header.
+var ErrChecksumMismatch = errors.New("binary data checksum mismatch")
+
+// ErrTruncatedData is returned by GetSeriesData when the plugin sends fewer
+// bytes than its header announced, e.g. because it crashed mid-send, or an
+// odd number of values for x/y storage.
+var ErrTruncatedData = errors.New("truncated binary data")
+
+// GracefulShutdownTimeout is how long Close waits for a plugin to acknowledge
+// quit, and then for it to exit, before killing it.
+var GracefulShutdownTimeout = 2 * time.Second
+
 // Loader discovers and manages IPC plugins.
 type Loader struct {
-	searchDirs []string
-	logger     logging.Logger
+	mu            sync.Mutex // Guards searchDirs, which RegisterDir extends
+	searchDirs    []string
+	sandbox       bool
+	dialogTimeout time.Duration
+	dpiScale      float64
+	locale        func() string
+	bufferSize    int
+	maxPlugins    int // Discover stops after this many plugins, <= 0 for no limit
+	logger        logging.Logger
 }
 
-// NewLoader creates a new IPC plugin loader.
+// NewLoader creates a new IPC plugin loader that scans each of searchDirs.
 func NewLoader(searchDirs []string, logger logging.Logger) *Loader {
 	return &Loader{
 		searchDirs: searchDirs,
@@ -36,11 +78,104 @@ func NewLoader(searchDirs []string, logger logging.Logger) *Loader {
 	}
 }
 
-// Discover finds and loads all IPC plugins in the plugins directory.
+// SetSandbox controls whether discovered plugins run in an OS-level sandbox.
+// It must be called before Discover and is currently only supported on Linux.
+func (l *Loader) SetSandbox(enabled bool) {
+	l.sandbox = enabled
+}
+
+// SetDialogTimeout sets how long show_form dialogs of discovered plugins wait
+// for the user. It must be called before Discover.
+func (l *Loader) SetDialogTimeout(t time.Duration) {
+	l.dialogTimeout = t
+}
+
+// SetDPIScale sets the display scaling factor used to size show_form dialogs
+// of discovered plugins. It must be called before Discover.
+func (l *Loader) SetDPIScale(scale float64) {
+	l.dpiScale = scale
+}
+
+// SetLocale sets the function returning the user's locale, e.g. "fr-FR",
+// which discovered plugins send with every request. It must be called before
+// Discover.
+func (l *Loader) SetLocale(locale func() string) {
+	l.locale = locale
+}
+
+// SetBufferSize sets the size of the buffer discovered plugins read their
+// stdout through. It must be called before Discover.
+func (l *Loader) SetBufferSize(size int) {
+	l.bufferSize = size
+}
+
+// SetMaxPlugins limits how many plugins Discover loads, since each one is
+// started to query its metadata. n <= 0 removes the limit. It must be called
+// before Discover.
+func (l *Loader) SetMaxPlugins(n int) {
+	l.maxPlugins = n
+}
+
+// Discover finds and loads all IPC plugins in the search directories.
 func (l *Loader) Discover() ([]*Plugin, error) {
+	l.mu.Lock()
+	dirs := slices.Clone(l.searchDirs)
+	l.mu.Unlock()
+	return l.discover(dirs)
+}
+
+// RegisterDir adds dir to the search directories and registers the plugins
+// found in it with manager, for a directory added while the app is running.
+// Plugins already registered, e.g. found through another directory, are
+// skipped. It returns the names of the plugins it registered.
+func (l *Loader) RegisterDir(dir string, manager *plugins.Manager) ([]string, error) {
+	l.mu.Lock()
+	if !slices.Contains(l.searchDirs, dir) {
+		l.searchDirs = append(l.searchDirs, dir)
+	}
+	l.mu.Unlock()
+
+	found, err := l.discover([]string{dir})
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	var limitErr error
+	for _, p := range found {
+		if limitErr != nil {
+			p.Close()
+			continue
+		}
+		if manager.Get(p.Name()) != nil {
+			l.logger.Info("IPC plugin already registered, skipping", "name", p.Name(), "dir", dir)
+			p.Close()
+			continue
+		}
+		if err := manager.Register(p, false); err != nil {
+			l.logger.Warn("Failed to register IPC plugin", "name", p.Name(), "error", err)
+			p.Close()
+			if errors.Is(err, plugins.ErrTooManyPlugins) {
+				limitErr = err
+			}
+			continue
+		}
+		names = append(names, p.Name())
+	}
+	return names, limitErr
+}
+
+// discover finds and loads the IPC plugins in dirs.
+func (l *Loader) discover(dirs []string) ([]*Plugin, error) {
 	var result []*Plugin
 
-	for _, dir := range l.searchDirs {
+	// Canonical paths of the manifests and executables found so far, so a
+	// plugin reachable from several directories, e.g. through a symlink, is
+	// only loaded once
+	seen := make(map[string]bool)
+
+scan:
+	for _, dir := range dirs {
+
 		l.logger.Info("Scanning for IPC plugins", "dir", dir)
 
 		// Check if directory exists
@@ -61,11 +196,17 @@ func (l *Loader) Discover() ([]*Plugin, error) {
 		}
 
 		for _, entry := range entries {
-			if !entry.IsDir() {
+			pluginDir := filepath.Join(dir, entry.Name())
+
+			// Stat follows symlinks, which ReadDir reports as non-directories
+			if info, err := os.Stat(pluginDir); err != nil || !info.IsDir() {
 				continue
 			}
+			if l.maxPlugins > 0 && len(result) >= l.maxPlugins {
+				l.logger.Warn("IPC plugin limit reached, skipping remaining plugins", "limit", l.maxPlugins, "dir", dir)
+				break scan
+			}
 
-			pluginDir := filepath.Join(dir, entry.Name())
 			manifestPath := filepath.Join(pluginDir, "olicana-plot-plugin.json")
 
 			var plugin *Plugin
@@ -73,6 +214,9 @@ func (l *Loader) Discover() ([]*Plugin, error) {
 
 			// 1. Try JSON manifest discovery (highest priority)
 			if _, errStat := os.Stat(manifestPath); errStat == nil {
+				if l.isDuplicate(seen, manifestPath) {
+					continue
+				}
 				l.logger.Info("Found JSON manifest plugin", "path", manifestPath)
 				plugin, err = l.NewPluginFromManifest(manifestPath)
 			} else {
@@ -82,11 +226,19 @@ func (l *Loader) Discover() ([]*Plugin, error) {
 				execPath := filepath.Join(pluginDir, execName)
 
 				if _, errStat := os.Stat(execPath); errStat == nil {
+					if l.isDuplicate(seen, execPath) {
+						continue
+					}
 					l.logger.Info("Found executable IPC plugin", "path", execPath)
-					plugin, err = NewPlugin(execPath)
+					plugin, err = newPlugin(execPath, l.sandbox, WithDialogTimeout(l.dialogTimeout), WithDPIScale(l.dpiScale), WithLocale(l.locale), WithBufferSize(l.bufferSize), WithLogger(l.logger))
 				}
 			}
 
+			var incompatible *ErrVersionIncompatible
+			if errors.As(err, &incompatible) {
+				l.logger.Error("IPC plugin is incompatible with this version of OlicanaPlot", "dir", entry.Name(), "error", err)
+				continue
+			}
 			if err != nil {
 				l.logger.Error("Failed to load IPC plugin", "dir", entry.Name(), "error", err)
 				continue
@@ -102,22 +254,69 @@ func (l *Loader) Discover() ([]*Plugin, error) {
 	return result, nil
 }
 
+// isDuplicate reports whether path resolves to a file already in seen, and
+// adds it otherwise. Manifests are compared rather than their commands,
+// which may name a shared interpreter.
+func (l *Loader) isDuplicate(seen map[string]bool, path string) bool {
+	canonical, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		canonical = path
+	}
+	if abs, err := filepath.Abs(canonical); err == nil {
+		canonical = abs
+	}
+	if seen[canonical] {
+		l.logger.Info("Skipping duplicate IPC plugin", "path", path, "canonical", canonical)
+		return true
+	}
+	seen[canonical] = true
+	return false
+}
+
 // Plugin wraps an external process as a plugin.
 type Plugin struct {
-	mu           sync.Mutex
-	execPath     string
-	execArgs     []string
-	workDir      string
-	cmd          *exec.Cmd
-	stdin        io.WriteCloser
-	stdout       *bufio.Reader
-	name         string
-	version      uint32
-	filePatterns []plugins.FilePattern
-	running      bool
-	logger       logging.Logger
-	app          *application.App
-	commsMu      sync.Mutex // For synchronizing stdin/stdout access
+	mu            sync.Mutex
+	execPath      string
+	execArgs      []string
+	workDir       string
+	cmd           *exec.Cmd
+	stdin         io.WriteCloser
+	stdout        *bufio.Reader
+	name          string
+	version       uint32
+	info          plugins.PluginInfo // Build metadata from --metadata or the manifest
+	capabilities  []string           // Methods declared in the metadata, nil if not declared
+	filePatterns  []plugins.FilePattern
+	initSchema    map[string]interface{} // From the metadata, nil if not declared
+	sandbox       bool                   // Run in an OS-level sandbox (Linux only)
+	dialogTimeout time.Duration          // How long show_form waits, defaultDialogTimeout if zero
+	dpiScale      float64                // Display scaling factor for dialog sizes, 1 if zero
+	traceID       string                 // Sent with every request once initialized
+	locale        func() string          // Returns the locale sent with every request, nil for none
+	bufferSize    int                    // Of the stdout reader, defaultBufferSize if zero
+	icon          []byte                 // PNG from the --icon flag, nil if not provided
+	concurrent    bool                   // Metadata allows several processes, see GetSeriesDataParallel
+	workers       []*Plugin              // Extra processes of a concurrent plugin, nil until needed
+	running       bool
+	initialized   bool          // Initialize succeeded, so restarts initialize again
+	initArgs      string        // Of the last Initialize
+	interactive   bool          // The last Initialize showed a dialog, so restarts don't repeat it
+	restartErr    error         // Why the plugin was left stopped until initialized again, nil if not
+	restarts      int           // By the watchdog since the last Initialize
+	stopWatchdog  chan struct{} // Closed to stop the watchdog, nil if not running
+	cpuTimeLimit  time.Duration // Per request, defaultCPUTimeLimit if zero, none if negative
+	cpuTimedOut   bool          // The process was killed by the CPU time limit, so restart it
+	logger        logging.Logger
+	app           *application.App
+	commsMu       sync.Mutex // For synchronizing stdin/stdout access
+
+	// minHostVersion is the oldest host protocol version the plugin works
+	// with, from its metadata. 0 if not declared.
+	minHostVersion uint32
+
+	// events receives the plugin's "event" messages. It is set on
+	// registration, possibly while a request is in flight.
+	events atomic.Pointer[plugins.PluginEventBus]
 }
 
 // Request represents an IPC request message sent from the host.
@@ -126,7 +325,13 @@ type Request struct {
 	Args             string                 `json:"args,omitempty"`
 	SeriesID         string                 `json:"series_id,omitempty"`
 	PreferredStorage string                 `json:"preferred_storage,omitempty"`
-	Data             map[string]interface{} `json:"data,omitempty"`
+	Data             map[string]interface{} `json:"data,omitempty"` // For form_change and update_config
+	TraceID          string                 `json:"trace_id,omitempty"`
+	RequestID        string                 `json:"request_id,omitempty"` // New for each request, echoed in the reply
+	Locale           string                 `json:"locale,omitempty"`     // e.g. "fr-FR"
+	Field            string                 `json:"field,omitempty"`      // For form_autocomplete
+	Query            string                 `json:"query,omitempty"`      // For form_autocomplete
+	Series           []plugins.SeriesPoints `json:"series,omitempty"`     // For save
 }
 
 // Response represents an IPC response message received from a plugin.
@@ -134,11 +339,13 @@ type Request struct {
 // to allow the host to unmarshal it into different concrete types.
 type Response struct {
 	Method           string          `json:"method,omitempty"` // For async messages like "log" or "show_form"
+	Event            string          `json:"event,omitempty"`  // For "event" messages
 	Result           json.RawMessage `json:"result,omitempty"`
 	Error            string          `json:"error,omitempty"`
 	Type             string          `json:"type,omitempty"`
 	Length           int             `json:"length,omitempty"`
 	Storage          string          `json:"storage,omitempty"`
+	Checksum         *uint32         `json:"checksum,omitempty"` // CRC-32 (IEEE) of the binary data, if sent
 	Name             string          `json:"name,omitempty"`
 	Version          uint32          `json:"version,omitempty"`
 	Title            string          `json:"title,omitempty"`
@@ -146,14 +353,68 @@ type Response struct {
 	UISchema         json.RawMessage `json:"uiSchema,omitempty"`
 	Data             json.RawMessage `json:"data,omitempty"`
 	HandleFormChange bool            `json:"handle_form_change,omitempty"`
+	InitSchema       json.RawMessage `json:"init_schema,omitempty"`
+	SeriesIDSchema   json.RawMessage `json:"series_id_schema,omitempty"`
+	Icon             string          `json:"icon,omitempty"`   // Base64 PNG for show_form
+	Accept           string          `json:"accept,omitempty"` // File pattern for show_file_dialog, e.g. "*.cal"
+	PluginVersion    string          `json:"plugin_version,omitempty"`
+	BuildDate        string          `json:"build_date,omitempty"`
+	CommitHash       string          `json:"commit_hash,omitempty"`
+	Capabilities     []string        `json:"capabilities,omitempty"` // For info
+	RequestID        string          `json:"request_id,omitempty"`   // Of the request answered, if echoed
+}
+
+// eventMessage is a host-to-plugin notification. Plugins must not answer it.
+type eventMessage struct {
+	Method  string          `json:"method"` // Always "event"
+	Event   string          `json:"event"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	TraceID string          `json:"trace_id,omitempty"`
 }
 
 // PluginMetadata contains everything required for plugin discovery.
 type PluginMetadata struct {
 	Name         string                `json:"name"`
 	FilePatterns []plugins.FilePattern `json:"patterns"`
-	Command      interface{}           `json:"command"` // string or []string
-	WorkDir      string                `json:"workDir"` // optional
+	Command      interface{}           `json:"command"`    // string or []string
+	WorkDir      string                `json:"workDir"`    // optional
+	Concurrent   bool                  `json:"concurrent"` // optional, several processes may serve requests
+
+	// MinHostVersion is the oldest host protocol version the plugin works
+	// with. Optional.
+	MinHostVersion uint32 `json:"minHostVersion"`
+
+	// The rest is optional too, and tells the host what it would otherwise
+	// have to start the plugin to ask: the protocol version it speaks, its
+	// build, the methods it implements and the JSON Schema of its
+	// initialize args.
+	APIVersion    uint32                 `json:"apiVersion"`
+	PluginVersion string                 `json:"pluginVersion"`
+	BuildDate     string                 `json:"buildDate"`
+	CommitHash    string                 `json:"commitHash"`
+	Capabilities  []string               `json:"capabilities"`
+	InitSchema    map[string]interface{} `json:"initSchema"`
+}
+
+// applyMetadata sets the plugin's fields from its metadata.
+func (p *Plugin) applyMetadata(meta PluginMetadata) {
+	if meta.Name != "" {
+		p.name = meta.Name
+	}
+	p.filePatterns = meta.FilePatterns
+	p.concurrent = meta.Concurrent
+	p.minHostVersion = meta.MinHostVersion
+	if meta.APIVersion != 0 {
+		p.version = meta.APIVersion
+	}
+	p.info = plugins.PluginInfo{
+		APIVersion:    p.version,
+		PluginVersion: meta.PluginVersion,
+		BuildDate:     meta.BuildDate,
+		CommitHash:    meta.CommitHash,
+	}
+	p.capabilities = meta.Capabilities
+	p.initSchema = meta.InitSchema
 }
 
 // NewPluginFromManifest creates an IPC plugin wrapper from a JSON manifest file.
@@ -171,10 +432,18 @@ func (l *Loader) NewPluginFromManifest(manifestPath string) (*Plugin, error) {
 	pluginDir := filepath.Dir(manifestPath)
 
 	p := &Plugin{
-		name:         meta.Name,
-		filePatterns: meta.FilePatterns,
-		workDir:      pluginDir,
-		version:      1,
+		workDir:       pluginDir,
+		version:       1,
+		sandbox:       l.sandbox,
+		dialogTimeout: l.dialogTimeout,
+		dpiScale:      l.dpiScale,
+		locale:        l.locale,
+		bufferSize:    l.bufferSize,
+		logger:        l.logger,
+	}
+	p.applyMetadata(meta)
+	if err := p.checkVersion(); err != nil {
+		return nil, err
 	}
 
 	// Override workDir if specified in manifest (relative to plugin dir or absolute)
@@ -213,8 +482,56 @@ func (l *Loader) NewPluginFromManifest(manifestPath string) (*Plugin, error) {
 	return p, nil
 }
 
+// PluginOption configures a Plugin created by NewPlugin.
+type PluginOption func(*Plugin)
+
+// WithDialogTimeout sets how long a show_form dialog waits for the user
+// before the plugin receives a "timeout" error. Zero keeps the default.
+func WithDialogTimeout(t time.Duration) PluginOption {
+	return func(p *Plugin) {
+		p.dialogTimeout = t
+	}
+}
+
+// WithDPIScale sets the display scaling factor by which show_form dialog
+// sizes are divided. Zero keeps the default of 1.
+func WithDPIScale(scale float64) PluginOption {
+	return func(p *Plugin) {
+		p.dpiScale = scale
+	}
+}
+
+// WithBufferSize sets the size of the buffer the plugin's stdout is read
+// through. Zero keeps the default of 64 KB.
+func WithBufferSize(size int) PluginOption {
+	return func(p *Plugin) {
+		p.bufferSize = size
+	}
+}
+
+// WithLocale sets the function returning the locale sent with every request,
+// so plugins can localize their forms. It is called per request, so locale
+// changes apply straight away.
+func WithLocale(locale func() string) PluginOption {
+	return func(p *Plugin) {
+		p.locale = locale
+	}
+}
+
+// WithLogger sets the logger used from construction on, so that messages
+// logged while fetching the plugin's metadata are not discarded.
+func WithLogger(logger logging.Logger) PluginOption {
+	return func(p *Plugin) {
+		p.SetLogger(logger)
+	}
+}
+
 // NewPlugin creates an IPC plugin wrapper and fetches its metadata.
-func NewPlugin(execPath string) (*Plugin, error) {
+func NewPlugin(execPath string, opts ...PluginOption) (*Plugin, error) {
+	return newPlugin(execPath, false, opts...)
+}
+
+func newPlugin(execPath string, sandbox bool, opts ...PluginOption) (*Plugin, error) {
 	// Verify exe exists first
 	if _, err := os.Stat(execPath); err != nil {
 		return nil, fmt.Errorf("plugin executable not found at %s: %w", execPath, err)
@@ -225,37 +542,135 @@ func NewPlugin(execPath string) (*Plugin, error) {
 		workDir:  filepath.Dir(execPath),
 		name:     displayNameFromPath(execPath),
 		version:  1,
+		sandbox:  sandbox,
+	}
+	for _, opt := range opts {
+		opt(p)
 	}
 
-	// Fetch metadata via CLI flag
-	cmd := exec.Command(execPath, "--metadata")
-	configureCommand(cmd, true)
-	output, err := cmd.Output()
-	if err == nil {
-		var meta PluginMetadata
-		if json.Unmarshal(output, &meta) == nil {
-			if meta.Name != "" {
-				p.name = meta.Name
-			}
-			p.filePatterns = meta.FilePatterns
-		}
+	// Plugins that don't support --metadata keep the defaults. The IPC
+	// session isn't started: a plugin may show a window as soon as it runs.
+	if err := p.fetchMetadata(); err != nil && p.logger != nil {
+		p.logger.Debug("No metadata from IPC plugin", "path", execPath, "error", err)
+	}
+	if err := p.checkVersion(); err != nil {
+		return nil, err
 	}
+	p.checkArch()
+
+	// Fetch the default dialog icon via CLI flag. Plugins without an icon
+	// leave it unset and dialogs use the default window icon.
+	p.fetchIcon()
 
 	return p, nil
 }
 
-// start launches the plugin subprocess.
+// fetchMetadata runs the plugin with the --metadata flag, which prints its
+// PluginMetadata as JSON, and applies it.
+func (p *Plugin) fetchMetadata() error {
+	cmd, err := p.command("--metadata")
+	if err != nil {
+		return err
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return err
+	}
+	var meta PluginMetadata
+	if err := json.Unmarshal(output, &meta); err != nil {
+		return fmt.Errorf("failed to parse metadata: %w", err)
+	}
+	p.applyMetadata(meta)
+	return nil
+}
+
+// fetchIcon runs the plugin with the --icon flag, which prints a base64
+// encoded PNG used for dialogs that don't carry their own icon.
+func (p *Plugin) fetchIcon() error {
+	cmd, err := p.command("--icon")
+	if err != nil {
+		return err
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return err
+	}
+
+	icon, err := decodeIcon(strings.TrimSpace(string(output)))
+	if err != nil {
+		return err
+	}
+	p.icon = icon
+	return nil
+}
+
+// newTraceID returns a random version 4 UUID.
+func newTraceID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// pngSignature is the 8-byte header every PNG file starts with.
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// decodeIcon decodes a base64 encoded PNG icon.
+func decodeIcon(iconBase64 string) ([]byte, error) {
+	icon, err := base64.StdEncoding.DecodeString(iconBase64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode icon: %w", err)
+	}
+	if !bytes.HasPrefix(icon, pngSignature) {
+		return nil, fmt.Errorf("icon is not a PNG image")
+	}
+	return icon, nil
+}
+
+// command builds a command running the plugin executable with extra args,
+// applying platform and sandbox settings.
+func (p *Plugin) command(args ...string) (*exec.Cmd, error) {
+	cmd := exec.Command(p.execPath, append(append([]string{}, p.execArgs...), args...)...)
+	cmd.Dir = p.workDir
+	configureCommand(cmd, true)
+	if p.sandbox {
+		if err := sandboxCommand(cmd); err != nil {
+			return nil, fmt.Errorf("failed to sandbox plugin: %w", err)
+		}
+	}
+	return cmd, nil
+}
+
+// start launches the plugin subprocess. A process killed by the CPU time
+// limit is restarted as the watchdog would, initializing it again.
 func (p *Plugin) start() error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	if p.cpuTimedOut {
+		p.cpuTimedOut = false
+		p.restartLocked()
+		if p.running {
+			return nil
+		}
+	}
+	if p.restartErr != nil {
+		return p.restartErr
+	}
+	return p.startLocked()
+}
 
+// startLocked is start for callers holding p.mu.
+func (p *Plugin) startLocked() error {
 	if p.running {
 		return nil
 	}
 
-	p.cmd = exec.Command(p.execPath, p.execArgs...)
-	p.cmd.Dir = p.workDir
-	configureCommand(p.cmd, true)
+	cmd, err := p.command()
+	if err != nil {
+		return err
+	}
+	p.cmd = cmd
 
 	stdin, err := p.cmd.StdinPipe()
 	if err != nil {
@@ -267,7 +682,7 @@ func (p *Plugin) start() error {
 	if err != nil {
 		return fmt.Errorf("failed to get stdout pipe: %w", err)
 	}
-	p.stdout = bufio.NewReader(stdout)
+	p.stdout = newStdoutReader(stdout, p.bufferSize)
 
 	// Capture stderr for debugging (goes to host stderr)
 	p.cmd.Stderr = os.Stderr
@@ -277,18 +692,7 @@ func (p *Plugin) start() error {
 	}
 
 	p.running = true
-	return nil
-}
-
-// fetchInfo gets the plugin name and version.
-func (p *Plugin) fetchInfo() error {
-	resp, err := p.sendRequest(Request{Method: "info"})
-	if err != nil {
-		return err
-	}
-
-	p.name = resp.Name
-	p.version = resp.Version
+	p.startWatchdogLocked()
 	return nil
 }
 
@@ -312,16 +716,73 @@ func (p *Plugin) sendRequest(req Request) (*Response, error) {
 	return p.sendLockedRequest(req)
 }
 
+// SendCustomEvent notifies a running plugin of a host event, such as a theme
+// change, without waiting for a response. data must encode to a JSON object
+// or be nil. It waits for any request in flight to finish first.
+//
+// Only plugins that list "event" in their capabilities are sent events.
+// Others may answer an unknown method with an error, which would be read as
+// the reply to the next request.
+func (p *Plugin) SendCustomEvent(eventName string, data interface{}) error {
+	var raw json.RawMessage
+	if data != nil {
+		b, err := json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("failed to encode event data: %w", err)
+		}
+		if trimmed := bytes.TrimSpace(b); len(trimmed) == 0 || (trimmed[0] != '{' && !bytes.Equal(trimmed, []byte("null"))) {
+			return fmt.Errorf("event data must be a JSON object")
+		}
+		raw = b
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.running {
+		return fmt.Errorf("plugin not running")
+	}
+	if !slices.Contains(p.capabilities, "event") {
+		return fmt.Errorf("plugin %s does not accept events", p.name)
+	}
+
+	p.commsMu.Lock()
+	defer p.commsMu.Unlock()
+
+	msg, err := json.Marshal(eventMessage{Method: "event", Event: eventName, Data: raw, TraceID: p.traceID})
+	if err != nil {
+		return err
+	}
+	if _, err := p.stdin.Write(append(msg, '\n')); err != nil {
+		return fmt.Errorf("failed to send event: %w", err)
+	}
+	return nil
+}
+
 // sendLockedRequest performs the actual comms while holding necessary locks.
 func (p *Plugin) sendLockedRequest(req Request) (*Response, error) {
 	p.commsMu.Lock()
 	defer p.commsMu.Unlock()
 
-	return p.sendInternal(req)
+	return p.sendInternal(context.Background(), req)
 }
 
-func (p *Plugin) sendInternal(req Request) (*Response, error) {
+// sendInternal sends req and reads the reply. The process is killed if the
+// reply takes longer than the CPU time limit, or if ctx is done first. The
+// caller must hold p.mu and commsMu.
+func (p *Plugin) sendInternal(ctx context.Context, req Request) (*Response, error) {
+	limited, release := p.limitRequest(ctx, req.Method)
+	defer func() { release() }()
+
 	// Send request as JSON line
+	if req.TraceID == "" {
+		req.TraceID = p.traceID
+	}
+	if req.RequestID == "" {
+		req.RequestID = newTraceID()
+	}
+	if req.Locale == "" {
+		req.Locale = p.currentLocale()
+	}
 	reqBytes, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
@@ -341,7 +802,7 @@ func (p *Plugin) sendInternal(req Request) (*Response, error) {
 		respLine, err := p.stdout.ReadString('\n')
 		if err != nil {
 			p.running = false
-			return nil, fmt.Errorf("failed to read response: %w", err)
+			return nil, p.cpuTimeError(limited, req.Method, fmt.Errorf("failed to read response: %w", err))
 		}
 
 		if p.logger != nil {
@@ -355,47 +816,158 @@ func (p *Plugin) sendInternal(req Request) (*Response, error) {
 
 		// Handle asynchronous "log" method from plugin
 		if resp.Method == "log" {
-			if p.logger != nil {
-				var logData struct {
-					Level   string `json:"level"`
-					Message string `json:"message"`
-				}
-				json.Unmarshal([]byte(respLine), &logData)
-
-				switch strings.ToLower(logData.Level) {
-				case "error":
-					p.logger.Error(logData.Message, "component", p.name)
-				case "warn":
-					p.logger.Warn(logData.Message, "component", p.name)
-				case "debug":
-					p.logger.Debug(logData.Message, "component", p.name)
-				default:
-					p.logger.Info(logData.Message, "component", p.name)
-				}
-			}
+			p.logMessage(respLine)
 			continue // Keep waiting for the actual response
 		}
 
+		if resp.Method == "event" {
+			p.emitEvent(resp)
+			continue
+		}
+
+		// Progress of a long request, such as initialize loading a large file
+		if resp.Method == "progress" {
+			var progress struct {
+				Pct     float64 `json:"pct"`
+				Message string  `json:"message"`
+			}
+			json.Unmarshal([]byte(respLine), &progress)
+			emitProgress(p.app, p.name, progress.Pct, progress.Message)
+			// The plugin is making headway, so the time starts again
+			release()
+			limited, release = p.limitRequest(ctx, req.Method)
+			continue
+		}
+
 		// Handle "show_form" request from plugin
 		if resp.Method == "show_form" {
-			// We MUST release commsMu while waiting for the form to allow form_change events
+			// We MUST release commsMu while waiting for the form to allow form_change events.
+			// The user's time doesn't count towards the CPU time limit.
+			release()
+			if req.Method == "initialize" {
+				p.interactive = true
+			}
 			p.commsMu.Unlock()
 			err := p.handleShowForm(resp)
 			p.commsMu.Lock()
+			limited, release = p.limitRequest(ctx, req.Method)
 			if err != nil {
 				return nil, err
 			}
 			continue // After handling the form, wait for plugin's final response
 		}
 
+		// Handle "show_file_dialog" request from plugin, released like show_form
+		if resp.Method == "show_file_dialog" {
+			release()
+			if req.Method == "initialize" {
+				p.interactive = true
+			}
+			p.commsMu.Unlock()
+			err := p.handleShowFileDialog(resp)
+			p.commsMu.Lock()
+			limited, release = p.limitRequest(ctx, req.Method)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// Plugins that predate request IDs don't echo them
+		if resp.RequestID != "" && resp.RequestID != req.RequestID {
+			if p.logger != nil {
+				p.logger.Warn("Ignoring reply to another request", "component", p.name, "method", req.Method, "request_id", resp.RequestID)
+			}
+			continue
+		}
+
 		if resp.Error != "" {
-			return nil, fmt.Errorf("plugin error: %s", resp.Error)
+			return nil, fmt.Errorf("%w: %s", errPluginReply, resp.Error)
 		}
 
 		return &resp, nil
 	}
 }
 
+// logMessage logs a "log" message from the plugin with its attributes.
+func (p *Plugin) logMessage(line string) {
+	if p.logger == nil {
+		return
+	}
+	var logData struct {
+		Level   string                 `json:"level"`
+		Message string                 `json:"message"`
+		Attrs   map[string]interface{} `json:"attrs"`
+	}
+	json.Unmarshal([]byte(line), &logData)
+
+	args := []any{"component", p.name}
+	keys := make([]string, 0, len(logData.Attrs))
+	for k := range logData.Attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		// The logger already carries our own trace ID
+		if k == "trace_id" && logData.Attrs[k] == p.traceID {
+			continue
+		}
+		args = append(args, k, logData.Attrs[k])
+	}
+
+	switch strings.ToLower(logData.Level) {
+	case "error":
+		p.logger.Error(logData.Message, args...)
+	case "warn":
+		p.logger.Warn(logData.Message, args...)
+	case "debug":
+		p.logger.Debug(logData.Message, args...)
+	default:
+		p.logger.Info(logData.Message, args...)
+	}
+}
+
+// SetEventBus sets the bus on which the plugin's "event" messages are
+// emitted. Without one they are dropped.
+func (p *Plugin) SetEventBus(bus *plugins.PluginEventBus) {
+	p.events.Store(bus)
+}
+
+// emitEvent forwards an "event" message from the plugin to the event bus.
+// Plugins can send them at any time, but they are only read while a request
+// is in flight, and subscribers must not make requests to this plugin.
+func (p *Plugin) emitEvent(msg Response) {
+	var data interface{}
+	if len(msg.Data) > 0 {
+		if err := json.Unmarshal(msg.Data, &data); err != nil {
+			if p.logger != nil {
+				p.logger.Warn("Ignoring plugin event with invalid data", "component", p.name, "event", msg.Event, "error", err)
+			}
+			return
+		}
+	}
+	if bus := p.events.Load(); bus != nil {
+		bus.Emit(p.name, msg.Event, data)
+	}
+}
+
+// currentLocale returns the locale to send with a request, "" if unknown.
+func (p *Plugin) currentLocale() string {
+	if p.locale == nil {
+		return ""
+	}
+	return p.locale()
+}
+
+// dialogSize converts a dialog dimension reported by the form to the
+// window size for SetSize, which uses logical pixels on some platforms.
+func (p *Plugin) dialogSize(v float64) int {
+	if p.dpiScale > 0 {
+		v /= p.dpiScale
+	}
+	return int(math.Round(v))
+}
+
 // handleShowForm processes a request from the plugin to show a configuration form.
 func (p *Plugin) handleShowForm(formMsg Response) error {
 	if p.app == nil {
@@ -477,6 +1049,27 @@ func (p *Plugin) handleShowForm(formMsg Response) error {
 		}()
 	}
 
+	// Answer autocomplete fields with the plugin's candidates for what the
+	// user has typed. The dialog debounces typing, so requests are few.
+	unsubAutocomplete := p.app.Event.On(`ipc-form-autocomplete-`+requestID, func(e *application.CustomEvent) {
+		data, ok := e.Data.(map[string]interface{})
+		if !ok {
+			return
+		}
+		field, _ := data["field"].(string)
+		query, _ := data["query"].(string)
+		candidates, err := p.formAutocomplete(field, query)
+		if err != nil {
+			p.logger.Warn("Form autocomplete failed", "field", field, "error", err)
+		}
+		p.app.Event.Emit(`ipc-form-autocomplete-result-`+requestID, map[string]interface{}{
+			"field":   field,
+			"query":   query,
+			"results": candidates,
+		})
+	})
+	defer unsubAutocomplete()
+
 	// Unmarshal schema, uiSchema and initial data so they are sent as objects, not raw bytes
 	var schemaObj, uiSchemaObj, dataObj interface{}
 	if len(formMsg.Schema) > 0 {
@@ -489,9 +1082,20 @@ func (p *Plugin) handleShowForm(formMsg Response) error {
 		json.Unmarshal(formMsg.Data, &dataObj)
 	}
 
+	// Prefer the icon sent with the form, falling back to the --icon flag output
+	icon := p.icon
+	if formMsg.Icon != "" {
+		if formIcon, err := decodeIcon(formMsg.Icon); err == nil {
+			icon = formIcon
+		} else {
+			p.logger.Warn("Ignoring invalid show_form icon", "error", err)
+		}
+	}
+
 	// Create a new window for the dialog
 	dialogWindow := p.app.Window.NewWithOptions(application.WebviewWindowOptions{
 		Title:       formMsg.Title,
+		Icon:        icon,
 		Width:       500,
 		Height:      500,
 		AlwaysOnTop: true,
@@ -511,7 +1115,7 @@ func (p *Plugin) handleShowForm(formMsg Response) error {
 		height, _ := data["height"].(float64)
 		if width > 0 && height > 0 {
 			// Add buffer for OS title bar (typically 30-48px)
-			dialogWindow.SetSize(int(width), int(height)+48)
+			dialogWindow.SetSize(p.dialogSize(width), p.dialogSize(height+48))
 		}
 	})
 	defer unsubResize()
@@ -529,17 +1133,7 @@ func (p *Plugin) handleShowForm(formMsg Response) error {
 	dialogWindow.Center()
 
 	// Wait for result or error
-	var finalResult interface{}
-	var finalError string
-
-	select {
-	case res := <-resultChan:
-		finalResult = res
-	case err := <-errChan:
-		finalError = err
-	case <-time.After(5 * time.Minute):
-		finalError = "timeout"
-	}
+	finalResult, finalError := p.awaitFormResult(resultChan, errChan)
 
 	// SIGNAL GOROUTINE TO STOP BEFORE SENDING RESULT
 	// This prevents a late form_change from being sent while the plugin is processing the result.
@@ -556,14 +1150,121 @@ func (p *Plugin) handleShowForm(formMsg Response) error {
 	p.commsMu.Lock()
 	defer p.commsMu.Unlock()
 
+	return p.writeFormResponse(finalResult, finalError)
+}
+
+// emitProgress tells the frontend how far the plugin has got with a long
+// request, pct being from 0 to 100. Tests replace it.
+var emitProgress = func(app *application.App, name string, pct float64, message string) {
+	if app == nil {
+		return
+	}
+	app.Event.Emit("ipc-plugin-init-progress", map[string]interface{}{
+		"plugin":  name,
+		"pct":     pct,
+		"message": message,
+	})
+}
+
+// openFileDialog asks the user to choose a file for show_file_dialog. accept
+// is a pattern such as "*.cal", or several separated by semicolons. Tests
+// replace it.
+var openFileDialog = func(app *application.App, title, accept string) (string, error) {
+	if app == nil {
+		return "", fmt.Errorf("no application context available")
+	}
+	dialog := app.Dialog.OpenFile().SetTitle(title)
+	if accept != "" {
+		dialog.AddFilter(accept, accept)
+	}
+	return dialog.AddFilter("All Files", "*.*").PromptForSingleSelection()
+}
+
+// handleShowFileDialog lets the user choose a file for the plugin, which is
+// sent the path as the result, or an error if the dialog was cancelled or
+// could not be shown.
+func (p *Plugin) handleShowFileDialog(msg Response) error {
+	if p.logger != nil {
+		p.logger.Info("Plugin requested file dialog", "title", msg.Title, "accept", msg.Accept)
+	}
+
+	var result interface{}
+	var errStr string
+	path, err := openFileDialog(p.app, msg.Title, msg.Accept)
+	switch {
+	case err != nil:
+		errStr = err.Error()
+	case path == "":
+		errStr = "cancelled"
+	default:
+		result = path
+	}
+
+	p.commsMu.Lock()
+	defer p.commsMu.Unlock()
+	return p.writeFormResponse(result, errStr)
+}
+
+// formAutocomplete asks the plugin, which is showing a form, for the
+// candidate values of field that match query. A plugin that doesn't reply
+// within autocompleteTimeout is killed, as its late reply would hold up the
+// form's later messages.
+func (p *Plugin) formAutocomplete(field, query string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), autocompleteTimeout)
+	defer cancel()
+	p.commsMu.Lock()
+	resp, err := p.sendInternal(ctx, Request{
+		Method: "form_autocomplete",
+		Field:  field,
+		Query:  query,
+	})
+	p.commsMu.Unlock()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("no reply to form_autocomplete within %v, killed the plugin", autocompleteTimeout)
+		}
+		return nil, err
+	}
+
+	var candidates []string
+	if len(resp.Result) > 0 {
+		if err := json.Unmarshal(resp.Result, &candidates); err != nil {
+			return nil, fmt.Errorf("invalid autocomplete result: %w", err)
+		}
+	}
+	return candidates, nil
+}
+
+// awaitFormResult waits for the dialog to submit or cancel, or for the
+// dialog timeout to expire.
+func (p *Plugin) awaitFormResult(resultChan <-chan interface{}, errChan <-chan string) (interface{}, string) {
+	timeout := p.dialogTimeout
+	if timeout <= 0 {
+		timeout = defaultDialogTimeout
+	}
+
+	select {
+	case res := <-resultChan:
+		return res, ""
+	case err := <-errChan:
+		return nil, err
+	case <-time.After(timeout):
+		p.logger.Warn("Form timed out", "timeout", timeout)
+		return nil, "timeout"
+	}
+}
+
+// writeFormResponse sends the outcome of a show_form or show_file_dialog to
+// the plugin. The caller must hold commsMu.
+func (p *Plugin) writeFormResponse(result interface{}, errStr string) error {
 	var response map[string]interface{}
-	if finalError != "" {
+	if errStr != "" {
 		response = map[string]interface{}{
-			"error": finalError,
+			"error": errStr,
 		}
 	} else {
 		response = map[string]interface{}{
-			"result": finalResult,
+			"result": result,
 		}
 	}
 
@@ -599,6 +1300,30 @@ func (p *Plugin) Path() string {
 	return p.execPath
 }
 
+// GetInfo returns the build metadata the plugin reported in its metadata.
+func (p *Plugin) GetInfo() plugins.PluginInfo {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	info := p.info
+	info.APIVersion = p.version
+	return info
+}
+
+// Capabilities returns the protocol methods the plugin declared in its
+// metadata, or nil if it declared none.
+func (p *Plugin) Capabilities() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.capabilities)
+}
+
+// InitSchema returns the JSON Schema the plugin declared for its init args.
+func (p *Plugin) InitSchema() map[string]interface{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.initSchema
+}
+
 // GetFilePatterns returns the list of file patterns supported by the plugin.
 func (p *Plugin) GetFilePatterns() []plugins.FilePattern {
 	p.mu.Lock()
@@ -606,9 +1331,35 @@ func (p *Plugin) GetFilePatterns() []plugins.FilePattern {
 	return p.filePatterns
 }
 
-// Initialize executes plugin initialization.
+// SetLogger sets the logger for the plugin's messages until Initialize,
+// which replaces it with one carrying the trace ID.
+func (p *Plugin) SetLogger(logger logging.Logger) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.logger = logger
+}
+
+// Initialize executes plugin initialization. A nil logger keeps the one from
+// SetLogger, or discards the messages if there is none.
 func (p *Plugin) Initialize(ctx interface{}, initStr string, logger logging.Logger) (string, error) {
+	// The trace ID is created once and reused by every later request so that
+	// calls can be followed across plugin boundaries
+	p.mu.Lock()
+	if p.traceID == "" {
+		p.traceID = newTraceID()
+	}
+	traceID := p.traceID
+	if logger == nil {
+		logger = p.logger
+	}
+	if logger == nil {
+		logger = logging.NewNullLogger()
+	}
+	logger = logger.WithTraceID(traceID)
 	p.logger = logger
+	p.interactive, p.restartErr = false, nil
+	p.mu.Unlock()
+
 	if app, ok := ctx.(*application.App); ok {
 		p.app = app
 	}
@@ -628,6 +1379,11 @@ func (p *Plugin) Initialize(ctx interface{}, initStr string, logger logging.Logg
 		logger.Error("IPC plugin initialization failed", "error", err)
 		return "", err
 	}
+	p.mu.Lock()
+	p.initialized, p.initArgs, p.restarts = true, initStr, 0
+	// Extra processes are started again with the new args when next needed
+	p.closeWorkersLocked()
+	p.mu.Unlock()
 	logger.Info("IPC plugin initialized successfully")
 	return string(resp.Result), nil
 }
@@ -649,6 +1405,76 @@ func (p *Plugin) GetChartConfig(args string) (*plugins.ChartConfig, error) {
 	return &config, nil
 }
 
+// GetTimeRange returns the X extent of the plugin's data. Plugins that don't
+// implement get_time_range return an error.
+func (p *Plugin) GetTimeRange() (float64, float64, error) {
+	resp, err := p.sendRequest(Request{
+		Method: "get_time_range",
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var tr plugins.TimeRange
+	if err := json.Unmarshal(resp.Result, &tr); err != nil {
+		return 0, 0, fmt.Errorf("failed to parse time range: %w", err)
+	}
+	return tr.XMin, tr.XMax, nil
+}
+
+// GetFileInfo returns metadata about the file the plugin loaded. Plugins that
+// don't implement get_file_info return an error.
+func (p *Plugin) GetFileInfo() (*plugins.FileInfo, error) {
+	resp, err := p.sendRequest(Request{
+		Method: "get_file_info",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var info plugins.FileInfo
+	if err := json.Unmarshal(resp.Result, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse file info: %w", err)
+	}
+	return &info, nil
+}
+
+// Save sends series to the plugin to write out, e.g. to a file, with
+// plugin-specific args such as the output path.
+func (p *Plugin) Save(args string, series []plugins.SeriesPoints) error {
+	_, err := p.sendRequest(Request{
+		Method: "save",
+		Args:   args,
+		Series: series,
+	})
+	return err
+}
+
+// UpdateConfig sends new parameters to the running plugin.
+func (p *Plugin) UpdateConfig(data map[string]interface{}) error {
+	_, err := p.sendRequest(Request{
+		Method: "update_config",
+		Data:   data,
+	})
+	return err
+}
+
+// GetConfig returns the plugin's current parameters.
+func (p *Plugin) GetConfig() (map[string]interface{}, error) {
+	resp, err := p.sendRequest(Request{
+		Method: "get_config",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var config map[string]interface{}
+	if err := json.Unmarshal(resp.Result, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	return config, nil
+}
+
 // GetChartConfig returns chart configuration. (Note: duplicate comment in previous file, fixed below)
 // GetSeriesConfig returns series configuration.
 func (p *Plugin) GetSeriesConfig() ([]plugins.SeriesConfig, error) {
@@ -685,11 +1511,27 @@ func (p *Plugin) GetSeriesData(seriesID string, preferredStorage string) ([]floa
 		Method:           "get_series_data",
 		SeriesID:         seriesID,
 		PreferredStorage: preferredStorage,
+		Locale:           p.currentLocale(),
+		RequestID:        newTraceID(),
+	}
+	if err := p.writeRequest(req); err != nil {
+		return nil, "", err
 	}
+	ctx, release := p.limitRequest(context.Background(), req.Method)
+	defer release()
+	data, storage, _, err := p.readSeriesData(seriesID, func(id string) bool { return id == req.RequestID })
+	return data, storage, p.cpuTimeError(ctx, req.Method, err)
+}
 
+// writeRequest writes req to the plugin's stdin, with the trace ID. The
+// caller must hold commsMu.
+func (p *Plugin) writeRequest(req Request) error {
+	if req.TraceID == "" {
+		req.TraceID = p.traceID
+	}
 	reqBytes, err := json.Marshal(req)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to marshal request: %w", err)
+		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 	reqBytes = append(reqBytes, '\n')
 
@@ -698,15 +1540,23 @@ func (p *Plugin) GetSeriesData(seriesID string, preferredStorage string) ([]floa
 	}
 
 	if _, err := p.stdin.Write(reqBytes); err != nil {
-		return nil, "", fmt.Errorf("failed to write request: %w", err)
+		return fmt.Errorf("failed to write request: %w", err)
 	}
+	return nil
+}
 
+// readSeriesData reads the response to a get_series_data request for
+// seriesID, handling interleaved "log" and "event" messages. Replies with a
+// request ID that accept rejects are skipped, binary data and all, and the
+// request ID of the reply read is returned, "" if the plugin didn't echo
+// one. The caller must hold p.mu.
+func (p *Plugin) readSeriesData(seriesID string, accept func(requestID string) bool) ([]float64, string, string, error) {
 	for {
 		// Read header line
 		respLine, err := p.stdout.ReadString('\n')
 		if err != nil {
 			p.running = false
-			return nil, "", fmt.Errorf("failed to read response header: %w", err)
+			return nil, "", "", fmt.Errorf("failed to read response header: %w", err)
 		}
 
 		if p.logger != nil {
@@ -715,48 +1565,82 @@ func (p *Plugin) GetSeriesData(seriesID string, preferredStorage string) ([]floa
 
 		var resp Response
 		if err := json.Unmarshal([]byte(strings.TrimSpace(respLine)), &resp); err != nil {
-			return nil, "", fmt.Errorf("failed to parse response header: %w", err)
+			return nil, "", "", fmt.Errorf("failed to parse response header: %w", err)
 		}
 
 		// Handle intermediate "log" messages
 		if resp.Method == "log" {
+			p.logMessage(respLine)
+			continue
+		}
+
+		if resp.Method == "event" {
+			p.emitEvent(resp)
+			continue
+		}
+
+		// Plugins that predate request IDs don't echo them
+		if resp.RequestID != "" && !accept(resp.RequestID) {
 			if p.logger != nil {
-				var logData struct {
-					Level   string `json:"level"`
-					Message string `json:"message"`
+				p.logger.Warn("Ignoring reply to another request", "component", p.name, "method", "get_series_data", "request_id", resp.RequestID)
+			}
+			if resp.Type == "binary" {
+				if resp.Length < 0 {
+					p.killLocked() // The data that follows can't be skipped reliably
+					return nil, "", "", fmt.Errorf("invalid binary data length %d in reply to another request", resp.Length)
 				}
-				json.Unmarshal([]byte(respLine), &logData)
-				switch strings.ToLower(logData.Level) {
-				case "error":
-					p.logger.Error(logData.Message, "component", p.name)
-				case "warn":
-					p.logger.Warn(logData.Message, "component", p.name)
-				case "debug":
-					p.logger.Debug(logData.Message, "component", p.name)
-				default:
-					p.logger.Info(logData.Message, "component", p.name)
+				if _, err := io.CopyN(io.Discard, p.stdout, int64(resp.Length)); err != nil {
+					p.running = false
+					return nil, "", "", fmt.Errorf("failed to skip binary data: %w", err)
 				}
 			}
 			continue
 		}
 
 		if resp.Error != "" {
-			return nil, "", fmt.Errorf("plugin error: %s", resp.Error)
+			return nil, "", resp.RequestID, fmt.Errorf("%w: %s", errPluginReply, resp.Error)
 		}
 
 		if resp.Type != "binary" {
-			return nil, "", fmt.Errorf("expected binary response, got: %s", resp.Type)
+			return nil, "", resp.RequestID, fmt.Errorf("expected binary response, got: %s", resp.Type)
 		}
 
 		// Read binary data (resp.Length bytes)
+		if resp.Length < 0 || resp.Length%8 != 0 {
+			p.killLocked() // The data that follows can't be skipped reliably
+			return nil, "", resp.RequestID, fmt.Errorf("invalid binary data length %d for %s: not a whole number of float64 values", resp.Length, seriesID)
+		}
 		binaryData := make([]byte, resp.Length)
-		if _, err := io.ReadFull(p.stdout, binaryData); err != nil {
-			return nil, "", fmt.Errorf("failed to read binary data: %w", err)
+		if n, err := io.ReadFull(p.stdout, binaryData); err != nil {
+			if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+				p.running = false
+				return nil, "", resp.RequestID, fmt.Errorf("%w for %s: received %d of %d bytes", ErrTruncatedData, seriesID, n, resp.Length)
+			}
+			return nil, "", resp.RequestID, fmt.Errorf("failed to read binary data: %w", err)
+		}
+		if resp.Checksum != nil {
+			if sum := crc32.ChecksumIEEE(binaryData); sum != *resp.Checksum {
+				return nil, "", resp.RequestID, fmt.Errorf("%w for %s: got %08x, want %08x", ErrChecksumMismatch, seriesID, sum, *resp.Checksum)
+			}
+		}
+
+		// Both layouts hold as many y values as x values
+		if (resp.Storage == "interleaved" || resp.Storage == "arrays") && (len(binaryData)/8)%2 != 0 {
+			return nil, "", resp.RequestID, fmt.Errorf("%w for %s: received %d bytes, an odd number of %s values", ErrTruncatedData, seriesID, len(binaryData), resp.Storage)
 		}
 
 		// Convert bytes to float64 slice
-		return bytesToFloats(binaryData), resp.Storage, nil
+		return bytesToFloats(binaryData), resp.Storage, resp.RequestID, nil
+	}
+}
+
+// newStdoutReader buffers a plugin's stdout with size bytes, or
+// defaultBufferSize if size is not positive.
+func newStdoutReader(stdout io.Reader, size int) *bufio.Reader {
+	if size <= 0 {
+		size = defaultBufferSize
 	}
+	return bufio.NewReaderSize(stdout, size)
 }
 
 // bytesToFloats converts little-endian bytes to float64 slice without copying.
@@ -788,29 +1672,93 @@ func (p *Plugin) Close() error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	p.stopWatchdogLocked()
+	p.closeWorkersLocked()
 	if !p.running {
 		return nil
 	}
 
+	// Let the plugin flush its state before stdin closes
+	if err := p.sendQuitLocked(); err != nil && p.logger != nil {
+		p.logger.Debug("Plugin did not acknowledge quit", "name", p.name, "error", err)
+	}
+	p.waitExitLocked()
+	p.running = false
+	return nil
+}
+
+// waitExitLocked closes the plugin's stdin and gives it
+// GracefulShutdownTimeout to exit, then interrupts it, and kills it only if
+// that fails too. It returns once the process has exited. Callers must hold
+// p.mu.
+func (p *Plugin) waitExitLocked() {
 	if p.stdin != nil {
 		p.stdin.Close()
 	}
+	if p.cmd == nil || p.cmd.Process == nil || p.cmd.ProcessState != nil {
+		// Not started, or already waited for
+		return
+	}
 
-	if p.cmd != nil && p.cmd.Process != nil {
-		// Give it a moment to exit normally on stdin close before killing
-		done := make(chan error, 1)
-		go func() {
-			done <- p.cmd.Wait()
-		}()
+	// Give it a moment to exit normally on stdin close before killing
+	done := make(chan error, 1)
+	go func() {
+		done <- p.cmd.Wait()
+	}()
 
+	select {
+	case <-done:
+		// exited cleanly
+		return
+	case <-time.After(GracefulShutdownTimeout):
+	}
+	// Where possible, let the plugin handle Ctrl+Break before the kill
+	if err := interruptProcess(p.cmd); err == nil {
 		select {
 		case <-done:
-			// exited cleanly
-		case <-time.After(500 * time.Millisecond):
-			p.cmd.Process.Kill()
+			return
+		case <-time.After(GracefulShutdownTimeout):
 		}
 	}
+	p.cmd.Process.Kill()
+	<-done
+}
 
-	p.running = false
-	return nil
+// SendGracefulShutdown sends quit to a running plugin, which runs its
+// cleanup handlers, and waits up to GracefulShutdownTimeout for the
+// acknowledgement. A plugin that does not answer in time is stopped as by
+// Close, without waiting for quit again.
+func (p *Plugin) SendGracefulShutdown() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.running {
+		return fmt.Errorf("plugin not running")
+	}
+	return p.sendQuitLocked()
+}
+
+// sendQuitLocked is SendGracefulShutdown for callers holding p.mu. Plugins
+// built before quit existed answer with an error.
+func (p *Plugin) sendQuitLocked() error {
+	if p.stdin == nil {
+		return fmt.Errorf("plugin not running")
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.sendLockedRequest(Request{Method: "quit"})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(GracefulShutdownTimeout):
+		// Stop it as Close does; its exit unblocks the pending read, which
+		// marks it as not running
+		p.waitExitLocked()
+		<-done
+		return fmt.Errorf("no reply to quit within %v", GracefulShutdownTimeout)
+	}
 }
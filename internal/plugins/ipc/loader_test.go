@@ -0,0 +1,1537 @@
+package ipc
+
+import (
+	"bufio"
+	"bytes"
+	"debug/elf"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"olicanaplot/internal/logging"
+	"olicanaplot/internal/plugins"
+	sdk "olicanaplot/sdk/go"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// TestHelperProcess is not a real test. The tests in this package re-execute
+// the test binary with OLICANA_IPC_HELPER=1 so that it acts as a mock IPC
+// plugin speaking the protocol over stdin/stdout.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("OLICANA_IPC_HELPER") != "1" {
+		return
+	}
+	for _, arg := range os.Args {
+		if arg == "--icon" {
+			if os.Getenv("OLICANA_IPC_MODE") != "legacy" {
+				fmt.Println(base64.StdEncoding.EncodeToString(mockIcon))
+			}
+			os.Exit(0)
+		}
+		if arg == "--metadata" {
+			writeMockMetadata(os.Getenv("OLICANA_IPC_MODE"))
+			os.Exit(0)
+		}
+	}
+	if started := os.Getenv("OLICANA_IPC_STARTED"); started != "" {
+		os.WriteFile(started, nil, 0644)
+	}
+	runMockPlugin(os.Getenv("OLICANA_IPC_MODE"))
+	os.Exit(0)
+}
+
+// newMockPlugin returns a Plugin that launches the test binary as a mock
+// plugin running in the given mode.
+func newMockPlugin(t testing.TB, mode string) *Plugin {
+	t.Helper()
+	t.Setenv("OLICANA_IPC_HELPER", "1")
+	t.Setenv("OLICANA_IPC_MODE", mode)
+	p := &Plugin{
+		execPath: os.Args[0],
+		execArgs: []string{"-test.run=^TestHelperProcess$", "--"},
+		name:     "Mock Plugin",
+		version:  1,
+		logger:   logging.NewLogger("MockPlugin"),
+	}
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+// writeMockMetadata prints the mock plugin's --metadata output.
+func writeMockMetadata(mode string) {
+	switch mode {
+	case "legacy":
+		writeMock(map[string]interface{}{"name": "Mock Plugin", "patterns": []interface{}{}})
+	case "future":
+		writeMock(map[string]interface{}{"name": "Mock Plugin", "apiVersion": 2})
+	default:
+		writeMock(map[string]interface{}{
+			"name":          "Mock Plugin",
+			"patterns":      []interface{}{},
+			"apiVersion":    1,
+			"pluginVersion": "1.4.2",
+			"buildDate":     "2026-01-31",
+			"commitHash":    "abc1234",
+			"capabilities":  []string{"get_series_data", "stream_series_data", "event", "ping"},
+			"initSchema": map[string]interface{}{
+				"type":     "object",
+				"required": []string{"path"},
+				"properties": map[string]interface{}{
+					"path": map[string]interface{}{"type": "string"},
+				},
+			},
+		})
+	}
+}
+
+// mockIcon is a PNG signature followed by filler, enough for decodeIcon.
+var mockIcon = append(append([]byte{}, pngSignature...), "mock-icon"...)
+
+func writeMock(v interface{}) {
+	b, _ := json.Marshal(v)
+	os.Stdout.Write(append(b, '\n'))
+}
+
+// mockConfig is the mock plugin's state for update_config and get_config.
+var mockConfig = map[string]interface{}{"noise": 1.0}
+
+// mockEvents records the events received by the mock plugin.
+var mockEvents []eventMessage
+
+// writeMockSeries answers a get_series_data request for a numeric series ID
+// n with the points (0, n) and (2, n), echoing its request ID.
+func writeMockSeries(req Request) {
+	n, _ := strconv.Atoi(req.SeriesID)
+	var raw []byte
+	for _, v := range []float64{0, float64(n), 2, float64(n)} {
+		raw = binary.LittleEndian.AppendUint64(raw, math.Float64bits(v))
+	}
+	writeMock(map[string]interface{}{"type": "binary", "length": len(raw), "storage": "interleaved", "request_id": req.RequestID})
+	os.Stdout.Write(raw)
+}
+
+// runMockPlugin serves requests until stdin is closed.
+func runMockPlugin(mode string) {
+	reader := bufio.NewReader(os.Stdin)
+	var held *Request // A get_series_data request yet to be answered
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		var req Request
+		if err := json.Unmarshal([]byte(strings.TrimSpace(line)), &req); err != nil {
+			writeMock(map[string]string{"error": "bad request"})
+			continue
+		}
+
+		// A line without a method is the host's answer to a show_form; echo
+		// its error back so tests can check what the plugin received
+		if req.Method == "" {
+			var form map[string]interface{}
+			json.Unmarshal([]byte(line), &form)
+			writeMock(map[string]interface{}{"result": form["error"]})
+			continue
+		}
+
+		switch req.Method {
+		case "info":
+			if mode == "legacy" {
+				writeMock(map[string]interface{}{"name": "Mock Plugin", "version": 1})
+				continue
+			}
+			writeMock(map[string]interface{}{
+				"name":           "Mock Plugin",
+				"version":        1,
+				"plugin_version": "1.4.2",
+				"build_date":     "2026-01-31",
+				"commit_hash":    "abc1234",
+				"capabilities":   []string{"get_series_data", "stream_series_data", "event", "ping"},
+			})
+		case "initialize", "echo_trace":
+			if mode == "runaway" && req.Method == "initialize" {
+				// Longer than the CPU time limit of the tests, like a user
+				// filling in the plugin's own window
+				time.Sleep(400 * time.Millisecond)
+			}
+			if req.Method == "initialize" && req.Args == "pick" {
+				// Ask the user for the file to read, like a plugin's own setup
+				writeMock(map[string]string{"method": "show_file_dialog", "title": "Select Data", "accept": "*.csv"})
+				reader.ReadString('\n')
+			}
+			if mode == "progress" && req.Method == "initialize" {
+				for _, pct := range []float64{25, 50, 75} {
+					writeMock(map[string]interface{}{"method": "progress", "pct": pct, "message": fmt.Sprintf("Reading %g%%", pct)})
+				}
+			}
+			writeMock(map[string]interface{}{"result": req.TraceID})
+		case "get_time_range":
+			if mode == "legacy" {
+				writeMock(map[string]string{"error": "unknown method: get_time_range"})
+				continue
+			}
+			writeMock(map[string]interface{}{"result": map[string]float64{"x_min": -1.5, "x_max": 42}})
+		case "get_file_info":
+			if mode == "legacy" {
+				writeMock(map[string]string{"error": "unknown method: get_file_info"})
+				continue
+			}
+			writeMock(map[string]interface{}{"result": map[string]interface{}{
+				"path": "data.csv", "size_bytes": 12345, "row_count": 1000, "modified_unix": 1700000000,
+			}})
+		case "update_config":
+			if _, ok := req.Data["noise"].(float64); !ok {
+				writeMock(map[string]string{"error": "noise must be a number"})
+				continue
+			}
+			mockConfig["noise"] = req.Data["noise"]
+			writeMock(map[string]string{"result": "ok"})
+		case "get_config":
+			if mode == "runaway" {
+				time.Sleep(time.Minute)
+			}
+			writeMock(map[string]interface{}{"result": mockConfig})
+		case "event":
+			var ev eventMessage
+			json.Unmarshal([]byte(line), &ev)
+			mockEvents = append(mockEvents, ev)
+		case "echo_request_id":
+			// A late answer to an earlier request comes first
+			writeMock(map[string]string{"result": "stale", "request_id": "earlier"})
+			writeMock(map[string]string{"result": req.RequestID, "request_id": req.RequestID})
+		case "save":
+			// Keep the request so tests can check what the plugin received
+			os.WriteFile(req.Args, []byte(line), 0o644)
+			writeMock(map[string]string{"result": "ok"})
+		case "long_task":
+			// Reports progress more often than the CPU time limit, but takes
+			// longer overall
+			for pct := 20.0; pct <= 100; pct += 20 {
+				time.Sleep(100 * time.Millisecond)
+				writeMock(map[string]interface{}{"method": "progress", "pct": pct})
+			}
+			writeMock(map[string]string{"result": "done"})
+		case "emit_event":
+			writeMock(map[string]interface{}{"method": "event", "event": "dataUpdated", "data": map[string]int{"rows": 1200}})
+			writeMock(map[string]string{"result": "ok"})
+		case "get_series_data":
+			if mode == "async_series" {
+				// A late answer to an earlier request comes first
+				writeMockSeries(Request{SeriesID: "99", RequestID: "earlier"})
+				writeMockSeries(req)
+				continue
+			}
+			if mode == "reorder" {
+				// Each pair of requests is answered in reverse
+				if held == nil {
+					held = &req
+					continue
+				}
+				writeMockSeries(req)
+				writeMockSeries(*held)
+				held = nil
+				continue
+			}
+			if mode == "log_series" {
+				writeMock(map[string]interface{}{"method": "log", "level": "info", "message": "Sending series",
+					"attrs": map[string]string{"trace_id": req.TraceID, "series": req.SeriesID}})
+			}
+			if mode == "slow_series" {
+				time.Sleep(10 * time.Millisecond)
+			}
+			if mode == "runaway" {
+				time.Sleep(time.Minute)
+			}
+			if req.SeriesID == "missing" {
+				writeMock(map[string]string{"error": "series not found: missing"})
+				continue
+			}
+			data := []float64{0, 1, 2, 3}
+			// Numeric IDs are the Y values, so replies can be told apart
+			if n, err := strconv.Atoi(req.SeriesID); err == nil {
+				data[1], data[3] = float64(n), float64(n)
+			}
+			raw := make([]byte, 0, len(data)*8)
+			for _, v := range data {
+				raw = binary.LittleEndian.AppendUint64(raw, math.Float64bits(v))
+			}
+			checksum := crc32.ChecksumIEEE(raw)
+			if mode == "corrupt" {
+				raw[5] ^= 0xFF // After the checksum was computed
+			}
+			switch mode {
+			case "crash_mid_send":
+				// Announce all the data but exit part way through it
+				writeMock(map[string]interface{}{"type": "binary", "length": len(raw), "storage": "interleaved"})
+				os.Stdout.Write(raw[:12])
+				os.Exit(1)
+			case "odd_values":
+				writeMock(map[string]interface{}{"type": "binary", "length": len(raw) - 8, "storage": "interleaved"})
+				os.Stdout.Write(raw[:len(raw)-8])
+				continue
+			case "misaligned":
+				writeMock(map[string]interface{}{"type": "binary", "length": len(raw) - 3, "storage": "interleaved"})
+				os.Stdout.Write(raw[:len(raw)-3])
+				continue
+			}
+			writeMock(map[string]interface{}{"type": "binary", "length": len(raw), "storage": "interleaved", "checksum": checksum})
+			os.Stdout.Write(raw)
+		case "form_autocomplete":
+			if mode == "slow" {
+				time.Sleep(4 * autocompleteTimeout)
+			}
+			var matches []string
+			for _, c := range []string{"pressure", "temperature", "temp_ambient"} {
+				if strings.Contains(c, req.Query) {
+					matches = append(matches, c)
+				}
+			}
+			writeMock(map[string]interface{}{"result": matches})
+		case "pick_file":
+			// Ask for a file and reply with the host's answer
+			writeMock(map[string]string{"method": "show_file_dialog", "title": "Select Calibration", "accept": "*.cal"})
+			answer, _ := reader.ReadString('\n')
+			var reply map[string]interface{}
+			json.Unmarshal([]byte(answer), &reply)
+			writeMock(map[string]interface{}{"result": reply})
+		case "get_locale":
+			sdk.ParseRequest([]byte(line))
+			writeMock(map[string]interface{}{"result": sdk.CurrentLocale()})
+		case "get_events":
+			writeMock(map[string]interface{}{"result": mockEvents})
+		case "ping":
+			// The first process to be pinged hangs, its replacement answers
+			if mode == "hang_ping" {
+				if f, err := os.OpenFile(os.Getenv("OLICANA_IPC_HUNG_FILE"), os.O_CREATE|os.O_EXCL, 0o644); err == nil {
+					f.Close()
+					time.Sleep(time.Minute)
+				}
+			}
+			writeMock(map[string]string{"result": "pong"})
+		case "quit":
+			if mode == "hang" {
+				time.Sleep(time.Minute)
+			}
+			// Record the quit so tests can check it arrived before the exit
+			os.WriteFile(os.Getenv("OLICANA_IPC_QUIT_FILE"), []byte("quit"), 0o644)
+			writeMock(map[string]string{"result": "ok"})
+		default:
+			writeMock(map[string]string{"error": fmt.Sprintf("unknown method: %s", req.Method)})
+		}
+	}
+}
+
+func TestFetchMetadata(t *testing.T) {
+	p := newMockPlugin(t, "")
+	if err := p.fetchMetadata(); err != nil {
+		t.Fatalf("fetchMetadata failed: %v", err)
+	}
+	want := plugins.PluginInfo{APIVersion: 1, PluginVersion: "1.4.2", BuildDate: "2026-01-31", CommitHash: "abc1234"}
+	if got := p.GetInfo(); got != want {
+		t.Errorf("GetInfo() = %+v, want %+v", got, want)
+	}
+	if caps := p.Capabilities(); len(caps) != 4 || caps[1] != "stream_series_data" {
+		t.Errorf("Capabilities() = %v", caps)
+	}
+	if schema := p.InitSchema(); schema == nil || schema["type"] != "object" {
+		t.Errorf("InitSchema() = %v, want an object schema", schema)
+	}
+	// Discovery doesn't start the IPC session
+	if p.running {
+		t.Error("plugin process should not be started for its metadata")
+	}
+
+	// Plugins that only report name and patterns leave the rest unset
+	legacy := newMockPlugin(t, "legacy")
+	if err := legacy.fetchMetadata(); err != nil {
+		t.Fatalf("fetchMetadata failed: %v", err)
+	}
+	if got := legacy.GetInfo(); got != (plugins.PluginInfo{APIVersion: 1}) {
+		t.Errorf("GetInfo() = %+v, want only the API version", got)
+	}
+	if legacy.Capabilities() != nil || legacy.InitSchema() != nil {
+		t.Errorf("Capabilities() = %v, InitSchema() = %v, want nil", legacy.Capabilities(), legacy.InitSchema())
+	}
+}
+
+func TestNewPluginDoesNotStartSession(t *testing.T) {
+	started := filepath.Join(t.TempDir(), "started")
+	t.Setenv("OLICANA_IPC_STARTED", started)
+	mock := newMockPlugin(t, "")
+
+	p, err := newPlugin(mock.execPath, false, func(p *Plugin) { p.execArgs = mock.execArgs })
+	if err != nil {
+		t.Fatalf("newPlugin failed: %v", err)
+	}
+	if p.Name() != "Mock Plugin" || p.InitSchema() == nil || len(p.Capabilities()) != 4 {
+		t.Errorf("name %q, schema %v, capabilities %v, want them from --metadata", p.Name(), p.InitSchema(), p.Capabilities())
+	}
+	// Plugins such as the synthetic data generator open a window once running
+	if _, err := os.Stat(started); err == nil {
+		t.Error("discovery started the plugin's IPC session")
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	p := newMockPlugin(t, "future")
+	if err := p.fetchMetadata(); err != nil {
+		t.Fatalf("fetchMetadata failed: %v", err)
+	}
+	err := p.checkVersion()
+	var incompatible *ErrVersionIncompatible
+	if !errors.As(err, &incompatible) {
+		t.Fatalf("checkVersion() = %v, want ErrVersionIncompatible", err)
+	}
+	if *incompatible != (ErrVersionIncompatible{PluginVersion: 2, HostVersion: 1}) {
+		t.Errorf("error = %+v", *incompatible)
+	}
+	if !strings.Contains(err.Error(), "upgrade OlicanaPlot") {
+		t.Errorf("error %q doesn't suggest upgrading", err)
+	}
+
+	// A host that understands the newer protocol loads it
+	oldHost := host
+	t.Cleanup(func() { host = oldHost })
+	host = hostCapabilities{APIVersion: 2, MaxPluginVersion: 2}
+	if err := p.checkVersion(); err != nil {
+		t.Errorf("checkVersion() with a newer host = %v", err)
+	}
+
+	// A plugin whose metadata needs a newer host is refused from its manifest
+	manifest := filepath.Join(t.TempDir(), "olicana-plot-plugin.json")
+	os.WriteFile(manifest, []byte(`{"name": "Future", "command": "future", "minHostVersion": 2}`), 0644)
+	if _, err := (&Loader{}).NewPluginFromManifest(manifest); err != nil {
+		t.Errorf("NewPluginFromManifest() with a newer host = %v", err)
+	}
+	host = oldHost
+	if _, err := (&Loader{}).NewPluginFromManifest(manifest); !errors.As(err, &incompatible) {
+		t.Errorf("NewPluginFromManifest() = %v, want ErrVersionIncompatible", err)
+	}
+}
+
+// recordingLogger records the messages logged through it, and the args of
+// those at info level.
+type recordingLogger struct {
+	messages []string
+	infoArgs [][]any
+}
+
+func (l *recordingLogger) Debug(msg string, args ...any) { l.messages = append(l.messages, msg) }
+func (l *recordingLogger) Info(msg string, args ...any) {
+	l.messages = append(l.messages, msg)
+	l.infoArgs = append(l.infoArgs, args)
+}
+func (l *recordingLogger) Warn(msg string, args ...any)      { l.messages = append(l.messages, msg) }
+func (l *recordingLogger) Error(msg string, args ...any)     { l.messages = append(l.messages, msg) }
+func (l *recordingLogger) Fatal(msg string, args ...any)     { l.messages = append(l.messages, msg) }
+func (l *recordingLogger) WithTraceID(string) logging.Logger { return l }
+func (l *recordingLogger) With(...any) logging.Logger        { return l }
+
+func TestSetLoggerBeforeInitialize(t *testing.T) {
+	p := newMockPlugin(t, "")
+	logger := &recordingLogger{}
+	p.SetLogger(logger)
+	if err := p.start(); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	if _, err := p.sendRequest(Request{Method: "info"}); err != nil {
+		t.Fatalf("info failed: %v", err)
+	}
+	if len(logger.messages) == 0 || logger.messages[0] != "IPC -> PLUGIN" {
+		t.Errorf("logged %q, want the info request", logger.messages)
+	}
+}
+
+func TestInitializeWithoutLogger(t *testing.T) {
+	p := newMockPlugin(t, "")
+	p.logger = nil
+	if _, err := p.Initialize(nil, "args", nil); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	// The logger from SetLogger is kept
+	logger := &recordingLogger{}
+	p.SetLogger(logger)
+	if _, err := p.Initialize(nil, "args", nil); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if !slices.Contains(logger.messages, "IPC plugin initialized successfully") {
+		t.Errorf("logged %q, want the initialization", logger.messages)
+	}
+}
+
+func TestSetLoggerDuringRequests(t *testing.T) {
+	p := newMockPlugin(t, "")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			p.SetLogger(&recordingLogger{})
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		if _, _, err := p.GetSeriesData("s1", ""); err != nil {
+			t.Fatalf("GetSeriesData failed: %v", err)
+		}
+	}
+	<-done
+}
+
+func TestGetTimeRange(t *testing.T) {
+	p := newMockPlugin(t, "")
+	xMin, xMax, err := p.GetTimeRange()
+	if err != nil {
+		t.Fatalf("GetTimeRange failed: %v", err)
+	}
+	if xMin != -1.5 || xMax != 42 {
+		t.Errorf("unexpected range: [%v, %v]", xMin, xMax)
+	}
+}
+
+func TestGetTimeRangeLegacyPlugin(t *testing.T) {
+	p := newMockPlugin(t, "legacy")
+	if _, _, err := p.GetTimeRange(); err == nil {
+		t.Fatal("expected error from plugin without get_time_range")
+	}
+}
+
+func TestGetFileInfo(t *testing.T) {
+	p := newMockPlugin(t, "")
+	info, err := p.GetFileInfo()
+	if err != nil {
+		t.Fatalf("GetFileInfo failed: %v", err)
+	}
+	want := plugins.FileInfo{Path: "data.csv", SizeBytes: 12345, RowCount: 1000, ModifiedUnix: 1700000000}
+	if *info != want {
+		t.Errorf("GetFileInfo() = %+v, want %+v", *info, want)
+	}
+
+	if _, err := newMockPlugin(t, "legacy").GetFileInfo(); err == nil {
+		t.Fatal("expected error from plugin without get_file_info")
+	}
+}
+
+func TestUpdateConfig(t *testing.T) {
+	p := newMockPlugin(t, "")
+	if err := p.UpdateConfig(map[string]interface{}{"noise": 2.5}); err != nil {
+		t.Fatalf("UpdateConfig failed: %v", err)
+	}
+	config, err := p.GetConfig()
+	if err != nil {
+		t.Fatalf("GetConfig failed: %v", err)
+	}
+	if config["noise"] != 2.5 {
+		t.Errorf("noise = %v, want 2.5", config["noise"])
+	}
+
+	if err := p.UpdateConfig(map[string]interface{}{"noise": "loud"}); err == nil {
+		t.Error("expected error for invalid config")
+	}
+}
+
+func TestSendCustomEvent(t *testing.T) {
+	p := newMockPlugin(t, "")
+	if err := p.SendCustomEvent("themeChanged", nil); err == nil {
+		t.Error("expected error sending an event to a stopped plugin")
+	}
+	if err := p.fetchMetadata(); err != nil {
+		t.Fatalf("fetchMetadata failed: %v", err)
+	}
+	if err := p.start(); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+
+	if err := p.SendCustomEvent("themeChanged", map[string]string{"theme": "dark"}); err != nil {
+		t.Fatalf("SendCustomEvent failed: %v", err)
+	}
+	if err := p.SendCustomEvent("windowResized", nil); err != nil {
+		t.Fatalf("SendCustomEvent failed: %v", err)
+	}
+	if err := p.SendCustomEvent("bad", "not an object"); err == nil {
+		t.Error("expected error for non-object event data")
+	}
+
+	// The plugin sends nothing back for events, so the next response read
+	// belongs to the next request
+	resp, err := p.sendRequest(Request{Method: "get_events"})
+	if err != nil {
+		t.Fatalf("get_events failed: %v", err)
+	}
+	var events []eventMessage
+	if err := json.Unmarshal(resp.Result, &events); err != nil {
+		t.Fatalf("failed to decode events: %v", err)
+	}
+	if len(events) != 2 || events[0].Event != "themeChanged" || string(events[0].Data) != `{"theme":"dark"}` || events[1].Event != "windowResized" {
+		t.Errorf("plugin received %+v", events)
+	}
+
+	// Plugins that don't declare the capability might answer the event with
+	// an error, so they are not sent any
+	legacy := newMockPlugin(t, "legacy")
+	if err := legacy.fetchMetadata(); err != nil {
+		t.Fatalf("fetchMetadata failed: %v", err)
+	}
+	if err := legacy.start(); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	if err := legacy.SendCustomEvent("themeChanged", nil); err == nil {
+		t.Error("expected error sending an event to a plugin without the capability")
+	}
+	resp, err = legacy.sendRequest(Request{Method: "get_events"})
+	if err != nil {
+		t.Fatalf("get_events failed: %v", err)
+	}
+	if string(resp.Result) != "null" && string(resp.Result) != "[]" {
+		t.Errorf("legacy plugin received %s", resp.Result)
+	}
+}
+
+func TestDialogTimeoutForwardedToPlugin(t *testing.T) {
+	p := newMockPlugin(t, "")
+	WithDialogTimeout(100 * time.Millisecond)(p)
+	if err := p.start(); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+
+	start := time.Now()
+	result, errStr := p.awaitFormResult(make(chan interface{}), make(chan string))
+	elapsed := time.Since(start)
+	if errStr != "timeout" {
+		t.Fatalf("awaitFormResult error = %q, want timeout", errStr)
+	}
+	if elapsed < 100*time.Millisecond || elapsed > 5*time.Second {
+		t.Errorf("form timed out after %v, want ~100ms", elapsed)
+	}
+
+	p.commsMu.Lock()
+	defer p.commsMu.Unlock()
+	if err := p.writeFormResponse(result, errStr); err != nil {
+		t.Fatalf("writeFormResponse failed: %v", err)
+	}
+	line, err := p.stdout.ReadString('\n')
+	if err != nil {
+		t.Fatalf("failed to read plugin reply: %v", err)
+	}
+	var reply map[string]interface{}
+	json.Unmarshal([]byte(line), &reply)
+	if reply["result"] != "timeout" {
+		t.Errorf("plugin received error %v, want timeout", reply["result"])
+	}
+}
+
+func TestFetchIcon(t *testing.T) {
+	p := newMockPlugin(t, "")
+	if err := p.fetchIcon(); err != nil {
+		t.Fatalf("fetchIcon failed: %v", err)
+	}
+	if !bytes.Equal(p.icon, mockIcon) {
+		t.Errorf("unexpected icon: %q", p.icon)
+	}
+}
+
+func TestFetchIconLegacyPlugin(t *testing.T) {
+	p := newMockPlugin(t, "legacy")
+	if err := p.fetchIcon(); err == nil {
+		t.Fatal("expected error from plugin without --icon")
+	}
+	if p.icon != nil {
+		t.Error("icon should remain unset for legacy plugins")
+	}
+}
+
+func TestDecodeIcon(t *testing.T) {
+	if _, err := decodeIcon("not base64!"); err == nil {
+		t.Error("expected error for invalid base64")
+	}
+	if _, err := decodeIcon(base64.StdEncoding.EncodeToString([]byte("GIF89a"))); err == nil {
+		t.Error("expected error for non-PNG data")
+	}
+	icon, err := decodeIcon(base64.StdEncoding.EncodeToString(mockIcon))
+	if err != nil {
+		t.Fatalf("decodeIcon failed: %v", err)
+	}
+	if !bytes.Equal(icon, mockIcon) {
+		t.Errorf("unexpected icon: %q", icon)
+	}
+}
+
+func TestTraceIDReusedAcrossRequests(t *testing.T) {
+	p := newMockPlugin(t, "")
+	result, err := p.Initialize(nil, "", logging.NewLogger("Test"))
+	if err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	var initTrace string
+	json.Unmarshal([]byte(result), &initTrace)
+	if len(initTrace) != 36 {
+		t.Fatalf("expected a UUID trace ID, got %q", initTrace)
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, err := p.sendRequest(Request{Method: "echo_trace"})
+		if err != nil {
+			t.Fatalf("echo_trace failed: %v", err)
+		}
+		var got string
+		json.Unmarshal(resp.Result, &got)
+		if got != initTrace {
+			t.Errorf("request %d trace ID = %q, want %q", i, got, initTrace)
+		}
+	}
+
+	// Re-initializing keeps the same trace
+	result, _ = p.Initialize(nil, "", logging.NewLogger("Test"))
+	var again string
+	json.Unmarshal([]byte(result), &again)
+	if again != initTrace {
+		t.Errorf("trace ID changed on re-initialize: %q != %q", again, initTrace)
+	}
+}
+
+func TestPing(t *testing.T) {
+	p := newMockPlugin(t, "")
+	if err := p.Ping(); err == nil {
+		t.Error("expected an error for a plugin that is not running")
+	}
+	if err := p.start(); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	if err := p.Ping(); err != nil {
+		t.Errorf("Ping failed: %v", err)
+	}
+
+	// Plugins without ping answer with an error, which still shows they are alive
+	legacy := newMockPlugin(t, "legacy")
+	legacy.start()
+	if _, err := legacy.sendRequest(Request{Method: "no_such_method"}); !errors.Is(err, errPluginReply) {
+		t.Fatalf("error = %v, want errPluginReply", err)
+	}
+}
+
+func TestWatchdogRestartsUnresponsivePlugin(t *testing.T) {
+	interval, timeout := PingInterval, PingTimeout
+	PingInterval, PingTimeout = 20*time.Millisecond, 100*time.Millisecond
+	t.Cleanup(func() { PingInterval, PingTimeout = interval, timeout })
+	t.Setenv("OLICANA_IPC_HUNG_FILE", filepath.Join(t.TempDir(), "hung"))
+
+	p := newMockPlugin(t, "hang_ping")
+	if err := p.fetchMetadata(); err != nil {
+		t.Fatalf("fetchMetadata failed: %v", err)
+	}
+	if _, err := p.Initialize(nil, "args", logging.NewLogger("Test")); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	p.mu.Lock()
+	first := p.cmd.Process.Pid
+	p.mu.Unlock()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		p.mu.Lock()
+		restarted := p.restarts == 1 && p.running && p.cmd.Process.Pid != first
+		p.mu.Unlock()
+		if restarted {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("plugin was not restarted after a ping timed out")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The new process answers requests
+	if err := p.Ping(); err != nil {
+		t.Errorf("Ping after restart failed: %v", err)
+	}
+}
+
+func TestWatchdogOnlyWatchesPluginsThatPing(t *testing.T) {
+	legacy := newMockPlugin(t, "legacy")
+	if err := legacy.fetchMetadata(); err != nil {
+		t.Fatalf("fetchMetadata failed: %v", err)
+	}
+	if err := legacy.start(); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	legacy.mu.Lock()
+	defer legacy.mu.Unlock()
+	if legacy.stopWatchdog != nil {
+		t.Error("watchdog started for a plugin without the ping capability")
+	}
+}
+
+func TestWatchdogLeavesInteractivePluginStopped(t *testing.T) {
+	interval, timeout := PingInterval, PingTimeout
+	PingInterval, PingTimeout = 20*time.Millisecond, 100*time.Millisecond
+	t.Cleanup(func() { PingInterval, PingTimeout = interval, timeout })
+	t.Setenv("OLICANA_IPC_HUNG_FILE", filepath.Join(t.TempDir(), "hung"))
+
+	var dialogs atomic.Int32
+	defer func(f func(*application.App, string, string) (string, error)) { openFileDialog = f }(openFileDialog)
+	openFileDialog = func(app *application.App, title, accept string) (string, error) {
+		dialogs.Add(1)
+		return "/data/run1.csv", nil
+	}
+
+	p := newMockPlugin(t, "hang_ping")
+	if err := p.fetchMetadata(); err != nil {
+		t.Fatalf("fetchMetadata failed: %v", err)
+	}
+	if _, err := p.Initialize(nil, "pick", logging.NewNullLogger()); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		p.mu.Lock()
+		stopped := !p.running
+		p.mu.Unlock()
+		if stopped {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("plugin was not stopped after a ping timed out")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The dialog isn't shown again, and requests fail until Initialize
+	if _, err := p.GetConfig(); err == nil {
+		t.Error("expected an error for a plugin left stopped")
+	}
+	if n := dialogs.Load(); n != 1 {
+		t.Errorf("file dialog shown %d times, want 1", n)
+	}
+	if _, err := p.Initialize(nil, "args", logging.NewNullLogger()); err != nil {
+		t.Errorf("Initialize after the restart failed: %v", err)
+	}
+}
+
+func TestCPUTimeLimit(t *testing.T) {
+	p := newMockPlugin(t, "runaway")
+	p.SetCPUTimeLimit(200 * time.Millisecond)
+	if _, err := p.Initialize(nil, "args", logging.NewNullLogger()); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name string
+		call func() error
+	}{
+		{"GetSeriesData", func() error { _, _, err := p.GetSeriesData("s1", "interleaved"); return err }},
+		{"GetConfig", func() error { _, err := p.GetConfig(); return err }},
+	} {
+		p.mu.Lock()
+		pid := p.cmd.Process.Pid
+		p.mu.Unlock()
+
+		start := time.Now()
+		if err := tt.call(); !errors.Is(err, ErrCPUTimeExceeded) {
+			t.Fatalf("%s error = %v, want ErrCPUTimeExceeded", tt.name, err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("%s took %v to time out", tt.name, elapsed)
+		}
+
+		// The next request runs in a new process, initialized again
+		resp, err := p.sendRequest(Request{Method: "echo_trace"})
+		if err != nil {
+			t.Fatalf("request after %s timed out failed: %v", tt.name, err)
+		}
+		if string(resp.Result) != strconv.Quote(p.traceID) {
+			t.Errorf("trace ID = %s, want %q", resp.Result, p.traceID)
+		}
+		p.mu.Lock()
+		restarted := p.running && p.cmd.Process.Pid != pid
+		p.mu.Unlock()
+		if !restarted {
+			t.Errorf("plugin was not restarted after %s timed out", tt.name)
+		}
+	}
+	if p.restarts != 2 {
+		t.Errorf("restarts = %d, want 2", p.restarts)
+	}
+}
+
+func TestSaveSendsGaps(t *testing.T) {
+	p := newMockPlugin(t, "")
+	path := filepath.Join(t.TempDir(), "request.json")
+	series := []plugins.SeriesPoints{{ID: "s1", X: []float64{0, 1, 2}, Y: []float64{5, math.NaN(), math.Inf(1)}}}
+	if err := p.Save(path, series); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// The plugin decodes the gaps back to NaN
+	line, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("plugin did not receive save: %v", err)
+	}
+	var req sdk.Request
+	if err := json.Unmarshal(line, &req); err != nil {
+		t.Fatalf("failed to decode request: %v", err)
+	}
+	if len(req.Series) != 1 || !slices.Equal(req.Series[0].X, []float64{0, 1, 2}) {
+		t.Fatalf("series = %+v", req.Series)
+	}
+	if y := req.Series[0].Y; len(y) != 3 || y[0] != 5 || !math.IsNaN(y[1]) || !math.IsNaN(y[2]) {
+		t.Errorf("Y = %v, want [5 NaN NaN]", y)
+	}
+}
+
+func TestCPUTimeLimitExemptions(t *testing.T) {
+	p := newMockPlugin(t, "runaway")
+	p.SetCPUTimeLimit(200 * time.Millisecond)
+
+	// initialize takes twice the limit
+	if _, err := p.Initialize(nil, "args", logging.NewNullLogger()); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	// So does long_task, but its progress messages start the time again
+	resp, err := p.sendRequest(Request{Method: "long_task"})
+	if err != nil {
+		t.Fatalf("long_task failed: %v", err)
+	}
+	if string(resp.Result) != `"done"` {
+		t.Errorf("long_task result = %s", resp.Result)
+	}
+}
+
+func TestCloseSendsQuit(t *testing.T) {
+	quitFile := t.TempDir() + "/quit"
+	t.Setenv("OLICANA_IPC_QUIT_FILE", quitFile)
+	p := newMockPlugin(t, "")
+	if err := p.start(); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := os.Stat(quitFile); err != nil {
+		t.Errorf("plugin did not receive quit: %v", err)
+	}
+	// The plugin exited by itself rather than being killed
+	if state := p.cmd.ProcessState; state == nil || !state.Success() {
+		t.Errorf("process state = %v, want a clean exit", state)
+	}
+}
+
+func TestCloseKillsUnresponsivePlugin(t *testing.T) {
+	orig := GracefulShutdownTimeout
+	GracefulShutdownTimeout = 100 * time.Millisecond
+	t.Cleanup(func() { GracefulShutdownTimeout = orig })
+
+	p := newMockPlugin(t, "hang")
+	if err := p.start(); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+
+	start := time.Now()
+	if err := p.SendGracefulShutdown(); err == nil {
+		t.Error("expected error when the plugin does not acknowledge quit")
+	}
+	p.mu.Lock()
+	running := p.running
+	p.mu.Unlock()
+	if running {
+		t.Error("plugin still running after it did not acknowledge quit")
+	}
+	p.Close()
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("shutdown took %v", elapsed)
+	}
+}
+
+func TestDialogSizeScaling(t *testing.T) {
+	for _, tt := range []struct {
+		scale float64
+		in    float64
+		want  int
+	}{
+		{0, 500, 500}, // Unset
+		{1, 500, 500},
+		{1.5, 600, 400},
+		{2, 548, 274},
+	} {
+		p := &Plugin{logger: logging.NewNullLogger()}
+		WithDPIScale(tt.scale)(p)
+		if got := p.dialogSize(tt.in); got != tt.want {
+			t.Errorf("scale %v: dialogSize(%v) = %d, want %d", tt.scale, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDiscoverSkipsSymlinkedDuplicates(t *testing.T) {
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	writeManifest := func(dir, name string) {
+		t.Helper()
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		manifest := fmt.Sprintf(`{"name": %q, "command": "python plugin.py"}`, name)
+		if err := os.WriteFile(filepath.Join(dir, "olicana-plot-plugin.json"), []byte(manifest), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeManifest(filepath.Join(dir1, "shared"), "Shared")
+	writeManifest(filepath.Join(dir2, "other"), "Other")
+	if err := os.Symlink(filepath.Join(dir1, "shared"), filepath.Join(dir2, "shared")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	// dir1 listed twice is also only scanned for new plugins once
+	loader := NewLoader([]string{dir1, dir2, dir1}, logging.NewLogger("test"))
+	found, err := loader.Discover()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range found {
+		names = append(names, p.Name())
+	}
+	if len(names) != 2 || names[0] != "Shared" || names[1] != "Other" {
+		t.Errorf("Discover found %v, want [Shared Other]", names)
+	}
+}
+
+func TestRegisterDir(t *testing.T) {
+	manager := plugins.NewManager(logging.NewNullLogger())
+	service := plugins.NewService(manager, nil, logging.NewNullLogger())
+	loader := NewLoader(nil, logging.NewNullLogger())
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "added"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	manifest := `{"name": "Added", "command": "python plugin.py"}`
+	if err := os.WriteFile(filepath.Join(dir, "added", "olicana-plot-plugin.json"), []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := loader.RegisterDir(dir, manager)
+	if err != nil {
+		t.Fatalf("RegisterDir failed: %v", err)
+	}
+	if !slices.Equal(names, []string{"Added"}) {
+		t.Errorf("registered %v, want [Added]", names)
+	}
+	listed := false
+	for _, m := range service.ListPlugins() {
+		listed = listed || m.Name == "Added"
+	}
+	if !listed {
+		t.Errorf("ListPlugins = %+v, want Added", service.ListPlugins())
+	}
+
+	// Scanning it again registers nothing new
+	if names, err := loader.RegisterDir(dir, manager); err != nil || len(names) != 0 {
+		t.Errorf("second RegisterDir = %v, %v, want nothing", names, err)
+	}
+	if !slices.Equal(loader.searchDirs, []string{dir}) {
+		t.Errorf("search dirs = %v, want [%s]", loader.searchDirs, dir)
+	}
+}
+
+func TestRequestIDMatchesReply(t *testing.T) {
+	p := newMockPlugin(t, "")
+	var ids []string
+	for i := 0; i < 2; i++ {
+		resp, err := p.sendRequest(Request{Method: "echo_request_id"})
+		if err != nil {
+			t.Fatalf("echo_request_id failed: %v", err)
+		}
+		var id string
+		if err := json.Unmarshal(resp.Result, &id); err != nil {
+			t.Fatalf("result %s: %v", resp.Result, err)
+		}
+		if id == "" || id != resp.RequestID {
+			t.Errorf("got reply %s to request %s, want the reply echoing its ID", resp.RequestID, id)
+		}
+		ids = append(ids, id)
+	}
+	if ids[0] == ids[1] {
+		t.Errorf("requests share ID %s", ids[0])
+	}
+
+	// Replies without an ID are taken as before
+	if _, err := p.sendRequest(Request{Method: "initialize"}); err != nil {
+		t.Errorf("initialize failed: %v", err)
+	}
+}
+
+func TestPluginEventForwardedToBus(t *testing.T) {
+	p := newMockPlugin(t, "")
+	bus := plugins.NewPluginEventBus()
+	p.SetEventBus(bus)
+
+	var got []interface{}
+	bus.Subscribe("Mock Plugin", "dataUpdated", func(data interface{}) {
+		got = append(got, data)
+	})
+
+	resp, err := p.sendRequest(Request{Method: "emit_event"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Result) != `"ok"` {
+		t.Errorf("result = %s, want the response after the event", resp.Result)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d events, want 1", len(got))
+	}
+	if data, ok := got[0].(map[string]interface{}); !ok || data["rows"] != 1200.0 {
+		t.Errorf("event data = %#v, want rows 1200", got[0])
+	}
+}
+
+func TestRequestLocaleRoundTrip(t *testing.T) {
+	p := &Plugin{logger: logging.NewNullLogger()}
+	WithLocale(func() string { return "fr-FR" })(p)
+	b, err := json.Marshal(Request{Method: "initialize", Locale: p.currentLocale()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := sdk.ParseRequest(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Locale != "fr-FR" || req.Method != "initialize" {
+		t.Errorf("plugin received %+v, want initialize in fr-FR", req)
+	}
+	if got := sdk.CurrentLocale(); got != "fr-FR" {
+		t.Errorf("CurrentLocale() = %q, want fr-FR", got)
+	}
+
+	// Requests without a locale keep the last one
+	if _, err := sdk.ParseRequest([]byte(`{"method": "info"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got := sdk.CurrentLocale(); got != "fr-FR" {
+		t.Errorf("CurrentLocale() after request without locale = %q, want fr-FR", got)
+	}
+
+	// Decoding a request by other means doesn't change it
+	var other sdk.Request
+	if err := json.Unmarshal([]byte(`{"method": "info", "locale": "de-DE"}`), &other); err != nil {
+		t.Fatal(err)
+	}
+	if got := sdk.CurrentLocale(); got != "fr-FR" {
+		t.Errorf("CurrentLocale() after json.Unmarshal = %q, want fr-FR", got)
+	}
+}
+
+func TestRequestLocale(t *testing.T) {
+	locale := "fr-FR"
+	p := newMockPlugin(t, "")
+	WithLocale(func() string { return locale })(p)
+
+	// Every request carries the locale current when it is sent, as decoded
+	// by the SDK
+	for _, want := range []string{"fr-FR", "de-DE"} {
+		locale = want
+		resp, err := p.sendRequest(Request{Method: "get_locale"})
+		if err != nil {
+			t.Fatalf("get_locale failed: %v", err)
+		}
+		if string(resp.Result) != `"`+want+`"` {
+			t.Errorf("plugin received locale %s, want %s", resp.Result, want)
+		}
+	}
+}
+
+func TestGetSeriesDataVerifiesChecksum(t *testing.T) {
+	p := newMockPlugin(t, "")
+	data, _, err := p.GetSeriesData("s1", "")
+	if err != nil {
+		t.Fatalf("GetSeriesData with a valid checksum: %v", err)
+	}
+	if len(data) != 4 || data[3] != 3 {
+		t.Errorf("data = %v, want [0 1 2 3]", data)
+	}
+
+	p = newMockPlugin(t, "corrupt")
+	if _, _, err := p.GetSeriesData("s1", ""); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("GetSeriesData with a corrupted byte: error = %v, want ErrChecksumMismatch", err)
+	}
+}
+
+func TestGetSeriesDataTruncated(t *testing.T) {
+	p := newMockPlugin(t, "crash_mid_send")
+	_, _, err := p.GetSeriesData("s1", "")
+	if !errors.Is(err, ErrTruncatedData) {
+		t.Fatalf("GetSeriesData from a plugin crashing mid-send: error = %v, want ErrTruncatedData", err)
+	}
+	if !strings.Contains(err.Error(), "received 12 of 32 bytes") {
+		t.Errorf("error %q doesn't give the byte count", err)
+	}
+
+	p = newMockPlugin(t, "odd_values")
+	if _, _, err := p.GetSeriesData("s1", ""); !errors.Is(err, ErrTruncatedData) {
+		t.Errorf("GetSeriesData with 3 interleaved values: error = %v, want ErrTruncatedData", err)
+	}
+
+	p = newMockPlugin(t, "misaligned")
+	if _, _, err := p.GetSeriesData("s1", ""); err == nil || !strings.Contains(err.Error(), "invalid binary data length 29") {
+		t.Errorf("GetSeriesData with a 29 byte length: error = %v", err)
+	}
+	// The process is stopped rather than left running out of step
+	if p.running || p.cmd.ProcessState == nil {
+		t.Error("plugin process still running after a misaligned length")
+	}
+}
+
+func TestSeriesDataTraceID(t *testing.T) {
+	p := newMockPlugin(t, "log_series")
+	logger := &recordingLogger{}
+	p.SetLogger(logger)
+	p.traceID = "trace-1"
+
+	if _, _, err := p.GetSeriesData("s1", ""); err != nil {
+		t.Fatalf("GetSeriesData failed: %v", err)
+	}
+	if _, err := p.GetSeriesDataParallel([]string{"s2"}, ""); err != nil {
+		t.Fatalf("GetSeriesDataParallel failed: %v", err)
+	}
+
+	// The plugin logs the trace ID it received, which is left out when it
+	// is the plugin's own
+	want := [][]any{
+		{"component", "Mock Plugin", "series", "s1"},
+		{"component", "Mock Plugin", "series", "s2"},
+	}
+	if fmt.Sprint(logger.infoArgs) != fmt.Sprint(want) {
+		t.Errorf("logged args %v, want %v", logger.infoArgs, want)
+	}
+}
+
+func TestSeriesDataRequestID(t *testing.T) {
+	// A late reply to an earlier request is skipped, binary data and all
+	p := newMockPlugin(t, "async_series")
+	data, _, err := p.GetSeriesData("7", "")
+	if err != nil {
+		t.Fatalf("GetSeriesData failed: %v", err)
+	}
+	if want := []float64{0, 7, 2, 7}; !slices.Equal(data, want) {
+		t.Errorf("GetSeriesData() = %v, want %v", data, want)
+	}
+
+	// Pipelined replies in another order than the requests still reach
+	// their own series
+	p = newMockPlugin(t, "reorder")
+	got, err := p.GetSeriesDataParallel([]string{"1", "2"}, "")
+	if err != nil {
+		t.Fatalf("GetSeriesDataParallel failed: %v", err)
+	}
+	for _, id := range []string{"1", "2"} {
+		n, _ := strconv.ParseFloat(id, 64)
+		if want := []float64{0, n, 2, n}; !slices.Equal(got[id], want) {
+			t.Errorf("series %s = %v, want %v", id, got[id], want)
+		}
+	}
+}
+
+func TestFormAutocomplete(t *testing.T) {
+	p := newMockPlugin(t, "")
+	if _, err := p.sendRequest(Request{Method: "echo_trace"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := p.formAutocomplete("channelName", "temp")
+	if err != nil {
+		t.Fatalf("formAutocomplete failed: %v", err)
+	}
+	if strings.Join(got, ",") != "temperature,temp_ambient" {
+		t.Errorf("candidates = %v, want temperature and temp_ambient", got)
+	}
+}
+
+func TestFormAutocompleteTimeout(t *testing.T) {
+	p := newMockPlugin(t, "slow")
+	p.traceID = "trace-1"
+	p.SetCPUTimeLimit(-1) // Only the autocomplete timeout stops the request
+	if _, err := p.sendRequest(Request{Method: "echo_trace"}); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if _, err := p.formAutocomplete("channelName", "temp"); err == nil {
+		t.Fatal("expected an error for a reply slower than the timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 2*autocompleteTimeout {
+		t.Errorf("formAutocomplete took %v to time out", elapsed)
+	}
+
+	// Nothing is left holding the lock for later form messages
+	if !p.commsMu.TryLock() {
+		t.Fatal("commsMu still held after the timeout")
+	}
+	p.commsMu.Unlock()
+
+	// The slow plugin was killed, so its late reply can't be taken as the
+	// answer to the next request, which starts a new process
+	resp, err := p.sendRequest(Request{Method: "echo_trace"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Result) != `"trace-1"` {
+		t.Errorf("result = %s, want the echoed trace ID", resp.Result)
+	}
+}
+
+func TestGetSeriesDataParallel(t *testing.T) {
+	for _, concurrent := range []bool{false, true} {
+		t.Run(fmt.Sprintf("concurrent=%v", concurrent), func(t *testing.T) {
+			p := newMockPlugin(t, "")
+			p.concurrent = concurrent
+			if _, err := p.Initialize(nil, "args", logging.NewLogger("Test")); err != nil {
+				t.Fatalf("Initialize failed: %v", err)
+			}
+
+			ids := []string{"1", "2", "3", "4", "5"}
+			got, err := p.GetSeriesDataParallel(ids, "arrays")
+			if err != nil {
+				t.Fatalf("GetSeriesDataParallel failed: %v", err)
+			}
+			for _, id := range ids {
+				n, _ := strconv.ParseFloat(id, 64)
+				// Converted from the mock's interleaved reply
+				if want := []float64{0, 2, n, n}; fmt.Sprint(got[id]) != fmt.Sprint(want) {
+					t.Errorf("series %s = %v, want %v", id, got[id], want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetSeriesDataParallelPluginError(t *testing.T) {
+	p := newMockPlugin(t, "")
+	if _, err := p.GetSeriesDataParallel([]string{"1", "missing", "2"}, ""); !errors.Is(err, errPluginReply) {
+		t.Fatalf("error = %v, want the plugin's error", err)
+	}
+
+	// The replies after the error were read, so the next request gets its own
+	data, _, err := p.GetSeriesData("3", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data[1] != 3 {
+		t.Errorf("data = %v, want the reply for series 3", data)
+	}
+}
+
+// BenchmarkGetSeriesDataParallel fetches 10 series from a plugin taking 10ms
+// per series, one at a time, pipelined, and from concurrent processes.
+func BenchmarkGetSeriesDataParallel(b *testing.B) {
+	ids := make([]string, 10)
+	for i := range ids {
+		ids[i] = strconv.Itoa(i)
+	}
+
+	b.Run("Sequential", func(b *testing.B) {
+		p := newMockPlugin(b, "slow_series")
+		if _, _, err := p.GetSeriesData(ids[0], ""); err != nil {
+			b.Fatal(err)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, id := range ids {
+				if _, _, err := p.GetSeriesData(id, ""); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	for _, concurrent := range []bool{false, true} {
+		name := "Pipelined"
+		if concurrent {
+			name = "Concurrent"
+		}
+		b.Run(name, func(b *testing.B) {
+			p := newMockPlugin(b, "slow_series")
+			p.concurrent = concurrent
+			// Start the processes before timing
+			if _, err := p.GetSeriesDataParallel(ids, ""); err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := p.GetSeriesDataParallel(ids, ""); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// discardCloser is a plugin stdin that drops requests.
+type discardCloser struct{}
+
+func (discardCloser) Write(b []byte) (int, error) { return len(b), nil }
+func (discardCloser) Close() error                { return nil }
+
+// BenchmarkGetSeriesDataBufferSize reads binary series data through an OS
+// pipe, as from a plugin process, with stdout buffers of different sizes.
+func BenchmarkGetSeriesDataBufferSize(b *testing.B) {
+	const points = 1 << 17 // 2 MB interleaved
+	payload := make([]byte, points*16)
+	header, _ := json.Marshal(Response{Type: "binary", Length: len(payload), Storage: "interleaved"})
+	header = append(header, '\n')
+
+	for _, size := range []int{4 << 10, 16 << 10, 64 << 10, 256 << 10} {
+		b.Run(fmt.Sprintf("%dKB", size>>10), func(b *testing.B) {
+			r, w, err := os.Pipe()
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.Cleanup(func() { r.Close(); w.Close() })
+			go func(n int) {
+				for i := 0; i < n; i++ {
+					w.Write(header)
+					w.Write(payload)
+				}
+			}(b.N)
+
+			p := &Plugin{stdin: discardCloser{}, stdout: newStdoutReader(r, size), running: true, logger: logging.NewNullLogger()}
+			b.SetBytes(int64(len(payload)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := p.GetSeriesData("s1", ""); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestShowFileDialog(t *testing.T) {
+	var title, accept string
+	chosen := "/data/run1.cal"
+	defer func(f func(*application.App, string, string) (string, error)) { openFileDialog = f }(openFileDialog)
+	openFileDialog = func(app *application.App, dialogTitle, dialogAccept string) (string, error) {
+		title, accept = dialogTitle, dialogAccept
+		return chosen, nil
+	}
+
+	p := newMockPlugin(t, "")
+	resp, err := p.sendRequest(Request{Method: "pick_file"})
+	if err != nil {
+		t.Fatalf("pick_file failed: %v", err)
+	}
+	if got := string(resp.Result); got != `{"result":"/data/run1.cal"}` {
+		t.Errorf("plugin received %s", got)
+	}
+	if title != "Select Calibration" || accept != "*.cal" {
+		t.Errorf("dialog title %q, accept %q", title, accept)
+	}
+
+	// Cancelling the dialog sends an error
+	chosen = ""
+	resp, err = p.sendRequest(Request{Method: "pick_file"})
+	if err != nil {
+		t.Fatalf("pick_file failed: %v", err)
+	}
+	if got := string(resp.Result); got != `{"error":"cancelled"}` {
+		t.Errorf("plugin received %s after cancelling", got)
+	}
+}
+
+func TestInitializeProgress(t *testing.T) {
+	type progress struct {
+		name, message string
+		pct           float64
+	}
+	var got []progress
+	defer func(f func(*application.App, string, float64, string)) { emitProgress = f }(emitProgress)
+	emitProgress = func(app *application.App, name string, pct float64, message string) {
+		got = append(got, progress{name, message, pct})
+	}
+
+	p := newMockPlugin(t, "progress")
+	if _, err := p.Initialize(nil, "", logging.NewNullLogger()); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	want := []progress{
+		{p.name, "Reading 25%", 25},
+		{p.name, "Reading 50%", 50},
+		{p.name, "Reading 75%", 75},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("progress = %+v, want %+v", got, want)
+	}
+}
+
+// writeELFHeader writes an ELF file with only a header for the given machine.
+func writeELFHeader(t *testing.T, path string, machine elf.Machine) {
+	t.Helper()
+	hdr := elf.Header64{
+		Type:    uint16(elf.ET_EXEC),
+		Machine: uint16(machine),
+		Version: uint32(elf.EV_CURRENT),
+		Ehsize:  uint16(binary.Size(elf.Header64{})),
+	}
+	copy(hdr.Ident[:], elf.ELFMAG)
+	hdr.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	hdr.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	hdr.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, hdr)
+	if err := os.WriteFile(path, buf.Bytes(), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckArch(t *testing.T) {
+	if archs := executableArchs(os.Args[0]); !slices.Contains(archs, runtime.GOARCH) {
+		t.Errorf("test binary architectures = %v, want %s", archs, runtime.GOARCH)
+	}
+
+	dir := t.TempDir()
+	script := filepath.Join(dir, "plugin.sh")
+	os.WriteFile(script, []byte("#!/bin/sh\n"), 0755)
+	if archs := executableArchs(script); archs != nil {
+		t.Errorf("script architectures = %v, want none", archs)
+	}
+
+	other, otherArch := elf.EM_AARCH64, "arm64"
+	if runtime.GOARCH == "arm64" {
+		other, otherArch = elf.EM_X86_64, "amd64"
+	}
+	foreign := filepath.Join(dir, "foreign")
+	writeELFHeader(t, foreign, other)
+	if archs := executableArchs(foreign); len(archs) != 1 || archs[0] != otherArch {
+		t.Errorf("foreign architectures = %v, want [%s]", archs, otherArch)
+	}
+
+	for _, tt := range []struct {
+		path string
+		warn bool
+	}{
+		{os.Args[0], false},
+		{script, false},
+		{foreign, true},
+	} {
+		logger := &recordingLogger{}
+		p := &Plugin{execPath: tt.path, name: "Mock Plugin", logger: logger}
+		p.checkArch()
+		if warned := len(logger.messages) > 0; warned != tt.warn {
+			t.Errorf("%s: warned = %v (%v), want %v", filepath.Base(tt.path), warned, logger.messages, tt.warn)
+		}
+	}
+}
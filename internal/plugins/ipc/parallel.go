@@ -0,0 +1,239 @@
+package ipc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// maxProcesses caps the processes of a concurrent plugin serving
+// GetSeriesDataParallel, including the plugin's own. Plugins often wait on
+// files or devices rather than the CPU, so there are at least 4.
+var maxProcesses = max(runtime.NumCPU(), 4)
+
+// GetSeriesDataParallel fetches several series at once and returns their
+// data by ID, converted to the given storage ("interleaved" if empty).
+//
+// Plugins whose metadata declares "concurrent": true are served by a pool
+// of extra processes, started and initialized with the same args as the
+// plugin on first use and kept until Close. Those processes have no window
+// to show forms in, so such plugins must initialize from their args alone.
+// Other plugins answer one request at a time, so their requests are
+// pipelined instead: they are all written up front and the replies read in
+// order, which saves a round trip per series.
+func (p *Plugin) GetSeriesDataParallel(ids []string, storage string) (map[string][]float64, error) {
+	if storage == "" {
+		storage = "interleaved"
+	}
+	if len(ids) == 0 {
+		return map[string][]float64{}, nil
+	}
+	if p.concurrent && len(ids) > 1 {
+		return p.fetchConcurrent(ids, storage)
+	}
+	return p.fetchPipelined(ids, storage)
+}
+
+// fetchConcurrent shares out the requests for ids among the pool's processes.
+func (p *Plugin) fetchConcurrent(ids []string, storage string) (map[string][]float64, error) {
+	procs, err := p.pool(min(len(ids), maxProcesses))
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make(chan string)
+	result := make(map[string][]float64, len(ids))
+	var firstErr error
+	var mu sync.Mutex // Guards result and firstErr
+
+	var wg sync.WaitGroup
+	for _, proc := range procs {
+		wg.Add(1)
+		go func(proc *Plugin) {
+			defer wg.Done()
+			for id := range jobs {
+				data, got, err := proc.GetSeriesData(id, storage)
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = fmt.Errorf("series %s: %w", id, err)
+					}
+				} else {
+					result[id] = convertStorage(data, got, storage)
+				}
+				mu.Unlock()
+			}
+		}(proc)
+	}
+	for _, id := range ids {
+		jobs <- id
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return result, nil
+}
+
+// pool returns the plugin followed by n-1 extra processes, starting the
+// missing ones.
+func (p *Plugin) pool(n int) ([]*Plugin, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for len(p.workers) < n-1 {
+		w := &Plugin{
+			execPath:      p.execPath,
+			execArgs:      p.execArgs,
+			workDir:       p.workDir,
+			name:          p.name,
+			version:       p.version,
+			sandbox:       p.sandbox,
+			dialogTimeout: p.dialogTimeout,
+			dpiScale:      p.dpiScale,
+			traceID:       p.traceID,
+			locale:        p.locale,
+			bufferSize:    p.bufferSize,
+			cpuTimeLimit:  p.cpuTimeLimit,
+			logger:        p.logger,
+		}
+		if err := w.start(); err != nil {
+			return nil, fmt.Errorf("failed to start plugin process: %w", err)
+		}
+		if p.initialized {
+			if _, err := w.sendRequest(Request{Method: "initialize", Args: p.initArgs}); err != nil {
+				w.Close()
+				return nil, fmt.Errorf("failed to initialize plugin process: %w", err)
+			}
+			w.initialized, w.initArgs = true, p.initArgs
+		}
+		p.workers = append(p.workers, w)
+	}
+	return append([]*Plugin{p}, p.workers[:n-1]...), nil
+}
+
+// closeWorkersLocked stops the pool's extra processes. Callers must hold
+// p.mu.
+func (p *Plugin) closeWorkersLocked() {
+	for _, w := range p.workers {
+		w.Close()
+	}
+	p.workers = nil
+}
+
+// fetchPipelined writes the requests for ids from a goroutine, taking
+// commsMu for each so that events can be sent in between, while the replies
+// are read and matched to the requests by their request IDs.
+func (p *Plugin) fetchPipelined(ids []string, storage string) (map[string][]float64, error) {
+	if !p.running {
+		if err := p.start(); err != nil {
+			return nil, err
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.running {
+		return nil, fmt.Errorf("plugin not running")
+	}
+
+	// Plugins that echo request IDs may answer in any order
+	requestIDs := make([]string, len(ids))
+	seriesOf := make(map[string]string, len(ids)) // By request ID
+	for i, id := range ids {
+		requestIDs[i] = newTraceID()
+		seriesOf[requestIDs[i]] = id
+	}
+
+	written := make(chan error, 1)
+	go func() {
+		for i, id := range ids {
+			p.commsMu.Lock()
+			err := p.writeRequest(Request{
+				Method:           "get_series_data",
+				SeriesID:         id,
+				PreferredStorage: storage,
+				Locale:           p.currentLocale(),
+				RequestID:        requestIDs[i],
+			})
+			p.commsMu.Unlock()
+			if err != nil {
+				written <- err
+				return
+			}
+		}
+		written <- nil
+	}()
+
+	result := make(map[string][]float64, len(ids))
+	answered := make(map[string]bool, len(ids))
+	accept := func(requestID string) bool {
+		_, ok := seriesOf[requestID]
+		return ok && !answered[requestID]
+	}
+	var replyErr error
+	for i := range ids {
+		// Each reply has the CPU time limit from the one before
+		ctx, release := p.limitRequest(context.Background(), "get_series_data")
+		data, got, requestID, err := p.readSeriesData(ids[i], accept)
+		err = p.cpuTimeError(ctx, "get_series_data", err)
+		release()
+
+		// Replies without a request ID come in the order of the requests
+		if requestID == "" {
+			requestID = requestIDs[i]
+		}
+		answered[requestID] = true
+		id := seriesOf[requestID]
+		if errors.Is(err, errPluginReply) || errors.Is(err, ErrChecksumMismatch) {
+			// The reply was read whole, so the next one is still in step
+			if replyErr == nil {
+				replyErr = fmt.Errorf("series %s: %w", id, err)
+			}
+			continue
+		}
+		if err != nil {
+			// The remaining replies can't be matched to their requests
+			p.killLocked()
+			<-written
+			return nil, err
+		}
+		result[id] = convertStorage(data, got, storage)
+	}
+
+	if err := <-written; err != nil {
+		return nil, err
+	}
+	if replyErr != nil {
+		return nil, replyErr
+	}
+	return result, nil
+}
+
+// convertStorage converts data between storage formats if necessary.
+func convertStorage(data []float64, current, desired string) []float64 {
+	if current == desired || current == "" {
+		return data
+	}
+
+	n := len(data) / 2
+	result := make([]float64, len(data))
+	switch {
+	case current == "interleaved" && desired == "arrays":
+		for i := 0; i < n; i++ {
+			result[i], result[n+i] = data[i*2], data[i*2+1]
+		}
+	case current == "arrays" && desired == "interleaved":
+		for i := 0; i < n; i++ {
+			result[i*2], result[i*2+1] = data[i], data[n+i]
+		}
+	default:
+		return data
+	}
+	return result
+}
@@ -0,0 +1,112 @@
+//go:build linux
+
+package ipc
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Seccomp return actions and seccomp_data offsets from linux/seccomp.h.
+const (
+	seccompRetKillProcess = 0x80000000
+	seccompRetErrno       = 0x00050000
+	seccompRetAllow       = 0x7fff0000
+
+	seccompDataNrOffset   = 0
+	seccompDataArchOffset = 4
+)
+
+// sandboxSyscalls is the set of syscalls available on every supported
+// architecture that plugins need for file I/O, memory management, threads and
+// child processes. Anything else, notably sockets, fails with EPERM.
+var sandboxSyscalls = []uint32{
+	// File I/O
+	unix.SYS_READ, unix.SYS_WRITE, unix.SYS_READV, unix.SYS_WRITEV,
+	unix.SYS_PREAD64, unix.SYS_PWRITE64, unix.SYS_OPENAT, unix.SYS_CLOSE,
+	unix.SYS_CLOSE_RANGE, unix.SYS_LSEEK, unix.SYS_FSTAT, unix.SYS_STATX,
+	unix.SYS_FCNTL, unix.SYS_IOCTL, unix.SYS_FSYNC, unix.SYS_FTRUNCATE,
+	unix.SYS_GETDENTS64, unix.SYS_READLINKAT, unix.SYS_FACCESSAT,
+	unix.SYS_FACCESSAT2, unix.SYS_MKDIRAT, unix.SYS_UNLINKAT,
+	unix.SYS_RENAMEAT2, unix.SYS_GETCWD, unix.SYS_CHDIR, unix.SYS_FCHDIR,
+	unix.SYS_DUP, unix.SYS_DUP3, unix.SYS_PIPE2,
+	unix.SYS_EPOLL_CREATE1, unix.SYS_EPOLL_CTL, unix.SYS_EPOLL_PWAIT,
+	unix.SYS_PPOLL, unix.SYS_PSELECT6, unix.SYS_EVENTFD2,
+
+	// Memory
+	unix.SYS_MMAP, unix.SYS_MUNMAP, unix.SYS_MPROTECT, unix.SYS_MREMAP,
+	unix.SYS_MADVISE, unix.SYS_BRK, unix.SYS_MEMBARRIER,
+
+	// Signals, threads and time
+	unix.SYS_RT_SIGACTION, unix.SYS_RT_SIGPROCMASK, unix.SYS_RT_SIGRETURN,
+	unix.SYS_SIGALTSTACK, unix.SYS_FUTEX, unix.SYS_SET_ROBUST_LIST,
+	unix.SYS_SET_TID_ADDRESS, unix.SYS_RSEQ, unix.SYS_SCHED_YIELD,
+	unix.SYS_SCHED_GETAFFINITY, unix.SYS_NANOSLEEP, unix.SYS_CLOCK_GETTIME,
+	unix.SYS_CLOCK_NANOSLEEP, unix.SYS_GETTIMEOFDAY, unix.SYS_RESTART_SYSCALL,
+	unix.SYS_GETRANDOM,
+
+	// Process management
+	unix.SYS_CLONE, unix.SYS_CLONE3, unix.SYS_EXECVE, unix.SYS_WAIT4,
+	unix.SYS_WAITID, unix.SYS_EXIT, unix.SYS_EXIT_GROUP, unix.SYS_KILL,
+	unix.SYS_TKILL, unix.SYS_TGKILL, unix.SYS_GETPID, unix.SYS_GETPPID,
+	unix.SYS_GETTID, unix.SYS_GETPGID, unix.SYS_SETPGID, unix.SYS_GETSID,
+	unix.SYS_SETSID, unix.SYS_GETUID, unix.SYS_GETEUID, unix.SYS_GETGID,
+	unix.SYS_GETEGID, unix.SYS_PRLIMIT64, unix.SYS_GETRLIMIT, unix.SYS_UNAME,
+	unix.SYS_SYSINFO, unix.SYS_PRCTL,
+}
+
+// buildSeccompFilter returns a BPF program that allows the given syscalls,
+// fails every other syscall with EPERM and kills the process if it was built
+// for a different architecture.
+func buildSeccompFilter(arch uint32, allowed []uint32) ([]unix.SockFilter, error) {
+	// Each check jumps over the remaining checks and the deny to reach allow
+	if len(allowed) > 255 {
+		return nil, fmt.Errorf("too many syscalls for a single jump table: %d", len(allowed))
+	}
+
+	filter := []unix.SockFilter{
+		bpfStmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, seccompDataArchOffset),
+		bpfJump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, arch, 1, 0),
+		bpfStmt(unix.BPF_RET|unix.BPF_K, seccompRetKillProcess),
+		bpfStmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, seccompDataNrOffset),
+	}
+	for i, nr := range allowed {
+		filter = append(filter, bpfJump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, nr, uint8(len(allowed)-i), 0))
+	}
+	filter = append(filter,
+		bpfStmt(unix.BPF_RET|unix.BPF_K, seccompRetErrno|uint32(unix.EPERM)),
+		bpfStmt(unix.BPF_RET|unix.BPF_K, seccompRetAllow),
+	)
+	return filter, nil
+}
+
+// installSeccompFilter applies the sandbox filter to the calling thread.
+func installSeccompFilter() error {
+	filter, err := buildSeccompFilter(seccompArch, append(sandboxSyscalls, archSandboxSyscalls...))
+	if err != nil {
+		return err
+	}
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("failed to set no_new_privs: %w", err)
+	}
+
+	prog := unix.SockFprog{
+		Len:    uint16(len(filter)),
+		Filter: &filter[0],
+	}
+	if err := unix.Prctl(unix.PR_SET_SECCOMP, unix.SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(&prog)), 0, 0); err != nil {
+		return fmt.Errorf("failed to install seccomp filter: %w", err)
+	}
+	return nil
+}
+
+func bpfStmt(code uint16, k uint32) unix.SockFilter {
+	return unix.SockFilter{Code: code, K: k}
+}
+
+func bpfJump(code uint16, k uint32, jt, jf uint8) unix.SockFilter {
+	return unix.SockFilter{Code: code, Jt: jt, Jf: jf, K: k}
+}
@@ -0,0 +1,15 @@
+package ipc
+
+import "golang.org/x/sys/unix"
+
+const seccompArch = unix.AUDIT_ARCH_X86_64
+
+// archSandboxSyscalls are legacy syscalls that only exist on amd64.
+var archSandboxSyscalls = []uint32{
+	unix.SYS_OPEN, unix.SYS_STAT, unix.SYS_LSTAT, unix.SYS_NEWFSTATAT,
+	unix.SYS_ACCESS, unix.SYS_READLINK, unix.SYS_GETDENTS, unix.SYS_MKDIR,
+	unix.SYS_UNLINK, unix.SYS_RENAME, unix.SYS_RENAMEAT, unix.SYS_PIPE,
+	unix.SYS_DUP2, unix.SYS_POLL, unix.SYS_SELECT, unix.SYS_EPOLL_CREATE,
+	unix.SYS_EPOLL_WAIT, unix.SYS_ARCH_PRCTL, unix.SYS_FORK, unix.SYS_VFORK,
+	unix.SYS_TIME, unix.SYS_GETPGRP,
+}
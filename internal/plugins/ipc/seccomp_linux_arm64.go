@@ -0,0 +1,10 @@
+package ipc
+
+import "golang.org/x/sys/unix"
+
+const seccompArch = unix.AUDIT_ARCH_AARCH64
+
+// archSandboxSyscalls are syscalls whose names differ on arm64.
+var archSandboxSyscalls = []uint32{
+	unix.SYS_FSTATAT,
+}
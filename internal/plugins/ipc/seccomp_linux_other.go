@@ -0,0 +1,9 @@
+//go:build linux && !amd64 && !arm64
+
+package ipc
+
+// seccompArch is zero where no seccomp filter is defined, which makes
+// sandboxCommand refuse to launch sandboxed plugins.
+const seccompArch = 0
+
+var archSandboxSyscalls []uint32
@@ -0,0 +1,40 @@
+//go:build linux
+
+package ipc
+
+import (
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestBuildSeccompFilter(t *testing.T) {
+	allowed := []uint32{unix.SYS_READ, unix.SYS_WRITE, unix.SYS_EXIT_GROUP}
+	filter, err := buildSeccompFilter(seccompArch, allowed)
+	if err != nil {
+		t.Fatalf("buildSeccompFilter failed: %v", err)
+	}
+
+	allowIdx := len(filter) - 1
+	if filter[allowIdx].K != seccompRetAllow {
+		t.Fatalf("last instruction should allow, got %#x", filter[allowIdx].K)
+	}
+	if filter[allowIdx-1].K != seccompRetErrno|uint32(unix.EPERM) {
+		t.Fatalf("default action should be EPERM, got %#x", filter[allowIdx-1].K)
+	}
+
+	// Every syscall check must jump to the allow instruction on match
+	for i := range allowed {
+		idx := 4 + i
+		if filter[idx].K != allowed[i] {
+			t.Errorf("check %d compares %d, want %d", i, filter[idx].K, allowed[i])
+		}
+		if target := idx + 1 + int(filter[idx].Jt); target != allowIdx {
+			t.Errorf("check %d jumps to %d, want %d", i, target, allowIdx)
+		}
+	}
+
+	if _, err := buildSeccompFilter(seccompArch, make([]uint32, 256)); err == nil {
+		t.Error("expected error for an oversized allow list")
+	}
+}
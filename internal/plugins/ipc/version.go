@@ -0,0 +1,47 @@
+package ipc
+
+import (
+	"fmt"
+
+	"olicanaplot/internal/plugins"
+)
+
+// hostCapabilities describes the protocol versions this build of the host
+// supports.
+type hostCapabilities struct {
+	APIVersion       uint32 // Compared with plugins' MinHostVersion
+	MaxPluginVersion uint32 // Newest protocol version a plugin may report in info
+}
+
+// host holds the capabilities of this build. Tests replace it to simulate
+// other versions.
+var host = hostCapabilities{
+	APIVersion:       plugins.PluginAPIVersion,
+	MaxPluginVersion: plugins.PluginAPIVersion,
+}
+
+// ErrVersionIncompatible is returned when loading a plugin that needs a newer
+// host, or speaks a newer protocol than the host understands.
+type ErrVersionIncompatible struct {
+	PluginVersion uint32 // Protocol version the plugin needs
+	HostVersion   uint32 // Protocol version the host supports
+}
+
+func (e *ErrVersionIncompatible) Error() string {
+	return fmt.Sprintf("plugin needs protocol version %d but this version of OlicanaPlot supports version %d: "+
+		"upgrade OlicanaPlot, or use a release of the plugin built for protocol version %d",
+		e.PluginVersion, e.HostVersion, e.HostVersion)
+}
+
+// checkVersion returns an *ErrVersionIncompatible if the plugin's
+// MinHostVersion is newer than the host, or the version from its info
+// response newer than the host's MaxPluginVersion.
+func (p *Plugin) checkVersion() error {
+	if p.minHostVersion > host.APIVersion {
+		return &ErrVersionIncompatible{PluginVersion: p.minHostVersion, HostVersion: host.APIVersion}
+	}
+	if p.version > host.MaxPluginVersion {
+		return &ErrVersionIncompatible{PluginVersion: p.version, HostVersion: host.MaxPluginVersion}
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package ipc
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// PingInterval is how often the watchdog pings a running plugin, and
+// PingTimeout how long it waits for the reply before restarting it.
+var (
+	PingInterval = 5 * time.Second
+	PingTimeout  = time.Second
+)
+
+// maxRestarts is how many times the watchdog restarts an unresponsive
+// plugin before leaving it stopped.
+const maxRestarts = 3
+
+// errPluginReply is wrapped by the errors of requests that the plugin
+// answered with an error.
+var errPluginReply = errors.New("plugin error")
+
+// Ping checks that the running plugin answers requests within PingTimeout.
+// Plugins that predate ping answer with an error, which shows they are alive
+// just the same. A plugin that does not answer in time is killed.
+func (p *Plugin) Ping() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.running {
+		return fmt.Errorf("plugin not running")
+	}
+	return p.pingLocked()
+}
+
+// pingLocked is Ping for callers holding p.mu.
+func (p *Plugin) pingLocked() error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.sendLockedRequest(Request{Method: "ping"})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil && !errors.Is(err, errPluginReply) {
+			return err
+		}
+		return nil
+	case <-time.After(PingTimeout):
+		// Unblock the pending read
+		if p.cmd != nil && p.cmd.Process != nil {
+			p.cmd.Process.Kill()
+		}
+		<-done
+		return fmt.Errorf("no reply to ping within %v", PingTimeout)
+	}
+}
+
+// startWatchdogLocked starts pinging the plugin every PingInterval until
+// stopWatchdogLocked is called, replacing the watchdog of a process that
+// exited by itself. Only plugins that list "ping" in their capabilities are
+// watched, as others might never answer it. Callers must hold p.mu.
+func (p *Plugin) startWatchdogLocked() {
+	p.stopWatchdogLocked()
+	if !slices.Contains(p.capabilities, "ping") {
+		return
+	}
+	p.stopWatchdog = make(chan struct{})
+	go p.watch(p.stopWatchdog, PingInterval)
+}
+
+// stopWatchdogLocked stops the watchdog, if running. Callers must hold p.mu.
+func (p *Plugin) stopWatchdogLocked() {
+	if p.stopWatchdog != nil {
+		close(p.stopWatchdog)
+		p.stopWatchdog = nil
+	}
+}
+
+// watch pings the plugin until stop is closed, and restarts it if a ping
+// fails. Pings are skipped while a request is in flight, as the plugin could
+// not answer before it finishes.
+func (p *Plugin) watch(stop <-chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		if !p.mu.TryLock() {
+			continue
+		}
+		select {
+		case <-stop:
+			// Stopped while the lock was free, e.g. between requests of Close
+			p.mu.Unlock()
+			return
+		default:
+		}
+		if !p.running {
+			if p.cpuTimedOut {
+				// Killed by the CPU time limit; restarting starts a new watchdog
+				p.cpuTimedOut = false
+				p.restartLocked()
+				p.mu.Unlock()
+				return
+			}
+			// The process exited; the next request starts it again
+			p.mu.Unlock()
+			continue
+		}
+		if err := p.pingLocked(); err != nil {
+			if p.logger != nil {
+				p.logger.Warn("IPC plugin is unresponsive", "name", p.name, "error", err)
+			}
+			// Restarting starts a new watchdog
+			p.restartLocked()
+			p.mu.Unlock()
+			return
+		}
+		p.mu.Unlock()
+	}
+}
+
+// restartLocked replaces the plugin's process with a new one and, if the
+// plugin had been initialized, initializes it again with the same args.
+// After maxRestarts the plugin is left stopped, and started again by the
+// next request. A plugin whose initialize showed a dialog is left stopped
+// instead, as only the user can initialize it again; requests fail until
+// they do. Callers must hold p.mu.
+func (p *Plugin) restartLocked() {
+	p.killLocked()
+
+	if p.initialized && p.interactive {
+		p.initialized = false
+		p.restartErr = fmt.Errorf("plugin %s stopped responding and must be initialized again", p.name)
+		if p.logger != nil {
+			p.logger.Error("IPC plugin stopped responding, leaving it stopped", "name", p.name)
+		}
+		return
+	}
+
+	if p.restarts >= maxRestarts {
+		if p.logger != nil {
+			p.logger.Error("IPC plugin restarted too often, leaving it stopped", "name", p.name, "restarts", maxRestarts)
+		}
+		return
+	}
+	p.restarts++
+
+	if p.logger != nil {
+		p.logger.Info("Restarting IPC plugin", "name", p.name, "attempt", p.restarts)
+	}
+	if err := p.startLocked(); err != nil {
+		if p.logger != nil {
+			p.logger.Error("Failed to restart IPC plugin", "name", p.name, "error", err)
+		}
+		return
+	}
+	if p.initialized {
+		if _, err := p.sendLockedRequest(Request{Method: "initialize", Args: p.initArgs}); err != nil && p.logger != nil {
+			p.logger.Error("Failed to initialize restarted IPC plugin", "name", p.name, "error", err)
+		}
+	}
+}
+
+// killLocked stops the process without asking it to quit, for a plugin that
+// is hung or whose replies are out of step with the requests. The next
+// request starts a new process. Callers must hold p.mu.
+func (p *Plugin) killLocked() {
+	p.stopWatchdogLocked()
+	if p.stdin != nil {
+		p.stdin.Close()
+	}
+	if p.cmd != nil && p.cmd.Process != nil {
+		p.cmd.Process.Kill()
+		p.cmd.Wait()
+	}
+	p.running = false
+}
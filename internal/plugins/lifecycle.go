@@ -0,0 +1,28 @@
+package plugins
+
+// Plugin lifecycle events passed to Manager.Subscribe handlers.
+const (
+	EventRegistered = "registered" // Register added the plugin
+	EventActivated  = "activated"  // SetActive made the plugin active
+	EventClosed     = "closed"     // Close or Unregister closed the plugin
+	EventEnabled    = "enabled"    // SetEnabled enabled the plugin
+	EventDisabled   = "disabled"   // SetEnabled disabled the plugin
+)
+
+// Subscribe registers fn to be called with the plugin's name on each event,
+// one of the Event constants. Handlers run on the goroutine that caused the
+// event, after the manager has released its lock, so they may call the
+// manager. The returned function cancels the subscription and may be called
+// more than once.
+func (m *Manager) Subscribe(event string, fn func(name string)) (unsubscribe func()) {
+	// The events are the manager's, not any one plugin's
+	return m.lifecycle.Subscribe("", event, func(data interface{}) {
+		fn(data.(string))
+	})
+}
+
+// emitLifecycle calls the handlers subscribed to event, in the order they
+// subscribed. Callers must not hold m.mu.
+func (m *Manager) emitLifecycle(event, name string) {
+	m.lifecycle.Emit("", event, name)
+}
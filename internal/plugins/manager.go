@@ -2,12 +2,29 @@
 package plugins
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
 	"sync"
 
 	"olicanaplot/internal/logging"
 )
 
+// DefaultMaxPlugins is the default limit on registered external plugins.
+const DefaultMaxPlugins = 50
+
+// ErrTooManyPlugins is returned by Register when an external plugin would
+// exceed the manager's limit.
+var ErrTooManyPlugins = errors.New("too many plugins")
+
+// ErrPluginDisabled is returned by SetActive for a plugin the user disabled.
+var ErrPluginDisabled = errors.New("plugin is disabled")
+
+// ErrReadOnly is returned by the mutating methods of a Manager made by Clone.
+var ErrReadOnly = errors.New("plugin manager is a read-only snapshot")
+
 // pluginEntry wraps a plugin with its metadata and state.
 type pluginEntry struct {
 	plugin   Plugin
@@ -17,23 +34,137 @@ type pluginEntry struct {
 
 // Manager handles registration and lookup of plugins.
 type Manager struct {
-	mu           sync.RWMutex
-	plugins      map[string]pluginEntry
-	activePlugin string         // Currently active plugin name
-	logger       logging.Logger // Structured logger
+	mu                sync.RWMutex
+	plugins           map[string]pluginEntry
+	registrationOrder []string       // Plugin names in registration order
+	activePlugin      string         // Currently active plugin name
+	logger            logging.Logger // Structured logger
+	maxPlugins        int            // Limit on external plugins, <= 0 for none
+	readOnly          bool           // A snapshot made by Clone
+
+	// seriesOverrides holds runtime patches to the active plugin's series,
+	// keyed by series ID. They are dropped whenever the active plugin changes.
+	seriesOverrides map[string]SeriesConfig
+
+	// configCache holds encoded /api/chart_config and /api/series_config
+	// responses of the active plugin, and metadataCache the metadata computed
+	// from its series' data. configGen counts invalidations of both.
+	configCache   map[string]configCacheEntry
+	metadataCache map[string]SeriesMetadata
+	configGen     uint64
+
+	// seriesCache holds the active plugin's series configs as served by
+	// /api/series_config, so that its pages are sliced from one
+	// GetSeriesConfig call. It is invalidated with configCache.
+	seriesCache map[string][]SeriesConfig
+
+	// events carries events emitted by plugins implementing EventEmitter
+	events *PluginEventBus
+
+	// lifecycle carries the Manager's own events to Subscribe handlers
+	lifecycle *PluginEventBus
 }
 
-// NewManager creates a new plugin manager.
-func NewManager(logger logging.Logger) *Manager {
-	return &Manager{
-		plugins: make(map[string]pluginEntry),
-		logger:  logger,
+// ManagerOption configures a Manager created by NewManager.
+type ManagerOption func(*Manager)
+
+// WithMaxPlugins limits how many external plugins can be registered, so a
+// search directory full of executables cannot exhaust memory and file
+// descriptors. n <= 0 removes the limit.
+func WithMaxPlugins(n int) ManagerOption {
+	return func(m *Manager) {
+		m.maxPlugins = n
+	}
+}
+
+// NewManager creates a new plugin manager. External plugins are limited to
+// DefaultMaxPlugins unless WithMaxPlugins is given.
+func NewManager(logger logging.Logger, opts ...ManagerOption) *Manager {
+	m := &Manager{
+		plugins:         make(map[string]pluginEntry),
+		logger:          logger,
+		maxPlugins:      DefaultMaxPlugins,
+		seriesOverrides: make(map[string]SeriesConfig),
+		configCache:     make(map[string]configCacheEntry),
+		metadataCache:   make(map[string]SeriesMetadata),
+		seriesCache:     make(map[string][]SeriesConfig),
+		events:          NewPluginEventBus(),
+		lifecycle:       NewPluginEventBus(),
+	}
+	for _, opt := range opts {
+		opt(m)
 	}
+	return m
+}
+
+// Clone returns a read-only snapshot of the registered plugins, their
+// enabled state and the active plugin, for inspecting them without holding
+// m's lock. The snapshot shares the plugins themselves with m, so its
+// Register, Unregister, SetActive, SetEnabled, PatchSeries, RefreshActive and
+// Close fail with ErrReadOnly rather than change them.
+func (m *Manager) Clone() *Manager {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c := NewManager(m.logger, WithMaxPlugins(m.maxPlugins))
+	c.readOnly = true
+	c.events = m.events
+	maps.Copy(c.plugins, m.plugins)
+	c.registrationOrder = slices.Clone(m.registrationOrder)
+	c.activePlugin = m.activePlugin
+	maps.Copy(c.seriesOverrides, m.seriesOverrides)
+	return c
+}
+
+// Events returns the bus on which registered plugins emit events.
+func (m *Manager) Events() *PluginEventBus {
+	return m.events
+}
+
+// GetMaxPlugins returns the limit on registered external plugins, <= 0 if
+// there is none.
+func (m *Manager) GetMaxPlugins() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.maxPlugins
+}
+
+// SetMaxPlugins changes the limit on registered external plugins. Plugins
+// already registered are kept. n <= 0 removes the limit.
+func (m *Manager) SetMaxPlugins(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxPlugins = n
+}
+
+// externalCount returns the number of registered external plugins. Callers
+// must hold m.mu.
+func (m *Manager) externalCount() int {
+	n := 0
+	for _, entry := range m.plugins {
+		if !entry.internal {
+			n++
+		}
+	}
+	return n
 }
 
 // Register adds a plugin to the manager.
-// Returns an error if a plugin with the same name already exists.
+// Returns an error if a plugin with the same name already exists, or
+// ErrTooManyPlugins if an external plugin would exceed the limit. Subscribers
+// to EventRegistered are notified.
 func (m *Manager) Register(p Plugin, isInternal bool) error {
+	if m.readOnly {
+		return ErrReadOnly
+	}
+	if err := m.register(p, isInternal); err != nil {
+		return err
+	}
+	m.emitLifecycle(EventRegistered, p.Name())
+	return nil
+}
+
+func (m *Manager) register(p Plugin, isInternal bool) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -48,13 +179,26 @@ func (m *Manager) Register(p Plugin, isInternal bool) error {
 			name, p.Version(), PluginAPIVersion)
 	}
 
+	if !isInternal && m.maxPlugins > 0 && m.externalCount() >= m.maxPlugins {
+		return fmt.Errorf("cannot register %s: %w (limit %d)", name, ErrTooManyPlugins, m.maxPlugins)
+	}
+
 	m.plugins[name] = pluginEntry{
 		plugin:   p,
 		internal: isInternal,
 		enabled:  true, // Default to enabled
 	}
+	m.registrationOrder = append(m.registrationOrder, name)
+	m.invalidateConfigCacheLocked() // The chart config lists all plugins
 	m.logger.Info("Registered plugin", "name", name, "version", p.Version(), "internal", isInternal)
 
+	if rn, ok := p.(RefreshNotifier); ok {
+		rn.OnRefresh(m.InvalidateConfigCache)
+	}
+	if ee, ok := p.(EventEmitter); ok {
+		ee.SetEventBus(m.events)
+	}
+
 	// Set as active if it's the first plugin
 	if m.activePlugin == "" {
 		m.activePlugin = name
@@ -63,6 +207,43 @@ func (m *Manager) Register(p Plugin, isInternal bool) error {
 	return nil
 }
 
+// Unregister closes a plugin and removes it from the manager. If it was the
+// active plugin, no plugin is active afterwards. Internal plugins cannot be
+// unregistered.
+func (m *Manager) Unregister(name string) error {
+	if m.readOnly {
+		return ErrReadOnly
+	}
+	m.mu.Lock()
+	entry, exists := m.plugins[name]
+	if !exists {
+		m.mu.Unlock()
+		return fmt.Errorf("plugin not found: %s", name)
+	}
+	if entry.internal {
+		m.mu.Unlock()
+		return fmt.Errorf("cannot unregister internal plugin: %s", name)
+	}
+	delete(m.plugins, name)
+	m.registrationOrder = slices.DeleteFunc(m.registrationOrder, func(n string) bool { return n == name })
+	m.invalidateConfigCacheLocked()
+	if m.activePlugin == name {
+		m.activePlugin = ""
+		clear(m.seriesOverrides)
+	}
+	m.mu.Unlock()
+
+	m.logger.Info("Unregistered plugin", "name", name)
+
+	// Close outside the lock, IPC plugins may take a while to shut down
+	err := entry.plugin.Close()
+	m.emitLifecycle(EventClosed, name)
+	if err != nil {
+		return fmt.Errorf("error closing plugin %s: %w", name, err)
+	}
+	return nil
+}
+
 // Get returns a plugin by name, or nil if not found.
 func (m *Manager) Get(name string) Plugin {
 	m.mu.RLock()
@@ -73,28 +254,97 @@ func (m *Manager) Get(name string) Plugin {
 	return nil
 }
 
-// GetActive returns the currently active plugin.
+// GetPluginByPath returns the plugin whose executable is execPath, or nil if
+// there is none. Paths are compared after resolving symlinks, so a link to a
+// plugin finds it as well; internal plugins have no path and never match.
+func (m *Manager) GetPluginByPath(execPath string) Plugin {
+	if execPath == "" {
+		return nil
+	}
+	want := canonicalPath(execPath)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, name := range m.registrationOrder {
+		entry := m.plugins[name]
+		if p := entry.plugin.Path(); p != "" && canonicalPath(p) == want {
+			return entry.plugin
+		}
+	}
+	return nil
+}
+
+// canonicalPath returns path made absolute with its symlinks resolved, or
+// just made absolute if it cannot be resolved.
+func canonicalPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// GetActive returns the currently active plugin, or a NullPlugin if there is
+// none. It never returns nil.
 func (m *Manager) GetActive() Plugin {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	if entry, ok := m.plugins[m.activePlugin]; ok {
 		return entry.plugin
 	}
-	return nil
+	return NullPlugin{}
 }
 
-// SetActive sets the active plugin by name.
+// SetActive sets the active plugin by name. It returns ErrPluginDisabled if
+// the plugin is disabled. The previously active plugin is deactivated if it
+// implements Deactivator, then subscribers to EventActivated are notified.
 func (m *Manager) SetActive(name string) error {
+	if m.readOnly {
+		return ErrReadOnly
+	}
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	if _, exists := m.plugins[name]; !exists {
+	entry, exists := m.plugins[name]
+	if !exists {
+		m.mu.Unlock()
 		return fmt.Errorf("plugin not found: %s", name)
 	}
+	if !entry.enabled {
+		m.mu.Unlock()
+		return fmt.Errorf("cannot activate %s: %w", name, ErrPluginDisabled)
+	}
+	var previous Plugin
+	if prev, ok := m.plugins[m.activePlugin]; ok && m.activePlugin != name {
+		previous = prev.plugin
+	}
 	m.activePlugin = name
+	clear(m.seriesOverrides)
+	m.invalidateConfigCacheLocked()
+	m.mu.Unlock()
+
+	if d, ok := previous.(Deactivator); ok {
+		d.Deactivate()
+	}
+	m.emitLifecycle(EventActivated, name)
 	return nil
 }
 
+// RefreshActive makes the active plugin re-read its data source. It fails if
+// the plugin does not implement Refresher.
+func (m *Manager) RefreshActive() error {
+	if m.readOnly {
+		return ErrReadOnly
+	}
+	active := m.GetActive()
+	refresher, ok := active.(Refresher)
+	if !ok {
+		return fmt.Errorf("plugin %s does not support refreshing", active.Name())
+	}
+	defer m.InvalidateConfigCache()
+	return refresher.Refresh()
+}
+
 // ActiveName returns the name of the active plugin.
 func (m *Manager) ActiveName() string {
 	m.mu.RLock()
@@ -102,20 +352,114 @@ func (m *Manager) ActiveName() string {
 	return m.activePlugin
 }
 
-// ListMetadata returns metadata for all registered plugins.
+// PatchSeries records runtime overrides for the active plugin's series. Only
+// the set fields of each patch are applied, on top of any earlier patch for
+// the same series.
+func (m *Manager) PatchSeries(patches []SeriesConfig) error {
+	if m.readOnly {
+		return ErrReadOnly
+	}
+	for _, p := range patches {
+		if p.ID == "" {
+			return fmt.Errorf("series patch is missing an id")
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, p := range patches {
+		m.seriesOverrides[p.ID] = mergeSeries(m.seriesOverrides[p.ID], p)
+	}
+	m.invalidateConfigCacheLocked()
+	return nil
+}
+
+// ApplySeriesOverrides merges the recorded overrides into series in place.
+func (m *Manager) ApplySeriesOverrides(series []SeriesConfig) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for i := range series {
+		if o, ok := m.seriesOverrides[series[i].ID]; ok {
+			series[i] = mergeSeries(series[i], o)
+		}
+	}
+}
+
+// mergeSeries returns base with every set field of patch copied over it.
+func mergeSeries(base, patch SeriesConfig) SeriesConfig {
+	base.ID = patch.ID
+	if patch.Name != "" {
+		base.Name = patch.Name
+	}
+	if patch.Color != "" {
+		base.Color = patch.Color
+	}
+	if patch.Subplot != nil {
+		base.Subplot = patch.Subplot
+	}
+	if patch.LineType != "" {
+		base.LineType = patch.LineType
+	}
+	if patch.LineWidth != nil {
+		base.LineWidth = patch.LineWidth
+	}
+	if patch.MarkerType != "" {
+		base.MarkerType = patch.MarkerType
+	}
+	if patch.MarkerSize != nil {
+		base.MarkerSize = patch.MarkerSize
+	}
+	if patch.MarkerFill != "" {
+		base.MarkerFill = patch.MarkerFill
+	}
+	if patch.Unit != "" {
+		base.Unit = patch.Unit
+	}
+	if patch.Visible != nil {
+		base.Visible = patch.Visible
+	}
+	if patch.YAxis != "" {
+		base.YAxis = patch.YAxis
+	}
+	if patch.Opacity != 0 {
+		base.Opacity = patch.Opacity
+	}
+	if patch.Description != "" {
+		base.Description = patch.Description
+	}
+	if patch.ColorScaleColumn != "" {
+		base.ColorScaleColumn = patch.ColorScaleColumn
+	}
+	if patch.LoadPriority != 0 {
+		base.LoadPriority = patch.LoadPriority
+	}
+	if patch.RefreshIntervalMs != 0 {
+		base.RefreshIntervalMs = patch.RefreshIntervalMs
+	}
+	return base
+}
+
+// ListMetadata returns metadata for all registered plugins in registration
+// order.
 func (m *Manager) ListMetadata() []PluginMetadata {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	result := make([]PluginMetadata, 0, len(m.plugins))
-	for name, entry := range m.plugins {
-		result = append(result, PluginMetadata{
+	for _, name := range m.registrationOrder {
+		entry := m.plugins[name]
+		meta := PluginMetadata{
 			Name:         name,
 			Path:         entry.plugin.Path(),
 			FilePatterns: entry.plugin.GetFilePatterns(),
 			IsInternal:   entry.internal,
 			Enabled:      entry.enabled,
-		})
+		}
+		if informer, ok := entry.plugin.(Informer); ok {
+			info := informer.GetInfo()
+			meta.Info = &info
+		}
+		result = append(result, meta)
 	}
 	return result
 }
@@ -132,17 +476,35 @@ func (m *Manager) List() []string {
 	return names
 }
 
-// SetEnabled sets the enabled status of a plugin.
+// ListByRegistrationOrder returns the names of all registered plugins in the
+// order they were registered.
+func (m *Manager) ListByRegistrationOrder() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return slices.Clone(m.registrationOrder)
+}
+
+// SetEnabled sets the enabled status of a plugin. Subscribers to
+// EventEnabled or EventDisabled are notified.
 func (m *Manager) SetEnabled(name string, enabled bool) error {
+	if m.readOnly {
+		return ErrReadOnly
+	}
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	entry, exists := m.plugins[name]
 	if !exists {
+		m.mu.Unlock()
 		return fmt.Errorf("plugin not found: %s", name)
 	}
 	entry.enabled = enabled
 	m.plugins[name] = entry
+	m.mu.Unlock()
+
+	if enabled {
+		m.emitLifecycle(EventEnabled, name)
+	} else {
+		m.emitLifecycle(EventDisabled, name)
+	}
 	return nil
 }
 
@@ -183,16 +545,24 @@ func (m *Manager) GetAllFilePatterns() []FilePatternWithPlugin {
 	return allPatterns
 }
 
-// Close shuts down all plugins.
+// Close shuts down all plugins, notifying subscribers to EventClosed of each.
 func (m *Manager) Close() error {
+	if m.readOnly {
+		return ErrReadOnly
+	}
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	var firstErr error
-	for _, entry := range m.plugins {
+	var closed []string
+	for name, entry := range m.plugins {
 		if err := entry.plugin.Close(); err != nil && firstErr == nil {
 			firstErr = fmt.Errorf("error closing plugin: %w", err)
 		}
+		closed = append(closed, name)
+	}
+	m.mu.Unlock()
+
+	for _, name := range closed {
+		m.emitLifecycle(EventClosed, name)
 	}
 	return firstErr
 }
@@ -0,0 +1,455 @@
+package plugins
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"olicanaplot/internal/logging"
+)
+
+// namedPlugin is a seriesPlugin with a configurable name that records Close.
+type namedPlugin struct {
+	seriesPlugin
+	name   string
+	closed bool
+}
+
+func (p *namedPlugin) Name() string { return p.name }
+func (p *namedPlugin) Close() error { p.closed = true; return nil }
+
+// watchingPlugin counts its deactivations.
+type watchingPlugin struct {
+	namedPlugin
+	deactivated int
+}
+
+func (p *watchingPlugin) Deactivate() { p.deactivated++ }
+
+func TestSetActiveDeactivatesPrevious(t *testing.T) {
+	m := NewManager(logging.NewLogger("Test"))
+	watcher := &watchingPlugin{namedPlugin: namedPlugin{name: "CSV"}}
+	other := &namedPlugin{name: "Other"}
+	m.Register(watcher, true)
+	m.Register(other, true)
+
+	m.SetActive("CSV")
+	m.SetActive("CSV")
+	if watcher.deactivated != 0 {
+		t.Errorf("deactivated %d times by activating it", watcher.deactivated)
+	}
+	m.SetActive("Other")
+	if watcher.deactivated != 1 || watcher.closed {
+		t.Errorf("deactivated = %d, closed = %v, want deactivated once and not closed", watcher.deactivated, watcher.closed)
+	}
+}
+
+func TestUnregister(t *testing.T) {
+	m := NewManager(logging.NewLogger("Test"))
+	internal := &namedPlugin{name: "Internal"}
+	external := &namedPlugin{name: "External"}
+	if err := m.Register(internal, true); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := m.Register(external, false); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := m.SetActive("External"); err != nil {
+		t.Fatalf("SetActive failed: %v", err)
+	}
+
+	if err := m.Unregister("External"); err != nil {
+		t.Fatalf("Unregister failed: %v", err)
+	}
+	if m.Get("External") != nil {
+		t.Error("External is still registered")
+	}
+	if !external.closed {
+		t.Error("External was not closed")
+	}
+	if m.ActiveName() != "" || m.GetActive() != (NullPlugin{}) {
+		t.Errorf("active plugin = %q, want none", m.ActiveName())
+	}
+
+	// The next registered plugin becomes active again
+	if err := m.Register(&namedPlugin{name: "Replacement"}, false); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if m.ActiveName() != "Replacement" {
+		t.Errorf("active plugin = %q, want Replacement", m.ActiveName())
+	}
+
+	if err := m.Unregister("Internal"); err == nil {
+		t.Error("expected error unregistering an internal plugin")
+	}
+	if m.Get("Internal") == nil || internal.closed {
+		t.Error("internal plugin should be untouched")
+	}
+	if err := m.Unregister("Missing"); err == nil {
+		t.Error("expected error unregistering an unknown plugin")
+	}
+}
+
+func TestSeriesOverrides(t *testing.T) {
+	m := NewManager(logging.NewLogger("Test"))
+	m.Register(&namedPlugin{name: "A"}, true)
+	m.Register(&namedPlugin{name: "B"}, true)
+
+	w := 4.0
+	if err := m.PatchSeries([]SeriesConfig{{ID: "s1", Color: "#ff0000"}}); err != nil {
+		t.Fatalf("PatchSeries failed: %v", err)
+	}
+	if err := m.PatchSeries([]SeriesConfig{{ID: "s1", LineWidth: &w}}); err != nil {
+		t.Fatalf("PatchSeries failed: %v", err)
+	}
+	if err := m.PatchSeries([]SeriesConfig{{ID: "s1", LoadPriority: 5, RefreshIntervalMs: 250}}); err != nil {
+		t.Fatalf("PatchSeries failed: %v", err)
+	}
+	if err := m.PatchSeries([]SeriesConfig{{Color: "#00ff00"}}); err == nil {
+		t.Error("expected error for patch without id")
+	}
+
+	series := []SeriesConfig{{ID: "s1", Name: "One", Color: "#0000ff"}, {ID: "s2", Name: "Two"}}
+	m.ApplySeriesOverrides(series)
+	if s := series[0]; s.Name != "One" || s.Color != "#ff0000" || s.LineWidth == nil || *s.LineWidth != 4 {
+		t.Errorf("s1 = %+v, want name kept, color and width patched", s)
+	}
+	if s := series[0]; s.LoadPriority != 5 || s.RefreshIntervalMs != 250 {
+		t.Errorf("s1 = %+v, want load priority and refresh interval patched", s)
+	}
+	if series[1].Color != "" {
+		t.Errorf("s2 color = %q, want untouched", series[1].Color)
+	}
+
+	// Activating a plugin drops the overrides
+	m.SetActive("B")
+	series = []SeriesConfig{{ID: "s1", Color: "#0000ff"}}
+	m.ApplySeriesOverrides(series)
+	if series[0].Color != "#0000ff" {
+		t.Errorf("color = %q, want overrides cleared on activation", series[0].Color)
+	}
+}
+
+func TestListMetadataRegistrationOrder(t *testing.T) {
+	m := NewManager(logging.NewLogger("Test"))
+	names := []string{"Zeta", "Alpha", "Mu"}
+	for _, name := range names {
+		if err := m.Register(&namedPlugin{name: name}, false); err != nil {
+			t.Fatalf("Register failed: %v", err)
+		}
+	}
+
+	// Map iteration order is random, so check several times
+	for i := 0; i < 10; i++ {
+		meta := m.ListMetadata()
+		if len(meta) != len(names) {
+			t.Fatalf("got %d plugins, want %d", len(meta), len(names))
+		}
+		for j, md := range meta {
+			if md.Name != names[j] {
+				t.Fatalf("metadata[%d] = %s, want %s", j, md.Name, names[j])
+			}
+		}
+	}
+
+	m.Unregister("Alpha")
+	if got := m.ListByRegistrationOrder(); len(got) != 2 || got[0] != "Zeta" || got[1] != "Mu" {
+		t.Errorf("ListByRegistrationOrder() = %v, want [Zeta Mu]", got)
+	}
+}
+
+func TestMaxPlugins(t *testing.T) {
+	m := NewManager(logging.NewLogger("Test"))
+	if m.GetMaxPlugins() != DefaultMaxPlugins {
+		t.Fatalf("GetMaxPlugins() = %d, want %d", m.GetMaxPlugins(), DefaultMaxPlugins)
+	}
+
+	// Internal plugins don't count towards the limit
+	if err := m.Register(&namedPlugin{name: "Internal"}, true); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	for i := 0; i < 51; i++ {
+		err := m.Register(&namedPlugin{name: fmt.Sprintf("External %d", i)}, false)
+		if i < DefaultMaxPlugins && err != nil {
+			t.Fatalf("Register %d failed: %v", i, err)
+		}
+		if i == DefaultMaxPlugins && !errors.Is(err, ErrTooManyPlugins) {
+			t.Fatalf("Register %d: err = %v, want ErrTooManyPlugins", i, err)
+		}
+	}
+	if len(m.List()) != DefaultMaxPlugins+1 {
+		t.Errorf("%d plugins registered, want %d", len(m.List()), DefaultMaxPlugins+1)
+	}
+
+	m.SetMaxPlugins(0)
+	if err := m.Register(&namedPlugin{name: "Unlimited"}, false); err != nil {
+		t.Errorf("Register without a limit failed: %v", err)
+	}
+
+	m = NewManager(logging.NewLogger("Test"), WithMaxPlugins(1))
+	m.Register(&namedPlugin{name: "First"}, false)
+	if err := m.Register(&namedPlugin{name: "Second"}, false); !errors.Is(err, ErrTooManyPlugins) {
+		t.Errorf("err = %v, want ErrTooManyPlugins", err)
+	}
+}
+
+// capablePlugin declares its capabilities.
+type capablePlugin struct {
+	namedPlugin
+	caps []string
+}
+
+func (p *capablePlugin) Capabilities() []string { return p.caps }
+
+func TestGetPluginCapabilities(t *testing.T) {
+	m := NewManager(logging.NewLogger("Test"))
+	m.Register(&namedPlugin{name: "Built-in"}, true)
+	m.Register(&capablePlugin{namedPlugin: namedPlugin{name: "Streamer"}, caps: []string{"get_series_data", "stream_series_data"}}, false)
+	m.Register(&capablePlugin{namedPlugin: namedPlugin{name: "Legacy"}}, false)
+	s := NewService(m, nil, logging.NewLogger("Test"))
+
+	for _, tt := range []struct {
+		name string
+		want []string
+	}{
+		{"Built-in", BaseCapabilities()},
+		{"Streamer", []string{"get_series_data", "stream_series_data"}},
+		{"Legacy", BaseCapabilities()},
+	} {
+		caps, err := s.GetPluginCapabilities(tt.name)
+		if err != nil {
+			t.Fatalf("%s: GetPluginCapabilities failed: %v", tt.name, err)
+		}
+		if fmt.Sprint(caps) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: capabilities = %v, want %v", tt.name, caps, tt.want)
+		}
+	}
+
+	if _, err := s.GetPluginCapabilities("Missing"); err == nil {
+		t.Error("expected error for an unknown plugin")
+	}
+}
+
+func TestGetActiveWithoutPlugin(t *testing.T) {
+	m := NewManager(logging.NewLogger("Test"))
+	active := m.GetActive()
+	if active == nil {
+		t.Fatal("GetActive returned nil")
+	}
+	if _, err := active.GetChartConfig(""); !errors.Is(err, ErrNoActivePlugin) {
+		t.Errorf("GetChartConfig error = %v, want %v", err, ErrNoActivePlugin)
+	}
+	if err := m.RefreshActive(); !errors.Is(err, ErrNoActivePlugin) {
+		t.Errorf("RefreshActive error = %v, want %v", err, ErrNoActivePlugin)
+	}
+}
+
+func TestSetActiveDisabledPlugin(t *testing.T) {
+	m := NewManager(logging.NewLogger("Test"))
+	m.Register(&namedPlugin{name: "A"}, true)
+	m.Register(&namedPlugin{name: "B"}, false)
+	m.SetActive("A")
+	m.SetEnabled("B", false)
+
+	if err := m.SetActive("B"); !errors.Is(err, ErrPluginDisabled) {
+		t.Errorf("SetActive error = %v, want ErrPluginDisabled", err)
+	}
+	if m.ActiveName() != "A" {
+		t.Errorf("active plugin = %q, want A to stay active", m.ActiveName())
+	}
+
+	s := NewService(m, nil, logging.NewLogger("Test"))
+	if err := s.ActivatePlugin("B", ""); !errors.Is(err, ErrPluginDisabled) {
+		t.Errorf("ActivatePlugin error = %v, want ErrPluginDisabled", err)
+	}
+	if m.Get("A").(*namedPlugin).closed {
+		t.Error("the active plugin was closed for a disabled one")
+	}
+
+	m.SetEnabled("B", true)
+	if err := m.SetActive("B"); err != nil {
+		t.Errorf("SetActive failed after enabling: %v", err)
+	}
+}
+
+// pathPlugin is a namedPlugin with an executable path.
+type pathPlugin struct {
+	namedPlugin
+	path string
+}
+
+func (p *pathPlugin) Path() string { return p.path }
+
+func TestClone(t *testing.T) {
+	m := NewManager(logging.NewLogger("Test"))
+	first := &namedPlugin{name: "First"}
+	m.Register(first, false)
+	m.Register(&namedPlugin{name: "Second"}, false)
+	m.SetEnabled("Second", false)
+	m.PatchSeries([]SeriesConfig{{ID: "s1", Name: "Renamed"}})
+
+	c := m.Clone()
+	if c.ActiveName() != "First" || c.Get("First") != first {
+		t.Errorf("clone has active plugin %q, want First", c.ActiveName())
+	}
+	if got := c.ListByRegistrationOrder(); len(got) != 2 || got[0] != "First" || got[1] != "Second" {
+		t.Errorf("clone lists %v, want [First Second]", got)
+	}
+	if c.IsEnabled("Second") {
+		t.Error("Second is enabled in the clone")
+	}
+	series := []SeriesConfig{{ID: "s1"}}
+	c.ApplySeriesOverrides(series)
+	if series[0].Name != "Renamed" {
+		t.Errorf("clone series name = %q, want Renamed", series[0].Name)
+	}
+
+	// Mutations of the clone fail and leave the original alone
+	for name, err := range map[string]error{
+		"Register":    c.Register(&namedPlugin{name: "Third"}, false),
+		"Unregister":  c.Unregister("First"),
+		"SetActive":   c.SetActive("Second"),
+		"SetEnabled":  c.SetEnabled("Second", true),
+		"PatchSeries": c.PatchSeries([]SeriesConfig{{ID: "s2"}}),
+		"Close":       c.Close(),
+	} {
+		if !errors.Is(err, ErrReadOnly) {
+			t.Errorf("%s error = %v, want ErrReadOnly", name, err)
+		}
+	}
+	if first.closed {
+		t.Error("closing the clone closed the plugin")
+	}
+	if m.IsEnabled("Second") || len(m.List()) != 2 {
+		t.Error("original manager was changed through the clone")
+	}
+
+	// Later changes to the original don't show in the clone
+	m.Register(&namedPlugin{name: "Third"}, false)
+	m.SetEnabled("Second", true)
+	if len(c.List()) != 2 || c.IsEnabled("Second") {
+		t.Error("clone changed with the original")
+	}
+}
+
+func TestGetPluginByPath(t *testing.T) {
+	dir := t.TempDir()
+	pathA := filepath.Join(dir, "reader_a")
+	pathB := filepath.Join(dir, "reader_b")
+	for _, path := range []string{pathA, pathB} {
+		if err := os.WriteFile(path, nil, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := NewManager(logging.NewLogger("Test"))
+	m.Register(&namedPlugin{name: "Internal"}, true)
+	m.Register(&pathPlugin{namedPlugin: namedPlugin{name: "A"}, path: pathA}, false)
+	m.Register(&pathPlugin{namedPlugin: namedPlugin{name: "B"}, path: pathB}, false)
+
+	if p := m.GetPluginByPath(pathA); p == nil || p.Name() != "A" {
+		t.Errorf("GetPluginByPath(%s) = %v, want A", pathA, p)
+	}
+	if p := m.GetPluginByPath(pathB); p == nil || p.Name() != "B" {
+		t.Errorf("GetPluginByPath(%s) = %v, want B", pathB, p)
+	}
+
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(pathB, link); err == nil {
+		if p := m.GetPluginByPath(link); p == nil || p.Name() != "B" {
+			t.Errorf("GetPluginByPath(link) = %v, want B", p)
+		}
+	}
+
+	if p := m.GetPluginByPath(filepath.Join(dir, "missing")); p != nil {
+		t.Errorf("GetPluginByPath(missing) = %s, want nil", p.Name())
+	}
+	if p := m.GetPluginByPath(""); p != nil {
+		t.Errorf("GetPluginByPath(\"\") = %s, want nil", p.Name())
+	}
+
+	s := NewService(m, nil, logging.NewLogger("Test"))
+	if err := s.ActivatePluginByPath(pathB, ""); err != nil {
+		t.Fatalf("ActivatePluginByPath failed: %v", err)
+	}
+	if m.ActiveName() != "B" {
+		t.Errorf("active plugin = %q, want B", m.ActiveName())
+	}
+	if err := s.ActivatePluginByPath(filepath.Join(dir, "missing"), ""); err == nil {
+		t.Error("expected error for an unknown path")
+	}
+}
+
+func TestSubscribeLifecycle(t *testing.T) {
+	m := NewManager(logging.NewLogger("Test"))
+	var events []string
+	record := func(event string) func(string) {
+		return func(name string) { events = append(events, event+" "+name) }
+	}
+	for _, event := range []string{EventRegistered, EventActivated, EventDisabled, EventEnabled, EventClosed} {
+		m.Subscribe(event, record(event))
+	}
+
+	m.Register(&namedPlugin{name: "A"}, false)
+	m.Register(&namedPlugin{name: "B"}, false)
+	m.SetActive("B")
+	m.SetEnabled("A", false)
+	m.SetActive("A") // Fails, so no event
+	m.SetEnabled("A", true)
+	m.Unregister("A")
+
+	want := "registered A,registered B,activated B,disabled A,enabled A,closed A"
+	if got := strings.Join(events, ","); got != want {
+		t.Errorf("events = %s, want %s", got, want)
+	}
+}
+
+func TestSubscribeConcurrent(t *testing.T) {
+	m := NewManager(logging.NewLogger("Test"))
+	if err := m.Register(&namedPlugin{name: "A"}, false); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	// A subscription held throughout sees every event
+	var count atomic.Int64
+	m.Subscribe(EventActivated, func(string) { count.Add(1) })
+
+	const workers, iterations = 8, 200
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for range iterations {
+				m.SetActive("A")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range iterations {
+				unsubscribe := m.Subscribe(EventActivated, func(string) {})
+				unsubscribe()
+				unsubscribe() // Repeated calls are harmless
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := count.Load(); got != workers*iterations {
+		t.Errorf("handler called %d times, want %d", got, workers*iterations)
+	}
+
+	// Unsubscribed handlers are not called
+	called := false
+	unsubscribe := m.Subscribe(EventActivated, func(string) { called = true })
+	unsubscribe()
+	m.SetActive("A")
+	if called {
+		t.Error("handler called after unsubscribing")
+	}
+}
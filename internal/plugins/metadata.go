@@ -0,0 +1,71 @@
+package plugins
+
+import (
+	"fmt"
+	"math"
+)
+
+// SeriesMetadata returns the point count and ranges of a series of the
+// active plugin. Plugins implementing MetadataProvider are asked for it;
+// for other plugins the series' data is loaded and the result cached until
+// the config cache is invalidated.
+func (m *Manager) SeriesMetadata(seriesID string) (SeriesMetadata, error) {
+	active := m.GetActive()
+	if mp, ok := active.(MetadataProvider); ok {
+		return mp.GetMetadata(seriesID)
+	}
+
+	m.mu.RLock()
+	meta, ok := m.metadataCache[seriesID]
+	gen := m.configGen
+	m.mu.RUnlock()
+	if ok {
+		return meta, nil
+	}
+
+	data, storage, err := active.GetSeriesData(seriesID, "")
+	if err != nil {
+		return SeriesMetadata{}, fmt.Errorf("failed to get series %s: %w", seriesID, err)
+	}
+	meta = computeMetadata(seriesID, data, storage)
+
+	// Not cached if the data may have changed while it was loaded
+	m.mu.Lock()
+	if gen == m.configGen {
+		m.metadataCache[seriesID] = meta
+	}
+	m.mu.Unlock()
+	return meta, nil
+}
+
+// computeMetadata summarizes series data in the given storage.
+func computeMetadata(seriesID string, data []float64, storage string) SeriesMetadata {
+	if storage == "" {
+		storage = "interleaved"
+	}
+	n := len(data) / 2
+	meta := SeriesMetadata{ID: seriesID, Count: n, Storage: storage}
+
+	xMin, xMax := math.Inf(1), math.Inf(-1)
+	yMin, yMax := math.Inf(1), math.Inf(-1)
+	for i := 0; i < n; i++ {
+		x, y := data[i*2], data[i*2+1]
+		if storage == "arrays" {
+			x, y = data[i], data[n+i]
+		}
+		if !math.IsNaN(x) && !math.IsInf(x, 0) {
+			xMin, xMax = min(xMin, x), max(xMax, x)
+		}
+		if !math.IsNaN(y) && !math.IsInf(y, 0) {
+			yMin, yMax = min(yMin, y), max(yMax, y)
+		}
+	}
+	// Infinite bounds can't be encoded in JSON
+	if xMin <= xMax {
+		meta.XMin, meta.XMax = xMin, xMax
+	}
+	if yMin <= yMax {
+		meta.YMin, meta.YMax = yMin, yMax
+	}
+	return meta
+}
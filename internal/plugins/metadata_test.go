@@ -0,0 +1,32 @@
+package plugins
+
+import (
+	"math"
+	"testing"
+)
+
+func TestComputeMetadata(t *testing.T) {
+	nan, inf := math.NaN(), math.Inf(1)
+	for _, tt := range []struct {
+		name    string
+		data    []float64
+		storage string
+		want    SeriesMetadata
+	}{
+		{"interleaved", []float64{1, -2, 0, 5, 3, 1}, "interleaved", SeriesMetadata{Count: 3, XMin: 0, XMax: 3, YMin: -2, YMax: 5}},
+		{"arrays", []float64{1, 0, 3, -2, 5, 1}, "arrays", SeriesMetadata{Count: 3, XMin: 0, XMax: 3, YMin: -2, YMax: 5}},
+		{"non-finite values left out", []float64{nan, 4, 2, inf, 1, nan}, "interleaved", SeriesMetadata{Count: 3, XMin: 1, XMax: 2, YMin: 4, YMax: 4}},
+		{"empty", nil, "", SeriesMetadata{Storage: "interleaved"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := computeMetadata("s1", tt.data, tt.storage)
+			tt.want.ID = "s1"
+			if tt.want.Storage == "" {
+				tt.want.Storage = tt.storage
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
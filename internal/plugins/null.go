@@ -0,0 +1,52 @@
+package plugins
+
+import (
+	"errors"
+
+	"olicanaplot/internal/logging"
+)
+
+// ErrNoActivePlugin is returned by NullPlugin, i.e. when no plugin is active.
+var ErrNoActivePlugin = errors.New("no active plugin")
+
+// NullPlugin stands in for the active plugin when there is none, so callers
+// of Manager.GetActive need no nil checks. Every method, including those of
+// the optional interfaces that need an active plugin, fails with
+// ErrNoActivePlugin.
+type NullPlugin struct{}
+
+func (NullPlugin) Name() string                    { return "" }
+func (NullPlugin) Version() uint32                 { return PluginAPIVersion }
+func (NullPlugin) Path() string                    { return "" }
+func (NullPlugin) GetFilePatterns() []FilePattern  { return nil }
+func (NullPlugin) Close() error                    { return nil }
+func (NullPlugin) Refresh() error                  { return ErrNoActivePlugin }
+func (NullPlugin) GetFileInfo() (*FileInfo, error) { return nil, ErrNoActivePlugin }
+
+func (NullPlugin) GetTimeRange() (float64, float64, error) {
+	return 0, 0, ErrNoActivePlugin
+}
+
+func (NullPlugin) Initialize(ctx interface{}, initStr string, logger logging.Logger) (string, error) {
+	return "", ErrNoActivePlugin
+}
+
+func (NullPlugin) GetChartConfig(args string) (*ChartConfig, error) {
+	return nil, ErrNoActivePlugin
+}
+
+func (NullPlugin) GetSeriesConfig() ([]SeriesConfig, error) {
+	return nil, ErrNoActivePlugin
+}
+
+func (NullPlugin) GetSeriesData(seriesID string, preferredStorage string) ([]float64, string, error) {
+	return nil, "", ErrNoActivePlugin
+}
+
+func (NullPlugin) UpdateConfig(data map[string]interface{}) error {
+	return ErrNoActivePlugin
+}
+
+func (NullPlugin) GetConfig() (map[string]interface{}, error) {
+	return nil, ErrNoActivePlugin
+}
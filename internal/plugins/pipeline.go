@@ -0,0 +1,237 @@
+package plugins
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+	"strconv"
+	"strings"
+
+	"olicanaplot/internal/logging"
+
+	"gonum.org/v1/gonum/dsp/fourier"
+)
+
+// DataTransformer is one step of a Pipeline. It maps a series' points to new
+// points, which need not have the same length or X values.
+type DataTransformer interface {
+	Name() string
+	Transform(x, y []float64) ([]float64, []float64, error)
+}
+
+// Pipeline is a plugin that passes every series of a source plugin through a
+// chain of transformations, e.g. moving_average(20) then derivative.
+type Pipeline struct {
+	Source Plugin
+	Steps  []DataTransformer
+}
+
+// NewPipeline creates a pipeline applying steps in order to source's series.
+func NewPipeline(source Plugin, steps ...DataTransformer) *Pipeline {
+	return &Pipeline{Source: source, Steps: steps}
+}
+
+// Name returns the source name followed by the step names.
+func (p *Pipeline) Name() string {
+	names := []string{p.Source.Name()}
+	for _, step := range p.Steps {
+		names = append(names, step.Name())
+	}
+	return strings.Join(names, " | ")
+}
+
+// Version returns the plugin API version.
+func (p *Pipeline) Version() uint32 {
+	return PluginAPIVersion
+}
+
+// Path returns the source's path.
+func (p *Pipeline) Path() string {
+	return p.Source.Path()
+}
+
+// GetFilePatterns returns nil; pipelines are not opened from files.
+func (p *Pipeline) GetFilePatterns() []FilePattern {
+	return nil
+}
+
+// Initialize initializes the source.
+func (p *Pipeline) Initialize(ctx interface{}, initStr string, logger logging.Logger) (string, error) {
+	return p.Source.Initialize(ctx, initStr, logger)
+}
+
+// GetChartConfig returns the source's chart configuration.
+func (p *Pipeline) GetChartConfig(args string) (*ChartConfig, error) {
+	return p.Source.GetChartConfig(args)
+}
+
+// GetSeriesConfig returns the source's series.
+func (p *Pipeline) GetSeriesConfig() ([]SeriesConfig, error) {
+	return p.Source.GetSeriesConfig()
+}
+
+// GetSeriesData returns the source's data for a series after every step.
+func (p *Pipeline) GetSeriesData(seriesID string, preferredStorage string) ([]float64, string, error) {
+	data, storage, err := p.Source.GetSeriesData(seriesID, "interleaved")
+	if err != nil {
+		return nil, "", err
+	}
+
+	x, y := splitSeries(data, storage)
+	for _, step := range p.Steps {
+		x, y, err = step.Transform(x, y)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", step.Name(), err)
+		}
+	}
+
+	if preferredStorage == "arrays" {
+		return append(x, y...), "arrays", nil
+	}
+	result := make([]float64, 0, len(x)*2)
+	for i := range x {
+		result = append(result, x[i], y[i])
+	}
+	return result, "interleaved", nil
+}
+
+// Close closes the steps that hold resources. The source is a registered
+// plugin in its own right, shared with the manager and other pipelines, so
+// it is left open.
+func (p *Pipeline) Close() error {
+	var firstErr error
+	for _, step := range p.Steps {
+		if c, ok := step.(io.Closer); ok {
+			if err := c.Close(); err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("error closing step %s: %w", step.Name(), err)
+			}
+		}
+	}
+	return firstErr
+}
+
+// splitSeries returns the X and Y values of series data in either storage.
+func splitSeries(data []float64, storage string) ([]float64, []float64) {
+	n := len(data) / 2
+	if storage == "arrays" {
+		return data[:n:n], data[n : 2*n]
+	}
+	x := make([]float64, n)
+	y := make([]float64, n)
+	for i := 0; i < n; i++ {
+		x[i], y[i] = data[i*2], data[i*2+1]
+	}
+	return x, y
+}
+
+// ParseTransformer returns the built-in step named by spec, one of
+// "moving_average(N)", "derivative" or "fft".
+func ParseTransformer(spec string) (DataTransformer, error) {
+	name, arg := strings.TrimSpace(spec), ""
+	if i := strings.IndexByte(name, '('); i >= 0 && strings.HasSuffix(name, ")") {
+		name, arg = strings.TrimSpace(name[:i]), strings.TrimSpace(name[i+1:len(name)-1])
+	}
+
+	switch name {
+	case "moving_average":
+		window, err := strconv.Atoi(arg)
+		if err != nil || window < 1 {
+			return nil, fmt.Errorf("moving_average needs a positive window, got %q", arg)
+		}
+		return movingAverage{window: window}, nil
+	case "derivative", "fft":
+		if arg != "" {
+			return nil, fmt.Errorf("%s takes no argument", name)
+		}
+		if name == "fft" {
+			return fftMagnitude{}, nil
+		}
+		return derivative{}, nil
+	}
+	return nil, fmt.Errorf("unknown pipeline step: %q", spec)
+}
+
+// movingAverage replaces each point by the mean of the trailing window.
+type movingAverage struct {
+	window int
+}
+
+func (t movingAverage) Name() string {
+	return fmt.Sprintf("moving_average(%d)", t.window)
+}
+
+func (t movingAverage) Transform(x, y []float64) ([]float64, []float64, error) {
+	if len(y) < t.window {
+		return nil, nil, nil
+	}
+	outX := make([]float64, 0, len(y)-t.window+1)
+	outY := make([]float64, 0, len(y)-t.window+1)
+	var sum float64
+	for i, v := range y {
+		sum += v
+		if i >= t.window {
+			sum -= y[i-t.window]
+		}
+		if i >= t.window-1 {
+			outX = append(outX, x[i])
+			outY = append(outY, sum/float64(t.window))
+		}
+	}
+	return outX, outY, nil
+}
+
+// derivative computes dy/dx by finite differences at the midpoint of each
+// pair of neighbouring points.
+type derivative struct{}
+
+func (derivative) Name() string {
+	return "derivative"
+}
+
+func (derivative) Transform(x, y []float64) ([]float64, []float64, error) {
+	var outX, outY []float64
+	for i := 1; i < len(x); i++ {
+		dx := x[i] - x[i-1]
+		if dx == 0 {
+			continue
+		}
+		outX = append(outX, (x[i]+x[i-1])/2)
+		outY = append(outY, (y[i]-y[i-1])/dx)
+	}
+	return outX, outY, nil
+}
+
+// fftMagnitude computes the single-sided amplitude spectrum, assuming the
+// points are evenly spaced in X.
+type fftMagnitude struct{}
+
+func (fftMagnitude) Name() string {
+	return "fft"
+}
+
+func (fftMagnitude) Transform(x, y []float64) ([]float64, []float64, error) {
+	n := len(y)
+	if n < 2 {
+		return nil, nil, fmt.Errorf("need at least 2 points, got %d", n)
+	}
+	dx := (x[n-1] - x[0]) / float64(n-1)
+	if dx <= 0 || math.IsNaN(dx) {
+		return nil, nil, fmt.Errorf("X values must be increasing")
+	}
+
+	fft := fourier.NewFFT(n)
+	coeffs := fft.Coefficients(nil, y)
+	freqs := make([]float64, len(coeffs))
+	amps := make([]float64, len(coeffs))
+	for i, c := range coeffs {
+		freqs[i] = fft.Freq(i) / dx
+		amps[i] = cmplx.Abs(c) / float64(n)
+		// Fold the negative frequencies onto the positive ones, except DC
+		// and, for even n, Nyquist
+		if i > 0 && !(n%2 == 0 && i == n/2) {
+			amps[i] *= 2
+		}
+	}
+	return freqs, amps, nil
+}
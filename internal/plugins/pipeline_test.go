@@ -0,0 +1,105 @@
+package plugins
+
+import (
+	"math"
+	"testing"
+
+	"olicanaplot/internal/logging"
+)
+
+func TestPipelineDerivative(t *testing.T) {
+	source := &seriesPlugin{series: map[string][]float64{
+		"line": sampleArrays(func(x float64) float64 { return 3*x + 1 }, 0, 10, 11),
+	}}
+	steps := make([]DataTransformer, 0, 2)
+	for _, spec := range []string{"moving_average(2)", "derivative"} {
+		step, err := ParseTransformer(spec)
+		if err != nil {
+			t.Fatalf("ParseTransformer(%q) failed: %v", spec, err)
+		}
+		steps = append(steps, step)
+	}
+	p := NewPipeline(source, steps...)
+
+	m := NewManager(logging.NewLogger("Test"))
+	if err := m.Register(p, true); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if m.Get("Series | moving_average(2) | derivative") == nil {
+		t.Fatalf("pipeline not registered under its name, have %v", m.List())
+	}
+
+	data, storage, err := p.GetSeriesData("line", "interleaved")
+	if err != nil {
+		t.Fatalf("GetSeriesData failed: %v", err)
+	}
+	// 11 points, 10 averages, 9 differences
+	if storage != "interleaved" || len(data) != 18 {
+		t.Fatalf("storage = %s, len = %d, want interleaved and 18", storage, len(data))
+	}
+	for i := 0; i < len(data); i += 2 {
+		if math.Abs(data[i+1]-3) > 1e-12 {
+			t.Errorf("slope at x=%v is %v, want 3", data[i], data[i+1])
+		}
+	}
+}
+
+func TestPipelineFFT(t *testing.T) {
+	// 5 Hz sine with amplitude 2, sampled at 100 Hz for 1 s
+	n := 100
+	source := &seriesPlugin{series: map[string][]float64{
+		"sine": sampleArrays(func(x float64) float64 { return 2 * math.Sin(2*math.Pi*5*x) }, 0, 0.99, n),
+	}}
+	fft, _ := ParseTransformer("fft")
+	data, storage, err := NewPipeline(source, fft).GetSeriesData("sine", "arrays")
+	if err != nil {
+		t.Fatalf("GetSeriesData failed: %v", err)
+	}
+	if storage != "arrays" || len(data) != 2*(n/2+1) {
+		t.Fatalf("storage = %s, len = %d", storage, len(data))
+	}
+
+	freqs, amps := data[:n/2+1], data[n/2+1:]
+	peak := 0
+	for i := range amps {
+		if amps[i] > amps[peak] {
+			peak = i
+		}
+	}
+	if math.Abs(freqs[peak]-5) > 1e-9 || math.Abs(amps[peak]-2) > 1e-9 {
+		t.Errorf("peak at %v Hz with amplitude %v, want 5 Hz and 2", freqs[peak], amps[peak])
+	}
+}
+
+// closingStep is an identity step that records being closed.
+type closingStep struct{ closed bool }
+
+func (s *closingStep) Name() string { return "identity" }
+func (s *closingStep) Close() error { s.closed = true; return nil }
+func (s *closingStep) Transform(x, y []float64) ([]float64, []float64, error) {
+	return x, y, nil
+}
+
+func TestPipelineCloseKeepsSource(t *testing.T) {
+	// Switching away from an active pipeline closes it, while the source
+	// stays registered
+	source := &namedPlugin{name: "CSV"}
+	step := &closingStep{}
+	if err := NewPipeline(source, step).Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if source.closed {
+		t.Error("closing the pipeline closed its source")
+	}
+	if !step.closed {
+		t.Error("closing the pipeline left its step open")
+	}
+}
+
+func TestParseTransformerErrors(t *testing.T) {
+	for _, spec := range []string{"moving_average", "moving_average(0)", "moving_average(x)", "derivative(2)", "integral"} {
+		if _, err := ParseTransformer(spec); err == nil {
+			t.Errorf("ParseTransformer(%q): expected error", spec)
+		}
+	}
+}
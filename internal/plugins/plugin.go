@@ -2,7 +2,11 @@
 package plugins
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"slices"
+
 	"olicanaplot/internal/logging"
 )
 
@@ -24,6 +28,25 @@ type ChartConfig struct {
 	Axes  []AxisGroupConfig `json:"axes,omitempty"`
 	LinkX *bool             `json:"link_x,omitempty"`
 	LinkY *bool             `json:"link_y,omitempty"`
+
+	// LineWidthDefault is the line width for series that do not set their
+	// own. When nil the host's default line width is used.
+	LineWidthDefault *float64 `json:"line_width_default,omitempty"`
+
+	// SubplotTitles titles the subplots in row-major order, overriding the
+	// Title of their axis groups, so simple grids need no Axes. Empty
+	// entries leave a subplot's title unchanged.
+	SubplotTitles []string `json:"subplot_titles,omitempty"`
+
+	// Colorscale names the continuous color scale, e.g. "viridis", "plasma"
+	// or "hot", that colors the points of series with a ColorScaleColumn.
+	// Empty uses viridis.
+	Colorscale string `json:"colorscale,omitempty"`
+
+	// Deprecated: Rows and Cols are kept for plugins written before Grid
+	// existed. Use Grid instead; the host migrates these when Grid is nil.
+	Rows int `json:"rows,omitempty"`
+	Cols int `json:"cols,omitempty"`
 }
 
 // GridConfig describes the subplot grid layout.
@@ -40,6 +63,13 @@ type AxisConfig struct {
 	Type     string   `json:"type,omitempty"` // "linear", "log", "date"
 	Min      *float64 `json:"min,omitempty"`
 	Max      *float64 `json:"max,omitempty"`
+
+	// ScaleFactor and ScaleOffset convert the raw values plotted against
+	// the axis for display, as value*ScaleFactor + ScaleOffset, e.g. from
+	// pascals to kilopascals. A zero ScaleFactor means 1. The data itself
+	// is not changed.
+	ScaleFactor float64 `json:"scale_factor,omitempty"`
+	ScaleOffset float64 `json:"scale_offset,omitempty"`
 }
 
 // SubPlot describes a cell in the chart grid.
@@ -69,7 +99,38 @@ type SeriesConfig struct {
 	MarkerFill string   `json:"marker_fill,omitempty"` // "empty" or "solid"
 	Unit       string   `json:"unit,omitempty"`
 	Visible    *bool    `json:"visible"`
-	YAxis      string   `json:"y_axis,omitempty"` // references Y axis title
+	YAxis      string   `json:"y_axis,omitempty"`  // references Y axis title
+	Opacity    float64  `json:"opacity,omitempty"` // 0.0-1.0, defaults to 1.0
+
+	// Description is free text about the series, shown in its tooltip.
+	Description string `json:"description,omitempty"`
+
+	// LoadPriority orders data fetches: higher priorities load first. Series
+	// default to 0.
+	LoadPriority int `json:"load_priority,omitempty"`
+
+	// RefreshIntervalMs is how often /ws/series_stream polls the series for
+	// new data, in milliseconds. 0 uses the default of one second.
+	RefreshIntervalMs int `json:"refresh_interval_ms,omitempty"`
+
+	// ColorScaleColumn is the ID of another series whose Y values, one per
+	// point, color this series' points on the chart's Colorscale. They are
+	// served by /api/colorscale_data.
+	ColorScaleColumn string `json:"color_scale_column,omitempty"`
+}
+
+// HostLineWidthDefault returns the application's default line width. main
+// points it at the ConfigService setting; it is a variable rather than an
+// import to avoid a cycle with appconfig.
+var HostLineWidthDefault = func() float64 { return 2.0 }
+
+// ApplyLineWidthDefault gives the series the chart's default line width if it
+// does not set its own.
+func (s *SeriesConfig) ApplyLineWidthDefault(c *ChartConfig) {
+	if s.LineWidth == nil && c != nil && c.LineWidthDefault != nil {
+		w := *c.LineWidthDefault
+		s.LineWidth = &w
+	}
 }
 
 // SetDefaults ensures all required fields have sensible defaults if they are empty
@@ -81,7 +142,7 @@ func (s *SeriesConfig) SetDefaults() {
 		s.LineType = "solid"
 	}
 	if s.LineWidth == nil {
-		w := 2.0
+		w := HostLineWidthDefault()
 		s.LineWidth = &w
 	}
 	if s.MarkerType == "" {
@@ -98,6 +159,9 @@ func (s *SeriesConfig) SetDefaults() {
 		v := true
 		s.Visible = &v
 	}
+	if s.Opacity == 0 {
+		s.Opacity = 1.0
+	}
 }
 
 // SetDefaults ensures all required fields have sensible defaults
@@ -140,8 +204,21 @@ func (ag *AxisGroupConfig) SetDefaults() {
 	}
 }
 
-// SetDefaults ensures all sub-configs have defaults
-func (c *ChartConfig) SetDefaults() {
+// SetDefaults ensures all sub-configs have defaults. It fails, leaving c
+// unchanged, if an axis group is placed at a negative row or column.
+func (c *ChartConfig) SetDefaults() error {
+	for i, ag := range c.Axes {
+		if ag.Subplot != nil && (ag.Subplot.Row < 0 || ag.Subplot.Col < 0) {
+			return fmt.Errorf("axis group %d has negative subplot index (%d, %d)", i, ag.Subplot.Row, ag.Subplot.Col)
+		}
+	}
+
+	// Migrate the deprecated top-level grid size
+	if c.Grid == nil && (c.Rows > 0 || c.Cols > 0) {
+		c.Grid = &GridConfig{Rows: c.Rows, Cols: c.Cols}
+	}
+	c.Rows, c.Cols = 0, 0
+
 	if len(c.Axes) == 0 {
 		c.Axes = []AxisGroupConfig{
 			{
@@ -209,9 +286,25 @@ func (c *ChartConfig) SetDefaults() {
 		}
 	}
 
+	for i, ag := range c.Axes {
+		row, col := 0, 0
+		if ag.Subplot != nil {
+			row, col = ag.Subplot.Row, ag.Subplot.Col
+		}
+		if idx := row*c.Grid.Cols + col; idx < len(c.SubplotTitles) && c.SubplotTitles[idx] != "" {
+			c.Axes[i].Title = c.SubplotTitles[idx]
+		}
+	}
+
 	for i := range c.Axes {
 		c.Axes[i].SetDefaults()
 	}
+
+	if c.LineWidthDefault == nil {
+		w := HostLineWidthDefault()
+		c.LineWidthDefault = &w
+	}
+	return nil
 }
 
 // FilePattern describes a file type supported by a plugin.
@@ -220,6 +313,82 @@ type FilePattern struct {
 	Patterns    []string `json:"patterns"`
 }
 
+// TimeRange is the X extent reported by a get_time_range request.
+type TimeRange struct {
+	XMin float64 `json:"x_min"`
+	XMax float64 `json:"x_max"`
+}
+
+// FileInfo describes the file a plugin loaded, as reported by a get_file_info
+// request.
+type FileInfo struct {
+	Path         string `json:"path"`
+	SizeBytes    int64  `json:"size_bytes"`
+	RowCount     int    `json:"row_count"`
+	ModifiedUnix int64  `json:"modified_unix"`
+}
+
+// SeriesPoints is a series' points, sent with a save request. NaN values
+// are sent as null.
+type SeriesPoints struct {
+	ID string    `json:"id"`
+	X  []float64 `json:"x"`
+	Y  []float64 `json:"y"`
+}
+
+// MarshalJSON encodes non-finite values, such as the NaN of a gap, as null,
+// since JSON has no number for them.
+func (s SeriesPoints) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonSeriesPoints{ID: s.ID, X: nullableFloats(s.X), Y: nullableFloats(s.Y)})
+}
+
+// UnmarshalJSON decodes null values as NaN.
+func (s *SeriesPoints) UnmarshalJSON(data []byte) error {
+	var js jsonSeriesPoints
+	if err := json.Unmarshal(data, &js); err != nil {
+		return err
+	}
+	*s = SeriesPoints{ID: js.ID, X: nanFloats(js.X), Y: nanFloats(js.Y)}
+	return nil
+}
+
+// jsonSeriesPoints is SeriesPoints as encoded, with null for NaN.
+type jsonSeriesPoints struct {
+	ID string     `json:"id"`
+	X  []*float64 `json:"x"`
+	Y  []*float64 `json:"y"`
+}
+
+// nullableFloats returns v with nil in place of non-finite values.
+func nullableFloats(v []float64) []*float64 {
+	if v == nil {
+		return nil
+	}
+	out := make([]*float64, len(v))
+	for i := range v {
+		if !math.IsNaN(v[i]) && !math.IsInf(v[i], 0) {
+			out[i] = &v[i]
+		}
+	}
+	return out
+}
+
+// nanFloats returns v with NaN in place of nil values.
+func nanFloats(v []*float64) []float64 {
+	if v == nil {
+		return nil
+	}
+	out := make([]float64, len(v))
+	for i, p := range v {
+		if p == nil {
+			out[i] = math.NaN()
+		} else {
+			out[i] = *p
+		}
+	}
+	return out
+}
+
 // Plugin is the interface that all data source plugins must implement.
 type Plugin interface {
 	// Name returns the display name of the plugin.
@@ -258,3 +427,222 @@ type Plugin interface {
 	// Close cleans up plugin resources. Called on shutdown.
 	Close() error
 }
+
+// TimeRanger is an optional interface for plugins that can report the X range
+// of their data cheaply, so the chart can scale its axes before data loads.
+type TimeRanger interface {
+	GetTimeRange() (xMin, xMax float64, err error)
+}
+
+// YAxisLabeler is an optional interface for plugins whose series have
+// different units, e.g. one per file column. Each series is plotted against a
+// Y axis titled with its label; an empty label leaves the series on the
+// plugin's own axis.
+type YAxisLabeler interface {
+	GetYAxisLabel(seriesID string) string
+}
+
+// Refresher is an optional interface for plugins that can re-read their
+// source, e.g. a file changed by another process, keeping their configuration.
+type Refresher interface {
+	Refresh() error
+}
+
+// RefreshNotifier is an optional interface for plugins that refresh their data
+// on their own, e.g. when a watched file changes. The manager registers a
+// callback, to be called after each such refresh, that drops cached configs.
+type RefreshNotifier interface {
+	OnRefresh(callback func())
+}
+
+// Deactivator is an optional interface for plugins that run background work,
+// e.g. a file watcher, only while active. The manager calls Deactivate when
+// another plugin is made active, without closing the plugin.
+type Deactivator interface {
+	Deactivate()
+}
+
+// EventEmitter is an optional interface for plugins that emit events, e.g.
+// "dataUpdated", for the frontend. The manager passes its event bus on
+// registration; plugins emit on it under their own name.
+type EventEmitter interface {
+	SetEventBus(bus *PluginEventBus)
+}
+
+// LoggerSetter is an optional interface for plugins that log before
+// Initialize is called, e.g. while being discovered. The service sets the
+// plugin-specific logger when activating them.
+type LoggerSetter interface {
+	SetLogger(logger logging.Logger)
+}
+
+// FileInformer is an optional interface for plugins that load a file and can
+// report its metadata without reloading it. Plugins that report their
+// capabilities only count if they list "get_file_info".
+type FileInformer interface {
+	GetFileInfo() (*FileInfo, error)
+}
+
+// ColumnStatsProvider is an optional interface for plugins that load a table
+// and summarize its columns, to help choose axis ranges. min, max and mean
+// leave out NaN and infinite values; nanCount is the number of NaN cells.
+type ColumnStatsProvider interface {
+	GetColumnStats(column string) (min, max, mean float64, nanCount int, err error)
+}
+
+// Saver is an optional interface for plugins that write other plugins'
+// series somewhere, e.g. to a CSV file. args are plugin specific, such as an
+// output path. Plugins that report their capabilities must list "save".
+type Saver interface {
+	Save(args string, series []SeriesPoints) error
+}
+
+// PluginInfo is a plugin's build metadata, shown in the options dialog to help
+// with debugging.
+type PluginInfo struct {
+	APIVersion    uint32 `json:"api_version"`
+	PluginVersion string `json:"plugin_version,omitempty"` // Semver of the plugin itself
+	BuildDate     string `json:"build_date,omitempty"`
+	CommitHash    string `json:"commit_hash,omitempty"`
+}
+
+// Informer is an optional interface for plugins that report build metadata
+// beyond their API version.
+type Informer interface {
+	GetInfo() PluginInfo
+}
+
+// BaseCapabilities returns the methods every plugin implements, reported by
+// plugins that don't declare their capabilities.
+func BaseCapabilities() []string {
+	return []string{"get_chart_config", "get_series_config", "get_series_data"}
+}
+
+// CapabilityProvider is an optional interface for plugins that declare the
+// protocol methods they implement, e.g. "stream_series_data", so the
+// frontend can show features only when they are available.
+type CapabilityProvider interface {
+	Capabilities() []string
+}
+
+// ConfigUpdater is an optional interface for plugins that can change their
+// parameters after initialization without starting a new session.
+type ConfigUpdater interface {
+	UpdateConfig(data map[string]interface{}) error
+	GetConfig() (map[string]interface{}, error)
+}
+
+// SeriesMetadata summarizes a series without its data. The ranges leave out
+// NaN and infinite values and are 0 for a series without finite values.
+type SeriesMetadata struct {
+	ID      string  `json:"id"`
+	Count   int     `json:"count"` // Number of points
+	XMin    float64 `json:"x_min"`
+	XMax    float64 `json:"x_max"`
+	YMin    float64 `json:"y_min"`
+	YMax    float64 `json:"y_max"`
+	Storage string  `json:"storage"` // Layout the plugin serves the data in
+}
+
+// MetadataProvider is an optional interface for plugins that know a series'
+// metadata without loading its data, e.g. from a file's index. The manager
+// computes the metadata of other plugins' series from their data.
+type MetadataProvider interface {
+	GetMetadata(seriesID string) (SeriesMetadata, error)
+}
+
+// ApplyTimeRange fills the first X axis Min/Max from the plugin's time range
+// when the plugin implements TimeRanger and the axis has no explicit limits.
+func ApplyTimeRange(p Plugin, config *ChartConfig) {
+	tr, ok := p.(TimeRanger)
+	if !ok || config == nil || len(config.Axes) == 0 || len(config.Axes[0].XAxes) == 0 {
+		return
+	}
+
+	xMin, xMax, err := tr.GetTimeRange()
+	if err != nil || xMin > xMax {
+		return
+	}
+
+	axis := &config.Axes[0].XAxes[0]
+	if axis.Min == nil {
+		axis.Min = &xMin
+	}
+	if axis.Max == nil {
+		axis.Max = &xMax
+	}
+}
+
+// ApplyYAxisLabels gives each series the Y axis labelled by the plugin when it
+// implements YAxisLabeler, unless the series already names its YAxis. A
+// series' axis is found, or added, by title among the Y axes of the axis group
+// of its subplot, the first label in a group retitling the plugin's first
+// axis unless a series already references that by title, and the series'
+// YAxis references it. config may be nil to only set the references.
+func ApplyYAxisLabels(p Plugin, config *ChartConfig, series []SeriesConfig) {
+	labeler, ok := p.(YAxisLabeler)
+	if !ok {
+		return
+	}
+
+	// Axes that series already reference keep their titles
+	referenced := make(map[int]map[string]bool)
+	if config != nil && len(config.Axes) > 0 {
+		for _, s := range series {
+			if s.YAxis == "" {
+				continue
+			}
+			gi := axisGroupIndex(config, s.Subplot)
+			if referenced[gi] == nil {
+				referenced[gi] = make(map[string]bool)
+			}
+			referenced[gi][s.YAxis] = true
+		}
+	}
+
+	relabelled := make(map[int]bool)
+	for i := range series {
+		if series[i].YAxis != "" {
+			continue // Already on a chosen axis
+		}
+		label := labeler.GetYAxisLabel(series[i].ID)
+		if label == "" {
+			continue
+		}
+		series[i].YAxis = label
+		if config == nil || len(config.Axes) == 0 {
+			continue
+		}
+
+		gi := axisGroupIndex(config, series[i].Subplot)
+		group := &config.Axes[gi]
+		hasAxis := slices.ContainsFunc(group.YAxes, func(a AxisConfig) bool { return a.Title == label })
+		switch {
+		case hasAxis:
+		case len(group.YAxes) > 0 && !relabelled[gi] && !referenced[gi][group.YAxes[0].Title]:
+			group.YAxes[0].Title = label
+		default:
+			group.YAxes = append(group.YAxes, AxisConfig{Title: label})
+		}
+		relabelled[gi] = true
+	}
+}
+
+// axisGroupIndex returns the index of the axis group plotted in subplot, or 0
+// if there is none. A nil subplot is the first cell.
+func axisGroupIndex(config *ChartConfig, subplot *SubPlot) int {
+	cell := SubPlot{}
+	if subplot != nil {
+		cell = *subplot
+	}
+	for i, ag := range config.Axes {
+		agCell := SubPlot{}
+		if ag.Subplot != nil {
+			agCell = *ag.Subplot
+		}
+		if agCell == cell {
+			return i
+		}
+	}
+	return 0
+}
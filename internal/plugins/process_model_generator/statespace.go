@@ -0,0 +1,141 @@
+package process_model_generator
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Default matrices for the State-Space simulation: a lightly damped
+// two-state oscillator observed directly with a little measurement noise.
+const (
+	defaultMatrixA = "0.9,0.1;-0.1,0.9"
+	defaultMatrixB = "1,0;0,1"
+	defaultMatrixC = "1,0;0,1"
+	defaultMatrixD = "0.1,0;0,0.1"
+)
+
+// stateSpace is a discrete-time linear system
+//
+//	x[k+1] = A x[k] + B w[k]
+//	y[k]   = C x[k] + D v[k]
+//
+// where w and v are independent standard normal process and measurement
+// noise vectors.
+type stateSpace struct {
+	A, B, C, D *mat.Dense
+}
+
+// parseMatrix parses a matrix written as rows separated by ';' and columns
+// separated by ',', e.g. "0.9,0.1;-0.1,0.9".
+func parseMatrix(s string) (*mat.Dense, error) {
+	rows := strings.Split(strings.TrimSpace(s), ";")
+	var data []float64
+	cols := 0
+	for i, row := range rows {
+		fields := strings.Split(row, ",")
+		if i == 0 {
+			cols = len(fields)
+		} else if len(fields) != cols {
+			return nil, fmt.Errorf("row %d has %d columns, expected %d", i+1, len(fields), cols)
+		}
+		for _, field := range fields {
+			v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %w", i+1, err)
+			}
+			data = append(data, v)
+		}
+	}
+	return mat.NewDense(len(rows), cols, data), nil
+}
+
+// newStateSpace parses the four system matrices and checks that their
+// dimensions are consistent.
+func newStateSpace(a, b, c, d string) (*stateSpace, error) {
+	var ss stateSpace
+	for _, m := range []struct {
+		name string
+		src  string
+		dst  **mat.Dense
+	}{
+		{"A", a, &ss.A},
+		{"B", b, &ss.B},
+		{"C", c, &ss.C},
+		{"D", d, &ss.D},
+	} {
+		parsed, err := parseMatrix(m.src)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s matrix: %w", m.name, err)
+		}
+		*m.dst = parsed
+	}
+
+	n, nc := ss.A.Dims()
+	if n != nc {
+		return nil, fmt.Errorf("A matrix must be square, got %dx%d", n, nc)
+	}
+	if r, _ := ss.B.Dims(); r != n {
+		return nil, fmt.Errorf("B matrix must have %d rows, got %d", n, r)
+	}
+	p, cc := ss.C.Dims()
+	if cc != n {
+		return nil, fmt.Errorf("C matrix must have %d columns, got %d", n, cc)
+	}
+	if r, _ := ss.D.Dims(); r != p {
+		return nil, fmt.Errorf("D matrix must have %d rows, got %d", p, r)
+	}
+	return &ss, nil
+}
+
+// outputs returns the number of output components, i.e. the rows of C.
+func (ss *stateSpace) outputs() int {
+	p, _ := ss.C.Dims()
+	return p
+}
+
+// simulate runs the system from a zero initial state for numPoints steps and
+// returns numPoints+1 samples for each output component. noise scales both
+// the process and measurement noise.
+func (ss *stateSpace) simulate(numPoints int, noise float64, rng *rand.Rand) [][]float64 {
+	n, _ := ss.A.Dims()
+	_, m := ss.B.Dims()
+	p, q := ss.D.Dims()
+
+	out := make([][]float64, p)
+	for i := range out {
+		out[i] = make([]float64, numPoints+1)
+	}
+
+	x := mat.NewVecDense(n, nil)
+	w := mat.NewVecDense(m, nil)
+	v := mat.NewVecDense(q, nil)
+	ax := mat.NewVecDense(n, nil)
+	bw := mat.NewVecDense(n, nil)
+	cx := mat.NewVecDense(p, nil)
+	dv := mat.NewVecDense(p, nil)
+	y := mat.NewVecDense(p, nil)
+
+	for k := 0; k <= numPoints; k++ {
+		for j := 0; j < q; j++ {
+			v.SetVec(j, rng.NormFloat64()*noise)
+		}
+		cx.MulVec(ss.C, x)
+		dv.MulVec(ss.D, v)
+		y.AddVec(cx, dv)
+		for i := 0; i < p; i++ {
+			out[i][k] = y.AtVec(i)
+		}
+
+		for j := 0; j < m; j++ {
+			w.SetVec(j, rng.NormFloat64()*noise)
+		}
+		ax.MulVec(ss.A, x)
+		bw.MulVec(ss.B, w)
+		x.AddVec(ax, bw)
+	}
+	return out
+}
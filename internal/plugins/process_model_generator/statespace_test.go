@@ -0,0 +1,127 @@
+package process_model_generator
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// correlation returns the Pearson correlation coefficient of a and b.
+func correlation(a, b []float64) float64 {
+	var meanA, meanB float64
+	for i := range a {
+		meanA += a[i]
+		meanB += b[i]
+	}
+	meanA /= float64(len(a))
+	meanB /= float64(len(b))
+
+	var cov, varA, varB float64
+	for i := range a {
+		da, db := a[i]-meanA, b[i]-meanB
+		cov += da * db
+		varA += da * da
+		varB += db * db
+	}
+	return cov / math.Sqrt(varA*varB)
+}
+
+func TestParseMatrix(t *testing.T) {
+	m, err := parseMatrix("0.9, 0.1; -0.1, 0.9")
+	if err != nil {
+		t.Fatalf("parseMatrix failed: %v", err)
+	}
+	if r, c := m.Dims(); r != 2 || c != 2 {
+		t.Fatalf("dims = %dx%d, want 2x2", r, c)
+	}
+	if m.At(1, 0) != -0.1 {
+		t.Errorf("m[1,0] = %v, want -0.1", m.At(1, 0))
+	}
+
+	for _, bad := range []string{"1,2;3", "1,x", ""} {
+		if _, err := parseMatrix(bad); err == nil {
+			t.Errorf("parseMatrix(%q): expected error", bad)
+		}
+	}
+}
+
+func TestNewStateSpaceDimensions(t *testing.T) {
+	if _, err := newStateSpace("1,0", "1", "1", "1"); err == nil {
+		t.Error("expected error for non-square A")
+	}
+	if _, err := newStateSpace("1,0;0,1", "1", "1,0", "1"); err == nil {
+		t.Error("expected error for B with wrong row count")
+	}
+	if _, err := newStateSpace("1,0;0,1", "1;1", "1", "1"); err == nil {
+		t.Error("expected error for C with wrong column count")
+	}
+	ss, err := newStateSpace("1,0;0,1", "1;1", "1,0;0,1;1,1", "1;1;1")
+	if err != nil {
+		t.Fatalf("newStateSpace failed: %v", err)
+	}
+	if ss.outputs() != 3 {
+		t.Errorf("outputs = %d, want 3", ss.outputs())
+	}
+}
+
+func TestStateSpaceDiagonalOutputsUncorrelated(t *testing.T) {
+	ss, err := newStateSpace("0.9,0;0,0.5", "1,0;0,1", "1,0;0,1", "0,0;0,0")
+	if err != nil {
+		t.Fatalf("newStateSpace failed: %v", err)
+	}
+	y := ss.simulate(20000, 1.0, rand.New(rand.NewSource(1)))
+	if r := correlation(y[0], y[1]); math.Abs(r) > 0.05 {
+		t.Errorf("correlation = %v, want ~0 for a diagonal system", r)
+	}
+
+	// Sharing one noise input couples the states
+	ss, _ = newStateSpace("0.9,0;0,0.5", "1;1", "1,0;0,1", "0;0")
+	y = ss.simulate(20000, 1.0, rand.New(rand.NewSource(1)))
+	if r := correlation(y[0], y[1]); r < 0.5 {
+		t.Errorf("correlation = %v, want strong correlation with shared noise", r)
+	}
+}
+
+func TestStateSpaceSeries(t *testing.T) {
+	p := New()
+	err := p.SetParameters(ConfigResult{
+		SimulationType: "State-Space",
+		NumPoints:      100,
+		NumSeries:      5,
+		Noise:          1.0,
+		MatrixA:        defaultMatrixA,
+		MatrixB:        defaultMatrixB,
+		MatrixC:        "1,0;0,1;1,1",
+		MatrixD:        "0;0;0",
+	})
+	if err != nil {
+		t.Fatalf("SetParameters failed: %v", err)
+	}
+
+	series, _ := p.GetSeriesConfig()
+	if len(series) != 3 {
+		t.Fatalf("got %d series, want one per row of C", len(series))
+	}
+
+	// The third output is the sum of the first two states
+	var ys [3][]float64
+	for i, s := range series {
+		data, storage, err := p.GetSeriesData(s.ID, "arrays")
+		if err != nil {
+			t.Fatalf("GetSeriesData(%s) failed: %v", s.ID, err)
+		}
+		if storage != "arrays" || len(data) != 202 {
+			t.Fatalf("storage = %s, len = %d", storage, len(data))
+		}
+		ys[i] = data[101:]
+	}
+	for k := range ys[2] {
+		if math.Abs(ys[2][k]-(ys[0][k]+ys[1][k])) > 1e-9 {
+			t.Fatalf("y3[%d] = %v, want y1+y2 = %v", k, ys[2][k], ys[0][k]+ys[1][k])
+		}
+	}
+
+	if err := p.SetParameters(ConfigResult{SimulationType: "State-Space", MatrixA: "1,2"}); err == nil {
+		t.Error("expected error for invalid matrices")
+	}
+}
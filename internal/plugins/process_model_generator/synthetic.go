@@ -25,6 +25,11 @@ type Plugin struct {
 	noise           float64
 	correlationTime float64
 	amplitude       float64
+	matrixA         string
+	matrixB         string
+	matrixC         string
+	matrixD         string
+	stateSpace      *stateSpace
 }
 
 type ConfigResult struct {
@@ -34,6 +39,10 @@ type ConfigResult struct {
 	Noise           float64
 	CorrelationTime float64
 	Amplitude       float64
+	MatrixA         string
+	MatrixB         string
+	MatrixC         string
+	MatrixD         string
 	Cancelled       bool
 }
 
@@ -56,7 +65,7 @@ func NewSyntheticDialog(app *application.App) *SyntheticDialog {
 			"simulationType": map[string]interface{}{
 				"title":   "Simulation Type",
 				"type":    "string",
-				"enum":    []string{"Random Walk", "Gauss-Markov", "Random Constant", "White Noise"},
+				"enum":    []string{"Random Walk", "Gauss-Markov", "Random Constant", "White Noise", "State-Space"},
 				"default": "Random Walk",
 			},
 			"numPoints": map[string]interface{}{
@@ -99,6 +108,27 @@ func NewSyntheticDialog(app *application.App) *SyntheticDialog {
 				"maximum": 100,
 				"default": 0.0,
 			},
+			"matrixA": map[string]interface{}{
+				"title":       "A Matrix (for State-Space)",
+				"description": "Rows separated by ';', columns by ','",
+				"type":        "string",
+				"default":     defaultMatrixA,
+			},
+			"matrixB": map[string]interface{}{
+				"title":   "B Matrix (for State-Space)",
+				"type":    "string",
+				"default": defaultMatrixB,
+			},
+			"matrixC": map[string]interface{}{
+				"title":   "C Matrix (for State-Space)",
+				"type":    "string",
+				"default": defaultMatrixC,
+			},
+			"matrixD": map[string]interface{}{
+				"title":   "D Matrix (for State-Space)",
+				"type":    "string",
+				"default": defaultMatrixD,
+			},
 		},
 	}
 
@@ -117,6 +147,10 @@ func NewSyntheticDialog(app *application.App) *SyntheticDialog {
 					CorrelationTime: data["correlationTime"].(float64),
 					Amplitude:       data["amplitude"].(float64),
 				}
+				result.MatrixA, _ = data["matrixA"].(string)
+				result.MatrixB, _ = data["matrixB"].(string)
+				result.MatrixC, _ = data["matrixC"].(string)
+				result.MatrixD, _ = data["matrixD"].(string)
 				d.submit(result)
 			}
 		}
@@ -177,6 +211,10 @@ func New() *Plugin {
 		noise:           1.0,
 		correlationTime: 10.0,
 		amplitude:       0.0,
+		matrixA:         defaultMatrixA,
+		matrixB:         defaultMatrixB,
+		matrixC:         defaultMatrixC,
+		matrixD:         defaultMatrixD,
 	}
 }
 
@@ -219,7 +257,9 @@ func (p *Plugin) Initialize(ctx interface{}, initStr string, logger logging.Logg
 		return "{}", fmt.Errorf("configuration cancelled")
 	}
 
-	p.SetParameters(result)
+	if err := p.SetParameters(result); err != nil {
+		return "{}", err
+	}
 	logger.Info("Synthetic data configured", "type", result.SimulationType, "points", result.NumPoints, "series", result.NumSeries)
 
 	return "{}", nil
@@ -241,8 +281,20 @@ func (p *Plugin) GetChartConfig(args string) (*plugins.ChartConfig, error) {
 	}, nil
 }
 
-// SetParameters updates the simulation parameters.
+// SetParameters updates the simulation parameters. For the State-Space
+// simulation the matrices are parsed and validated first, and the number of
+// series becomes the number of rows of C.
 func (p *Plugin) SetParameters(result ConfigResult) error {
+	var ss *stateSpace
+	if result.SimulationType == "State-Space" {
+		var err error
+		ss, err = newStateSpace(result.MatrixA, result.MatrixB, result.MatrixC, result.MatrixD)
+		if err != nil {
+			return err
+		}
+		result.NumSeries = ss.outputs()
+	}
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -252,6 +304,11 @@ func (p *Plugin) SetParameters(result ConfigResult) error {
 	p.noise = result.Noise
 	p.correlationTime = result.CorrelationTime
 	p.amplitude = result.Amplitude
+	p.matrixA = result.MatrixA
+	p.matrixB = result.MatrixB
+	p.matrixC = result.MatrixC
+	p.matrixD = result.MatrixD
+	p.stateSpace = ss
 	// Reset seed for new generation
 	p.seed = uint64(time.Now().UnixNano())
 	return nil
@@ -268,6 +325,9 @@ func (p *Plugin) GetSeriesConfig() ([]plugins.SeriesConfig, error) {
 			ID:   fmt.Sprintf("synthetic_%d", i),
 			Name: fmt.Sprintf("Series %d", i+1),
 		}
+		if p.stateSpace != nil {
+			series[i].Name = fmt.Sprintf("Output %d", i+1)
+		}
 	}
 	return series, nil
 }
@@ -281,12 +341,17 @@ func (p *Plugin) GetSeriesData(seriesID string, preferredStorage string) ([]floa
 	noise := p.noise
 	correlationTime := p.correlationTime
 	amplitude := p.amplitude
+	ss := p.stateSpace
 	p.mu.Unlock()
 
 	// Parse series index from ID to create unique seed per series
 	var seriesIdx int
 	fmt.Sscanf(seriesID, "synthetic_%d", &seriesIdx)
 
+	if ss != nil {
+		return stateSpaceSeries(ss, seriesIdx, numPoints, seed, noise, preferredStorage)
+	}
+
 	// Use standard math/rand with unique seed for each series
 	rng := rand.New(rand.NewSource(int64(seed + uint64(seriesIdx)*12345)))
 
@@ -346,6 +411,31 @@ func (p *Plugin) GetSeriesData(seriesID string, preferredStorage string) ([]floa
 	return result, storage, nil
 }
 
+// stateSpaceSeries simulates the whole system and returns output component
+// idx against the sample index. All outputs share one seed so that they come
+// from the same realisation of the state.
+func stateSpaceSeries(ss *stateSpace, idx, numPoints int, seed uint64, noise float64, preferredStorage string) ([]float64, string, error) {
+	if idx < 0 || idx >= ss.outputs() {
+		return nil, "", fmt.Errorf("series %d out of range", idx)
+	}
+	rng := rand.New(rand.NewSource(int64(seed)))
+	y := ss.simulate(numPoints, noise, rng)[idx]
+
+	result := make([]float64, (numPoints+1)*2)
+	if preferredStorage == "arrays" {
+		for k := range y {
+			result[k] = float64(k)
+		}
+		copy(result[numPoints+1:], y)
+		return result, "arrays", nil
+	}
+	for k, v := range y {
+		result[k*2] = float64(k)
+		result[k*2+1] = v
+	}
+	return result, "interleaved", nil
+}
+
 // Close cleans up plugin resources.
 func (p *Plugin) Close() error {
 	return nil
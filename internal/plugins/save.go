@@ -0,0 +1,52 @@
+package plugins
+
+import (
+	"fmt"
+	"slices"
+)
+
+// FindSaver returns the registered plugin named name that can save series,
+// or the first one registered if name is empty. Plugins that report their
+// capabilities only count if they list "save".
+func (m *Manager) FindSaver(name string) (Saver, string, error) {
+	names := m.ListByRegistrationOrder()
+	if name != "" {
+		names = []string{name}
+	}
+	for _, n := range names {
+		p := m.Get(n)
+		saver, ok := p.(Saver)
+		if !ok {
+			continue
+		}
+		if cp, ok := p.(CapabilityProvider); ok && !slices.Contains(cp.Capabilities(), "save") {
+			continue
+		}
+		return saver, n, nil
+	}
+	if name != "" {
+		return nil, "", fmt.Errorf("plugin %s cannot save series", name)
+	}
+	return nil, "", fmt.Errorf("no plugin can save series")
+}
+
+// ActiveSeriesPoints returns the points of every series of the active
+// plugin, to be saved.
+func (m *Manager) ActiveSeriesPoints() ([]SeriesPoints, error) {
+	active := m.GetActive()
+	configs, err := active.GetSeriesConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get series: %w", err)
+	}
+
+	points := make([]SeriesPoints, 0, len(configs))
+	for _, c := range configs {
+		data, storage, err := active.GetSeriesData(c.ID, "arrays")
+		if err != nil {
+			return nil, fmt.Errorf("failed to get series %s: %w", c.ID, err)
+		}
+		x, y := splitSeries(data, storage)
+		points = append(points, SeriesPoints{ID: c.ID, X: x, Y: y})
+	}
+	return points, nil
+}
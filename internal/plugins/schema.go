@@ -0,0 +1,166 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// SchemaProvider is implemented by plugins that declare a JSON Schema for the
+// initStr passed to Initialize.
+type SchemaProvider interface {
+	// InitSchema returns the JSON Schema for initStr, or nil if none is declared.
+	InitSchema() map[string]interface{}
+}
+
+// ValidateInitStr checks initStr against a plugin's declared init schema.
+// An empty initStr or a nil schema is always accepted. When the schema's root
+// type is "string" the raw initStr is validated, otherwise it is parsed as JSON.
+func ValidateInitStr(schema map[string]interface{}, initStr string) error {
+	if schema == nil || initStr == "" {
+		return nil
+	}
+
+	var value interface{}
+	if t, _ := schema["type"].(string); t == "string" {
+		value = initStr
+	} else if err := json.Unmarshal([]byte(initStr), &value); err != nil {
+		return fmt.Errorf("init args are not valid JSON: %w", err)
+	}
+
+	return validateValue(schema, value, "$")
+}
+
+// validateValue implements the subset of JSON Schema used by plugin forms:
+// type, enum, const, required, properties, items, minimum and maximum.
+func validateValue(schema map[string]interface{}, value interface{}, path string) error {
+	if t, ok := schema["type"]; ok {
+		if !matchesType(t, value) {
+			return fmt.Errorf("%s: expected type %v, got %s", path, t, jsonTypeName(value))
+		}
+	}
+
+	if c, ok := schema["const"]; ok && !reflect.DeepEqual(c, value) {
+		return fmt.Errorf("%s: expected %v", path, c)
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: value %v is not one of %v", path, value, enum)
+		}
+	}
+
+	if n, ok := value.(float64); ok {
+		if min, ok := schema["minimum"].(float64); ok && n < min {
+			return fmt.Errorf("%s: %v is less than minimum %v", path, n, min)
+		}
+		if max, ok := schema["maximum"].(float64); ok && n > max {
+			return fmt.Errorf("%s: %v is greater than maximum %v", path, n, max)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, r := range required {
+				key, _ := r.(string)
+				if _, present := v[key]; !present {
+					return fmt.Errorf("%s: missing required property %q", path, key)
+				}
+			}
+		}
+		if props, ok := schema["properties"].(map[string]interface{}); ok {
+			for key, propSchema := range props {
+				ps, ok := propSchema.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if pv, present := v[key]; present {
+					if err := validateValue(ps, pv, path+"."+key); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := validateValue(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// matchesType reports whether value satisfies a schema "type" keyword, which
+// may be a single type name or a list of names.
+func matchesType(t interface{}, value interface{}) bool {
+	switch tt := t.(type) {
+	case string:
+		return matchesTypeName(tt, value)
+	case []interface{}:
+		for _, name := range tt {
+			if s, ok := name.(string); ok && matchesTypeName(s, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func matchesTypeName(name string, value interface{}) bool {
+	switch strings.ToLower(name) {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,59 @@
+package plugins
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func loadSchemaFixture(t *testing.T, name string) map[string]interface{} {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+func TestValidateInitStr(t *testing.T) {
+	schema := loadSchemaFixture(t, "function_generator_schema.json")
+
+	tests := []struct {
+		name    string
+		initStr string
+		wantErr bool
+	}{
+		{"empty", "", false},
+		{"valid", `{"expression":"sin(x)","xMin":0,"xMax":10,"numPoints":100}`, false},
+		{"only required", `{"expression":"x"}`, false},
+		{"missing required", `{"xMin":0}`, true},
+		{"wrong type", `{"expression":42}`, true},
+		{"non integer", `{"expression":"x","numPoints":10.5}`, true},
+		{"below minimum", `{"expression":"x","numPoints":1}`, true},
+		{"not json", `sin(x)`, true},
+		{"not object", `[1,2]`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateInitStr(schema, tt.initStr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateInitStr(%s) error = %v, wantErr %v", tt.initStr, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateInitStrStringSchema(t *testing.T) {
+	schema := map[string]interface{}{"type": "string"}
+	if err := ValidateInitStr(schema, `C:\data\file.csv`); err != nil {
+		t.Errorf("raw path should match string schema: %v", err)
+	}
+	if err := ValidateInitStr(nil, `anything`); err != nil {
+		t.Errorf("nil schema should accept anything: %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 
 	"olicanaplot/internal/appconfig"
 	"olicanaplot/internal/logging"
@@ -20,15 +21,31 @@ type Service struct {
 	config  *appconfig.ConfigService
 	app     interface{}    // Application context for plugins
 	logger  logging.Logger // Structured logger
+
+	// eventSubs cancels the plugin event subscriptions made by the frontend,
+	// keyed by the application event they are forwarded to.
+	eventMu   sync.Mutex
+	eventSubs map[string]func()
 }
 
 // NewService creates a new plugin service.
 func NewService(manager *Manager, config *appconfig.ConfigService, logger logging.Logger) *Service {
-	return &Service{
-		manager: manager,
-		config:  config,
-		logger:  logger,
+	s := &Service{
+		manager:   manager,
+		config:    config,
+		logger:    logger,
+		eventSubs: make(map[string]func()),
+	}
+	// The frontend lists the plugins, marking the active and disabled ones
+	notify := func(string) {
+		if app, ok := s.app.(*application.App); ok {
+			app.Event.Emit("pluginsChanged")
+		}
 	}
+	for _, event := range []string{EventRegistered, EventActivated, EventClosed, EventEnabled, EventDisabled} {
+		manager.Subscribe(event, notify)
+	}
+	return s
 }
 
 // SetApp sets the application context for plugins.
@@ -40,12 +57,26 @@ func (s *Service) SetApp(app interface{}) {
 func (s *Service) ActivatePlugin(name string, initStr string) error {
 	s.logger.Info("Activating plugin", "name", name)
 
+	// Refuse before tearing down the current plugin, so the chart is kept.
+	// The frontend shows the error to the user.
+	if s.manager.Get(name) != nil && !s.manager.IsEnabled(name) {
+		s.logger.Warn("Refusing to activate disabled plugin", "name", name)
+		return fmt.Errorf("cannot activate %s: %w", name, ErrPluginDisabled)
+	}
+
+	// Reject init args that don't match the plugin's declared schema before
+	// tearing down the current plugin
+	if sp, ok := s.manager.Get(name).(SchemaProvider); ok {
+		if err := ValidateInitStr(sp.InitSchema(), initStr); err != nil {
+			s.logger.Error("Invalid plugin init args", "name", name, "error", err)
+			return fmt.Errorf("invalid init args for %s: %w", name, err)
+		}
+	}
+
 	// Close the current active plugin if it's an IPC plugin to ensure fresh start
 	active := s.manager.GetActive()
-	if active != nil {
-		s.logger.Debug("Closing current active plugin before switch", "name", active.Name())
-		active.Close()
-	}
+	s.logger.Debug("Closing current active plugin before switch", "name", active.Name())
+	active.Close()
 
 	if err := s.manager.SetActive(name); err != nil {
 		s.logger.Error("Failed to set active plugin", "name", name, "error", err)
@@ -53,23 +84,33 @@ func (s *Service) ActivatePlugin(name string, initStr string) error {
 	}
 
 	plugin := s.manager.GetActive()
-	if plugin == nil {
-		err := fmt.Errorf("plugin not found: %s", name)
-		s.logger.Error("Plugin not found", "name", name)
-		return err
-	}
 
 	// Create a plugin-specific logger
 	pluginLogger := logging.NewLogger(name)
+	if ls, ok := plugin.(LoggerSetter); ok {
+		ls.SetLogger(pluginLogger)
+	}
 
 	// Call Initialize with the app context and logger
 	_, err := plugin.Initialize(s.app, initStr, pluginLogger)
+	s.manager.InvalidateConfigCache()
 	if err != nil {
 		s.logger.Warn("Plugin initialization returned error", "name", name, "error", err)
 	}
 	return err
 }
 
+// ActivatePluginByPath activates the plugin whose executable is path, as
+// ActivatePlugin does. It lets callers that know a plugin's executable but
+// not its name, which may change between versions, select it.
+func (s *Service) ActivatePluginByPath(path string, initStr string) error {
+	plugin := s.manager.GetPluginByPath(path)
+	if plugin == nil {
+		return fmt.Errorf("no plugin registered for %s", path)
+	}
+	return s.ActivatePlugin(plugin.Name(), initStr)
+}
+
 // PluginMetadata contains basic information about a plugin.
 type PluginMetadata struct {
 	Name         string        `json:"name"`
@@ -77,6 +118,7 @@ type PluginMetadata struct {
 	FilePatterns []FilePattern `json:"patterns"`
 	IsInternal   bool          `json:"is_internal"`
 	Enabled      bool          `json:"enabled"`
+	Info         *PluginInfo   `json:"info,omitempty"` // Set for plugins implementing Informer
 }
 
 // OpenFileResult contains the result of a file open operation.
@@ -112,14 +154,88 @@ func (s *Service) SetPluginEnabled(name string, enabled bool) error {
 	}
 	s.config.SetDisabledPlugins(disabled)
 
-	// Notify frontend
-	if app, ok := s.app.(*application.App); ok {
-		app.Event.Emit("pluginsChanged")
+	return nil
+}
+
+// UnregisterPlugin removes an external plugin, e.g. after its executable
+// has been deleted.
+func (s *Service) UnregisterPlugin(name string) error {
+	s.logger.Info("Unregistering plugin", "name", name)
+	return s.manager.Unregister(name)
+}
+
+// CreatePipeline registers a plugin that applies the named steps, such as
+// "moving_average(20)" or "fft", to every series of sourceName. Activate it
+// by its name, the source and step names joined by " | ".
+func (s *Service) CreatePipeline(sourceName string, steps []string) error {
+	source := s.manager.Get(sourceName)
+	if source == nil {
+		return fmt.Errorf("plugin not found: %s", sourceName)
+	}
+	if len(steps) == 0 {
+		return fmt.Errorf("pipeline needs at least one step")
+	}
+
+	transformers := make([]DataTransformer, len(steps))
+	for i, spec := range steps {
+		t, err := ParseTransformer(spec)
+		if err != nil {
+			return err
+		}
+		transformers[i] = t
+	}
+
+	pipeline := NewPipeline(source, transformers...)
+	if err := s.manager.Register(pipeline, true); err != nil {
+		return err
 	}
+	s.logger.Info("Created pipeline", "name", pipeline.Name())
 
 	return nil
 }
 
+// PluginEventName returns the application event to which SubscribePluginEvent
+// forwards eventName of the named plugin.
+func PluginEventName(pluginName, eventName string) string {
+	return fmt.Sprintf("plugin-event-%s-%s", pluginName, eventName)
+}
+
+// SubscribePluginEvent forwards eventName of the named plugin, with its data,
+// to the frontend as the application event it returns. Subscribing again to
+// the same event has no further effect.
+func (s *Service) SubscribePluginEvent(pluginName, eventName string) (string, error) {
+	if s.manager.Get(pluginName) == nil {
+		return "", fmt.Errorf("plugin not found: %s", pluginName)
+	}
+	name := PluginEventName(pluginName, eventName)
+
+	s.eventMu.Lock()
+	defer s.eventMu.Unlock()
+	if _, ok := s.eventSubs[name]; ok {
+		return name, nil
+	}
+	s.eventSubs[name] = s.manager.Events().Subscribe(pluginName, eventName, func(data interface{}) {
+		if app, ok := s.app.(*application.App); ok {
+			app.Event.Emit(name, data)
+		}
+	})
+	s.logger.Debug("Subscribed to plugin event", "plugin", pluginName, "event", eventName)
+	return name, nil
+}
+
+// UnsubscribePluginEvent stops forwarding an event subscribed to with
+// SubscribePluginEvent.
+func (s *Service) UnsubscribePluginEvent(pluginName, eventName string) {
+	name := PluginEventName(pluginName, eventName)
+
+	s.eventMu.Lock()
+	defer s.eventMu.Unlock()
+	if cancel, ok := s.eventSubs[name]; ok {
+		cancel()
+		delete(s.eventSubs, name)
+	}
+}
+
 // LogSeriesAdded logs when a new series is added (e.g., from the frontend).
 func (s *Service) LogSeriesAdded(name string, points int) {
 	s.logger.Info("Series added", "name", name, "points", points)
@@ -254,18 +370,84 @@ func (s *Service) OpenFile() (*OpenFileResult, error) {
 	}, nil
 }
 
+// GetPluginCapabilities returns the protocol methods a plugin implements.
+// Plugins that don't declare any, including the built-in ones, report
+// BaseCapabilities.
+func (s *Service) GetPluginCapabilities(name string) ([]string, error) {
+	plugin := s.manager.Get(name)
+	if plugin == nil {
+		return nil, fmt.Errorf("plugin not found: %s", name)
+	}
+	if cp, ok := plugin.(CapabilityProvider); ok {
+		if caps := cp.Capabilities(); len(caps) > 0 {
+			return caps, nil
+		}
+	}
+	return BaseCapabilities(), nil
+}
+
+// ComputeCorrelation returns the Pearson correlation coefficient between two
+// series of the active plugin.
+func (s *Service) ComputeCorrelation(seriesA, seriesB string) (float64, error) {
+	r, err := s.manager.ComputeCorrelation(seriesA, seriesB)
+	if err != nil {
+		s.logger.Warn("Failed to compute correlation", "a", seriesA, "b", seriesB, "error", err)
+		return 0, err
+	}
+	return r, nil
+}
+
+// RefreshPlugin makes the active plugin re-read its data source without
+// reconfiguring it. The frontend should reload the series afterwards.
+func (s *Service) RefreshPlugin() error {
+	if err := s.manager.RefreshActive(); err != nil {
+		s.logger.Warn("Failed to refresh plugin", "name", s.manager.ActiveName(), "error", err)
+		return err
+	}
+	s.logger.Info("Refreshed plugin", "name", s.manager.ActiveName())
+	return nil
+}
+
+// UpdatePluginConfig changes the active plugin's parameters without
+// reinitializing it. The frontend should reload the series afterwards.
+func (s *Service) UpdatePluginConfig(data map[string]interface{}) error {
+	active := s.manager.GetActive()
+	updater, ok := active.(ConfigUpdater)
+	if !ok {
+		return fmt.Errorf("plugin %s does not support config updates", active.Name())
+	}
+	err := updater.UpdateConfig(data)
+	s.manager.InvalidateConfigCache()
+	if err != nil {
+		s.logger.Warn("Failed to update plugin config", "name", active.Name(), "error", err)
+		return err
+	}
+	s.logger.Info("Updated plugin config", "name", active.Name())
+	return nil
+}
+
+// GetPluginConfig returns the active plugin's current parameters.
+func (s *Service) GetPluginConfig() (map[string]interface{}, error) {
+	active := s.manager.GetActive()
+	updater, ok := active.(ConfigUpdater)
+	if !ok {
+		return nil, fmt.Errorf("plugin %s does not support config updates", active.Name())
+	}
+	return updater.GetConfig()
+}
+
 // GetChartConfig returns the chart configuration for the active plugin.
 func (s *Service) GetChartConfig() (*ChartConfig, error) {
 	active := s.manager.GetActive()
-	if active == nil {
-		return nil, fmt.Errorf("no active plugin")
-	}
 	config, err := active.GetChartConfig("")
 	if err != nil {
 		return nil, err
 	}
 	if config != nil {
-		config.SetDefaults()
+		if err := config.SetDefaults(); err != nil {
+			return nil, fmt.Errorf("invalid chart config from %s: %w", active.Name(), err)
+		}
+		ApplyTimeRange(active, config)
 	}
 	return config, nil
 }
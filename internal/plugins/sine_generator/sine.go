@@ -2,21 +2,56 @@
 package sine_generator
 
 import (
+	"encoding/json"
+	"fmt"
 	"math"
+	"sync"
+
 	"olicanaplot/internal/logging"
 	"olicanaplot/internal/plugins"
 )
 
 const pluginName = "Sine Wave"
 
+// sampleRate is the number of points per second of the generated wave, so
+// that the default of 361 points is one cycle of 1 Hz.
+const sampleRate = 360.0
+
+// waveConfig is the JSON accepted as Initialize's initStr. Fields left out
+// keep their defaults, those of defaultWaveConfig.
+type waveConfig struct {
+	Frequency float64 `json:"frequency"` // In Hz
+	Amplitude float64 `json:"amplitude"`
+	Phase     float64 `json:"phase"` // In radians
+	NumPoints int     `json:"numPoints"`
+}
+
+// defaultWaveConfig returns the wave generated when initStr is empty.
+func defaultWaveConfig() waveConfig {
+	return waveConfig{Frequency: 1, Amplitude: 1, Phase: 0, NumPoints: 361}
+}
+
 // Plugin implements the sine wave generator.
 type Plugin struct {
-	logger logging.Logger
+	mu        sync.Mutex
+	frequency float64
+	amplitude float64
+	phase     float64
+	numPoints int
+	logger    logging.Logger
 }
 
 // New creates a new sine wave plugin.
 func New() *Plugin {
-	return &Plugin{}
+	p := &Plugin{}
+	p.setConfig(defaultWaveConfig())
+	return p
+}
+
+func (p *Plugin) setConfig(c waveConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.frequency, p.amplitude, p.phase, p.numPoints = c.Frequency, c.Amplitude, c.Phase, c.NumPoints
 }
 
 // Name returns the display name of the plugin.
@@ -39,20 +74,35 @@ func (p *Plugin) GetFilePatterns() []plugins.FilePattern {
 	return nil
 }
 
-// Initialize sets up the plugin. No configuration needed for sine wave.
+// Initialize configures the wave from initStr, a JSON waveConfig, or restores
+// the defaults if it is empty.
 func (p *Plugin) Initialize(ctx interface{}, initStr string, logger logging.Logger) (string, error) {
 	p.logger = logger
-	logger.Debug("Sine wave plugin initialized")
+	c := defaultWaveConfig()
+	if initStr != "" {
+		if err := json.Unmarshal([]byte(initStr), &c); err != nil {
+			return "{}", fmt.Errorf("invalid sine wave config: %w", err)
+		}
+		if c.NumPoints < 1 {
+			return "{}", fmt.Errorf("invalid sine wave config: numPoints must be positive, got %d", c.NumPoints)
+		}
+	}
+	p.setConfig(c)
+	logger.Debug("Sine wave plugin initialized", "frequency", c.Frequency, "amplitude", c.Amplitude,
+		"phase", c.Phase, "points", c.NumPoints)
 	return "{}", nil
 }
 
 // GetChartConfig returns chart display configuration.
 func (p *Plugin) GetChartConfig(args string) (*plugins.ChartConfig, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	return &plugins.ChartConfig{
-		Title: "Sine Wave",
+		Title: fmt.Sprintf("Sine Wave (%g Hz)", p.frequency),
 		Axes: []plugins.AxisGroupConfig{
 			{
-				XAxes: []plugins.AxisConfig{{Title: "Degrees"}},
+				XAxes: []plugins.AxisConfig{{Title: "Time (s)"}},
 				YAxes: []plugins.AxisConfig{{Title: "Amplitude"}},
 			},
 		},
@@ -74,34 +124,31 @@ func (p *Plugin) GetSeriesData(seriesID string, preferredStorage string) ([]floa
 	if p.logger != nil {
 		p.logger.Info("Sine plugin data request", "seriesID", seriesID, "preferredStorage", preferredStorage)
 	}
-	if preferredStorage == "arrays" {
-		return getSeriesDataArrays(), "arrays", nil
-	}
-	return getSeriesDataInterleaved(), "interleaved", nil
-}
-
-func getSeriesDataArrays() []float64 {
-	numPoints := 361
-	result := make([]float64, numPoints*2)
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
-	for i := 0; i < numPoints; i++ {
-		x := float64(i)
-		y := math.Sin(float64(i) * math.Pi / 180.0)
-		result[i] = x
-		result[numPoints+i] = y
+	if preferredStorage == "arrays" {
+		return p.generate(true), "arrays", nil
 	}
-	return result
+	return p.generate(false), "interleaved", nil
 }
 
-func getSeriesDataInterleaved() []float64 {
-	numPoints := 361
+// generate samples the wave in the arrays or interleaved layout. Callers
+// must hold p.mu.
+func (p *Plugin) generate(arrays bool) []float64 {
+	numPoints := p.numPoints
 	result := make([]float64, numPoints*2)
 
 	for i := 0; i < numPoints; i++ {
-		x := float64(i)
-		y := math.Sin(float64(i) * math.Pi / 180.0)
-		result[i*2] = x
-		result[i*2+1] = y
+		x := float64(i) / sampleRate
+		y := p.amplitude * math.Sin(2*math.Pi*p.frequency*x+p.phase)
+		if arrays {
+			result[i] = x
+			result[numPoints+i] = y
+		} else {
+			result[i*2] = x
+			result[i*2+1] = y
+		}
 	}
 	return result
 }
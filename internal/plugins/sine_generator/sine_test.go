@@ -0,0 +1,63 @@
+package sine_generator
+
+import (
+	"math"
+	"testing"
+
+	"olicanaplot/internal/logging"
+)
+
+func TestInitializeConfig(t *testing.T) {
+	p := New()
+	if _, err := p.Initialize(nil, `{"frequency":0.05,"amplitude":2.0,"phase":0.785,"numPoints":1000}`, logging.NewNullLogger()); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	data, storage, err := p.GetSeriesData("sine_0", "arrays")
+	if err != nil || storage != "arrays" {
+		t.Fatalf("GetSeriesData = %s, %v", storage, err)
+	}
+	if len(data) != 2000 {
+		t.Fatalf("got %d values, want 1000 points", len(data))
+	}
+	// 1000 points at 360 per second cover 2.8 s, taking the phase from
+	// about π/4 past the peak at π/2
+	peak := 0.0
+	for _, y := range data[1000:] {
+		peak = max(peak, math.Abs(y))
+	}
+	if math.Abs(peak-2) > 1e-3 {
+		t.Errorf("amplitude = %v, want 2", peak)
+	}
+	if want := 2 * math.Sin(0.785); math.Abs(data[1000]-want) > 1e-12 {
+		t.Errorf("first value = %v, want %v from the phase", data[1000], want)
+	}
+
+	config, _ := p.GetChartConfig("")
+	if config.Title != "Sine Wave (0.05 Hz)" {
+		t.Errorf("title = %q", config.Title)
+	}
+}
+
+func TestInitializeDefaults(t *testing.T) {
+	p := New()
+	p.Initialize(nil, `{"amplitude":3}`, logging.NewNullLogger())
+	if _, err := p.Initialize(nil, "", logging.NewNullLogger()); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	// One cycle of 1 Hz, sampled every degree
+	data, _, _ := p.GetSeriesData("sine_0", "interleaved")
+	if len(data) != 361*2 {
+		t.Fatalf("got %d values, want 361 points", len(data))
+	}
+	if y := data[90*2+1]; math.Abs(y-1) > 1e-12 {
+		t.Errorf("y at 90 degrees = %v, want 1", y)
+	}
+
+	for _, initStr := range []string{`{"numPoints":0}`, `not json`} {
+		if _, err := p.Initialize(nil, initStr, logging.NewNullLogger()); err == nil {
+			t.Errorf("Initialize(%q) succeeded, want error", initStr)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package plugins
 
 import (
+	"encoding/json"
 	sdk "olicanaplot/sdk/go"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -24,6 +26,9 @@ func TestStructSynchronization(t *testing.T) {
 		{"AxisGroupConfig", AxisGroupConfig{}, sdk.AxisGroupConfig{}},
 		{"SeriesConfig", SeriesConfig{}, sdk.SeriesConfig{}},
 		{"FilePattern", FilePattern{}, sdk.FilePattern{}},
+		{"TimeRange", TimeRange{}, sdk.TimeRange{}},
+		{"FileInfo", FileInfo{}, sdk.FileInfo{}},
+		{"SeriesPoints", SeriesPoints{}, sdk.SeriesPoints{}},
 	}
 
 	for _, tt := range tests {
@@ -60,3 +65,190 @@ func TestStructSynchronization(t *testing.T) {
 		})
 	}
 }
+
+// encode returns the JSON the host sends the frontend for v.
+func encode(t *testing.T, v interface{}) string {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	return string(raw)
+}
+
+func TestSeriesConfigOpacity(t *testing.T) {
+	// As a plugin in any language sends it
+	var s SeriesConfig
+	if err := json.Unmarshal([]byte(`{"id":"s1","opacity":0.25}`), &s); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	s.SetDefaults()
+	if s.Opacity != 0.25 {
+		t.Errorf("Opacity = %v, want 0.25 to be kept", s.Opacity)
+	}
+	if got := encode(t, s); !strings.Contains(got, `"opacity":0.25`) {
+		t.Errorf("series = %s, want opacity 0.25", got)
+	}
+
+	s = SeriesConfig{}
+	s.SetDefaults()
+	if s.Opacity != 1.0 {
+		t.Errorf("default Opacity = %v, want 1.0", s.Opacity)
+	}
+	if got := encode(t, sdk.SeriesConfig{ID: "s1"}); strings.Contains(got, "opacity") {
+		t.Errorf("series = %s, want no opacity when unset", got)
+	}
+}
+
+func TestChartConfigDeprecatedGridMigration(t *testing.T) {
+	// A plugin built against the SDK still sets the top-level fields
+	raw, err := json.Marshal(sdk.ChartConfig{Title: "Legacy", Rows: 2, Cols: 3})
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	var c ChartConfig
+	if err := json.Unmarshal(raw, &c); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if err := c.SetDefaults(); err != nil {
+		t.Fatalf("SetDefaults failed: %v", err)
+	}
+	if c.Grid == nil || c.Grid.Rows != 2 || c.Grid.Cols != 3 {
+		t.Fatalf("Grid = %+v, want 2x3", c.Grid)
+	}
+	if c.Rows != 0 || c.Cols != 0 {
+		t.Errorf("deprecated fields not cleared: Rows=%d Cols=%d", c.Rows, c.Cols)
+	}
+
+	// Grid wins when both are set
+	c = ChartConfig{Grid: &GridConfig{Rows: 1, Cols: 1}, Rows: 4, Cols: 4}
+	if err := c.SetDefaults(); err != nil {
+		t.Fatalf("SetDefaults failed: %v", err)
+	}
+	if c.Grid.Rows != 1 || c.Grid.Cols != 1 {
+		t.Errorf("Grid = %+v, want 1x1", c.Grid)
+	}
+}
+
+func TestSeriesConfigDescription(t *testing.T) {
+	var s SeriesConfig
+	if err := json.Unmarshal([]byte(`{"id":"s1","description":"Inlet pressure"}`), &s); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if s.Description != "Inlet pressure" {
+		t.Errorf("Description = %q, want Inlet pressure", s.Description)
+	}
+	if got := encode(t, s); !strings.Contains(got, `"description":"Inlet pressure"`) {
+		t.Errorf("series = %s, want the description", got)
+	}
+	if got := encode(t, sdk.SeriesConfig{ID: "s1"}); strings.Contains(got, "description") {
+		t.Errorf("series = %s, want no description when unset", got)
+	}
+}
+
+func TestAxisConfigScale(t *testing.T) {
+	var a AxisConfig
+	if err := json.Unmarshal([]byte(`{"title":"Temperature","scale_factor":1,"scale_offset":-273.15}`), &a); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if a.ScaleFactor != 1 || a.ScaleOffset != -273.15 {
+		t.Errorf("scale = %v, %v, want 1 and -273.15", a.ScaleFactor, a.ScaleOffset)
+	}
+	if got := encode(t, a); !strings.Contains(got, `"scale_factor":1,"scale_offset":-273.15`) {
+		t.Errorf("axis = %s, want the scale hints", got)
+	}
+
+	// Unscaled axes leave the hints out of the chart config
+	if got := encode(t, sdk.AxisConfig{Title: "Pressure"}); got != `{"title":"Pressure"}` {
+		t.Errorf("unscaled axis = %s, want no scale hints", got)
+	}
+}
+
+func TestColorscaleFields(t *testing.T) {
+	var c ChartConfig
+	if err := json.Unmarshal([]byte(`{"colorscale":"Viridis"}`), &c); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	var s SeriesConfig
+	if err := json.Unmarshal([]byte(`{"id":"s1","color_scale_column":"density"}`), &s); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if c.Colorscale != "Viridis" || s.ColorScaleColumn != "density" {
+		t.Errorf("colorscale = %q, column = %q, want Viridis and density", c.Colorscale, s.ColorScaleColumn)
+	}
+	if got := encode(t, c); !strings.Contains(got, `"colorscale":"Viridis"`) {
+		t.Errorf("chart = %s, want the colorscale", got)
+	}
+	if got := encode(t, s); !strings.Contains(got, `"color_scale_column":"density"`) {
+		t.Errorf("series = %s, want the color scale column", got)
+	}
+	for _, v := range []interface{}{sdk.ChartConfig{}, sdk.SeriesConfig{}} {
+		if got := encode(t, v); strings.Contains(got, "colorscale") || strings.Contains(got, "color_scale") {
+			t.Errorf("%s: want no colorscale when unset", got)
+		}
+	}
+}
+
+func TestSeriesConfigLoadPriority(t *testing.T) {
+	var series []SeriesConfig
+	if err := json.Unmarshal([]byte(`[{"id":"a","load_priority":-1},{"id":"b"}]`), &series); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	for i := range series {
+		series[i].SetDefaults()
+	}
+	// Series that don't set a priority load in the default group
+	if series[0].LoadPriority != -1 || series[1].LoadPriority != 0 {
+		t.Errorf("priorities = %d, %d, want -1 and 0", series[0].LoadPriority, series[1].LoadPriority)
+	}
+	if got := encode(t, series[0]); !strings.Contains(got, `"load_priority":-1`) {
+		t.Errorf("series = %s, want load_priority -1", got)
+	}
+	if got := encode(t, series[1]); strings.Contains(got, "load_priority") {
+		t.Errorf("series = %s, want no load_priority when unset", got)
+	}
+}
+
+func TestChartConfigSubplotTitles(t *testing.T) {
+	c := ChartConfig{Rows: 2, Cols: 2, SubplotTitles: []string{"A", "B", "", "D", "Extra"}}
+	if err := c.SetDefaults(); err != nil {
+		t.Fatalf("SetDefaults failed: %v", err)
+	}
+
+	want := map[[2]int]string{{0, 0}: "A", {0, 1}: "B", {1, 0}: "", {1, 1}: "D"}
+	if len(c.Axes) != len(want) {
+		t.Fatalf("got %d axis groups, want %d", len(c.Axes), len(want))
+	}
+	for _, ag := range c.Axes {
+		cell := [2]int{ag.Subplot.Row, ag.Subplot.Col}
+		if ag.Title != want[cell] {
+			t.Errorf("subplot %v title = %q, want %q", cell, ag.Title, want[cell])
+		}
+	}
+
+	// Titles override the axis groups' own, except for empty entries
+	c = ChartConfig{
+		Axes: []AxisGroupConfig{
+			{Title: "Kept", Subplot: &SubPlot{Row: 0, Col: 0}},
+			{Title: "Replaced", Subplot: &SubPlot{Row: 0, Col: 1}},
+		},
+		SubplotTitles: []string{"", "New"},
+	}
+	if err := c.SetDefaults(); err != nil {
+		t.Fatalf("SetDefaults failed: %v", err)
+	}
+	if c.Axes[0].Title != "Kept" || c.Axes[1].Title != "New" {
+		t.Errorf("titles = %q, %q, want Kept and New", c.Axes[0].Title, c.Axes[1].Title)
+	}
+
+	// A negative index is rejected instead of indexing SubplotTitles with it
+	for _, cell := range []SubPlot{{Row: 0, Col: -1}, {Row: -1, Col: 1}} {
+		c = ChartConfig{
+			Axes:          []AxisGroupConfig{{Subplot: &cell}},
+			SubplotTitles: []string{"A", "B"},
+		}
+		if err := c.SetDefaults(); err == nil {
+			t.Errorf("expected error for subplot %+v", cell)
+		}
+	}
+}
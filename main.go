@@ -3,10 +3,13 @@ package main
 import (
 	"embed"
 	_ "embed"
+	"errors"
 	"io"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/wailsapp/wails/v3/pkg/application"
 
@@ -42,6 +45,10 @@ func init() {
 // and starts a goroutine that emits a time-based event every second. It subsequently runs the application and
 // logs any error that might occur.
 func main() {
+	// When relaunched to start a sandboxed IPC plugin, this execs the plugin
+	// and never returns
+	ipc.RunSandboxShim()
+
 	// Create config service first to get log path
 	configService := appconfig.NewConfigService()
 
@@ -65,6 +72,9 @@ func main() {
 	logger := logging.NewLogger("OlicanaPlot")
 	logger.Info("Starting OlicanaPlot")
 
+	// Series without a line width fall back to the configured default
+	plugins.HostLineWidthDefault = configService.GetDefaultLineWidth
+
 	// Create plugin manager and register plugins
 	pluginManager := plugins.NewManager(logger)
 
@@ -77,7 +87,7 @@ func main() {
 	if err := pluginManager.Register(process_model_generator.New(), true); err != nil {
 		logger.Warn("Failed to register process model plugin", "error", err)
 	}
-	if err := pluginManager.Register(csv_reader.New(), true); err != nil {
+	if err := pluginManager.Register(csv_reader.New(configService), true); err != nil {
 		logger.Warn("Failed to register CSV plugin", "error", err)
 	}
 	if err := pluginManager.Register(attributes_generator.New(), true); err != nil {
@@ -91,6 +101,12 @@ func main() {
 	builtInDir, _ := filepath.Abs("plugins")
 	searchDirs := append([]string{builtInDir}, configService.GetPluginSearchDirs()...)
 	loader := ipc.NewLoader(searchDirs, logger)
+	loader.SetSandbox(configService.GetSandboxIPC())
+	loader.SetDialogTimeout(time.Duration(configService.GetDialogTimeoutSeconds()) * time.Second)
+	loader.SetDPIScale(configService.GetDPIScale())
+	loader.SetLocale(configService.GetLocale)
+	loader.SetBufferSize(configService.GetIPCBufferSize())
+	loader.SetMaxPlugins(pluginManager.GetMaxPlugins())
 	ipcPlugins, err := loader.Discover()
 	if err != nil {
 		logger.Warn("Failed to discover IPC plugins", "error", err)
@@ -98,6 +114,9 @@ func main() {
 	for _, p := range ipcPlugins {
 		if err := pluginManager.Register(p, false); err != nil {
 			logger.Warn("Failed to register IPC plugin", "name", p.Name(), "error", err)
+			if errors.Is(err, plugins.ErrTooManyPlugins) {
+				break
+			}
 		}
 	}
 
@@ -126,8 +145,10 @@ func main() {
 			application.NewService(configService),
 		},
 		Assets: application.AssetOptions{
-			Handler:    application.AssetFileServerFS(assets),
-			Middleware: data.Middleware(pluginManager, logger),
+			Handler: application.AssetFileServerFS(assets),
+			Middleware: func(next http.Handler) http.Handler {
+				return data.BenchmarkMiddleware(data.Middleware(pluginManager, logger, data.WithAutoRefresh(configService.GetAutoRefreshSeconds))(next), logger)
+			},
 		},
 		Mac: application.MacOptions{
 			ApplicationShouldTerminateAfterLastWindowClosed: true,
@@ -157,6 +178,18 @@ func main() {
 	// Provide application context to services for dialog spawning
 	pluginService.SetApp(app)
 	configService.SetApp(app)
+	if err := configService.WatchConfig(); err != nil {
+		logger.Warn("Config file changes will not be picked up", "error", err)
+	}
+
+	// Plugin directories added at runtime are scanned straight away
+	configService.SetPluginDiscoverer(func(dir string) error {
+		names, err := loader.RegisterDir(dir, pluginManager)
+		if len(names) > 0 {
+			logger.Info("Registered IPC plugins from new directory", "dir", dir, "plugins", names)
+		}
+		return err
+	})
 
 	// Fetch IPC plugin file patterns in the background
 	go func() {
@@ -173,6 +206,6 @@ func main() {
 
 	// If an error occurred while running the application, log it and exit.
 	if err != nil {
-		log.Fatal(err)
+		logger.Fatal("Application exited with error", "error", err)
 	}
 }
@@ -0,0 +1,3 @@
+@echo off
+REM Build ARMA Simulator IPC Plugin
+go build -ldflags="-w -s -H windowsgui" -o arma_simulator.exe .
@@ -0,0 +1,331 @@
+// ARMA Simulator IPC Plugin - Generates ARMA(p, q) processes with
+// user-specified coefficients:
+//
+//	y[t] = Σ a_i * y[t-i] + e[t] + Σ b_j * e[t-j]
+//
+// where e is white noise. Each series starts from a different initial value.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	sdk "olicanaplot/sdk/go"
+)
+
+const (
+	pluginName    = "ARMA Simulator"
+	pluginVersion = 1
+)
+
+var capabilities = append(sdk.BaseCapabilities(), "event", "ping")
+
+type pluginState struct {
+	ar            []float64 // a_1..a_p
+	ma            []float64 // b_1..b_q
+	numSeries     int
+	numPoints     int
+	noise         float64
+	initialSpread float64 // Series start evenly spaced in [-spread, spread]
+	seed          int64
+}
+
+var state = &pluginState{
+	ar:            []float64{0.7, -0.2},
+	ma:            []float64{0.3, 0.1},
+	numSeries:     3,
+	numPoints:     10000,
+	noise:         1.0,
+	initialSpread: 10.0,
+}
+
+func main() {
+	// Metadata support
+	if len(os.Args) > 1 && os.Args[1] == "--metadata" {
+		sdk.SendMetadata(sdk.Metadata{Name: pluginName, APIVersion: pluginVersion, Capabilities: capabilities})
+		return
+	}
+
+	state.seed = time.Now().UnixNano()
+	handleIPC()
+}
+
+func handleIPC() {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		req, err := sdk.ParseRequest(scanner.Bytes())
+		if err != nil {
+			sdk.SendError("invalid json")
+			continue
+		}
+
+		switch req.Method {
+		case "info":
+			sdk.SendResponse(sdk.Response{
+				Name:         pluginName,
+				Version:      pluginVersion,
+				Capabilities: capabilities,
+			})
+
+		case "initialize":
+			if err := handleInitialize(scanner); err != nil {
+				sdk.SendError(err.Error())
+			} else {
+				sdk.SendResponse(sdk.Response{Result: "success"})
+			}
+
+		case "get_chart_config":
+			sdk.SendResponse(sdk.Response{
+				Result: sdk.ChartConfig{
+					Title: fmt.Sprintf("ARMA(%d, %d) Simulation", len(state.ar), len(state.ma)),
+					Axes: []sdk.AxisGroupConfig{
+						{
+							XAxes: []sdk.AxisConfig{{Title: "Sample"}},
+							YAxes: []sdk.AxisConfig{{Title: "Value"}},
+						},
+					},
+				},
+			})
+
+		case "get_series_config":
+			series := make([]sdk.SeriesConfig, state.numSeries)
+			for i := range series {
+				series[i] = sdk.SeriesConfig{
+					ID:   fmt.Sprintf("s%d", i),
+					Name: fmt.Sprintf("y0 = %g", initialValue(i)),
+				}
+			}
+			sdk.SendResponse(sdk.Response{Result: series})
+
+		case "get_series_data":
+			data, storage := generateData(req.SeriesID, req.PreferredStorage)
+			sdk.SendBinaryData(data, storage)
+
+		case "get_time_range":
+			sdk.SendTimeRange(0, float64(state.numPoints-1))
+
+		case "ping":
+			sdk.HandlePing(req)
+
+		case "event":
+			// Host notifications need no reply
+			sdk.HandleEvent(req)
+
+		case "quit":
+			sdk.HandleQuit(req)
+
+		default:
+			sdk.SendError(fmt.Sprintf("unknown method: %s", req.Method))
+		}
+	}
+}
+
+// handleInitialize shows the configuration form and applies the result.
+func handleInitialize(scanner *bufio.Scanner) error {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"arCoefficients": map[string]interface{}{
+				"type":        "string",
+				"title":       "AR Coefficients",
+				"description": "Comma-separated a_1, a_2, ... applied to past values",
+				"default":     formatCoefficients(state.ar),
+			},
+			"maCoefficients": map[string]interface{}{
+				"type":        "string",
+				"title":       "MA Coefficients",
+				"description": "Comma-separated b_1, b_2, ... applied to past noise",
+				"default":     formatCoefficients(state.ma),
+			},
+			"numSeries": map[string]interface{}{
+				"type":    "integer",
+				"title":   "Number of Series",
+				"minimum": 1,
+				"maximum": 10,
+				"default": state.numSeries,
+			},
+			"numPoints": map[string]interface{}{
+				"type":    "integer",
+				"title":   "Number of Points",
+				"minimum": 10,
+				"maximum": 1000000,
+				"default": state.numPoints,
+			},
+			"noise": map[string]interface{}{
+				"type":    "number",
+				"title":   "Noise Sigma",
+				"minimum": 0,
+				"default": state.noise,
+			},
+			"initialSpread": map[string]interface{}{
+				"type":        "number",
+				"title":       "Initial Value Spread",
+				"description": "Series start evenly spaced between -spread and +spread",
+				"minimum":     0,
+				"default":     state.initialSpread,
+			},
+		},
+	}
+	uiSchema := map[string]interface{}{
+		"ui:order":  []string{"arCoefficients", "maCoefficients", "numSeries", "numPoints", "noise", "initialSpread"},
+		"numSeries": map[string]interface{}{"ui:widget": "range"},
+	}
+
+	sdk.SendShowForm("ARMA Configuration", schema, uiSchema, nil)
+
+	if !scanner.Scan() {
+		return fmt.Errorf("failed to read form response")
+	}
+	var resp struct {
+		Result struct {
+			ARCoefficients string  `json:"arCoefficients"`
+			MACoefficients string  `json:"maCoefficients"`
+			NumSeries      int     `json:"numSeries"`
+			NumPoints      int     `json:"numPoints"`
+			Noise          float64 `json:"noise"`
+			InitialSpread  float64 `json:"initialSpread"`
+		} `json:"result"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+		return fmt.Errorf("failed to parse form response: %v", err)
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("configuration cancelled: %s", resp.Error)
+	}
+
+	ar, err := parseCoefficients(resp.Result.ARCoefficients)
+	if err != nil {
+		return fmt.Errorf("invalid AR coefficients: %w", err)
+	}
+	ma, err := parseCoefficients(resp.Result.MACoefficients)
+	if err != nil {
+		return fmt.Errorf("invalid MA coefficients: %w", err)
+	}
+	if resp.Result.NumSeries < 1 || resp.Result.NumPoints < 1 {
+		return fmt.Errorf("number of series and points must be positive")
+	}
+
+	if !arBounded(ar) {
+		sdk.Log("warn", "AR coefficients do not satisfy sum(|a_i|) < 1, the series may diverge")
+	}
+
+	state.ar = ar
+	state.ma = ma
+	state.numSeries = resp.Result.NumSeries
+	state.numPoints = resp.Result.NumPoints
+	state.noise = resp.Result.Noise
+	state.initialSpread = resp.Result.InitialSpread
+	state.seed = time.Now().UnixNano()
+
+	sdk.Log("info", fmt.Sprintf("ARMA configured: ar=%v ma=%v series=%d points=%d", ar, ma, state.numSeries, state.numPoints))
+	return nil
+}
+
+// parseCoefficients parses a comma-separated list of numbers. An empty string
+// means no coefficients.
+func parseCoefficients(s string) ([]float64, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	fields := strings.Split(s, ",")
+	coeffs := make([]float64, len(fields))
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(strings.TrimSpace(f), 64)
+		if err != nil {
+			return nil, err
+		}
+		coeffs[i] = v
+	}
+	return coeffs, nil
+}
+
+func formatCoefficients(coeffs []float64) string {
+	parts := make([]string, len(coeffs))
+	for i, c := range coeffs {
+		parts[i] = strconv.FormatFloat(c, 'g', -1, 64)
+	}
+	return strings.Join(parts, ",")
+}
+
+// initialValue returns the starting value of series idx.
+func initialValue(idx int) float64 {
+	if state.numSeries < 2 {
+		return 0
+	}
+	return state.initialSpread * (2*float64(idx)/float64(state.numSeries-1) - 1)
+}
+
+// simulateARMA generates n samples of the ARMA process. The past values
+// y[-1]..y[-p] all start at y0 and the past noise at zero.
+func simulateARMA(ar, ma []float64, n int, y0, noise float64, rng *rand.Rand) []float64 {
+	// State buffers, index 0 holds the most recent value
+	yPast := make([]float64, len(ar))
+	ePast := make([]float64, len(ma))
+	for i := range yPast {
+		yPast[i] = y0
+	}
+
+	out := make([]float64, n)
+	for t := range out {
+		e := rng.NormFloat64() * noise
+		y := e
+		for i, a := range ar {
+			y += a * yPast[i]
+		}
+		for j, b := range ma {
+			y += b * ePast[j]
+		}
+		out[t] = y
+
+		if len(yPast) > 0 {
+			copy(yPast[1:], yPast)
+			yPast[0] = y
+		}
+		if len(ePast) > 0 {
+			copy(ePast[1:], ePast)
+			ePast[0] = e
+		}
+	}
+	return out
+}
+
+func generateData(seriesID string, preferredStorage string) ([]float64, string) {
+	var seriesIdx int
+	fmt.Sscanf(seriesID, "s%d", &seriesIdx)
+
+	rng := rand.New(rand.NewSource(state.seed + int64(seriesIdx*54321)))
+	y := simulateARMA(state.ar, state.ma, state.numPoints, initialValue(seriesIdx), state.noise, rng)
+
+	n := len(y)
+	data := make([]float64, n*2)
+	if preferredStorage == "arrays" {
+		for i := range y {
+			data[i] = float64(i)
+		}
+		copy(data[n:], y)
+		return data, "arrays"
+	}
+	for i, v := range y {
+		data[i*2] = float64(i)
+		data[i*2+1] = v
+	}
+	return data, "interleaved"
+}
+
+// arBounded reports whether Σ|a_i| < 1, a sufficient condition for the AR
+// part to be stationary.
+func arBounded(ar []float64) bool {
+	sum := 0.0
+	for _, a := range ar {
+		sum += math.Abs(a)
+	}
+	return sum < 1
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestParseCoefficients(t *testing.T) {
+	got, err := parseCoefficients(" 0.7, -0.2 ")
+	if err != nil {
+		t.Fatalf("parseCoefficients failed: %v", err)
+	}
+	if len(got) != 2 || got[0] != 0.7 || got[1] != -0.2 {
+		t.Errorf("got %v, want [0.7 -0.2]", got)
+	}
+	if got, err := parseCoefficients(""); err != nil || got != nil {
+		t.Errorf("empty string = %v, %v, want no coefficients", got, err)
+	}
+	if _, err := parseCoefficients("0.7,,0.1"); err == nil {
+		t.Error("expected error for an empty field")
+	}
+}
+
+func TestSimulateARMARecurrence(t *testing.T) {
+	ar := []float64{0.7, -0.2}
+	ma := []float64{0.3, 0.1}
+	y := simulateARMA(ar, ma, 50, 5, 1, rand.New(rand.NewSource(1)))
+
+	// Replay the same noise and check the recurrence term by term
+	rng := rand.New(rand.NewSource(1))
+	e := make([]float64, len(y))
+	for i := range e {
+		e[i] = rng.NormFloat64()
+	}
+	past := func(s []float64, i int, init float64) float64 {
+		if i < 0 {
+			return init
+		}
+		return s[i]
+	}
+	for t0 := range y {
+		want := e[t0] +
+			0.7*past(y, t0-1, 5) - 0.2*past(y, t0-2, 5) +
+			0.3*past(e, t0-1, 0) + 0.1*past(e, t0-2, 0)
+		if math.Abs(y[t0]-want) > 1e-12 {
+			t.Fatalf("y[%d] = %v, want %v", t0, y[t0], want)
+		}
+	}
+}
+
+func TestSimulateARMAInitialConditions(t *testing.T) {
+	// Without noise an AR(1) process decays geometrically from its start
+	y := simulateARMA([]float64{0.5}, nil, 3, 8, 0, rand.New(rand.NewSource(1)))
+	if y[0] != 4 || y[1] != 2 || y[2] != 1 {
+		t.Errorf("got %v, want [4 2 1]", y)
+	}
+}
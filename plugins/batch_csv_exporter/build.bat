@@ -0,0 +1,3 @@
+@echo off
+REM Build Batch CSV Exporter IPC Plugin
+go build -ldflags="-w -s -H windowsgui" -o batch_csv_exporter.exe .
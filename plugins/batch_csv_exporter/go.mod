@@ -0,0 +1,10 @@
+module batch_csv_exporter-ipc
+
+go 1.25
+
+replace olicanaplot => ../../
+
+require (
+	gopkg.in/yaml.v3 v3.0.1
+	olicanaplot v0.0.0-00010101000000-000000000000
+)
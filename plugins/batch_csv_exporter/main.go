@@ -0,0 +1,417 @@
+// Batch CSV Exporter IPC Plugin - Merges the series of many .olicanaplot
+// files into one table and exports it as CSV.
+//
+// Protocol:
+//   - Reads JSON requests from stdin (one per line)
+//   - Writes JSON responses to stdout (one per line)
+//   - Uses show_form to ask for the directory and file pattern
+//   - For binary data, writes a JSON header followed by raw bytes
+//
+// Series with the same name in different files are placed next to each
+// other, and all series are joined on their X values: the merged table has
+// a row for every X value in any file, with gaps where a file has no point.
+// The "save" method, or the output path of the initialize form, writes the
+// merged table to a CSV file.
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	sdk "olicanaplot/sdk/go"
+)
+
+const (
+	pluginName    = "Batch CSV Exporter"
+	pluginVersion = 1
+)
+
+var capabilities = append(sdk.BaseCapabilities(), "event", "ping")
+
+// defaultPattern selects the files of a directory to merge.
+const defaultPattern = "*.olicanaplot"
+
+// exportConfig is what is merged, from the initialize form or arguments.
+type exportConfig struct {
+	Directory string `json:"directory"`
+	Pattern   string `json:"pattern"`
+	Output    string `json:"output"` // CSV written on initialize, optional
+}
+
+// column is one file's series in the merged table.
+type column struct {
+	Name string    // Series name, shared by files with a matching series
+	File string    // Base name of the file
+	Y    []float64 // Aligned with mergedTable.X, NaN where the file has no point
+}
+
+// mergedTable holds the series of all files on a common X axis.
+type mergedTable struct {
+	X       []float64 // Sorted union of the X values of all series
+	Columns []column
+}
+
+// fileSeries are the series read from one file.
+type fileSeries struct {
+	File   string
+	Series []series
+}
+
+// Plugin state
+var (
+	config exportConfig
+	merged mergedTable
+)
+
+func main() {
+	for _, arg := range os.Args[1:] {
+		if arg == "--metadata" {
+			sdk.SendMetadata(sdk.Metadata{Name: pluginName, APIVersion: pluginVersion, Capabilities: capabilities})
+			return
+		}
+	}
+
+	processIPC()
+}
+
+// processIPC runs the main communication loop reading from stdin.
+func processIPC() {
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		req, err := sdk.ParseRequest([]byte(line))
+		if err != nil {
+			sdk.SendError(fmt.Sprintf("Invalid JSON: %v", err))
+			continue
+		}
+
+		handleMethod(req, scanner)
+	}
+}
+
+// handleMethod dispatches incoming IPC calls to specific handlers.
+func handleMethod(req sdk.Request, scanner *bufio.Scanner) {
+	switch req.Method {
+	case "info":
+		sdk.SendResponse(sdk.Response{
+			Name:         pluginName,
+			Version:      pluginVersion,
+			Capabilities: capabilities,
+		})
+
+	case "initialize":
+		if err := handleInitialize(req.Args, scanner); err != nil {
+			sdk.SendError(err.Error())
+		} else {
+			sdk.SendResponse(sdk.Response{Result: "initialized"})
+		}
+
+	case "get_chart_config":
+		sdk.SendResponse(sdk.Response{
+			Result: sdk.ChartConfig{
+				Title: fmt.Sprintf("%s in %s", config.Pattern, config.Directory),
+				Axes: []sdk.AxisGroupConfig{
+					{XAxes: []sdk.AxisConfig{{Title: "X"}}, YAxes: []sdk.AxisConfig{{Title: "Value"}}},
+				},
+			},
+		})
+
+	case "get_series_config":
+		sdk.SendResponse(sdk.Response{Result: getSeriesConfig()})
+
+	case "get_series_data":
+		handleGetSeriesData(req.SeriesID, req.PreferredStorage)
+
+	case "save":
+		// The args are the path of the CSV file to write
+		if req.Args == "" {
+			sdk.SendError("no output path provided")
+			return
+		}
+		if err := saveCSV(req.Args); err != nil {
+			sdk.SendError(err.Error())
+		} else {
+			sdk.SendResponse(sdk.Response{Result: "saved"})
+		}
+
+	case "ping":
+		sdk.HandlePing(req)
+
+	case "event":
+		// Host notifications need no reply
+		sdk.HandleEvent(req)
+
+	case "quit":
+		sdk.HandleQuit(req)
+
+	default:
+		sdk.SendError(fmt.Sprintf("Unknown method: %s", req.Method))
+	}
+}
+
+// handleInitialize merges the files chosen by the initialize args, a JSON
+// exportConfig, or else by the user in a form, and saves the result if an
+// output path was given.
+func handleInitialize(initStr string, scanner *bufio.Scanner) error {
+	var c exportConfig
+	if initStr != "" {
+		if err := json.Unmarshal([]byte(initStr), &c); err != nil {
+			return fmt.Errorf("invalid initialize args: %w", err)
+		}
+	} else {
+		var err error
+		if c, err = showExportForm(scanner); err != nil {
+			return err
+		}
+	}
+	if c.Pattern == "" {
+		c.Pattern = defaultPattern
+	}
+
+	paths, err := filepath.Glob(filepath.Join(c.Directory, c.Pattern))
+	if err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", c.Pattern, err)
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no files match %s in %s", c.Pattern, c.Directory)
+	}
+	sort.Strings(paths)
+
+	files := make([]fileSeries, 0, len(paths))
+	for _, p := range paths {
+		s, err := loadSeries(p)
+		if err != nil {
+			return err
+		}
+		files = append(files, fileSeries{File: filepath.Base(p), Series: s})
+	}
+
+	config = c
+	merged = mergeSeries(files)
+	sdk.Log("info", fmt.Sprintf("Merged %d files: %d series, %d rows", len(files), len(merged.Columns), len(merged.X)))
+
+	if c.Output != "" {
+		return saveCSV(c.Output)
+	}
+	return nil
+}
+
+// showExportForm asks the user which files to merge and where to save them.
+func showExportForm(scanner *bufio.Scanner) (exportConfig, error) {
+	schema := map[string]interface{}{
+		"type":     "object",
+		"required": []string{"directory"},
+		"properties": map[string]interface{}{
+			"directory": map[string]interface{}{
+				"type":  "string",
+				"title": "Directory",
+			},
+			"pattern": map[string]interface{}{
+				"type":    "string",
+				"title":   "File Pattern",
+				"default": defaultPattern,
+			},
+			"output": map[string]interface{}{
+				"type":        "string",
+				"title":       "Save Merged CSV To",
+				"description": "Leave empty to only plot the merged series",
+			},
+		},
+	}
+	uiSchema := map[string]interface{}{
+		"ui:order": []string{"directory", "pattern", "output"},
+	}
+	sdk.SendShowForm("Batch CSV Export", schema, uiSchema, map[string]interface{}{
+		"directory": config.Directory,
+		"pattern":   defaultPattern,
+	})
+
+	if !scanner.Scan() {
+		return exportConfig{}, fmt.Errorf("failed to read form response")
+	}
+	var resp struct {
+		Result exportConfig `json:"result"`
+		Error  string       `json:"error"`
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+		return exportConfig{}, fmt.Errorf("failed to parse form response: %v", err)
+	}
+	if resp.Error != "" {
+		return exportConfig{}, fmt.Errorf("export cancelled: %s", resp.Error)
+	}
+	return resp.Result, nil
+}
+
+// mergeSeries joins the series of files on their X values. Columns are
+// ordered by series name, in the order the names first appear, then by
+// file. A file's later series with a name it already used are ignored.
+func mergeSeries(files []fileSeries) mergedTable {
+	// The rows are every X value of any series
+	seen := make(map[float64]bool)
+	var xs []float64
+	for _, f := range files {
+		for _, s := range f.Series {
+			for _, x := range s.X {
+				if !math.IsNaN(x) && !seen[x] {
+					seen[x] = true
+					xs = append(xs, x)
+				}
+			}
+		}
+	}
+	sort.Float64s(xs)
+	row := make(map[float64]int, len(xs))
+	for i, x := range xs {
+		row[x] = i
+	}
+
+	var names []string
+	byName := make(map[string][]column)
+	for _, f := range files {
+		used := make(map[string]bool)
+		for _, s := range f.Series {
+			if used[s.Name] {
+				sdk.Log("warn", fmt.Sprintf("Ignoring duplicate series %q in %s", s.Name, f.File))
+				continue
+			}
+			used[s.Name] = true
+
+			y := make([]float64, len(xs))
+			for i := range y {
+				y[i] = math.NaN()
+			}
+			for i, x := range s.X {
+				if i < len(s.Y) && !math.IsNaN(x) {
+					y[row[x]] = s.Y[i]
+				}
+			}
+			if _, ok := byName[s.Name]; !ok {
+				names = append(names, s.Name)
+			}
+			byName[s.Name] = append(byName[s.Name], column{Name: s.Name, File: f.File, Y: y})
+		}
+	}
+
+	table := mergedTable{X: xs}
+	for _, name := range names {
+		table.Columns = append(table.Columns, byName[name]...)
+	}
+	return table
+}
+
+// columnTitle names a column in the chart and the CSV header.
+func columnTitle(c column) string {
+	return fmt.Sprintf("%s (%s)", c.Name, c.File)
+}
+
+// getSeriesConfig returns a series, identified by its column index, for
+// every column of the merged table.
+func getSeriesConfig() []sdk.SeriesConfig {
+	result := make([]sdk.SeriesConfig, len(merged.Columns))
+	for i, c := range merged.Columns {
+		result[i] = sdk.SeriesConfig{
+			ID:          strconv.Itoa(i),
+			Name:        columnTitle(c),
+			Description: c.File,
+		}
+	}
+	return result
+}
+
+// columnPoints returns the rows of a column that have a value, so the lines
+// of files sampled at different X values are not broken by the gaps.
+func columnPoints(c column, xs []float64) (x, y []float64) {
+	for i, v := range c.Y {
+		if !math.IsNaN(v) {
+			x = append(x, xs[i])
+			y = append(y, v)
+		}
+	}
+	return x, y
+}
+
+// handleGetSeriesData sends the points of a column of the merged table.
+func handleGetSeriesData(seriesID string, preferredStorage string) {
+	i, err := strconv.Atoi(seriesID)
+	if err != nil || i < 0 || i >= len(merged.Columns) {
+		sdk.SendError(fmt.Sprintf("series not found: %s", seriesID))
+		return
+	}
+	x, y := columnPoints(merged.Columns[i], merged.X)
+
+	data := make([]float64, len(x)*2)
+	storage := "interleaved"
+	if preferredStorage == "arrays" {
+		storage = "arrays"
+		copy(data, x)
+		copy(data[len(x):], y)
+	} else {
+		for j := range x {
+			data[j*2], data[j*2+1] = x[j], y[j]
+		}
+	}
+	sdk.SendBinaryData(data, storage)
+}
+
+// saveCSV writes the merged table to path, leaving the gaps empty.
+func saveCSV(path string) error {
+	if merged.Columns == nil {
+		return fmt.Errorf("no files merged")
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if err := writeCSV(f, merged); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	sdk.Log("info", fmt.Sprintf("Saved merged CSV to %s", path))
+	return nil
+}
+
+// writeCSV writes table with a header row of "x" and the column titles.
+func writeCSV(out io.Writer, table mergedTable) error {
+	w := csv.NewWriter(out)
+	header := []string{"x"}
+	for _, c := range table.Columns {
+		header = append(header, columnTitle(c))
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(header))
+	for i, x := range table.X {
+		record[0] = strconv.FormatFloat(x, 'g', -1, 64)
+		for j, c := range table.Columns {
+			record[j+1] = ""
+			if !math.IsNaN(c.Y[i]) {
+				record[j+1] = strconv.FormatFloat(c.Y[i], 'g', -1, 64)
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeRun writes an .olicanaplot file with a "Temp" series and one other.
+func writeRun(t *testing.T, dir, name, other, csv string) {
+	t.Helper()
+	header := `{"version": 1, "axes": [{"subplot": [0, 0], "series": [` +
+		`{"title": "Temp", "column": 1}, {"title": "` + other + `", "column": 2}]}]}`
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(header+"\f\n"+csv), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMergeMatchingSeries(t *testing.T) {
+	dir := t.TempDir()
+	writeRun(t, dir, "a.olicanaplot", "Pressure", "0,20,100\n1,21,101\n")
+	writeRun(t, dir, "b.olicanaplot", "Humidity", "1,22,40\n2,23,41\n")
+	writeRun(t, dir, "notes.txt", "Ignored", "0,0,0\n")
+
+	if err := handleInitialize(`{"directory": "`+filepath.ToSlash(dir)+`"}`, nil); err != nil {
+		t.Fatalf("handleInitialize failed: %v", err)
+	}
+
+	if len(merged.X) != 3 || merged.X[0] != 0 || merged.X[2] != 2 {
+		t.Fatalf("X = %v, want the union 0, 1, 2", merged.X)
+	}
+	var titles []string
+	for _, c := range merged.Columns {
+		titles = append(titles, columnTitle(c))
+	}
+	want := []string{"Temp (a.olicanaplot)", "Temp (b.olicanaplot)", "Pressure (a.olicanaplot)", "Humidity (b.olicanaplot)"}
+	if len(titles) != len(want) {
+		t.Fatalf("columns = %q, want %q", titles, want)
+	}
+	for i := range want {
+		if titles[i] != want[i] {
+			t.Fatalf("columns = %q, want matching series side by side: %q", titles, want)
+		}
+	}
+
+	// b has no point at x=0
+	tempB := merged.Columns[1].Y
+	if !math.IsNaN(tempB[0]) || tempB[1] != 22 || tempB[2] != 23 {
+		t.Errorf("Temp (b) = %v, want NaN, 22, 23", tempB)
+	}
+	x, y := columnPoints(merged.Columns[1], merged.X)
+	if len(x) != 2 || x[0] != 1 || y[0] != 22 {
+		t.Errorf("points = %v, %v, want the gap left out", x, y)
+	}
+}
+
+func TestSaveMergedCSV(t *testing.T) {
+	dir := t.TempDir()
+	writeRun(t, dir, "a.olicanaplot", "Pressure", "0,20,100\n1,21,101\n")
+	writeRun(t, dir, "b.olicanaplot", "Pressure", "1,22,102\n")
+	output := filepath.Join(dir, "merged.csv")
+
+	args := `{"directory": "` + filepath.ToSlash(dir) + `", "output": "` + filepath.ToSlash(output) + `"}`
+	if err := handleInitialize(args, nil); err != nil {
+		t.Fatalf("handleInitialize failed: %v", err)
+	}
+
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("merged CSV not written: %v", err)
+	}
+	want := "x,Temp (a.olicanaplot),Temp (b.olicanaplot),Pressure (a.olicanaplot),Pressure (b.olicanaplot)\n" +
+		"0,20,,100,\n" +
+		"1,21,22,101,102\n"
+	if !bytes.Equal(got, []byte(want)) {
+		t.Errorf("merged CSV =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestInitializeWithoutMatches(t *testing.T) {
+	if err := handleInitialize(`{"directory": "`+filepath.ToSlash(t.TempDir())+`"}`, nil); err == nil {
+		t.Error("expected an error when no files match")
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The types below are the part of the .olicanaplot header, as read by
+// olicanaplot_reader, needed to find each series' column and name. See
+// plugins/olicanaplot_reader/FORMAT.md for the whole format.
+type fileConfig struct {
+	Axes []axisEntry `yaml:"axes"`
+}
+
+type axisEntry struct {
+	XAxes  []axisDetail  `yaml:"x_axes"`
+	Series []seriesEntry `yaml:"series"`
+}
+
+type axisDetail struct {
+	Representation string `yaml:"representation"`
+}
+
+type seriesEntry struct {
+	Title          string `yaml:"title"`
+	Column         int    `yaml:"column"`
+	Representation string `yaml:"representation"`
+}
+
+// series is a named series read from a file.
+type series struct {
+	Name string
+	X, Y []float64
+}
+
+// loadSeries reads every series of an .olicanaplot or gzip compressed
+// .olicaplotz file. Series without a title are named like in
+// olicanaplot_reader.
+func loadSeries(path string) ([]series, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(strings.ToLower(path), ".olicaplotz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	// The YAML header and each axis' CSV block are separated by form feeds
+	parts := bytes.Split(content, []byte{'\f'})
+	var config fileConfig
+	if err := yaml.Unmarshal(parts[0], &config); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML in %s: %w", path, err)
+	}
+
+	var result []series
+	for i, entry := range config.Axes {
+		if i+1 >= len(parts) {
+			break
+		}
+		colReps := make(map[int]string)
+		if len(entry.XAxes) > 0 && entry.XAxes[0].Representation != "" {
+			colReps[0] = entry.XAxes[0].Representation
+		}
+		for _, s := range entry.Series {
+			if s.Representation != "" {
+				colReps[s.Column] = s.Representation
+			}
+		}
+
+		columns, err := parseCsvBlock(bytes.NewReader(parts[i+1]), colReps)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CSV block %d in %s: %w", i, path, err)
+		}
+		for _, s := range entry.Series {
+			if len(columns) == 0 || s.Column < 0 || s.Column >= len(columns) {
+				continue
+			}
+			name := s.Title
+			if name == "" {
+				name = fmt.Sprintf("Axis %d Col %d", i, s.Column)
+			}
+			result = append(result, series{Name: name, X: columns[0], Y: columns[s.Column]})
+		}
+	}
+	return result, nil
+}
+
+// parseCsvBlock returns the columns of a CSV block, with the values of the
+// columns in colReps parsed in that representation.
+func parseCsvBlock(content io.Reader, colReps map[int]string) ([][]float64, error) {
+	reader := csv.NewReader(content)
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := make([][]float64, len(records[0]))
+	for c := range columns {
+		columns[c] = make([]float64, len(records))
+		for r, record := range records {
+			valStr := ""
+			if c < len(record) {
+				valStr = record[c]
+			}
+			columns[c][r] = parseValue(valStr, colReps[c])
+		}
+	}
+	return columns, nil
+}
+
+// parseValue parses a CSV value as a number, or as a date in Unix seconds,
+// the same way as olicanaplot_reader. Invalid values are NaN.
+func parseValue(valStr string, rep string) float64 {
+	valStr = strings.TrimSpace(valStr)
+	if valStr == "" {
+		return math.NaN()
+	}
+
+	switch rep {
+	case "iso8601_timepoint":
+		if t, ok := parseISO8601(valStr); ok {
+			return unixSeconds(t)
+		}
+	case "iso8601_basic_timepoint":
+		layout := "20060102150405"
+		if dotIdx := strings.Index(valStr, "."); dotIdx >= 0 && len(valStr)-dotIdx-1 > 0 {
+			layout += "." + strings.Repeat("0", len(valStr)-dotIdx-1)
+		}
+		if t, err := time.Parse(layout, valStr); err == nil {
+			return unixSeconds(t)
+		}
+	}
+
+	if val, err := strconv.ParseFloat(valStr, 64); err == nil {
+		return val
+	}
+	// Dates are recognised without a representation too
+	if t, ok := parseISO8601(valStr); ok {
+		return unixSeconds(t)
+	}
+	return math.NaN()
+}
+
+// parseISO8601 parses an RFC 3339 time, with or without the time zone.
+func parseISO8601(s string) (time.Time, bool) {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t, err = time.Parse("2006-01-02T15:04:05", s)
+	}
+	return t, err == nil
+}
+
+func unixSeconds(t time.Time) float64 {
+	return float64(t.Unix()) + float64(t.Nanosecond())/1e9
+}
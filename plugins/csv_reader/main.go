@@ -13,10 +13,13 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math"
+	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	sdk "olicanaplot/sdk/go"
 )
@@ -26,6 +29,22 @@ const (
 	pluginVersion = 1
 )
 
+var capabilities = append(sdk.BaseCapabilities(), "get_file_info", "event", "ping")
+
+// initSchema describes the initialize args: an optional path or http(s) URL
+// of the CSV file.
+var initSchema = map[string]interface{}{
+	"type":        "string",
+	"description": "Path to a CSV file, or an http(s) URL to download it from",
+}
+
+// defaultMaxMessageSize is the largest request line read from the host
+// unless --max-message-size=N is given, e.g. in a plugin manifest's command.
+const defaultMaxMessageSize = 1024 * 1024
+
+// downloadTimeout bounds the whole HTTP download of a remote CSV file.
+const downloadTimeout = 30 * time.Second
+
 // Plugin state
 var (
 	currentFile string
@@ -33,6 +52,8 @@ var (
 	data        map[string][]float64
 	selectedX   string
 	selectedY   []string
+	fileInfo    *sdk.FileInfo // Set once the data is loaded
+	traceID     string        // From the host, forwarded in log messages
 )
 
 func main() {
@@ -49,29 +70,44 @@ func main() {
 func handleMetadata() bool {
 	for _, arg := range os.Args[1:] {
 		if arg == "--metadata" {
-			metadata := map[string]interface{}{
-				"name": pluginName,
-				"patterns": []map[string]interface{}{
-					{
-						"description": "CSV Files",
-						"patterns":    []string{"*.csv"},
-					},
-				},
-			}
-			jsonBytes, _ := json.Marshal(metadata)
-			fmt.Println(string(jsonBytes))
+			sdk.SendMetadata(sdk.Metadata{
+				Name:         pluginName,
+				Patterns:     []sdk.FilePattern{{Description: "CSV Files", Patterns: []string{"*.csv"}}},
+				APIVersion:   pluginVersion,
+				Capabilities: capabilities,
+				InitSchema:   initSchema,
+			})
 			return true
 		}
 	}
 	return false
 }
 
+// maxMessageSize returns the size given by a --max-message-size=N argument,
+// or defaultMaxMessageSize.
+func maxMessageSize(args []string) int {
+	for _, arg := range args {
+		value, ok := strings.CutPrefix(arg, "--max-message-size=")
+		if !ok {
+			continue
+		}
+		size, err := strconv.Atoi(value)
+		if err != nil || size <= 0 {
+			sdk.Log("warn", fmt.Sprintf("Ignoring invalid --max-message-size: %s", value))
+			continue
+		}
+		return size
+	}
+	return defaultMaxMessageSize
+}
+
 // processIPC runs the main communication loop reading from stdin.
 func processIPC() {
 	sdk.Log("info", "CSV IPC Plugin started")
 	scanner := bufio.NewScanner(os.Stdin)
 	// Increase buffer for large JSON messages
-	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	size := maxMessageSize(os.Args[1:])
+	scanner.Buffer(make([]byte, size), size)
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -79,8 +115,8 @@ func processIPC() {
 			continue
 		}
 
-		var req sdk.Request
-		if err := json.Unmarshal([]byte(line), &req); err != nil {
+		req, err := sdk.ParseRequest([]byte(line))
+		if err != nil {
 			sdk.SendError(fmt.Sprintf("Invalid JSON: %v", err))
 			continue
 		}
@@ -95,11 +131,17 @@ func processIPC() {
 
 // handleMethod dispatches incoming IPC calls to specific handlers.
 func handleMethod(req sdk.Request, scanner *bufio.Scanner) {
+	if req.TraceID != "" {
+		traceID = req.TraceID
+	}
+	sdk.Log("debug", fmt.Sprintf("Handling %s", req.Method), "trace_id", traceID)
+
 	switch req.Method {
 	case "info":
 		sdk.SendResponse(sdk.Response{
-			Name:    pluginName,
-			Version: pluginVersion,
+			Name:         pluginName,
+			Version:      pluginVersion,
+			Capabilities: capabilities,
 		})
 
 	case "initialize":
@@ -109,6 +151,9 @@ func handleMethod(req sdk.Request, scanner *bufio.Scanner) {
 			sdk.SendResponse(sdk.Response{Result: map[string]interface{}{}})
 		}
 
+	case "get_schema":
+		sdk.SendSchema(initSchema, map[string]interface{}{"type": "string"})
+
 	case "get_chart_config":
 		sdk.SendResponse(sdk.Response{
 			Result: getChartConfig(),
@@ -122,6 +167,26 @@ func handleMethod(req sdk.Request, scanner *bufio.Scanner) {
 	case "get_series_data":
 		handleGetSeriesData(req.SeriesID, req.PreferredStorage)
 
+	case "get_time_range":
+		handleGetTimeRange()
+
+	case "get_file_info":
+		if fileInfo == nil {
+			sdk.SendError("no file loaded")
+		} else {
+			sdk.SendFileInfo(*fileInfo)
+		}
+
+	case "ping":
+		sdk.HandlePing(req)
+
+	case "event":
+		// Host notifications need no reply
+		sdk.HandleEvent(req)
+
+	case "quit":
+		sdk.HandleQuit(req)
+
 	default:
 		sdk.SendError(fmt.Sprintf("Unknown method: %s", req.Method))
 	}
@@ -129,10 +194,14 @@ func handleMethod(req sdk.Request, scanner *bufio.Scanner) {
 
 // handleInitialize manages the multi-step initialization process (file selection -> column selection).
 func handleInitialize(initStr string, scanner *bufio.Scanner) error {
-	filePath, err := resolveFilePath(initStr, scanner)
+	filePath, source, err := resolveFilePath(initStr, scanner)
 	if err != nil {
 		return err
 	}
+	if filePath != source {
+		// Downloaded copy, the data is held in memory once loaded
+		defer os.Remove(filePath)
+	}
 
 	// Read ONLY headers initially (Lazy Loading)
 	h, err := readCSVHeaders(filePath)
@@ -140,7 +209,7 @@ func handleInitialize(initStr string, scanner *bufio.Scanner) error {
 		return fmt.Errorf("failed to read headers: %w", err)
 	}
 	headers = h
-	currentFile = filePath
+	currentFile = source
 
 	// Show column selection UI
 	result, err := showColumnSelection(scanner)
@@ -153,30 +222,46 @@ func handleInitialize(initStr string, scanner *bufio.Scanner) error {
 	selectedY = result.YColumns
 
 	// Load the actual data ONLY after user confirms
-	sdk.Log("info", fmt.Sprintf("Loading CSV data from %s...", filePath))
+	sdk.Log("info", fmt.Sprintf("Loading CSV data from %s...", source), "trace_id", traceID)
 	d, err := loadCSVData(filePath, headers)
 	if err != nil {
 		return fmt.Errorf("failed to load data: %w", err)
 	}
 	data = d
 
+	// Stat before a downloaded copy is removed, but report the source
+	info, err := sdk.NewFileInfo(filePath, rowCount(d))
+	if err != nil {
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
+	info.Path = source
+	fileInfo = &info
+
 	sdk.Log("info", fmt.Sprintf("CSV loaded: %d columns, X=%s, Y=%v", len(headers), selectedX, selectedY))
 	return nil
 }
 
-// resolveFilePath either uses the provided path or requests one from the host via show_form.
-func resolveFilePath(initStr string, scanner *bufio.Scanner) (string, error) {
+// resolveFilePath either uses the provided path or requests one from the host
+// via show_form. http(s) URLs are downloaded to a temp file first; filePath is
+// then the temp file and source the URL, and the caller removes the temp file.
+func resolveFilePath(initStr string, scanner *bufio.Scanner) (filePath, source string, err error) {
 	if initStr != "" {
 		sdk.Log("info", fmt.Sprintf("Using provided file path: %s", initStr))
-		return initStr, nil
+		return fetchIfURL(initStr)
 	}
 
 	schema := map[string]interface{}{
 		"type": "object",
 		"properties": map[string]interface{}{
+			"urlMode": map[string]interface{}{
+				"type":    "boolean",
+				"title":   "Load from URL",
+				"default": false,
+			},
 			"filePath": map[string]interface{}{
-				"type":  "string",
-				"title": "CSV File Path",
+				"type":        "string",
+				"title":       "CSV File Path",
+				"description": "With Load from URL enabled, an http(s) URL to download the file from",
 			},
 		},
 	}
@@ -192,23 +277,76 @@ func resolveFilePath(initStr string, scanner *bufio.Scanner) (string, error) {
 	sdk.SendShowForm("Select CSV File", schema, uiSchema, nil)
 
 	if !scanner.Scan() {
-		return "", fmt.Errorf("failed to read file selection response")
+		return "", "", fmt.Errorf("failed to read file selection response")
 	}
 
 	var resp struct {
 		Result struct {
+			URLMode  bool   `json:"urlMode"`
 			FilePath string `json:"filePath"`
 		} `json:"result"`
 		Error string `json:"error"`
 	}
 	if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
-		return "", fmt.Errorf("failed to parse file selection response: %v", err)
+		return "", "", fmt.Errorf("failed to parse file selection response: %v", err)
 	}
 	if resp.Error != "" {
-		return "", fmt.Errorf("file selection cancelled: %s", resp.Error)
+		return "", "", fmt.Errorf("file selection cancelled: %s", resp.Error)
+	}
+	if resp.Result.URLMode && !isURL(resp.Result.FilePath) {
+		return "", "", fmt.Errorf("not an http(s) URL: %s", resp.Result.FilePath)
+	}
+
+	return fetchIfURL(resp.Result.FilePath)
+}
+
+// isURL reports whether path is an http or https URL.
+func isURL(path string) bool {
+	lower := strings.ToLower(path)
+	return strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "http://")
+}
+
+// fetchIfURL downloads path to a temp file if it is a URL. It returns the
+// local file to read and the original path.
+func fetchIfURL(path string) (filePath, source string, err error) {
+	if !isURL(path) {
+		return path, path, nil
+	}
+	sdk.Log("info", fmt.Sprintf("Downloading CSV from %s", path), "trace_id", traceID)
+	filePath, err = downloadToTemp(path)
+	if err != nil {
+		return "", "", err
+	}
+	return filePath, path, nil
+}
+
+// downloadToTemp fetches url with an HTTP GET and writes the body to a new
+// temp file, returning its path.
+func downloadToTemp(url string) (string, error) {
+	client := &http.Client{Timeout: downloadTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download %s: %s", url, resp.Status)
 	}
 
-	return resp.Result.FilePath, nil
+	f, err := os.CreateTemp("", "olicanaplot-*.csv")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+	return f.Name(), nil
 }
 
 type ColumnSelectionResult struct {
@@ -393,6 +531,15 @@ func loadCSVData(path string, headers []string) (map[string][]float64, error) {
 	return resultMap, nil
 }
 
+// rowCount returns the number of data rows, i.e. the longest column.
+func rowCount(d map[string][]float64) int {
+	n := 0
+	for _, col := range d {
+		n = max(n, len(col))
+	}
+	return n
+}
+
 // handleGetSeriesData retrieves and sends binary data for a specific series.
 func handleGetSeriesData(seriesID string, preferredStorage string) {
 	yData, ok := data[seriesID]
@@ -433,3 +580,35 @@ func handleGetSeriesData(seriesID string, preferredStorage string) {
 
 	sdk.SendBinaryData(result, storage)
 }
+
+// handleGetTimeRange sends the X extent of the loaded data.
+func handleGetTimeRange() {
+	if selectedX == "" || selectedX == "Index" {
+		count := 0
+		for _, y := range selectedY {
+			if n := len(data[y]); n > count {
+				count = n
+			}
+		}
+		if count == 0 {
+			sdk.SendError("no data loaded")
+			return
+		}
+		sdk.SendTimeRange(0, float64(count-1))
+		return
+	}
+
+	xMin, xMax := math.Inf(1), math.Inf(-1)
+	for _, x := range data[selectedX] {
+		if math.IsNaN(x) {
+			continue
+		}
+		xMin = math.Min(xMin, x)
+		xMax = math.Max(xMax, x)
+	}
+	if xMin > xMax {
+		sdk.SendError(fmt.Sprintf("no numeric values in column %s", selectedX))
+		return
+	}
+	sdk.SendTimeRange(xMin, xMax)
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"math"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 )
@@ -70,4 +72,61 @@ func TestLoadCSVData(t *testing.T) {
 	}
 }
 
+func TestFetchIfURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/data.csv" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("Time,SignalA\n1,2\n3,4\n"))
+	}))
+	defer srv.Close()
+
+	filePath, source, err := fetchIfURL(srv.URL + "/data.csv")
+	if err != nil {
+		t.Fatalf("fetchIfURL failed: %v", err)
+	}
+	defer os.Remove(filePath)
+	if source != srv.URL+"/data.csv" || filePath == source {
+		t.Errorf("filePath = %q, source = %q", filePath, source)
+	}
+
+	headers, err := readCSVHeaders(filePath)
+	if err != nil {
+		t.Fatalf("readCSVHeaders failed: %v", err)
+	}
+	data, err := loadCSVData(filePath, headers)
+	if err != nil {
+		t.Fatalf("loadCSVData failed: %v", err)
+	}
+	if len(data["SignalA"]) != 2 || data["SignalA"][1] != 4 {
+		t.Errorf("SignalA = %v, want [2 4]", data["SignalA"])
+	}
+
+	if _, _, err := fetchIfURL(srv.URL + "/missing.csv"); err == nil {
+		t.Error("expected error for a 404 response")
+	}
+
+	// Local paths are passed through untouched
+	if filePath, source, err := fetchIfURL("local.csv"); err != nil || filePath != "local.csv" || source != "local.csv" {
+		t.Errorf("fetchIfURL(local.csv) = %q, %q, %v", filePath, source, err)
+	}
+}
+
 // End of tests
+
+func TestMaxMessageSize(t *testing.T) {
+	tests := []struct {
+		args []string
+		want int
+	}{
+		{nil, defaultMaxMessageSize},
+		{[]string{"--max-message-size=4194304"}, 4194304},
+		{[]string{"--max-message-size=big"}, defaultMaxMessageSize},
+	}
+	for _, tt := range tests {
+		if got := maxMessageSize(tt.args); got != tt.want {
+			t.Errorf("maxMessageSize(%q) = %d, want %d", tt.args, got, tt.want)
+		}
+	}
+}
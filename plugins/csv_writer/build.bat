@@ -0,0 +1,3 @@
+@echo off
+REM Build CSV Writer IPC Plugin
+go build -ldflags="-w -s -H windowsgui" -o csv_writer.exe .
@@ -0,0 +1,7 @@
+module csv_writer-ipc
+
+go 1.25
+
+replace olicanaplot => ../../
+
+require olicanaplot v0.0.0-00010101000000-000000000000
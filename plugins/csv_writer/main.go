@@ -0,0 +1,315 @@
+// CSV Writer IPC Plugin - Saves the series of the active plugin, e.g. the
+// output of an analysis plugin, to a CSV file.
+//
+// Protocol:
+//   - Reads JSON requests from stdin (one per line)
+//   - Writes JSON responses to stdout (one per line)
+//   - Uses show_form on initialize to ask for the output path and the
+//     decimal separator
+//   - The "save" method receives the series as {id, x, y} objects, sent by
+//     the host's /api/save endpoint, and writes them to the output path
+//
+// The file has a shared X column, the sorted union of the X values of all
+// series, and a column per series, left empty where a series has no point.
+// It plots nothing itself, and opens no files.
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	sdk "olicanaplot/sdk/go"
+)
+
+const (
+	pluginName    = "CSV Writer"
+	pluginVersion = 1
+)
+
+var capabilities = append(sdk.BaseCapabilities(), "save", "event", "ping")
+
+// maxRequestSize is the longest request line read, which for save holds
+// every point of every series.
+const maxRequestSize = 1 << 30
+
+// writerConfig is where and how series are saved, from the initialize form
+// or arguments.
+type writerConfig struct {
+	Path             string `json:"path"`
+	DecimalSeparator string `json:"decimalSeparator"` // "." or ","
+}
+
+// config is the plugin's state. Requests are handled one at a time, so it
+// needs no locking.
+var config = writerConfig{DecimalSeparator: "."}
+
+func main() {
+	for _, arg := range os.Args[1:] {
+		if arg == "--metadata" {
+			sdk.SendMetadata(sdk.Metadata{Name: pluginName, APIVersion: pluginVersion, Capabilities: capabilities})
+			return
+		}
+	}
+
+	processIPC()
+}
+
+// processIPC runs the main communication loop reading from stdin.
+func processIPC() {
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 1024*1024), maxRequestSize)
+
+	for scanner.Scan() {
+		req, err := sdk.ParseRequest(scanner.Bytes())
+		if err != nil {
+			sdk.SendError(fmt.Sprintf("Invalid JSON: %v", err))
+			continue
+		}
+
+		handleMethod(req, scanner)
+	}
+}
+
+// handleMethod dispatches incoming IPC calls to specific handlers.
+func handleMethod(req sdk.Request, scanner *bufio.Scanner) {
+	switch req.Method {
+	case "info":
+		sdk.SendResponse(sdk.Response{
+			Name:         pluginName,
+			Version:      pluginVersion,
+			Capabilities: capabilities,
+		})
+
+	case "initialize":
+		if err := handleInitialize(req.Args, scanner); err != nil {
+			sdk.SendError(err.Error())
+		} else {
+			sdk.SendResponse(sdk.Response{Result: "initialized"})
+		}
+
+	case "get_chart_config":
+		sdk.SendResponse(sdk.Response{
+			Result: sdk.ChartConfig{
+				Title: pluginName,
+				Axes: []sdk.AxisGroupConfig{
+					{XAxes: []sdk.AxisConfig{{Title: "X"}}, YAxes: []sdk.AxisConfig{{Title: "Value"}}},
+				},
+			},
+		})
+
+	case "get_series_config":
+		sdk.SendResponse(sdk.Response{Result: []sdk.SeriesConfig{}})
+
+	case "get_series_data":
+		sdk.SendError(fmt.Sprintf("series not found: %s", req.SeriesID))
+
+	case "save":
+		// The args, if any, are the path to write instead of the configured one
+		path := req.Args
+		if path == "" {
+			path = config.Path
+		}
+		if err := saveCSV(path, config.DecimalSeparator, req.Series); err != nil {
+			sdk.SendError(err.Error())
+		} else {
+			sdk.SendResponse(sdk.Response{Result: "saved"})
+		}
+
+	case "ping":
+		sdk.HandlePing(req)
+
+	case "event":
+		// Host notifications need no reply
+		sdk.HandleEvent(req)
+
+	case "quit":
+		sdk.HandleQuit(req)
+
+	default:
+		sdk.SendError(fmt.Sprintf("Unknown method: %s", req.Method))
+	}
+}
+
+// handleInitialize sets the output from the initialize args, a JSON
+// writerConfig, or else asks the user in a form.
+func handleInitialize(initStr string, scanner *bufio.Scanner) error {
+	c := config
+	if initStr != "" {
+		if err := json.Unmarshal([]byte(initStr), &c); err != nil {
+			return fmt.Errorf("invalid initialize args: %w", err)
+		}
+	} else {
+		var err error
+		if c, err = showWriterForm(scanner); err != nil {
+			return err
+		}
+	}
+	if c.DecimalSeparator == "" {
+		c.DecimalSeparator = "."
+	}
+	if c.DecimalSeparator != "." && c.DecimalSeparator != "," {
+		return fmt.Errorf("decimal separator must be \".\" or \",\", got %q", c.DecimalSeparator)
+	}
+
+	config = c
+	sdk.Log("info", fmt.Sprintf("Saving series to %s", config.Path))
+	return nil
+}
+
+// showWriterForm asks the user where to save the series and how to write
+// numbers.
+func showWriterForm(scanner *bufio.Scanner) (writerConfig, error) {
+	schema := map[string]interface{}{
+		"type":     "object",
+		"required": []string{"path"},
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":  "string",
+				"title": "Output CSV File",
+			},
+			"decimalSeparator": map[string]interface{}{
+				"type":        "string",
+				"title":       "Decimal Separator",
+				"description": "With a comma, fields are separated by semicolons",
+				"oneOf": []map[string]interface{}{
+					{"const": ".", "title": "Point (1.5)"},
+					{"const": ",", "title": "Comma (1,5)"},
+				},
+			},
+		},
+	}
+	uiSchema := map[string]interface{}{
+		"ui:order": []string{"path", "decimalSeparator"},
+	}
+	sdk.SendShowForm("CSV Writer", schema, uiSchema, map[string]interface{}{
+		"path":             config.Path,
+		"decimalSeparator": config.DecimalSeparator,
+	})
+
+	if !scanner.Scan() {
+		return writerConfig{}, fmt.Errorf("failed to read form response")
+	}
+	var resp struct {
+		Result writerConfig `json:"result"`
+		Error  string       `json:"error"` // e.g. "cancelled"
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+		return writerConfig{}, fmt.Errorf("failed to parse form response: %v", err)
+	}
+	if resp.Error != "" {
+		return writerConfig{}, fmt.Errorf("configuration cancelled: %s", resp.Error)
+	}
+	return resp.Result, nil
+}
+
+// saveCSV writes series to path.
+func saveCSV(path, decimalSeparator string, series []sdk.SeriesPoints) error {
+	if path == "" {
+		return fmt.Errorf("no output file: initialize %s or pass a path", pluginName)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if err := writeCSV(f, decimalSeparator, series); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	sdk.Log("info", fmt.Sprintf("Saved %d series to %s", len(series), path))
+	return nil
+}
+
+// writeCSV writes series with a header row of "x" and the series IDs. With
+// a decimal comma, fields are separated by semicolons, as spreadsheets in
+// those locales expect.
+func writeCSV(out io.Writer, decimalSeparator string, series []sdk.SeriesPoints) error {
+	// A row holds the k-th point at some X value of every series, so that
+	// a series with repeated X values, as at a step, gets a row per point
+	rows := make(map[csvRow]int)
+	var keys []csvRow
+	for _, s := range series {
+		eachRow(s.X, func(i int, key csvRow) {
+			if _, ok := rows[key]; !ok {
+				rows[key] = 0
+				keys = append(keys, key)
+			}
+		})
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].x != keys[j].x {
+			return keys[i].x < keys[j].x
+		}
+		return keys[i].n < keys[j].n
+	})
+	for i, key := range keys {
+		rows[key] = i
+	}
+
+	format := func(v float64) string {
+		if math.IsNaN(v) {
+			return ""
+		}
+		s := strconv.FormatFloat(v, 'g', -1, 64)
+		if decimalSeparator == "," {
+			s = strings.Replace(s, ".", ",", 1)
+		}
+		return s
+	}
+
+	w := csv.NewWriter(out)
+	if decimalSeparator == "," {
+		w.Comma = ';'
+	}
+	header := []string{"x"}
+	for _, s := range series {
+		header = append(header, s.ID)
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	records := make([][]string, len(keys))
+	for i, key := range keys {
+		records[i] = make([]string, len(header))
+		records[i][0] = format(key.x)
+	}
+	for j, s := range series {
+		eachRow(s.X, func(i int, key csvRow) {
+			if i < len(s.Y) {
+				records[rows[key]][j+1] = format(s.Y[i])
+			}
+		})
+	}
+	return w.WriteAll(records)
+}
+
+// csvRow identifies a row of the CSV file by its X value and, for X values
+// repeated within a series, which occurrence it is.
+type csvRow struct {
+	x float64
+	n int
+}
+
+// eachRow calls f with the index and row of each point at a number in xs.
+func eachRow(xs []float64, f func(i int, key csvRow)) {
+	seen := make(map[float64]int)
+	for i, x := range xs {
+		if math.IsNaN(x) {
+			continue
+		}
+		f(i, csvRow{x, seen[x]})
+		seen[x]++
+	}
+}
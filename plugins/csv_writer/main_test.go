@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sdk "olicanaplot/sdk/go"
+)
+
+// testSeries are two series sampled at partly different X values.
+var testSeries = []sdk.SeriesPoints{
+	{ID: "temp", X: []float64{0, 1, 2}, Y: []float64{20.5, 21, math.NaN()}},
+	{ID: "pressure", X: []float64{1, 3}, Y: []float64{101.25, 102}},
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, ".", testSeries); err != nil {
+		t.Fatalf("writeCSV failed: %v", err)
+	}
+	want := "x,temp,pressure\n" +
+		"0,20.5,\n" +
+		"1,21,101.25\n" +
+		"2,,\n" +
+		"3,,102\n"
+	if buf.String() != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteCSVRepeatedX(t *testing.T) {
+	series := []sdk.SeriesPoints{
+		{ID: "step", X: []float64{0, 1, 1, 2}, Y: []float64{0, 0, 1, 1}},
+		{ID: "ramp", X: []float64{1, 2}, Y: []float64{0.5, 1}},
+	}
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, ".", series); err != nil {
+		t.Fatalf("writeCSV failed: %v", err)
+	}
+	want := "x,step,ramp\n" +
+		"0,0,\n" +
+		"1,0,0.5\n" +
+		"1,1,\n" +
+		"2,1,1\n"
+	if buf.String() != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteCSVDecimalComma(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, ",", testSeries[:1]); err != nil {
+		t.Fatalf("writeCSV failed: %v", err)
+	}
+	want := "x;temp\n" +
+		"0;20,5\n" +
+		"1;21\n" +
+		"2;\n"
+	if buf.String() != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestSave(t *testing.T) {
+	config = writerConfig{DecimalSeparator: "."}
+	if err := saveCSV(config.Path, config.DecimalSeparator, testSeries); err == nil {
+		t.Error("expected an error before an output file is chosen")
+	}
+
+	path := filepath.Join(t.TempDir(), "out.csv")
+	if err := handleInitialize(`{"path": "`+filepath.ToSlash(path)+`", "decimalSeparator": ","}`, nil); err != nil {
+		t.Fatalf("handleInitialize failed: %v", err)
+	}
+	if err := saveCSV(config.Path, config.DecimalSeparator, testSeries[1:]); err != nil {
+		t.Fatalf("saveCSV failed: %v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("CSV not written: %v", err)
+	}
+	if want := "x;pressure\n1;101,25\n3;102\n"; string(got) != want {
+		t.Errorf("CSV =\n%s\nwant\n%s", got, want)
+	}
+
+	if err := handleInitialize(`{"path": "out.csv", "decimalSeparator": ";"}`, nil); err == nil {
+		t.Error("expected an error for an invalid decimal separator")
+	}
+}
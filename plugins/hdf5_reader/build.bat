@@ -0,0 +1,16 @@
+@echo off
+REM Build HDF5 IPC Plugin (requires CGO and the HDF5 C library)
+where gcc >nul 2>&1
+if errorlevel 1 (
+    echo Skipping HDF5 Reader: CGO needs gcc on the PATH.
+    exit /b 0
+)
+where hdf5.dll >nul 2>&1
+if errorlevel 1 (
+    echo Skipping HDF5 Reader: the HDF5 library ^(hdf5.dll^) is not on the PATH.
+    exit /b 0
+)
+REM go.sum is recorded on the first build with the library installed
+if not exist go.sum go mod tidy
+set CGO_ENABLED=1
+go build -ldflags="-w -s -H windowsgui" -o hdf5_reader.exe .
@@ -0,0 +1,10 @@
+module hdf5_reader-ipc
+
+go 1.25
+
+replace olicanaplot => ../../
+
+require (
+	gonum.org/v1/hdf5 v0.0.0-20210714002203-8c5d23bc6946
+	olicanaplot v0.0.0-00010101000000-000000000000
+)
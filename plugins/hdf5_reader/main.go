@@ -0,0 +1,589 @@
+// HDF5 IPC Plugin - A standalone HDF5 file loader plugin using host-controlled UI.
+//
+// Protocol:
+//   - Reads JSON requests from stdin (one per line)
+//   - Writes JSON responses to stdout (one per line)
+//   - Uses show_form for host-controlled dataset selection UI
+//   - For binary data, writes a JSON header followed by raw bytes
+//
+// Every one-dimensional integer or floating point dataset in the file, at
+// any depth of the group hierarchy, is selectable. Datasets are read when
+// the host requests their data rather than at initialization, since HDF5
+// files are often much larger than the parts being plotted.
+//
+// Reading HDF5 needs the HDF5 C library, so this plugin must be built with
+// CGO enabled and the library's headers installed.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	sdk "olicanaplot/sdk/go"
+
+	"gonum.org/v1/hdf5"
+)
+
+const (
+	pluginName    = "HDF5 IPC"
+	pluginVersion = 1
+)
+
+var capabilities = append(sdk.BaseCapabilities(), "event", "ping")
+
+// initSchema describes the initialize args, an optional path to the HDF5 file.
+var initSchema = map[string]interface{}{"type": "string"}
+
+// indexX selects the element index rather than a dataset for the X axis.
+const indexX = "Index"
+
+// Plugin state
+var (
+	currentFile string
+	datasets    []datasetInfo
+	selectedX   string
+	selectedY   []string
+	traceID     string // From the host, forwarded in log messages
+)
+
+// datasetInfo describes a plottable dataset.
+type datasetInfo struct {
+	Path   string // Absolute path within the file, e.g. "/sensors/temperature"
+	Points int
+}
+
+// hdf5Group is implemented by both *hdf5.File and *hdf5.Group.
+type hdf5Group interface {
+	NumObjects() (uint, error)
+	ObjectNameByIndex(idx uint) (string, error)
+	ObjectTypeByIndex(idx uint) (hdf5.GType, error)
+	OpenGroup(name string) (*hdf5.Group, error)
+	OpenDataset(name string) (*hdf5.Dataset, error)
+}
+
+func main() {
+	// Check for --metadata flag (Discovery Protocol)
+	if handleMetadata() {
+		return
+	}
+
+	processIPC()
+}
+
+// handleMetadata checks for the --metadata flag and exits if found.
+func handleMetadata() bool {
+	for _, arg := range os.Args[1:] {
+		if arg == "--metadata" {
+			sdk.SendMetadata(sdk.Metadata{
+				Name:         pluginName,
+				Patterns:     []sdk.FilePattern{{Description: "HDF5 Files", Patterns: []string{"*.h5", "*.hdf5"}}},
+				APIVersion:   pluginVersion,
+				Capabilities: capabilities,
+				InitSchema:   initSchema,
+			})
+			return true
+		}
+	}
+	return false
+}
+
+// processIPC runs the main communication loop reading from stdin.
+func processIPC() {
+	sdk.Log("info", "HDF5 IPC Plugin started")
+	scanner := bufio.NewScanner(os.Stdin)
+	// Increase buffer for large JSON messages
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		req, err := sdk.ParseRequest([]byte(line))
+		if err != nil {
+			sdk.SendError(fmt.Sprintf("Invalid JSON: %v", err))
+			continue
+		}
+
+		handleMethod(req, scanner)
+	}
+
+	if err := scanner.Err(); err != nil {
+		sdk.Log("error", fmt.Sprintf("Scanner error: %v", err))
+	}
+}
+
+// handleMethod dispatches incoming IPC calls to specific handlers.
+func handleMethod(req sdk.Request, scanner *bufio.Scanner) {
+	if req.TraceID != "" {
+		traceID = req.TraceID
+	}
+	sdk.Log("debug", fmt.Sprintf("Handling %s", req.Method), "trace_id", traceID)
+
+	switch req.Method {
+	case "info":
+		sdk.SendResponse(sdk.Response{
+			Name:         pluginName,
+			Version:      pluginVersion,
+			Capabilities: capabilities,
+		})
+
+	case "initialize":
+		if err := handleInitialize(req.Args, scanner); err != nil {
+			sdk.SendError(err.Error())
+		} else {
+			sdk.SendResponse(sdk.Response{Result: map[string]interface{}{}})
+		}
+
+	case "get_schema":
+		sdk.SendSchema(initSchema, map[string]interface{}{"type": "string"})
+
+	case "get_chart_config":
+		sdk.SendResponse(sdk.Response{
+			Result: getChartConfig(),
+		})
+
+	case "get_series_config":
+		sdk.SendResponse(sdk.Response{
+			Result: getSeriesConfig(),
+		})
+
+	case "get_series_data":
+		handleGetSeriesData(req.SeriesID, req.PreferredStorage)
+
+	case "get_time_range":
+		handleGetTimeRange()
+
+	case "ping":
+		sdk.HandlePing(req)
+
+	case "event":
+		// Host notifications need no reply
+		sdk.HandleEvent(req)
+
+	case "quit":
+		sdk.HandleQuit(req)
+
+	default:
+		sdk.SendError(fmt.Sprintf("Unknown method: %s", req.Method))
+	}
+}
+
+// handleInitialize manages the multi-step initialization process (file selection -> dataset selection).
+func handleInitialize(initStr string, scanner *bufio.Scanner) error {
+	filePath, err := resolveFilePath(initStr, scanner)
+	if err != nil {
+		return err
+	}
+
+	f, err := hdf5.OpenFile(filePath, hdf5.F_ACC_RDONLY)
+	if err != nil {
+		return fmt.Errorf("failed to open HDF5 file: %w", err)
+	}
+	found, err := listDatasets(f, "/")
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("failed to list datasets: %w", err)
+	}
+	if len(found) == 0 {
+		return fmt.Errorf("no one-dimensional numeric datasets found in %s", filePath)
+	}
+	sortDatasets(found)
+	datasets = found
+	currentFile = filePath
+
+	// Show dataset selection UI
+	result, err := showDatasetSelection(scanner)
+	if err != nil {
+		return err
+	}
+
+	// Apply selection
+	selectedX = result.XDataset
+	selectedY = result.YDatasets
+
+	sdk.Log("info", fmt.Sprintf("HDF5 opened: %d datasets, X=%s, Y=%v", len(datasets), selectedX, selectedY))
+	return nil
+}
+
+// resolveFilePath either uses the provided path or requests one from the host via show_form.
+func resolveFilePath(initStr string, scanner *bufio.Scanner) (string, error) {
+	if initStr != "" {
+		sdk.Log("info", fmt.Sprintf("Using provided file path: %s", initStr))
+		return initStr, nil
+	}
+
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"filePath": map[string]interface{}{
+				"type":  "string",
+				"title": "HDF5 File Path",
+			},
+		},
+	}
+	uiSchema := map[string]interface{}{
+		"filePath": map[string]interface{}{
+			"ui:widget": "file",
+			"ui:options": map[string]interface{}{
+				"accept": ".h5,.hdf5",
+			},
+		},
+	}
+
+	sdk.SendShowForm("Select HDF5 File", schema, uiSchema, nil)
+
+	if !scanner.Scan() {
+		return "", fmt.Errorf("failed to read file selection response")
+	}
+
+	var resp struct {
+		Result struct {
+			FilePath string `json:"filePath"`
+		} `json:"result"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+		return "", fmt.Errorf("failed to parse file selection response: %v", err)
+	}
+	if resp.Error != "" {
+		return "", fmt.Errorf("file selection cancelled: %s", resp.Error)
+	}
+
+	return resp.Result.FilePath, nil
+}
+
+// listDatasets walks g, whose path is groupPath, and returns the
+// one-dimensional numeric datasets within it and its subgroups.
+func listDatasets(g hdf5Group, groupPath string) ([]datasetInfo, error) {
+	n, err := g.NumObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	var found []datasetInfo
+	for i := uint(0); i < n; i++ {
+		name, err := g.ObjectNameByIndex(i)
+		if err != nil {
+			return nil, err
+		}
+		kind, err := g.ObjectTypeByIndex(i)
+		if err != nil {
+			return nil, err
+		}
+		objPath := path.Join(groupPath, name)
+
+		switch kind {
+		case hdf5.H5G_GROUP:
+			sub, err := g.OpenGroup(name)
+			if err != nil {
+				return nil, err
+			}
+			children, err := listDatasets(sub, objPath)
+			sub.Close()
+			if err != nil {
+				return nil, err
+			}
+			found = append(found, children...)
+
+		case hdf5.H5G_DATASET:
+			ds, err := g.OpenDataset(name)
+			if err != nil {
+				return nil, err
+			}
+			points, ok := numericPoints(ds)
+			ds.Close()
+			if ok {
+				found = append(found, datasetInfo{Path: objPath, Points: points})
+			}
+		}
+	}
+	return found, nil
+}
+
+// numericPoints returns the length of ds if it is a one-dimensional
+// integer or floating point dataset.
+func numericPoints(ds *hdf5.Dataset) (int, bool) {
+	dtype, err := ds.Datatype()
+	if err != nil {
+		return 0, false
+	}
+	class := dtype.Class()
+	dtype.Close()
+	if class != hdf5.T_INTEGER && class != hdf5.T_FLOAT {
+		return 0, false
+	}
+
+	space := ds.Space()
+	defer space.Close()
+	if space.SimpleExtentNDims() != 1 {
+		return 0, false
+	}
+	return space.SimpleExtentNPoints(), true
+}
+
+// sortDatasets orders datasets by path so that each group's datasets are
+// listed together, as in a tree.
+func sortDatasets(infos []datasetInfo) {
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Path < infos[j].Path
+	})
+}
+
+// treeTitle labels a dataset path for the selection form, indented by its
+// depth in the group hierarchy.
+func treeTitle(info datasetInfo) string {
+	parts := strings.Split(strings.Trim(info.Path, "/"), "/")
+	dir := ""
+	if len(parts) > 1 {
+		dir = "/" + strings.Join(parts[:len(parts)-1], "/") + "/"
+	}
+	// No-break spaces, as the form collapses leading spaces
+	indent := strings.Repeat("\u00a0\u00a0", len(parts)-1)
+	return fmt.Sprintf("%s%s%s [%d]", indent, dir, parts[len(parts)-1], info.Points)
+}
+
+type DatasetSelectionResult struct {
+	XDataset  string   `json:"xDataset"`
+	YDatasets []string `json:"yDatasets"`
+}
+
+// showDatasetSelection requests and parses the user's dataset choices.
+func showDatasetSelection(scanner *bufio.Scanner) (*DatasetSelectionResult, error) {
+	// Build dataset selection options
+	xOptions := make([]map[string]interface{}, 0, len(datasets)+1)
+	xOptions = append(xOptions, map[string]interface{}{
+		"const": indexX,
+		"title": "Index (element number)",
+	})
+	yItems := make([]map[string]interface{}, 0, len(datasets))
+	for _, d := range datasets {
+		option := map[string]interface{}{
+			"const": d.Path,
+			"title": treeTitle(d),
+		}
+		xOptions = append(xOptions, option)
+		yItems = append(yItems, option)
+	}
+
+	// Default to plotting the first dataset against its index
+	defaultX := indexX
+	defaultY := []string{datasets[0].Path}
+
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"xDataset": map[string]interface{}{
+				"type":    "string",
+				"title":   "X-Axis Dataset",
+				"oneOf":   xOptions,
+				"default": defaultX,
+			},
+			"yDatasets": map[string]interface{}{
+				"type":    "array",
+				"title":   "Y-Axis Datasets",
+				"default": defaultY,
+				"items": map[string]interface{}{
+					"type":  "string",
+					"oneOf": yItems,
+				},
+				"uniqueItems": true,
+				"minItems":    1,
+			},
+		},
+	}
+	uiSchema := map[string]interface{}{
+		"xDataset":  map[string]interface{}{"ui:widget": "select"},
+		"yDatasets": map[string]interface{}{"ui:widget": "checkboxes"},
+	}
+
+	sdk.SendShowForm("Select Datasets", schema, uiSchema, map[string]interface{}{
+		"xDataset":  defaultX,
+		"yDatasets": defaultY,
+	})
+
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("failed to read dataset selection response")
+	}
+
+	var resp struct {
+		Result DatasetSelectionResult `json:"result"`
+		Error  string                 `json:"error"`
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse dataset selection response: %v", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("dataset selection cancelled")
+	}
+
+	return &resp.Result, nil
+}
+
+func getChartConfig() sdk.ChartConfig {
+	title := "HDF5 Plot"
+	if currentFile != "" {
+		title = fmt.Sprintf("HDF5: %s", currentFile)
+	}
+	xLabel := "X"
+	if selectedX != "" {
+		xLabel = selectedX
+	}
+	return sdk.ChartConfig{
+		Title: title,
+		Axes: []sdk.AxisGroupConfig{
+			{
+				XAxes: []sdk.AxisConfig{{Title: xLabel}},
+				YAxes: []sdk.AxisConfig{{Title: "Y"}},
+			},
+		},
+	}
+}
+
+func getSeriesConfig() []sdk.SeriesConfig {
+	series := make([]sdk.SeriesConfig, len(selectedY))
+	for i, yPath := range selectedY {
+		series[i] = sdk.SeriesConfig{
+			ID:   yPath,
+			Name: path.Base(yPath),
+		}
+	}
+	return series
+}
+
+// readDataset reads the dataset at dsPath in the current file as float64s.
+// HDF5 converts integer datasets to the requested type while reading.
+func readDataset(dsPath string) ([]float64, error) {
+	f, err := hdf5.OpenFile(currentFile, hdf5.F_ACC_RDONLY)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open HDF5 file: %w", err)
+	}
+	defer f.Close()
+
+	ds, err := f.OpenDataset(dsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dataset %s: %w", dsPath, err)
+	}
+	defer ds.Close()
+
+	space := ds.Space()
+	values := make([]float64, space.SimpleExtentNPoints())
+	space.Close()
+	if err := ds.Read(&values); err != nil {
+		return nil, fmt.Errorf("failed to read dataset %s: %w", dsPath, err)
+	}
+	return values, nil
+}
+
+// isSelected reports whether dsPath was chosen as a Y dataset.
+func isSelected(dsPath string) bool {
+	for _, y := range selectedY {
+		if y == dsPath {
+			return true
+		}
+	}
+	return false
+}
+
+// packSeries combines x and y into the requested storage layout. x may be
+// nil to use the element index; otherwise the series is truncated to the
+// shorter of the two.
+func packSeries(x, y []float64, preferredStorage string) ([]float64, string) {
+	count := len(y)
+	if x != nil && len(x) < count {
+		count = len(x)
+	}
+
+	result := make([]float64, count*2)
+	isArrays := preferredStorage == "arrays"
+	storage := "interleaved"
+	if isArrays {
+		storage = "arrays"
+	}
+
+	for i := 0; i < count; i++ {
+		xv := float64(i)
+		if x != nil {
+			xv = x[i]
+		}
+
+		if isArrays {
+			result[i] = xv
+			result[count+i] = y[i]
+		} else {
+			result[i*2] = xv
+			result[i*2+1] = y[i]
+		}
+	}
+	return result, storage
+}
+
+// handleGetSeriesData reads and sends binary data for a specific series.
+func handleGetSeriesData(seriesID string, preferredStorage string) {
+	if !isSelected(seriesID) {
+		sdk.SendError(fmt.Sprintf("series not found: %s", seriesID))
+		return
+	}
+
+	yData, err := readDataset(seriesID)
+	if err != nil {
+		sdk.SendError(err.Error())
+		return
+	}
+
+	var xData []float64
+	if selectedX != "" && selectedX != indexX {
+		if xData, err = readDataset(selectedX); err != nil {
+			sdk.SendError(err.Error())
+			return
+		}
+	}
+
+	result, storage := packSeries(xData, yData, preferredStorage)
+	sdk.SendBinaryData(result, storage)
+}
+
+// handleGetTimeRange sends the X extent of the selected datasets.
+func handleGetTimeRange() {
+	if selectedX == "" || selectedX == indexX {
+		count := 0
+		for _, d := range datasets {
+			if isSelected(d.Path) && d.Points > count {
+				count = d.Points
+			}
+		}
+		if count == 0 {
+			sdk.SendError("no data loaded")
+			return
+		}
+		sdk.SendTimeRange(0, float64(count-1))
+		return
+	}
+
+	xData, err := readDataset(selectedX)
+	if err != nil {
+		sdk.SendError(err.Error())
+		return
+	}
+	xMin, xMax := math.Inf(1), math.Inf(-1)
+	for _, x := range xData {
+		if math.IsNaN(x) {
+			continue
+		}
+		xMin = math.Min(xMin, x)
+		xMax = math.Max(xMax, x)
+	}
+	if xMin > xMax {
+		sdk.SendError(fmt.Sprintf("no numeric values in dataset %s", selectedX))
+		return
+	}
+	sdk.SendTimeRange(xMin, xMax)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortDatasetsGroupsTree(t *testing.T) {
+	infos := []datasetInfo{
+		{Path: "/time"},
+		{Path: "/sensors/temperature"},
+		{Path: "/sensors/humidity"},
+		{Path: "/raw/adc/ch0"},
+	}
+	sortDatasets(infos)
+
+	got := make([]string, len(infos))
+	for i, d := range infos {
+		got[i] = d.Path
+	}
+	expected := []string{"/raw/adc/ch0", "/sensors/humidity", "/sensors/temperature", "/time"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestTreeTitle(t *testing.T) {
+	tests := []struct {
+		info     datasetInfo
+		expected string
+	}{
+		{datasetInfo{Path: "/time", Points: 10}, "time [10]"},
+		{datasetInfo{Path: "/sensors/temperature", Points: 5}, "\u00a0\u00a0/sensors/temperature [5]"},
+		{datasetInfo{Path: "/raw/adc/ch0", Points: 3}, "\u00a0\u00a0\u00a0\u00a0/raw/adc/ch0 [3]"},
+	}
+	for _, tt := range tests {
+		if got := treeTitle(tt.info); got != tt.expected {
+			t.Errorf("treeTitle(%q) = %q, expected %q", tt.info.Path, got, tt.expected)
+		}
+	}
+}
+
+func TestPackSeries(t *testing.T) {
+	y := []float64{10, 20, 30}
+
+	// Without X the element index is used
+	result, storage := packSeries(nil, y, "")
+	if storage != "interleaved" {
+		t.Errorf("expected interleaved storage, got %s", storage)
+	}
+	if expected := []float64{0, 10, 1, 20, 2, 30}; !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+
+	// A shorter X dataset truncates the series
+	result, storage = packSeries([]float64{0.5, 1.5}, y, "arrays")
+	if storage != "arrays" {
+		t.Errorf("expected arrays storage, got %s", storage)
+	}
+	if expected := []float64{0.5, 1.5, 10, 20}; !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+}
@@ -0,0 +1,3 @@
+@echo off
+REM Build JSON IPC Plugin
+go build -ldflags="-w -s -H windowsgui" -o json_reader.exe .
@@ -0,0 +1,507 @@
+// JSON IPC Plugin - A standalone JSON file loader plugin using host-controlled UI.
+//
+// Protocol:
+//   - Reads JSON requests from stdin (one per line)
+//   - Writes JSON responses to stdout (one per line)
+//   - Uses show_form for host-controlled column selection UI
+//   - For binary data, writes a JSON header followed by raw bytes
+//
+// The input file must be an array of objects. Nested objects are flattened
+// with dot notation (e.g. "sensors.temperature") and every key holding a
+// numeric value in the first object becomes a selectable column.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	sdk "olicanaplot/sdk/go"
+)
+
+const (
+	pluginName    = "JSON IPC"
+	pluginVersion = 1
+)
+
+var capabilities = append(sdk.BaseCapabilities(), "event", "ping")
+
+// initSchema describes the initialize args, an optional path to the JSON file.
+var initSchema = map[string]interface{}{"type": "string"}
+
+// Plugin state
+var (
+	currentFile string
+	headers     []string
+	data        map[string][]float64
+	selectedX   string
+	selectedY   []string
+	traceID     string // From the host, forwarded in log messages
+)
+
+func main() {
+	// Check for --metadata flag (Discovery Protocol)
+	if handleMetadata() {
+		return
+	}
+
+	data = make(map[string][]float64)
+	processIPC()
+}
+
+// handleMetadata checks for the --metadata flag and exits if found.
+func handleMetadata() bool {
+	for _, arg := range os.Args[1:] {
+		if arg == "--metadata" {
+			sdk.SendMetadata(sdk.Metadata{
+				Name:         pluginName,
+				Patterns:     []sdk.FilePattern{{Description: "JSON Files", Patterns: []string{"*.json"}}},
+				APIVersion:   pluginVersion,
+				Capabilities: capabilities,
+				InitSchema:   initSchema,
+			})
+			return true
+		}
+	}
+	return false
+}
+
+// processIPC runs the main communication loop reading from stdin.
+func processIPC() {
+	sdk.Log("info", "JSON IPC Plugin started")
+	scanner := bufio.NewScanner(os.Stdin)
+	// Increase buffer for large JSON messages
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		req, err := sdk.ParseRequest([]byte(line))
+		if err != nil {
+			sdk.SendError(fmt.Sprintf("Invalid JSON: %v", err))
+			continue
+		}
+
+		handleMethod(req, scanner)
+	}
+
+	if err := scanner.Err(); err != nil {
+		sdk.Log("error", fmt.Sprintf("Scanner error: %v", err))
+	}
+}
+
+// handleMethod dispatches incoming IPC calls to specific handlers.
+func handleMethod(req sdk.Request, scanner *bufio.Scanner) {
+	if req.TraceID != "" {
+		traceID = req.TraceID
+	}
+	sdk.Log("debug", fmt.Sprintf("Handling %s", req.Method), "trace_id", traceID)
+
+	switch req.Method {
+	case "info":
+		sdk.SendResponse(sdk.Response{
+			Name:         pluginName,
+			Version:      pluginVersion,
+			Capabilities: capabilities,
+		})
+
+	case "initialize":
+		if err := handleInitialize(req.Args, scanner); err != nil {
+			sdk.SendError(err.Error())
+		} else {
+			sdk.SendResponse(sdk.Response{Result: map[string]interface{}{}})
+		}
+
+	case "get_schema":
+		sdk.SendSchema(initSchema, map[string]interface{}{"type": "string"})
+
+	case "get_chart_config":
+		sdk.SendResponse(sdk.Response{
+			Result: getChartConfig(),
+		})
+
+	case "get_series_config":
+		sdk.SendResponse(sdk.Response{
+			Result: getSeriesConfig(),
+		})
+
+	case "get_series_data":
+		handleGetSeriesData(req.SeriesID, req.PreferredStorage)
+
+	case "get_time_range":
+		handleGetTimeRange()
+
+	case "ping":
+		sdk.HandlePing(req)
+
+	case "event":
+		// Host notifications need no reply
+		sdk.HandleEvent(req)
+
+	case "quit":
+		sdk.HandleQuit(req)
+
+	default:
+		sdk.SendError(fmt.Sprintf("Unknown method: %s", req.Method))
+	}
+}
+
+// handleInitialize manages the multi-step initialization process (file selection -> column selection).
+func handleInitialize(initStr string, scanner *bufio.Scanner) error {
+	filePath, err := resolveFilePath(initStr, scanner)
+	if err != nil {
+		return err
+	}
+
+	// JSON has to be parsed in full to find the keys, so keep the records
+	// around rather than reading the file twice.
+	records, err := readJSONRecords(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read JSON: %w", err)
+	}
+	headers = numericKeys(records[0])
+	if len(headers) == 0 {
+		return fmt.Errorf("no numeric keys found in %s", filePath)
+	}
+	currentFile = filePath
+
+	// Show column selection UI
+	result, err := showColumnSelection(scanner)
+	if err != nil {
+		return err
+	}
+
+	// Apply selection
+	selectedX = result.XColumn
+	selectedY = result.YColumns
+
+	data = loadJSONData(records, headers)
+
+	sdk.Log("info", fmt.Sprintf("JSON loaded: %d records, %d keys, X=%s, Y=%v", len(records), len(headers), selectedX, selectedY))
+	return nil
+}
+
+// resolveFilePath either uses the provided path or requests one from the host via show_form.
+func resolveFilePath(initStr string, scanner *bufio.Scanner) (string, error) {
+	if initStr != "" {
+		sdk.Log("info", fmt.Sprintf("Using provided file path: %s", initStr))
+		return initStr, nil
+	}
+
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"filePath": map[string]interface{}{
+				"type":  "string",
+				"title": "JSON File Path",
+			},
+		},
+	}
+	uiSchema := map[string]interface{}{
+		"filePath": map[string]interface{}{
+			"ui:widget": "file",
+			"ui:options": map[string]interface{}{
+				"accept": ".json",
+			},
+		},
+	}
+
+	sdk.SendShowForm("Select JSON File", schema, uiSchema, nil)
+
+	if !scanner.Scan() {
+		return "", fmt.Errorf("failed to read file selection response")
+	}
+
+	var resp struct {
+		Result struct {
+			FilePath string `json:"filePath"`
+		} `json:"result"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+		return "", fmt.Errorf("failed to parse file selection response: %v", err)
+	}
+	if resp.Error != "" {
+		return "", fmt.Errorf("file selection cancelled: %s", resp.Error)
+	}
+
+	return resp.Result.FilePath, nil
+}
+
+type ColumnSelectionResult struct {
+	XColumn  string   `json:"xColumn"`
+	YColumns []string `json:"yColumns"`
+}
+
+// showColumnSelection requests and parses the user's column choices.
+func showColumnSelection(scanner *bufio.Scanner) (*ColumnSelectionResult, error) {
+	// Build column selection options
+	columnOptions := make([]map[string]interface{}, 0, len(headers)+1)
+	columnOptions = append(columnOptions, map[string]interface{}{
+		"const": "Index",
+		"title": "Index (row number)",
+	})
+	for _, h := range headers {
+		columnOptions = append(columnOptions, map[string]interface{}{
+			"const": h,
+			"title": h,
+		})
+	}
+
+	yColumnItems := make([]map[string]interface{}, 0, len(headers))
+	for _, h := range headers {
+		yColumnItems = append(yColumnItems, map[string]interface{}{
+			"const": h,
+			"title": h,
+		})
+	}
+
+	// Defaults based on heuristics
+	defaultX := "Index"
+	defaultY := headers
+	if len(headers) > 1 {
+		defaultX = headers[0]
+		defaultY = headers[1:]
+	}
+
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"xColumn": map[string]interface{}{
+				"type":    "string",
+				"title":   "X-Axis Column",
+				"oneOf":   columnOptions,
+				"default": defaultX,
+			},
+			"yColumns": map[string]interface{}{
+				"type":    "array",
+				"title":   "Y-Axis Columns",
+				"default": defaultY,
+				"items": map[string]interface{}{
+					"type":  "string",
+					"oneOf": yColumnItems,
+				},
+				"uniqueItems": true,
+				"minItems":    1,
+			},
+		},
+	}
+	uiSchema := map[string]interface{}{
+		"xColumn":  map[string]interface{}{"ui:widget": "select"},
+		"yColumns": map[string]interface{}{"ui:widget": "checkboxes"},
+	}
+
+	sdk.SendShowForm("Select Columns", schema, uiSchema, map[string]interface{}{
+		"xColumn":  defaultX,
+		"yColumns": defaultY,
+	})
+
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("failed to read column selection response")
+	}
+
+	var resp struct {
+		Result ColumnSelectionResult `json:"result"`
+		Error  string                `json:"error"`
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse column selection response: %v", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("column selection cancelled")
+	}
+
+	return &resp.Result, nil
+}
+
+func getChartConfig() sdk.ChartConfig {
+	title := "JSON Plot"
+	if currentFile != "" {
+		title = fmt.Sprintf("JSON: %s", currentFile)
+	}
+	xLabel := "X"
+	if selectedX != "" {
+		xLabel = selectedX
+	}
+	return sdk.ChartConfig{
+		Title: title,
+		Axes: []sdk.AxisGroupConfig{
+			{
+				XAxes: []sdk.AxisConfig{{Title: xLabel}},
+				YAxes: []sdk.AxisConfig{{Title: "Y"}},
+			},
+		},
+	}
+}
+
+func getSeriesConfig() []sdk.SeriesConfig {
+	series := make([]sdk.SeriesConfig, len(selectedY))
+	for i, yCol := range selectedY {
+		series[i] = sdk.SeriesConfig{
+			ID:   yCol,
+			Name: yCol,
+		}
+	}
+	return series
+}
+
+// readJSONRecords parses a file holding a non-empty array of objects.
+func readJSONRecords(path string) ([]map[string]interface{}, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw interface{}
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, err
+	}
+
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a top-level array of objects")
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("array is empty")
+	}
+
+	records := make([]map[string]interface{}, len(items))
+	for i, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("element %d is not an object", i)
+		}
+		records[i] = flattenObject("", obj, make(map[string]interface{}))
+	}
+	return records, nil
+}
+
+// flattenObject copies the leaves of obj into out, joining nested keys with dots.
+func flattenObject(prefix string, obj map[string]interface{}, out map[string]interface{}) map[string]interface{} {
+	for k, v := range obj {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if nested, ok := v.(map[string]interface{}); ok {
+			flattenObject(key, nested, out)
+		} else {
+			out[key] = v
+		}
+	}
+	return out
+}
+
+// numericKeys returns the sorted keys of a flattened record whose values are numbers.
+func numericKeys(record map[string]interface{}) []string {
+	keys := make([]string, 0, len(record))
+	for k, v := range record {
+		if _, ok := v.(float64); ok {
+			keys = append(keys, k)
+		}
+	}
+	// Object key order is not preserved by encoding/json
+	sort.Strings(keys)
+	return keys
+}
+
+// loadJSONData extracts the selected keys from every record. Missing or
+// non-numeric values become NaN.
+func loadJSONData(records []map[string]interface{}, keys []string) map[string][]float64 {
+	resultMap := make(map[string][]float64)
+	for _, k := range keys {
+		resultMap[k] = make([]float64, 0, len(records))
+	}
+
+	for _, record := range records {
+		for _, k := range keys {
+			v, ok := record[k].(float64)
+			if !ok {
+				v = math.NaN()
+			}
+			resultMap[k] = append(resultMap[k], v)
+		}
+	}
+
+	return resultMap
+}
+
+// handleGetSeriesData retrieves and sends binary data for a specific series.
+func handleGetSeriesData(seriesID string, preferredStorage string) {
+	yData, ok := data[seriesID]
+	if !ok {
+		sdk.SendError(fmt.Sprintf("series not found: %s", seriesID))
+		return
+	}
+
+	count := len(yData)
+	result := make([]float64, count*2)
+	isArrays := preferredStorage == "arrays"
+	storage := "interleaved"
+	if isArrays {
+		storage = "arrays"
+	}
+
+	xSrc, hasX := data[selectedX]
+	if selectedX == "" || selectedX == "Index" {
+		hasX = false
+	}
+
+	for i := 0; i < count; i++ {
+		var x float64
+		if hasX && i < len(xSrc) {
+			x = xSrc[i]
+		} else {
+			x = float64(i)
+		}
+
+		if isArrays {
+			result[i] = x
+			result[count+i] = yData[i]
+		} else {
+			result[i*2] = x
+			result[i*2+1] = yData[i]
+		}
+	}
+
+	sdk.SendBinaryData(result, storage)
+}
+
+// handleGetTimeRange sends the X extent of the loaded data.
+func handleGetTimeRange() {
+	if selectedX == "" || selectedX == "Index" {
+		count := 0
+		for _, y := range selectedY {
+			if n := len(data[y]); n > count {
+				count = n
+			}
+		}
+		if count == 0 {
+			sdk.SendError("no data loaded")
+			return
+		}
+		sdk.SendTimeRange(0, float64(count-1))
+		return
+	}
+
+	xMin, xMax := math.Inf(1), math.Inf(-1)
+	for _, x := range data[selectedX] {
+		if math.IsNaN(x) {
+			continue
+		}
+		xMin = math.Min(xMin, x)
+		xMax = math.Max(xMax, x)
+	}
+	if xMin > xMax {
+		sdk.SendError(fmt.Sprintf("no numeric values in key %s", selectedX))
+		return
+	}
+	sdk.SendTimeRange(xMin, xMax)
+}
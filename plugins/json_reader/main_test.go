@@ -0,0 +1,79 @@
+package main
+
+import (
+	"math"
+	"os"
+	"testing"
+)
+
+func writeTempJSON(t *testing.T, content string) string {
+	t.Helper()
+	tmpfile, err := os.CreateTemp("", "test*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove(tmpfile.Name()) })
+
+	if _, err := tmpfile.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tmpfile.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return tmpfile.Name()
+}
+
+func TestNumericKeysFlattened(t *testing.T) {
+	path := writeTempJSON(t, `[
+		{"time": 0, "label": "a", "sensors": {"temperature": 20.5, "humidity": 40}},
+		{"time": 1, "label": "b", "sensors": {"temperature": 21.0, "humidity": 41}}
+	]`)
+
+	records, err := readJSONRecords(path)
+	if err != nil {
+		t.Fatalf("readJSONRecords failed: %v", err)
+	}
+
+	keys := numericKeys(records[0])
+	expected := []string{"sensors.humidity", "sensors.temperature", "time"}
+	if len(keys) != len(expected) {
+		t.Fatalf("expected keys %v, got %v", expected, keys)
+	}
+	for i, k := range keys {
+		if k != expected[i] {
+			t.Errorf("expected key %d to be %s, got %s", i, expected[i], k)
+		}
+	}
+}
+
+func TestLoadJSONData(t *testing.T) {
+	path := writeTempJSON(t, `[
+		{"t": 1, "v": {"x": 2}},
+		{"t": 3, "v": {"x": "invalid"}},
+		{"t": 5}
+	]`)
+
+	records, err := readJSONRecords(path)
+	if err != nil {
+		t.Fatalf("readJSONRecords failed: %v", err)
+	}
+
+	data := loadJSONData(records, []string{"t", "v.x"})
+	if len(data["t"]) != 3 || len(data["v.x"]) != 3 {
+		t.Fatalf("expected 3 rows, got %d and %d", len(data["t"]), len(data["v.x"]))
+	}
+	if data["t"][2] != 5 || data["v.x"][0] != 2 {
+		t.Errorf("unexpected values: t=%v v.x=%v", data["t"], data["v.x"])
+	}
+	if !math.IsNaN(data["v.x"][1]) || !math.IsNaN(data["v.x"][2]) {
+		t.Errorf("expected NaN for invalid and missing values, got %v", data["v.x"])
+	}
+}
+
+func TestReadJSONRecordsRejectsNonArray(t *testing.T) {
+	for _, content := range []string{`{"t": 1}`, `[]`, `[1, 2]`} {
+		if _, err := readJSONRecords(writeTempJSON(t, content)); err == nil {
+			t.Errorf("expected error for %s", content)
+		}
+	}
+}
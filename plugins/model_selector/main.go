@@ -17,6 +17,8 @@ const (
 	pluginVersion = 1
 )
 
+var capabilities = append(sdk.BaseCapabilities(), "event", "ping")
+
 type pluginState struct {
 	modelType  string
 	numSeries  int
@@ -45,11 +47,7 @@ var state = &pluginState{
 func main() {
 	// Metadata support
 	if len(os.Args) > 1 && os.Args[1] == "--metadata" {
-		meta := map[string]interface{}{
-			"name":     pluginName,
-			"patterns": []interface{}{},
-		}
-		json.NewEncoder(os.Stdout).Encode(meta)
+		sdk.SendMetadata(sdk.Metadata{Name: pluginName, APIVersion: pluginVersion, Capabilities: capabilities})
 		return
 	}
 
@@ -61,8 +59,8 @@ func handleIPC() {
 	scanner := bufio.NewScanner(os.Stdin)
 	for scanner.Scan() {
 		line := scanner.Text()
-		var req sdk.Request
-		if err := json.Unmarshal([]byte(line), &req); err != nil {
+		req, err := sdk.ParseRequest([]byte(line))
+		if err != nil {
 			sdk.SendError("invalid json")
 			continue
 		}
@@ -70,8 +68,9 @@ func handleIPC() {
 		switch req.Method {
 		case "info":
 			sdk.SendResponse(sdk.Response{
-				Name:    pluginName,
-				Version: pluginVersion,
+				Name:         pluginName,
+				Version:      pluginVersion,
+				Capabilities: capabilities,
 			})
 
 		case "initialize":
@@ -138,6 +137,16 @@ func handleIPC() {
 			data, storage := generateData(req.SeriesID, req.PreferredStorage)
 			sdk.SendBinaryData(data, storage)
 
+		case "ping":
+			sdk.HandlePing(req)
+
+		case "event":
+			// Host notifications need no reply
+			sdk.HandleEvent(req)
+
+		case "quit":
+			sdk.HandleQuit(req)
+
 		default:
 			sdk.SendError("unknown method")
 		}
@@ -240,6 +249,9 @@ func getUI(model string) (interface{}, interface{}) {
 		properties["p"] = map[string]interface{}{"type": "integer", "title": "p (AR)", "default": state.p}
 		properties["d"] = map[string]interface{}{"type": "integer", "title": "d (I)", "default": state.d}
 		properties["q"] = map[string]interface{}{"type": "integer", "title": "q (MA)", "default": state.q}
+		// Two columns keep the form short, with p, d and q side by side
+		uiSchema["ui:layout"] = "columns-2"
+		uiSchema["ui:order"] = []string{"model", "numSeries", "order", "multiplier", "p", "d", "q"}
 	case "Sinusoidal":
 		properties["amplitude"] = map[string]interface{}{"type": "number", "title": "Amplitude", "default": state.amplitude}
 		properties["frequency"] = map[string]interface{}{"type": "number", "title": "Frequency", "default": state.frequency}
@@ -283,6 +283,8 @@ def main() -> None:
                 req.get("series_id", ""),
                 req.get("preferred_storage", "interleaved"),
             )
+        elif method == "event":
+            pass  # Host notifications need no reply
         else:
             protocol.send_error(f"Unknown method: {method}")
 
@@ -5,6 +5,7 @@ go 1.25
 replace olicanaplot => ../../
 
 require (
+	golang.org/x/sys v0.33.0
 	gopkg.in/yaml.v3 v3.0.1
 	olicanaplot v0.0.0
 )
@@ -4,7 +4,6 @@ import (
 	"bufio"
 	"compress/gzip"
 	"encoding/csv"
-	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -24,6 +23,8 @@ const (
 	pluginVersion = 1
 )
 
+var capabilities = append(sdk.BaseCapabilities(), "get_file_info", "event", "ping")
+
 // YAML structures
 type FileConfig struct {
 	Version   int              `yaml:"version"`
@@ -31,16 +32,22 @@ type FileConfig struct {
 	Layout    LayoutSection    `yaml:"layout"`
 	Behaviour BehaviourSection `yaml:"behaviour"`
 	Axes      []AxisEntry      `yaml:"axes"`
+	Comment   string           `yaml:"comment"`
 }
 
 type ChartSection struct {
 	Title     string   `yaml:"title"`
 	LineWidth *float64 `yaml:"line_width"`
+	Comment   string   `yaml:"comment"`
 }
 
 type LayoutSection struct {
 	Rows int `yaml:"rows"`
 	Cols int `yaml:"cols"`
+
+	// TimeBlock makes every CSV block a consecutive time segment of the same
+	// columns, rather than the data of one axes entry.
+	TimeBlock bool `yaml:"time_block"`
 }
 
 type BehaviourSection struct {
@@ -54,6 +61,7 @@ type AxisEntry struct {
 	XAxes   []AxisDetail  `yaml:"x_axes"`
 	YAxes   []AxisDetail  `yaml:"y_axes"`
 	Series  []SeriesEntry `yaml:"series"`
+	Comment string        `yaml:"comment"`
 }
 
 type AxisDetail struct {
@@ -64,6 +72,8 @@ type AxisDetail struct {
 	Min            *float64 `yaml:"min"`
 	Max            *float64 `yaml:"max"`
 	Representation string   `yaml:"representation"`
+	ScaleFactor    float64  `yaml:"scale_factor"`
+	ScaleOffset    float64  `yaml:"scale_offset"`
 }
 
 type SeriesEntry struct {
@@ -78,6 +88,7 @@ type SeriesEntry struct {
 	MarkerType     string   `yaml:"marker_type"`
 	MarkerFill     string   `yaml:"marker_fill"`
 	MarkerSize     *float64 `yaml:"marker_size"`
+	Comment        string   `yaml:"comment"` // Shown in the series tooltip
 }
 
 type CsvBlock struct {
@@ -88,42 +99,46 @@ type Plugin struct {
 	mu         sync.Mutex
 	fileConfig *FileConfig
 	csvBlocks  []CsvBlock
+	fileInfo   *sdk.FileInfo
 }
 
 func (p *Plugin) loadFile(path string) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	file, err := os.Open(path)
+	// Stat the file as given, not the decompressed copy
+	info, err := sdk.NewFileInfo(path, 0)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	var reader io.Reader = file
 	if strings.HasSuffix(strings.ToLower(path), ".olicaplotz") {
-		gz, err := gzip.NewReader(file)
+		// gzip has no random access, so decompress to a temporary file
+		// that can be mapped instead of into memory
+		tmpPath, err := decompressToTemp(path)
 		if err != nil {
 			return err
 		}
-		defer gz.Close()
-		reader = gz
+		defer os.Remove(tmpPath)
+		path = tmpPath
 	}
 
-	content, err := io.ReadAll(reader)
+	m, err := OpenMMap(path)
 	if err != nil {
 		return err
 	}
+	defer m.Close()
 
 	// Split on form feed (\f)
-	parts := strings.Split(string(content), "\f")
-	if len(parts) == 0 {
-		return fmt.Errorf("empty file")
+	parts := m.Sections()
+	header, err := io.ReadAll(parts[0])
+	if err != nil {
+		return err
 	}
 
 	// Parse YAML
 	var config FileConfig
-	if err := yaml.Unmarshal([]byte(parts[0]), &config); err != nil {
+	if err := yaml.Unmarshal(header, &config); err != nil {
 		return fmt.Errorf("failed to parse YAML: %w", err)
 	}
 	p.fileConfig = &config
@@ -131,20 +146,12 @@ func (p *Plugin) loadFile(path string) error {
 	// Parse CSV blocks
 	p.csvBlocks = nil
 	for i := 1; i < len(parts); i++ {
-		colReps := make(map[int]string)
-		if i-1 < len(config.Axes) {
-			for _, s := range config.Axes[i-1].Series {
-				if s.Representation != "" {
-					colReps[s.Column] = s.Representation
-				}
-			}
-			// Check if X axis is set to date, but column 0 has no representation set
-			if len(config.Axes[i-1].XAxes) > 0 {
-				// If the user specified a representation on the X-axis itself, use it.
-				if config.Axes[i-1].XAxes[0].Representation != "" {
-					colReps[0] = config.Axes[i-1].XAxes[0].Representation
-				}
-			}
+		var colReps map[int]string
+		if config.Layout.TimeBlock {
+			// Every block holds the columns of all axes entries
+			colReps = columnRepresentations(config.Axes...)
+		} else if i-1 < len(config.Axes) {
+			colReps = columnRepresentations(config.Axes[i-1])
 		}
 
 		block, err := p.parseCsvBlock(parts[i], colReps)
@@ -152,11 +159,121 @@ func (p *Plugin) loadFile(path string) error {
 			return fmt.Errorf("failed to parse CSV block %d: %w", i-1, err)
 		}
 		p.csvBlocks = append(p.csvBlocks, block)
+		if len(block.Data) > 0 {
+			info.RowCount += len(block.Data[0])
+		}
 	}
+	p.fileInfo = &info
 
 	return nil
 }
 
+// columnRepresentations returns the representation of each CSV column set by
+// the series and first X axis of the given axes entries.
+func columnRepresentations(entries ...AxisEntry) map[int]string {
+	colReps := make(map[int]string)
+	for _, entry := range entries {
+		for _, s := range entry.Series {
+			if s.Representation != "" {
+				colReps[s.Column] = s.Representation
+			}
+		}
+		// If the user specified a representation on the X-axis itself, use
+		// it for column 0
+		if len(entry.XAxes) > 0 && entry.XAxes[0].Representation != "" {
+			colReps[0] = entry.XAxes[0].Representation
+		}
+	}
+	return colReps
+}
+
+// seriesColumns returns the X and Y values of the series with the given ID,
+// "axis<n>:col<m>". In files with time blocks, that is the column joined
+// across all blocks, while "axis<n>:col<m>:seg<k>" is the column of block k.
+func (p *Plugin) seriesColumns(id string) ([]float64, []float64, error) {
+	timeBlock := p.fileConfig != nil && p.fileConfig.Layout.TimeBlock
+	var axisIdx, colIdx, seg int
+	n, _ := fmt.Sscanf(id, "axis%d:col%d:seg%d", &axisIdx, &colIdx, &seg)
+	if n < 2 || (n == 3 && !timeBlock) {
+		return nil, nil, fmt.Errorf("invalid series ID")
+	}
+
+	if !timeBlock {
+		if axisIdx < 0 || axisIdx >= len(p.csvBlocks) {
+			return nil, nil, fmt.Errorf("axis index out of range")
+		}
+		block := p.csvBlocks[axisIdx]
+		if colIdx < 0 || colIdx >= len(block.Data) {
+			return nil, nil, fmt.Errorf("column index out of range")
+		}
+		return block.Data[0], block.Data[colIdx], nil
+	}
+
+	if axisIdx < 0 || axisIdx >= len(p.fileConfig.Axes) {
+		return nil, nil, fmt.Errorf("axis index out of range")
+	}
+	blocks := p.csvBlocks
+	if n == 3 {
+		if seg < 0 || seg >= len(blocks) {
+			return nil, nil, fmt.Errorf("segment index out of range")
+		}
+		blocks = blocks[seg : seg+1]
+	}
+
+	var x, y []float64
+	found := false
+	for _, block := range blocks {
+		if len(block.Data) == 0 {
+			continue
+		}
+		x = append(x, block.Data[0]...)
+		if colIdx >= 0 && colIdx < len(block.Data) {
+			y = append(y, block.Data[colIdx]...)
+			found = true
+			continue
+		}
+		// Keep the other segments lined up with their X values
+		for range block.Data[0] {
+			y = append(y, math.NaN())
+		}
+	}
+	if !found {
+		return nil, nil, fmt.Errorf("column index out of range")
+	}
+	return x, y, nil
+}
+
+// decompressToTemp writes the decompressed contents of a gzip file to a
+// temporary file and returns its path.
+func decompressToTemp(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return "", err
+	}
+	defer gz.Close()
+
+	tmp, err := os.CreateTemp("", "olicanaplot-*.olicanaplot")
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(tmp, gz); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to decompress: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
 func parseValue(valStr string, rep string) float64 {
 	valStr = strings.TrimSpace(valStr)
 	if valStr == "" {
@@ -202,8 +319,8 @@ func parseValue(valStr string, rep string) float64 {
 	return math.NaN()
 }
 
-func (p *Plugin) parseCsvBlock(content string, colReps map[int]string) (CsvBlock, error) {
-	reader := csv.NewReader(strings.NewReader(strings.TrimSpace(content)))
+func (p *Plugin) parseCsvBlock(content io.Reader, colReps map[int]string) (CsvBlock, error) {
+	reader := csv.NewReader(content)
 	reader.FieldsPerRecord = -1 // Allow variable fields if needed, but we expect consistency
 
 	records, err := reader.ReadAll()
@@ -238,17 +355,14 @@ func main() {
 	// Check for --metadata flag
 	for _, arg := range os.Args {
 		if arg == "--metadata" {
-			meta := map[string]interface{}{
-				"name": pluginName,
-				"patterns": []interface{}{
-					map[string]interface{}{
-						"description": "OlicanaPlot Files",
-						"patterns":    []string{"*.olicanaplot", "*.olicaplotz"},
-					},
+			sdk.SendMetadata(sdk.Metadata{
+				Name: pluginName,
+				Patterns: []sdk.FilePattern{
+					{Description: "OlicanaPlot Files", Patterns: []string{"*.olicanaplot", "*.olicaplotz"}},
 				},
-			}
-			bytes, _ := json.Marshal(meta)
-			fmt.Println(string(bytes))
+				APIVersion:   pluginVersion,
+				Capabilities: capabilities,
+			})
 			os.Exit(0)
 		}
 	}
@@ -265,8 +379,8 @@ func handleIPC(p *Plugin) {
 			return
 		}
 
-		var req sdk.Request
-		if err := json.Unmarshal([]byte(strings.TrimSpace(line)), &req); err != nil {
+		req, err := sdk.ParseRequest([]byte(strings.TrimSpace(line)))
+		if err != nil {
 			sdk.SendError("failed to parse request")
 			continue
 		}
@@ -274,8 +388,9 @@ func handleIPC(p *Plugin) {
 		switch req.Method {
 		case "info":
 			sdk.SendResponse(sdk.Response{
-				Name:    pluginName,
-				Version: uint32(pluginVersion),
+				Name:         pluginName,
+				Version:      uint32(pluginVersion),
+				Capabilities: capabilities,
 			})
 
 		case "initialize":
@@ -305,22 +420,26 @@ func handleIPC(p *Plugin) {
 				}
 				for _, x := range entry.XAxes {
 					axes[i].XAxes = append(axes[i].XAxes, sdk.AxisConfig{
-						Title:    x.Title,
-						Position: x.Position,
-						Unit:     x.Unit,
-						Type:     x.Type,
-						Min:      x.Min,
-						Max:      x.Max,
+						Title:       x.Title,
+						Position:    x.Position,
+						Unit:        x.Unit,
+						Type:        x.Type,
+						Min:         x.Min,
+						Max:         x.Max,
+						ScaleFactor: x.ScaleFactor,
+						ScaleOffset: x.ScaleOffset,
 					})
 				}
 				for _, y := range entry.YAxes {
 					axes[i].YAxes = append(axes[i].YAxes, sdk.AxisConfig{
-						Title:    y.Title,
-						Position: y.Position,
-						Unit:     y.Unit,
-						Type:     y.Type,
-						Min:      y.Min,
-						Max:      y.Max,
+						Title:       y.Title,
+						Position:    y.Position,
+						Unit:        y.Unit,
+						Type:        y.Type,
+						Min:         y.Min,
+						Max:         y.Max,
+						ScaleFactor: y.ScaleFactor,
+						ScaleOffset: y.ScaleOffset,
 					})
 				}
 			}
@@ -360,46 +479,51 @@ func handleIPC(p *Plugin) {
 						subplot = &sdk.SubPlot{Row: entry.Subplot[0], Col: entry.Subplot[1]}
 					}
 
-					seriesConfigs = append(seriesConfigs, sdk.SeriesConfig{
-						ID:         id,
-						Name:       name,
-						Color:      color,
-						Subplot:    subplot,
-						LineType:   s.LineType,
-						LineWidth:  s.LineWidth,
-						MarkerType: s.MarkerType,
-						MarkerFill: s.MarkerFill,
-						MarkerSize: s.MarkerSize,
-						Visible:    s.Visible,
-						YAxis:      s.YAxis,
-					})
+					// Hidden series load after the visible ones
+					priority := 0
+					if s.Visible != nil && !*s.Visible {
+						priority = -1
+					}
+
+					config := sdk.SeriesConfig{
+						ID:           id,
+						Name:         name,
+						Color:        color,
+						Subplot:      subplot,
+						LineType:     s.LineType,
+						LineWidth:    s.LineWidth,
+						MarkerType:   s.MarkerType,
+						MarkerFill:   s.MarkerFill,
+						MarkerSize:   s.MarkerSize,
+						Visible:      s.Visible,
+						YAxis:        s.YAxis,
+						Description:  s.Comment,
+						LoadPriority: priority,
+					}
+					if !p.fileConfig.Layout.TimeBlock {
+						seriesConfigs = append(seriesConfigs, config)
+						continue
+					}
+
+					// With time blocks, each block's segment of the column is
+					// a series
+					for seg := range p.csvBlocks {
+						segConfig := config
+						segConfig.ID = fmt.Sprintf("%s:seg%d", id, seg)
+						segConfig.Name = fmt.Sprintf("%s (segment %d)", name, seg+1)
+						seriesConfigs = append(seriesConfigs, segConfig)
+					}
 				}
 			}
 			sdk.SendResponse(sdk.Response{Result: seriesConfigs})
 
 		case "get_series_data":
-			// Parse ID: axis%d:col%d
-			var axisIdx, colIdx int
-			_, err := fmt.Sscanf(req.SeriesID, "axis%d:col%d", &axisIdx, &colIdx)
+			xData, yData, err := p.seriesColumns(req.SeriesID)
 			if err != nil {
-				sdk.SendError("invalid series ID")
-				continue
-			}
-
-			if axisIdx < 0 || axisIdx >= len(p.csvBlocks) {
-				sdk.SendError("axis index out of range")
-				continue
-			}
-
-			block := p.csvBlocks[axisIdx]
-			if colIdx < 0 || colIdx >= len(block.Data) {
-				sdk.SendError("column index out of range")
+				sdk.SendError(err.Error())
 				continue
 			}
 
-			xData := block.Data[0]
-			yData := block.Data[colIdx]
-
 			if req.PreferredStorage == "arrays" {
 				data := append(xData, yData...)
 				sdk.SendBinaryData(data, "arrays")
@@ -413,6 +537,23 @@ func handleIPC(p *Plugin) {
 				sdk.SendBinaryData(data, "interleaved")
 			}
 
+		case "get_file_info":
+			if p.fileInfo == nil {
+				sdk.SendError("no file loaded")
+				continue
+			}
+			sdk.SendFileInfo(*p.fileInfo)
+
+		case "ping":
+			sdk.HandlePing(req)
+
+		case "event":
+			// Host notifications need no reply
+			sdk.HandleEvent(req)
+
+		case "quit":
+			sdk.HandleQuit(req)
+
 		default:
 			sdk.SendError("unknown method: " + req.Method)
 		}
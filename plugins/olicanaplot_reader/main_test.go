@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	sdk "olicanaplot/sdk/go"
+	"olicanaplot/sdk/go/sdktest"
+)
+
+// runIPC feeds reqs to handleIPC over stdin and returns the JSON replies.
+func runIPC(t *testing.T, reqs ...sdk.Request) []json.RawMessage {
+	t.Helper()
+
+	in, out := sdktest.Serve(t, func() { handleIPC(&Plugin{}) })
+	enc := json.NewEncoder(in)
+	for _, req := range reqs {
+		if err := enc.Encode(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	in.Close()
+
+	var lines []json.RawMessage
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		lines = append(lines, json.RawMessage(append([]byte(nil), scanner.Bytes()...)))
+	}
+	return lines
+}
+
+func TestChartConfigAxisUnits(t *testing.T) {
+	path, err := filepath.Abs(filepath.Join("testdata", "units.olicanaplot"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replies := runIPC(t,
+		sdk.Request{Method: "initialize", Args: path},
+		sdk.Request{Method: "get_chart_config"},
+	)
+	if len(replies) != 2 {
+		t.Fatalf("expected 2 replies, got %d", len(replies))
+	}
+
+	var resp struct {
+		Result sdk.ChartConfig `json:"result"`
+		Error  string          `json:"error"`
+	}
+	if err := json.Unmarshal(replies[1], &resp); err != nil {
+		t.Fatalf("failed to parse chart config: %v", err)
+	}
+	if resp.Error != "" {
+		t.Fatalf("get_chart_config failed: %s", resp.Error)
+	}
+	axes := resp.Result.Axes
+	if len(axes) != 1 || len(axes[0].XAxes) != 1 || len(axes[0].YAxes) != 1 {
+		t.Fatalf("unexpected axes: %+v", axes)
+	}
+	if got := axes[0].YAxes[0].Unit; got != "m/s" {
+		t.Errorf("y axis unit = %q, want %q", got, "m/s")
+	}
+	if got := axes[0].XAxes[0].Unit; got != "s" {
+		t.Errorf("x axis unit = %q, want %q", got, "s")
+	}
+}
+
+func TestSeriesConfigTimeBlocks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "segments.olicanaplot")
+	content := `{"version": 1, "layout": {"time_block": true}, "axes": [{"subplot": [0, 0], "series": [{"title": "Speed", "column": 1}]}]}` +
+		"\f\n0,1\n1,2\n\f\n2,3\n3,4\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	replies := runIPC(t,
+		sdk.Request{Method: "initialize", Args: path},
+		sdk.Request{Method: "get_series_config"},
+	)
+	if len(replies) != 2 {
+		t.Fatalf("expected 2 replies, got %d", len(replies))
+	}
+	var resp struct {
+		Result []sdk.SeriesConfig `json:"result"`
+	}
+	if err := json.Unmarshal(replies[1], &resp); err != nil {
+		t.Fatalf("failed to parse series config: %v", err)
+	}
+	var ids, names []string
+	for _, s := range resp.Result {
+		ids = append(ids, s.ID)
+		names = append(names, s.Name)
+	}
+	if got, want := strings.Join(ids, ","), "axis0:col1:seg0,axis0:col1:seg1"; got != want {
+		t.Errorf("series IDs = %s, want %s", got, want)
+	}
+	if got, want := strings.Join(names, ","), "Speed (segment 1),Speed (segment 2)"; got != want {
+		t.Errorf("series names = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// MMapReader gives random access to a file without reading it into the Go
+// heap. The file is memory-mapped where the platform supports it, so files
+// larger than the available RAM can be parsed section by section.
+type MMapReader struct {
+	data  []byte
+	unmap func() error
+}
+
+// OpenMMap maps the file at path into memory.
+func OpenMMap(path string) (*MMapReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	// The mapping stays valid after the file is closed
+	defer file.Close()
+
+	data, unmap, err := mmapFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return &MMapReader{data: data, unmap: unmap}, nil
+}
+
+// Len returns the size of the mapped file.
+func (m *MMapReader) Len() int64 {
+	return int64(len(m.data))
+}
+
+// ReadAt implements io.ReaderAt over the mapped memory.
+func (m *MMapReader) ReadAt(p []byte, off int64) (int, error) {
+	if off >= int64(len(m.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, m.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Sections splits the file at form feed (\f) bytes and returns a reader for
+// each section, without copying the file contents.
+func (m *MMapReader) Sections() []*io.SectionReader {
+	var sections []*io.SectionReader
+	var start int64
+	for {
+		idx := bytes.IndexByte(m.data[start:], '\f')
+		if idx < 0 {
+			break
+		}
+		sections = append(sections, io.NewSectionReader(m, start, int64(idx)))
+		start += int64(idx) + 1
+	}
+	return append(sections, io.NewSectionReader(m, start, m.Len()-start))
+}
+
+// Close unmaps the file. Readers returned by Sections must not be used
+// afterwards.
+func (m *MMapReader) Close() error {
+	if m.unmap == nil {
+		return nil
+	}
+	err := m.unmap()
+	m.data, m.unmap = nil, nil
+	return err
+}
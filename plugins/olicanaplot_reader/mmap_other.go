@@ -0,0 +1,17 @@
+//go:build !linux && !darwin && !windows
+
+package main
+
+import (
+	"io"
+	"os"
+)
+
+// mmapFile reads the whole file on platforms without mmap support.
+func mmapFile(file *os.File) ([]byte, func() error, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, nil, nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMMapSections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sections.olicanaplot")
+	if err := os.WriteFile(path, []byte("header\f1,2\n\f3,4\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := OpenMMap(path)
+	if err != nil {
+		t.Fatalf("OpenMMap failed: %v", err)
+	}
+	defer m.Close()
+
+	expected := []string{"header", "1,2\n", "3,4\n"}
+	sections := m.Sections()
+	if len(sections) != len(expected) {
+		t.Fatalf("expected %d sections, got %d", len(expected), len(sections))
+	}
+	for i, section := range sections {
+		b, err := io.ReadAll(section)
+		if err != nil {
+			t.Fatalf("section %d: %v", i, err)
+		}
+		if string(b) != expected[i] {
+			t.Errorf("section %d = %q, want %q", i, b, expected[i])
+		}
+	}
+}
+
+// sampleFile has a JSON header, which is also valid YAML.
+const sampleFile = `{"version": 1, "axes": [{"subplot": [0, 0], "series": [{"column": 1}]}, {"subplot": [0, 1], "series": [{"column": 1}]}]}` +
+	"\f\n0,10.5\n1,11.2\n2,\n\f\n0,1\n1,2\n"
+
+func TestLoadCompressedMatchesPlain(t *testing.T) {
+	dir := t.TempDir()
+	plainPath := filepath.Join(dir, "sample.olicanaplot")
+	if err := os.WriteFile(plainPath, []byte(sampleFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	gzPath := filepath.Join(dir, "sample.olicaplotz")
+	f, err := os.Create(gzPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte(sampleFile))
+	gz.Close()
+	f.Close()
+
+	for _, path := range []string{plainPath, gzPath} {
+		p := &Plugin{}
+		if err := p.loadFile(path); err != nil {
+			t.Fatalf("%s: loadFile failed: %v", path, err)
+		}
+		if len(p.csvBlocks) != 2 {
+			t.Fatalf("%s: expected 2 blocks, got %d", path, len(p.csvBlocks))
+		}
+		y := p.csvBlocks[0].Data[1]
+		if len(y) != 3 || y[0] != 10.5 || y[1] != 11.2 || !math.IsNaN(y[2]) {
+			t.Errorf("%s: block 0 column 1 = %v", path, y)
+		}
+		if got := p.csvBlocks[1].Data[0]; len(got) != 2 || got[1] != 1 {
+			t.Errorf("%s: block 1 column 0 = %v", path, got)
+		}
+	}
+}
+
+func TestLoadFileInfo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sample.olicanaplot")
+	if err := os.WriteFile(path, []byte(sampleFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &Plugin{}
+	if err := p.loadFile(path); err != nil {
+		t.Fatalf("loadFile failed: %v", err)
+	}
+	info := p.fileInfo
+	if info == nil {
+		t.Fatal("expected file info after loading")
+	}
+	if info.Path != path || info.SizeBytes != int64(len(sampleFile)) || info.RowCount != 5 || info.ModifiedUnix == 0 {
+		t.Errorf("file info = %+v", *info)
+	}
+}
+
+func TestLoadComments(t *testing.T) {
+	const commented = `{"version": 1, "comment": "Bench run 3", "chart": {"title": "T", "comment": "Raw data"}, ` +
+		`"axes": [{"subplot": [0, 0], "comment": "Left", "series": [{"column": 1, "comment": "Sensor A, uncalibrated"}]}]}` +
+		"\f\n0,1\n1,2\n"
+	path := filepath.Join(t.TempDir(), "commented.olicanaplot")
+	if err := os.WriteFile(path, []byte(commented), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &Plugin{}
+	if err := p.loadFile(path); err != nil {
+		t.Fatalf("loadFile failed: %v", err)
+	}
+	cfg := p.fileConfig
+	if cfg.Comment != "Bench run 3" || cfg.Chart.Comment != "Raw data" || cfg.Axes[0].Comment != "Left" {
+		t.Errorf("comments = %q, %q, %q", cfg.Comment, cfg.Chart.Comment, cfg.Axes[0].Comment)
+	}
+	if got := cfg.Axes[0].Series[0].Comment; got != "Sensor A, uncalibrated" {
+		t.Errorf("series comment = %q", got)
+	}
+}
+
+func TestLoadAxisScale(t *testing.T) {
+	const scaled = `{"version": 1, "axes": [{"subplot": [0, 0], "y_axes": [{"title": "Pressure", "unit": "kPa", ` +
+		`"scale_factor": 0.001, "scale_offset": -101.325}], "series": [{"column": 1}]}]}` +
+		"\f\n0,101325\n1,101400\n"
+	path := filepath.Join(t.TempDir(), "scaled.olicanaplot")
+	if err := os.WriteFile(path, []byte(scaled), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &Plugin{}
+	if err := p.loadFile(path); err != nil {
+		t.Fatalf("loadFile failed: %v", err)
+	}
+	y := p.fileConfig.Axes[0].YAxes[0]
+	if y.ScaleFactor != 0.001 || y.ScaleOffset != -101.325 {
+		t.Errorf("scale = %v, %v, want 0.001, -101.325", y.ScaleFactor, y.ScaleOffset)
+	}
+}
+
+func TestLoadTimeBlocks(t *testing.T) {
+	var b strings.Builder
+	b.WriteString(`{"version": 1, "layout": {"time_block": true}, "axes": [{"subplot": [0, 0], "series": [{"column": 1}]}]}`)
+	for seg := 0; seg < 3; seg++ {
+		b.WriteString("\f\n")
+		for i := 0; i < 100; i++ {
+			row := seg*100 + i
+			fmt.Fprintf(&b, "%d,%d\n", row, row*2)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "segments.olicanaplot")
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &Plugin{}
+	if err := p.loadFile(path); err != nil {
+		t.Fatalf("loadFile failed: %v", err)
+	}
+
+	x, y, err := p.seriesColumns("axis0:col1")
+	if err != nil {
+		t.Fatalf("seriesColumns failed: %v", err)
+	}
+	if len(x) != 300 || len(y) != 300 {
+		t.Fatalf("joined series has %d x and %d y values, want 300", len(x), len(y))
+	}
+	for i := range x {
+		if x[i] != float64(i) || y[i] != float64(2*i) {
+			t.Fatalf("point %d = (%v, %v), want (%d, %d)", i, x[i], y[i], i, 2*i)
+		}
+	}
+
+	x, _, err = p.seriesColumns("axis0:col1:seg1")
+	if err != nil {
+		t.Fatalf("seriesColumns failed: %v", err)
+	}
+	if len(x) != 100 || x[0] != 100 {
+		t.Errorf("segment 1 has %d points starting at %v, want 100 from 100", len(x), x[0])
+	}
+	if _, _, err := p.seriesColumns("axis0:col1:seg3"); err == nil {
+		t.Error("expected error for a segment past the last block")
+	}
+}
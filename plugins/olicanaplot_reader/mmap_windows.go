@@ -0,0 +1,43 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// mmapFile maps the whole file read-only.
+func mmapFile(file *os.File) ([]byte, func() error, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	size := info.Size()
+	if size == 0 {
+		return nil, nil, nil
+	}
+
+	mapping, err := syscall.CreateFileMapping(syscall.Handle(file.Fd()), nil, syscall.PAGE_READONLY,
+		uint32(size>>32), uint32(size), nil)
+	if err != nil {
+		return nil, nil, os.NewSyscallError("CreateFileMapping", err)
+	}
+
+	addr, err := syscall.MapViewOfFile(mapping, syscall.FILE_MAP_READ, 0, 0, uintptr(size))
+	if err != nil {
+		syscall.CloseHandle(mapping)
+		return nil, nil, os.NewSyscallError("MapViewOfFile", err)
+	}
+
+	// Convert via the variable's address; addr points outside the Go heap
+	ptr := *(*unsafe.Pointer)(unsafe.Pointer(&addr))
+	data := unsafe.Slice((*byte)(ptr), size)
+	unmap := func() error {
+		err := syscall.UnmapViewOfFile(addr)
+		syscall.CloseHandle(mapping)
+		return err
+	}
+	return data, unmap, nil
+}
@@ -0,0 +1,3 @@
+@echo off
+REM Build Process Monitor IPC Plugin
+go build -ldflags="-w -s -H windowsgui" -o proc_monitor.exe .
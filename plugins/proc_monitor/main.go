@@ -0,0 +1,434 @@
+// Process Monitor IPC Plugin - Samples the CPU and memory use of running
+// processes from /proc at a fixed interval:
+//
+//	cpu_pct: CPU time used per interval, as a percentage of one core
+//	rss_kb:  resident set size (VmRSS)
+//	vss_kb:  virtual memory size (VmSize)
+//
+// A pattern matching several processes reports their sum. Only the latest
+// samples are kept, so get_series_data always returns the recent history.
+// /proc only exists on Linux; elsewhere the plugin reports a single empty
+// series whose ID explains why.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	sdk "olicanaplot/sdk/go"
+)
+
+const (
+	pluginName    = "Process Monitor"
+	pluginVersion = 1
+)
+
+var capabilities = append(sdk.BaseCapabilities(), "event", "ping")
+
+// Series IDs.
+const (
+	seriesCPU = "cpu_pct"
+	seriesRSS = "rss_kb"
+	seriesVSS = "vss_kb"
+)
+
+// monitorConfig holds the monitoring parameters, keyed as in the form.
+type monitorConfig struct {
+	Process    string `json:"process"`    // PID, or a regular expression matched against process names
+	IntervalMS int    `json:"intervalMs"` // Time between samples
+	Capacity   int    `json:"capacity"`   // Samples kept per series
+}
+
+var config = monitorConfig{
+	IntervalMS: 1000,
+	Capacity:   3600,
+}
+
+// monitor is the running sampler, nil before initialize.
+var monitor *sampler
+
+func main() {
+	// Metadata support
+	if len(os.Args) > 1 && os.Args[1] == "--metadata" {
+		sdk.SendMetadata(sdk.Metadata{Name: pluginName, APIVersion: pluginVersion, Capabilities: capabilities})
+		return
+	}
+
+	handleIPC()
+}
+
+func handleIPC() {
+	sdk.OnQuit(func() {
+		if monitor != nil {
+			monitor.stop()
+		}
+	})
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		req, err := sdk.ParseRequest(scanner.Bytes())
+		if err != nil {
+			sdk.SendError("invalid json")
+			continue
+		}
+
+		switch req.Method {
+		case "info":
+			sdk.SendResponse(sdk.Response{
+				Name:         pluginName,
+				Version:      pluginVersion,
+				Capabilities: capabilities,
+			})
+
+		case "initialize":
+			if errUnsupported != nil {
+				// Nothing to configure; the series explains the problem
+				sdk.Log("warn", errUnsupported.Error())
+				sdk.SendResponse(sdk.Response{Result: "success"})
+				continue
+			}
+			if err := handleInitialize(scanner); err != nil {
+				sdk.SendError(err.Error())
+			} else {
+				sdk.SendResponse(sdk.Response{Result: "success"})
+			}
+
+		case "get_chart_config":
+			sdk.SendResponse(sdk.Response{
+				Result: sdk.ChartConfig{
+					Title:         "Process Monitor: " + config.Process,
+					Grid:          &sdk.GridConfig{Rows: 2, Cols: 1},
+					SubplotTitles: []string{"CPU", "Memory"},
+					Axes: []sdk.AxisGroupConfig{
+						{
+							Subplot: &sdk.SubPlot{Row: 0, Col: 0},
+							XAxes:   []sdk.AxisConfig{{Title: "Time", Unit: "s"}},
+							YAxes:   []sdk.AxisConfig{{Title: "CPU", Unit: "%"}},
+						},
+						{
+							Subplot: &sdk.SubPlot{Row: 1, Col: 0},
+							XAxes:   []sdk.AxisConfig{{Title: "Time", Unit: "s"}},
+							YAxes:   []sdk.AxisConfig{{Title: "Memory", Unit: "kB"}},
+						},
+					},
+				},
+			})
+
+		case "get_series_config":
+			sdk.SendResponse(sdk.Response{Result: seriesConfig()})
+
+		case "get_series_data":
+			data, err := seriesData(req.SeriesID, req.PreferredStorage == "arrays")
+			if err != nil {
+				sdk.SendError(err.Error())
+				continue
+			}
+			storage := "interleaved"
+			if req.PreferredStorage == "arrays" {
+				storage = "arrays"
+			}
+			sdk.SendBinaryData(data, storage)
+
+		case "ping":
+			sdk.HandlePing(req)
+
+		case "event":
+			// Host notifications need no reply
+			sdk.HandleEvent(req)
+
+		case "quit":
+			sdk.HandleQuit(req)
+
+		default:
+			sdk.SendError(fmt.Sprintf("unknown method: %s", req.Method))
+		}
+	}
+}
+
+// handleInitialize shows the configuration form and starts sampling the
+// processes it selects.
+func handleInitialize(scanner *bufio.Scanner) error {
+	sdk.SendShowForm("Process Monitor", getSchema(), getUISchema(), nil)
+
+	if !scanner.Scan() {
+		return fmt.Errorf("failed to read form response")
+	}
+	var resp struct {
+		Result *monitorConfig `json:"result"`
+		Error  string         `json:"error"`
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+		return fmt.Errorf("failed to parse form response: %v", err)
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("configuration cancelled: %s", resp.Error)
+	}
+	if resp.Result == nil {
+		return fmt.Errorf("empty form response")
+	}
+
+	c := *resp.Result
+	match, err := newMatcher(c.Process)
+	if err != nil {
+		return err
+	}
+	if c.IntervalMS < 100 {
+		return fmt.Errorf("sample interval must be at least 100 ms")
+	}
+	if c.Capacity < 1 {
+		return fmt.Errorf("history must keep at least one sample")
+	}
+
+	s, err := startSampler(match, time.Duration(c.IntervalMS)*time.Millisecond, c.Capacity)
+	if err != nil {
+		return err
+	}
+	if monitor != nil {
+		monitor.stop()
+	}
+	monitor, config = s, c
+	sdk.Log("info", fmt.Sprintf("Monitoring %q every %d ms", c.Process, c.IntervalMS))
+	return nil
+}
+
+func getSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":     "object",
+		"required": []string{"process"},
+		"properties": map[string]interface{}{
+			"process": map[string]interface{}{
+				"type":        "string",
+				"title":       "Process",
+				"description": "A PID, or a regular expression matched against process names",
+				"default":     config.Process,
+			},
+			"intervalMs": map[string]interface{}{
+				"type":    "integer",
+				"title":   "Sample Interval (ms)",
+				"minimum": 100,
+				"default": config.IntervalMS,
+			},
+			"capacity": map[string]interface{}{
+				"type":        "integer",
+				"title":       "History (samples)",
+				"description": "Older samples are discarded",
+				"minimum":     1,
+				"default":     config.Capacity,
+			},
+		},
+	}
+}
+
+func getUISchema() map[string]interface{} {
+	return map[string]interface{}{
+		"ui:order": []string{"process", "intervalMs", "capacity"},
+	}
+}
+
+// seriesConfig lists the monitored series, or a single series whose ID
+// holds the error on platforms without /proc.
+func seriesConfig() []sdk.SeriesConfig {
+	if errUnsupported != nil {
+		return []sdk.SeriesConfig{{ID: "error: " + errUnsupported.Error(), Name: "Unsupported platform"}}
+	}
+	return []sdk.SeriesConfig{
+		{ID: seriesCPU, Name: "CPU", Unit: "%", Subplot: &sdk.SubPlot{Row: 0, Col: 0}},
+		{ID: seriesRSS, Name: "Resident Memory", Unit: "kB", Subplot: &sdk.SubPlot{Row: 1, Col: 0}},
+		{ID: seriesVSS, Name: "Virtual Memory", Unit: "kB", Subplot: &sdk.SubPlot{Row: 1, Col: 0}},
+	}
+}
+
+// seriesData returns the buffered samples of a series, with X in seconds
+// since monitoring started.
+func seriesData(seriesID string, arrays bool) ([]float64, error) {
+	if errUnsupported != nil {
+		return nil, nil
+	}
+	var value func(s sample) float64
+	switch seriesID {
+	case seriesCPU:
+		value = func(s sample) float64 { return s.cpuPct }
+	case seriesRSS:
+		value = func(s sample) float64 { return s.rssKB }
+	case seriesVSS:
+		value = func(s sample) float64 { return s.vssKB }
+	default:
+		return nil, fmt.Errorf("unknown series: %s", seriesID)
+	}
+
+	var samples []sample
+	if monitor != nil {
+		samples = monitor.snapshot()
+	}
+	n := len(samples)
+	data := make([]float64, n*2)
+	for i, s := range samples {
+		if arrays {
+			data[i] = s.t
+			data[n+i] = value(s)
+		} else {
+			data[i*2] = s.t
+			data[i*2+1] = value(s)
+		}
+	}
+	return data, nil
+}
+
+// newMatcher returns a function reporting whether a process is selected by
+// pattern: a PID, or a regular expression matched against the name.
+func newMatcher(pattern string) (func(pid int, name string) bool, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("process must not be empty")
+	}
+	if pid, err := strconv.Atoi(pattern); err == nil {
+		return func(p int, _ string) bool { return p == pid }, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid process pattern: %v", err)
+	}
+	return func(_ int, name string) bool { return re.MatchString(name) }, nil
+}
+
+// sample is one measurement of the monitored processes.
+type sample struct {
+	t      float64 // Seconds since monitoring started
+	cpuPct float64
+	rssKB  float64
+	vssKB  float64
+}
+
+// ringBuffer keeps the latest samples, overwriting the oldest when full.
+type ringBuffer struct {
+	samples []sample
+	next    int
+	full    bool
+}
+
+func newRingBuffer(capacity int) *ringBuffer {
+	return &ringBuffer{samples: make([]sample, capacity)}
+}
+
+func (r *ringBuffer) add(s sample) {
+	r.samples[r.next] = s
+	r.next = (r.next + 1) % len(r.samples)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// ordered returns a copy of the samples, oldest first.
+func (r *ringBuffer) ordered() []sample {
+	if !r.full {
+		return append([]sample(nil), r.samples[:r.next]...)
+	}
+	return append(append([]sample(nil), r.samples[r.next:]...), r.samples[:r.next]...)
+}
+
+// sampler measures the matching processes on a ticker until stopped.
+type sampler struct {
+	mu      sync.Mutex
+	buf     *ringBuffer
+	match   func(pid int, name string) bool
+	start   time.Time
+	last    time.Time
+	cpuPrev map[int]uint64 // CPU ticks of each process at the last sample
+	done    chan struct{}
+}
+
+// startSampler takes a baseline of the processes selected by match, which
+// must select at least one, and samples them every interval.
+func startSampler(match func(pid int, name string) bool, interval time.Duration, capacity int) (*sampler, error) {
+	s := &sampler{
+		buf:   newRingBuffer(capacity),
+		match: match,
+		done:  make(chan struct{}),
+	}
+	procs, err := readProcesses(match)
+	if err != nil {
+		return nil, err
+	}
+	if len(procs) == 0 {
+		return nil, fmt.Errorf("no running process matches")
+	}
+	s.start = time.Now()
+	s.last = s.start
+	s.cpuPrev = cpuTicks(procs)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.done:
+				return
+			case now := <-ticker.C:
+				// Processes that exited are simply missing from the sum;
+				// there is nobody to report read errors to between requests
+				procs, _ := readProcesses(s.match)
+				s.record(now, procs)
+			}
+		}
+	}()
+	return s, nil
+}
+
+// record adds a sample of procs taken at now. CPU use only counts processes
+// that were also present at the previous sample.
+func (s *sampler) record(now time.Time, procs []procStats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elapsed := now.Sub(s.last).Seconds()
+	cur := cpuTicks(procs)
+	var ticks uint64
+	for pid, t := range cur {
+		if prev, ok := s.cpuPrev[pid]; ok && t >= prev {
+			ticks += t - prev
+		}
+	}
+
+	smp := sample{t: now.Sub(s.start).Seconds()}
+	if elapsed > 0 {
+		smp.cpuPct = float64(ticks) / clockTicksPerSecond / elapsed * 100
+	}
+	for _, p := range procs {
+		smp.rssKB += p.rssKB
+		smp.vssKB += p.vssKB
+	}
+	s.buf.add(smp)
+	s.last, s.cpuPrev = now, cur
+}
+
+// snapshot returns the buffered samples, oldest first.
+func (s *sampler) snapshot() []sample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.ordered()
+}
+
+func (s *sampler) stop() {
+	close(s.done)
+}
+
+// procStats is what one /proc read reports about a process.
+type procStats struct {
+	pid      int
+	cpuTicks uint64 // User plus system time, in clock ticks
+	rssKB    float64
+	vssKB    float64
+}
+
+func cpuTicks(procs []procStats) map[int]uint64 {
+	ticks := make(map[int]uint64, len(procs))
+	for _, p := range procs {
+		ticks[p.pid] = p.cpuTicks
+	}
+	return ticks
+}
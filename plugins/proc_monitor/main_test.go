@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRingBufferKeepsLatest(t *testing.T) {
+	r := newRingBuffer(3)
+	if got := r.ordered(); len(got) != 0 {
+		t.Fatalf("empty buffer has %d samples", len(got))
+	}
+	for i := 1; i <= 5; i++ {
+		r.add(sample{t: float64(i)})
+	}
+	got := r.ordered()
+	if len(got) != 3 || got[0].t != 3 || got[1].t != 4 || got[2].t != 5 {
+		t.Errorf("ordered() = %v, want times 3, 4, 5", got)
+	}
+}
+
+func TestSamplerRecord(t *testing.T) {
+	start := time.Unix(1000, 0)
+	s := &sampler{
+		buf:     newRingBuffer(10),
+		start:   start,
+		last:    start,
+		cpuPrev: map[int]uint64{1: 100, 2: 50},
+	}
+
+	// Over 2 s, process 1 used 150 ticks and process 2 used 50; process 3
+	// is new and has no CPU baseline yet
+	s.record(start.Add(2*time.Second), []procStats{
+		{pid: 1, cpuTicks: 250, rssKB: 100, vssKB: 1000},
+		{pid: 2, cpuTicks: 100, rssKB: 20, vssKB: 200},
+		{pid: 3, cpuTicks: 999, rssKB: 1, vssKB: 10},
+	})
+
+	got := s.snapshot()
+	if len(got) != 1 {
+		t.Fatalf("got %d samples, want 1", len(got))
+	}
+	want := sample{t: 2, cpuPct: 200.0 / clockTicksPerSecond / 2 * 100, rssKB: 121, vssKB: 1210}
+	if got[0] != want {
+		t.Errorf("sample = %+v, want %+v", got[0], want)
+	}
+}
+
+func TestNewMatcher(t *testing.T) {
+	if _, err := newMatcher(""); err == nil {
+		t.Error("empty pattern accepted")
+	}
+	if _, err := newMatcher("("); err == nil {
+		t.Error("invalid regular expression accepted")
+	}
+
+	byPID, err := newMatcher("42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !byPID(42, "bash") || byPID(420, "bash") {
+		t.Error("PID pattern should only match PID 42")
+	}
+
+	byName, err := newMatcher("^python3?$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !byName(1, "python3") || byName(1, "bash") {
+		t.Error("name pattern should only match python")
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// errUnsupported is nil where /proc is available.
+var errUnsupported error
+
+// clockTicksPerSecond is USER_HZ, the unit of the CPU times in
+// /proc/<pid>/stat. The kernel fixes it at 100 for user space on every
+// architecture Go supports.
+const clockTicksPerSecond = 100
+
+// procRoot is where readProcesses looks for processes.
+var procRoot = "/proc"
+
+// readProcesses returns the stats of every process that match selects.
+// Processes that exit while being read are skipped.
+func readProcesses(match func(pid int, name string) bool) ([]procStats, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+
+	var procs []procStats
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue // Not a process
+		}
+		dir := filepath.Join(procRoot, entry.Name())
+		stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+		if err != nil {
+			continue
+		}
+		name, ticks, err := parseStat(stat)
+		if err != nil || !match(pid, name) {
+			continue
+		}
+		status, err := os.ReadFile(filepath.Join(dir, "status"))
+		if err != nil {
+			continue
+		}
+		p := procStats{pid: pid, cpuTicks: ticks}
+		p.rssKB, p.vssKB = parseStatus(status)
+		procs = append(procs, p)
+	}
+	return procs, nil
+}
+
+// parseStat returns the name and the user plus system CPU time of a process
+// from the contents of /proc/<pid>/stat.
+func parseStat(stat []byte) (string, uint64, error) {
+	// The name is in parentheses and may itself contain spaces or ')'
+	open, end := bytes.IndexByte(stat, '('), bytes.LastIndexByte(stat, ')')
+	if open < 0 || end < open {
+		return "", 0, fmt.Errorf("malformed stat")
+	}
+	name := string(stat[open+1 : end])
+
+	// Fields after the name start at field 3 (state); utime and stime are
+	// fields 14 and 15
+	fields := strings.Fields(string(stat[end+1:]))
+	if len(fields) < 13 {
+		return "", 0, fmt.Errorf("malformed stat")
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("malformed utime: %w", err)
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("malformed stime: %w", err)
+	}
+	return name, utime + stime, nil
+}
+
+// parseStatus returns VmRSS and VmSize in kB from the contents of
+// /proc/<pid>/status. Kernel threads have neither and report zero.
+func parseStatus(status []byte) (rssKB, vssKB float64) {
+	scanner := bufio.NewScanner(bytes.NewReader(status))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		kb, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "VmRSS":
+			rssKB = kb
+		case "VmSize":
+			vssKB = kb
+		}
+	}
+	return rssKB, vssKB
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseStat(t *testing.T) {
+	stat := []byte("1234 (my (odd) proc) S 1 1234 1234 0 -1 4194304 100 0 0 0 250 50 0 0 20 0 1 0 100 1000000 200\n")
+	name, ticks, err := parseStat(stat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "my (odd) proc" || ticks != 300 {
+		t.Errorf("parseStat = %q, %d, want \"my (odd) proc\", 300", name, ticks)
+	}
+
+	if _, _, err := parseStat([]byte("1234 (short) S 1")); err == nil {
+		t.Error("truncated stat accepted")
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	status := []byte("Name:\tbash\nVmPeak:\t  9000 kB\nVmSize:\t  8000 kB\nVmRSS:\t  3000 kB\nThreads:\t1\n")
+	rss, vss := parseStatus(status)
+	if rss != 3000 || vss != 8000 {
+		t.Errorf("parseStatus = %v, %v, want 3000, 8000", rss, vss)
+	}
+}
+
+func TestReadProcessesSelf(t *testing.T) {
+	pid := os.Getpid()
+	procs, err := readProcesses(func(p int, _ string) bool { return p == pid })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(procs) != 1 || procs[0].pid != pid {
+		t.Fatalf("readProcesses = %+v, want only PID %d", procs, pid)
+	}
+	if procs[0].rssKB <= 0 || procs[0].vssKB < procs[0].rssKB {
+		t.Errorf("implausible memory: RSS %v kB, VSS %v kB", procs[0].rssKB, procs[0].vssKB)
+	}
+}
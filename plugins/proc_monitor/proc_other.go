@@ -0,0 +1,14 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+// errUnsupported explains why no process can be monitored.
+var errUnsupported = errors.New("process monitoring requires Linux /proc")
+
+const clockTicksPerSecond = 100
+
+func readProcesses(match func(pid int, name string) bool) ([]procStats, error) {
+	return nil, errUnsupported
+}
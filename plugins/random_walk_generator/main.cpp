@@ -219,6 +219,14 @@ int main(int argc, char *argv[]) {
         }
       }
       generate_data(sid);
+    } else if (line.find("\"method\":\"ping\"") != std::string::npos) {
+      sdk::send_response("{\"result\":\"pong\"}");
+    } else if (line.find("\"method\":\"quit\"") != std::string::npos) {
+      sdk::send_response("{\"result\":\"ok\"}");
+      break;
+    } else if (line.find("\"method\":\"event\"") == std::string::npos) {
+      // Events get no reply, other requests must not be left unanswered
+      sdk::send_response("{\"error\":\"Unknown method\"}");
     }
   }
   return 0;
@@ -0,0 +1,3 @@
+@echo off
+REM Build Signal Generator IPC Plugin
+go build -ldflags="-w -s -H windowsgui" -o signal_generator.exe .
@@ -0,0 +1,337 @@
+// Signal Generator IPC Plugin - Generates deterministic test waveforms:
+//
+//	Chirp:    A * sin(2π * (f0*t + (f1-f0)/(2T) * t²)), sweeping f0 to f1 over T
+//	Square:   +A for the first DutyCycle of each period, -A for the rest
+//	Sawtooth: ramps from -A to +A once per period
+//	Triangle: ramps from -A to +A and back once per period
+//
+// The configuration form only shows the parameters of the selected waveform.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+
+	sdk "olicanaplot/sdk/go"
+)
+
+const (
+	pluginName    = "Signal Generator"
+	pluginVersion = 1
+)
+
+var capabilities = append(sdk.BaseCapabilities(), "event", "ping")
+
+// Waveform types.
+const (
+	waveChirp    = "Chirp"
+	waveSquare   = "Square"
+	waveSawtooth = "Sawtooth"
+	waveTriangle = "Triangle"
+)
+
+// signalConfig holds the generation parameters, keyed as in the form.
+type signalConfig struct {
+	Waveform     string  `json:"waveform"`
+	Amplitude    float64 `json:"amplitude"`
+	Frequency    float64 `json:"frequency"`    // Hz, the start frequency of a chirp
+	EndFrequency float64 `json:"endFrequency"` // Hz, chirp only
+	DutyCycle    float64 `json:"dutyCycle"`    // 0-1, square only
+	NumPoints    int     `json:"numPoints"`
+	SampleRate   float64 `json:"sampleRate"` // Hz
+}
+
+var state = signalConfig{
+	Waveform:     waveSquare,
+	Amplitude:    1.0,
+	Frequency:    5.0,
+	EndFrequency: 50.0,
+	DutyCycle:    0.5,
+	NumPoints:    1000,
+	SampleRate:   1000.0,
+}
+
+func main() {
+	// Metadata support
+	if len(os.Args) > 1 && os.Args[1] == "--metadata" {
+		sdk.SendMetadata(sdk.Metadata{Name: pluginName, APIVersion: pluginVersion, Capabilities: capabilities})
+		return
+	}
+
+	handleIPC()
+}
+
+func handleIPC() {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		req, err := sdk.ParseRequest(scanner.Bytes())
+		if err != nil {
+			sdk.SendError("invalid json")
+			continue
+		}
+
+		switch req.Method {
+		case "info":
+			sdk.SendResponse(sdk.Response{
+				Name:         pluginName,
+				Version:      pluginVersion,
+				Capabilities: capabilities,
+			})
+
+		case "initialize":
+			if err := handleInitialize(scanner); err != nil {
+				sdk.SendError(err.Error())
+			} else {
+				sdk.SendResponse(sdk.Response{Result: "success"})
+			}
+
+		case "get_chart_config":
+			sdk.SendResponse(sdk.Response{
+				Result: sdk.ChartConfig{
+					Title: state.Waveform + " Wave",
+					Axes: []sdk.AxisGroupConfig{
+						{
+							XAxes: []sdk.AxisConfig{{Title: "Time", Unit: "s"}},
+							YAxes: []sdk.AxisConfig{{Title: "Amplitude"}},
+						},
+					},
+				},
+			})
+
+		case "get_series_config":
+			sdk.SendResponse(sdk.Response{Result: []sdk.SeriesConfig{
+				{ID: "signal", Name: state.Waveform},
+			}})
+
+		case "get_series_data":
+			if req.SeriesID != "signal" {
+				sdk.SendError(fmt.Sprintf("unknown series: %s", req.SeriesID))
+				continue
+			}
+			data, storage := generateData(state, req.PreferredStorage)
+			sdk.SendBinaryData(data, storage)
+
+		case "get_time_range":
+			sdk.SendTimeRange(0, float64(state.NumPoints-1)/state.SampleRate)
+
+		case "ping":
+			sdk.HandlePing(req)
+
+		case "event":
+			// Host notifications need no reply
+			sdk.HandleEvent(req)
+
+		case "quit":
+			sdk.HandleQuit(req)
+
+		default:
+			sdk.SendError(fmt.Sprintf("unknown method: %s", req.Method))
+		}
+	}
+}
+
+// handleInitialize shows the configuration form, rebuilding it whenever the
+// waveform changes, and applies the result.
+func handleInitialize(scanner *bufio.Scanner) error {
+	schema, uiSchema := getUI(state.Waveform)
+	sdk.SendResponse(sdk.Response{
+		Method:           "show_form",
+		Title:            "Signal Configuration",
+		Schema:           schema,
+		UISchema:         uiSchema,
+		Data:             configData(state),
+		HandleFormChange: true,
+	})
+
+	shown := state.Waveform
+	for scanner.Scan() {
+		var resp struct {
+			Method string          `json:"method"`
+			Data   json.RawMessage `json:"data"`
+			Result json.RawMessage `json:"result"`
+			Error  string          `json:"error"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			return fmt.Errorf("failed to parse form response: %v", err)
+		}
+
+		// The form only has the shown waveform's fields, so the others keep
+		// their current values
+		values := state
+		if resp.Method == "form_change" {
+			json.Unmarshal(resp.Data, &values)
+			if values.Waveform == shown {
+				sdk.SendNoUpdate()
+				continue
+			}
+			shown = values.Waveform
+			schema, uiSchema := getUI(shown)
+			sdk.SendFormUpdate(schema, uiSchema, configData(values))
+			continue
+		}
+
+		if resp.Error != "" {
+			return fmt.Errorf("configuration cancelled: %s", resp.Error)
+		}
+		if err := json.Unmarshal(resp.Result, &values); err != nil {
+			return fmt.Errorf("failed to parse form result: %v", err)
+		}
+		if err := validate(values); err != nil {
+			return err
+		}
+		state = values
+		sdk.Log("info", fmt.Sprintf("Signal configured: %s, %d points at %g Hz", state.Waveform, state.NumPoints, state.SampleRate))
+		return nil
+	}
+	return fmt.Errorf("failed to read form response")
+}
+
+// configData returns c as form data.
+func configData(c signalConfig) map[string]interface{} {
+	b, _ := json.Marshal(c)
+	var data map[string]interface{}
+	json.Unmarshal(b, &data)
+	return data
+}
+
+// getUI returns the form for a waveform, with only its parameters.
+func getUI(waveform string) (interface{}, interface{}) {
+	frequencyTitle := "Frequency (Hz)"
+	if waveform == waveChirp {
+		frequencyTitle = "Start Frequency (Hz)"
+	}
+
+	properties := map[string]interface{}{
+		"waveform": map[string]interface{}{
+			"type":    "string",
+			"title":   "Waveform",
+			"enum":    []string{waveChirp, waveSquare, waveSawtooth, waveTriangle},
+			"default": waveform,
+		},
+		"amplitude": map[string]interface{}{
+			"type":    "number",
+			"title":   "Amplitude",
+			"default": state.Amplitude,
+		},
+		"frequency": map[string]interface{}{
+			"type":    "number",
+			"title":   frequencyTitle,
+			"minimum": 0,
+			"default": state.Frequency,
+		},
+		"numPoints": map[string]interface{}{
+			"type":    "integer",
+			"title":   "Number of Points",
+			"minimum": 2,
+			"maximum": 10000000,
+			"default": state.NumPoints,
+		},
+		"sampleRate": map[string]interface{}{
+			"type":    "number",
+			"title":   "Sample Rate (Hz)",
+			"minimum": 0,
+			"default": state.SampleRate,
+		},
+	}
+	order := []string{"waveform", "amplitude", "frequency"}
+
+	switch waveform {
+	case waveChirp:
+		properties["endFrequency"] = map[string]interface{}{
+			"type":    "number",
+			"title":   "End Frequency (Hz)",
+			"minimum": 0,
+			"default": state.EndFrequency,
+		}
+		order = append(order, "endFrequency")
+	case waveSquare:
+		properties["dutyCycle"] = map[string]interface{}{
+			"type":        "number",
+			"title":       "Duty Cycle",
+			"description": "Fraction of each period spent high",
+			"minimum":     0,
+			"maximum":     1,
+			"default":     state.DutyCycle,
+		}
+		order = append(order, "dutyCycle")
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	uiSchema := map[string]interface{}{
+		"ui:order": append(order, "numPoints", "sampleRate"),
+	}
+	return schema, uiSchema
+}
+
+// validate checks the parameters of a submitted form.
+func validate(c signalConfig) error {
+	switch c.Waveform {
+	case waveChirp, waveSquare, waveSawtooth, waveTriangle:
+	default:
+		return fmt.Errorf("unknown waveform: %q", c.Waveform)
+	}
+	if c.NumPoints < 2 {
+		return fmt.Errorf("number of points must be at least 2")
+	}
+	if c.SampleRate <= 0 {
+		return fmt.Errorf("sample rate must be positive")
+	}
+	if c.Waveform == waveSquare && (c.DutyCycle < 0 || c.DutyCycle > 1) {
+		return fmt.Errorf("duty cycle must be between 0 and 1")
+	}
+	return nil
+}
+
+// sample returns the value of the waveform at time t. duration is the
+// length of the signal, over which a chirp sweeps its frequency.
+func sample(c signalConfig, t, duration float64) float64 {
+	// Fraction of the current period, in [0, 1)
+	phase := c.Frequency*t - math.Floor(c.Frequency*t)
+
+	switch c.Waveform {
+	case waveChirp:
+		k := (c.EndFrequency - c.Frequency) / (2 * duration)
+		return c.Amplitude * math.Sin(2*math.Pi*(c.Frequency*t+k*t*t))
+	case waveSquare:
+		if phase < c.DutyCycle {
+			return c.Amplitude
+		}
+		return -c.Amplitude
+	case waveSawtooth:
+		return c.Amplitude * (2*phase - 1)
+	case waveTriangle:
+		return c.Amplitude * (1 - 4*math.Abs(phase-0.5))
+	}
+	return 0
+}
+
+// generateData samples the waveform NumPoints times at SampleRate.
+func generateData(c signalConfig, preferredStorage string) ([]float64, string) {
+	n := c.NumPoints
+	duration := float64(n-1) / c.SampleRate
+	data := make([]float64, n*2)
+	isArrays := preferredStorage == "arrays"
+
+	for i := 0; i < n; i++ {
+		t := float64(i) / c.SampleRate
+		y := sample(c, t, duration)
+		if isArrays {
+			data[i] = t
+			data[n+i] = y
+		} else {
+			data[i*2] = t
+			data[i*2+1] = y
+		}
+	}
+
+	if isArrays {
+		return data, "arrays"
+	}
+	return data, "interleaved"
+}
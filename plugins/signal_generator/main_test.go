@@ -0,0 +1,75 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPeriodicWaveforms(t *testing.T) {
+	c := signalConfig{Amplitude: 2, Frequency: 1, DutyCycle: 0.25}
+	for _, tt := range []struct {
+		waveform string
+		t        float64
+		want     float64
+	}{
+		{waveSquare, 0.1, 2},
+		{waveSquare, 0.3, -2},
+		{waveSquare, 1.1, 2}, // next period
+		{waveSawtooth, 0, -2},
+		{waveSawtooth, 0.5, 0},
+		{waveSawtooth, 0.75, 1},
+		{waveTriangle, 0, -2},
+		{waveTriangle, 0.25, 0},
+		{waveTriangle, 0.5, 2},
+		{waveTriangle, 0.75, 0},
+	} {
+		c.Waveform = tt.waveform
+		if got := sample(c, tt.t, 10); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("%s(%v) = %v, want %v", tt.waveform, tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestChirpSweepsFrequency(t *testing.T) {
+	c := signalConfig{Waveform: waveChirp, Amplitude: 1, Frequency: 1, EndFrequency: 9}
+	duration := 2.0
+
+	// The instantaneous frequency f0 + (f1-f0)*t/T is the derivative of the
+	// phase divided by 2π
+	phase := func(tv float64) float64 {
+		return c.Frequency*tv + (c.EndFrequency-c.Frequency)/(2*duration)*tv*tv
+	}
+	for _, tv := range []float64{0, 0.5, 1.3, 2} {
+		want := math.Sin(2 * math.Pi * phase(tv))
+		if got := sample(c, tv, duration); math.Abs(got-want) > 1e-12 {
+			t.Errorf("chirp(%v) = %v, want %v", tv, got, want)
+		}
+		h := 1e-6
+		freq := (phase(tv+h) - phase(tv-h)) / (2 * h)
+		if wantFreq := c.Frequency + (c.EndFrequency-c.Frequency)*tv/duration; math.Abs(freq-wantFreq) > 1e-6 {
+			t.Errorf("frequency at %v = %v, want %v", tv, freq, wantFreq)
+		}
+	}
+}
+
+func TestGenerateData(t *testing.T) {
+	c := signalConfig{Waveform: waveSawtooth, Amplitude: 1, Frequency: 10, NumPoints: 100, SampleRate: 1000}
+	data, storage := generateData(c, "arrays")
+	if storage != "arrays" || len(data) != 200 {
+		t.Fatalf("storage = %s, len = %d", storage, len(data))
+	}
+	if data[99] != 0.099 || data[100] != -1 {
+		t.Errorf("last time = %v, first value = %v, want 0.099 and -1", data[99], data[100])
+	}
+
+	for _, bad := range []signalConfig{
+		{Waveform: "Noise", NumPoints: 10, SampleRate: 1},
+		{Waveform: waveSquare, NumPoints: 1, SampleRate: 1},
+		{Waveform: waveSquare, NumPoints: 10, SampleRate: 0},
+		{Waveform: waveSquare, NumPoints: 10, SampleRate: 1, DutyCycle: 1.5},
+	} {
+		if err := validate(bad); err == nil {
+			t.Errorf("validate(%+v): expected error", bad)
+		}
+	}
+}
@@ -26,8 +26,10 @@ export function Cancel() {
  * @param {number} correlationTime
  * @param {number} amplitude
  * @param {number} frequency
+ * @param {number} stabilityIndex
+ * @param {number} skewnessParam
  * @returns {$CancellablePromise<void>}
  */
-export function Submit(simulationType, numPoints, numSeries, noise, correlationTime, amplitude, frequency) {
-    return $Call.ByID(2296039174, simulationType, numPoints, numSeries, noise, correlationTime, amplitude, frequency);
+export function Submit(simulationType, numPoints, numSeries, noise, correlationTime, amplitude, frequency, stabilityIndex, skewnessParam) {
+    return $Call.ByID(2296039174, simulationType, numPoints, numSeries, noise, correlationTime, amplitude, frequency, stabilityIndex, skewnessParam);
 }
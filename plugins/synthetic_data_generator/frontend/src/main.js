@@ -4,7 +4,8 @@ import { SyntheticService } from "../bindings/synthetic-ipc";
 const paramConfig = {
     'Random Walk': ['param-noise'],
     'Gauss-Markov': ['param-noise', 'param-correlation'],
-    'Sinusoidal': ['param-amplitude', 'param-frequency']
+    'Sinusoidal': ['param-amplitude', 'param-frequency'],
+    'Levy Stable': ['param-noise', 'param-stability', 'param-skewness']
 };
 
 function updateVisibleParams(simType) {
@@ -42,7 +43,9 @@ const sliders = [
     { id: 'noise', valueId: 'noise-value', format: v => v.toFixed(1) },
     { id: 'correlation', valueId: 'correlation-value', format: v => parseFloat(v).toFixed(1) },
     { id: 'amplitude', valueId: 'amplitude-value', format: v => parseFloat(v).toFixed(1) },
-    { id: 'frequency', valueId: 'frequency-value', format: v => parseFloat(v).toFixed(2) + ' Hz' }
+    { id: 'frequency', valueId: 'frequency-value', format: v => parseFloat(v).toFixed(2) + ' Hz' },
+    { id: 'stability', valueId: 'stability-value', format: v => parseFloat(v).toFixed(2) },
+    { id: 'skewness', valueId: 'skewness-value', format: v => parseFloat(v).toFixed(2) }
 ];
 
 sliders.forEach(({ id, valueId, format }) => {
@@ -73,11 +76,14 @@ document.getElementById('synthetic-form').addEventListener('submit', async (e) =
     const correlationTime = parseFloat(document.getElementById('correlation').value);
     const amplitude = parseFloat(document.getElementById('amplitude').value);
     const frequency = parseFloat(document.getElementById('frequency').value);
+    const stabilityIndex = parseFloat(document.getElementById('stability').value);
+    const skewnessParam = parseFloat(document.getElementById('skewness').value);
 
     try {
-        await SyntheticService.Submit(simulationType, numPoints, numSeries, noise, correlationTime, amplitude, frequency);
+        await SyntheticService.Submit(simulationType, numPoints, numSeries, noise, correlationTime, amplitude, frequency, stabilityIndex, skewnessParam);
     } catch (err) {
         console.error('Submit error:', err);
+        alert(`Invalid parameters: ${err.message ?? err}`);
     }
 });
 
@@ -34,6 +34,10 @@ const (
 	pluginVersion = 1
 )
 
+// capabilities leaves out "ping": a restarted process would reopen the
+// configuration window, as initialize waits on it.
+var capabilities = append(sdk.BaseCapabilities(), "event")
+
 // ConfigResult holds the result from the configuration UI.
 type ConfigResult struct {
 	SimulationType  string  `json:"simulationType"`
@@ -43,7 +47,9 @@ type ConfigResult struct {
 	CorrelationTime float64 `json:"correlationTime"`
 	Amplitude       float64 `json:"amplitude"`
 	Frequency       float64 `json:"frequency"`
-	Cancelled       bool    `json:"cancelled"`
+	StabilityIndex  float64 `json:"stabilityIndex"` // Levy stable α, in (0, 2]
+	SkewnessParam   float64 `json:"skewnessParam"`  // Levy stable β, in [-1, 1]
+	Cancelled       bool    `json:"cancelled,omitempty"`
 }
 
 // SyntheticService provides methods callable from the Svelte frontend.
@@ -57,8 +63,10 @@ func NewSyntheticService() *SyntheticService {
 	}
 }
 
-func (s *SyntheticService) Submit(simulationType string, numPoints int, numSeries int, noise float64, correlationTime float64, amplitude float64, frequency float64) {
-	s.resultChan <- ConfigResult{
+// Submit passes the parameters entered in the UI to the waiting initialize
+// request. Invalid parameters are rejected, leaving the form open.
+func (s *SyntheticService) Submit(simulationType string, numPoints int, numSeries int, noise float64, correlationTime float64, amplitude float64, frequency float64, stabilityIndex float64, skewnessParam float64) error {
+	result := ConfigResult{
 		SimulationType:  simulationType,
 		NumPoints:       numPoints,
 		NumSeries:       numSeries,
@@ -66,7 +74,14 @@ func (s *SyntheticService) Submit(simulationType string, numPoints int, numSerie
 		CorrelationTime: correlationTime,
 		Amplitude:       amplitude,
 		Frequency:       frequency,
+		StabilityIndex:  stabilityIndex,
+		SkewnessParam:   skewnessParam,
+	}
+	if err := result.validate(); err != nil {
+		return err
 	}
+	s.resultChan <- result
+	return nil
 }
 
 func (s *SyntheticService) Cancel() {
@@ -83,6 +98,8 @@ type pluginState struct {
 	correlationTime float64
 	amplitude       float64
 	frequency       float64
+	stabilityIndex  float64
+	skewnessParam   float64
 }
 
 var (
@@ -96,12 +113,7 @@ func main() {
 	// Check for --metadata flag
 	for _, arg := range os.Args {
 		if arg == "--metadata" {
-			metadata := map[string]interface{}{
-				"name":     pluginName,
-				"patterns": []interface{}{},
-			}
-			jsonBytes, _ := json.Marshal(metadata)
-			fmt.Println(string(jsonBytes))
+			sdk.SendMetadata(sdk.Metadata{Name: pluginName, APIVersion: pluginVersion, Capabilities: capabilities})
 			os.Exit(0)
 		}
 	}
@@ -116,6 +128,8 @@ func main() {
 		correlationTime: 10.0,
 		amplitude:       1.0,
 		frequency:       0.1,
+		stabilityIndex:  1.5,
+		skewnessParam:   0,
 		seed:            uint64(time.Now().UnixNano()),
 	}
 	service = NewSyntheticService()
@@ -162,8 +176,8 @@ func handleIPC() {
 	scanner := bufio.NewScanner(os.Stdin)
 	for scanner.Scan() {
 		line := scanner.Text()
-		var req sdk.Request
-		if err := json.Unmarshal([]byte(line), &req); err != nil {
+		req, err := sdk.ParseRequest([]byte(line))
+		if err != nil {
 			sdk.SendError(fmt.Sprintf("Invalid JSON: %v", err))
 			continue
 		}
@@ -171,8 +185,9 @@ func handleIPC() {
 		switch req.Method {
 		case "info":
 			sdk.SendResponse(sdk.Response{
-				Name:    pluginName,
-				Version: pluginVersion,
+				Name:         pluginName,
+				Version:      pluginVersion,
+				Capabilities: capabilities,
 			})
 
 		case "initialize":
@@ -202,6 +217,8 @@ func handleIPC() {
 			state.correlationTime = result.CorrelationTime
 			state.amplitude = result.Amplitude
 			state.frequency = result.Frequency
+			state.stabilityIndex = result.StabilityIndex
+			state.skewnessParam = result.SkewnessParam
 			state.seed = uint64(time.Now().UnixNano())
 
 			// Close the config window after submission
@@ -211,6 +228,16 @@ func handleIPC() {
 
 			sdk.SendResponse(sdk.Response{Result: map[string]interface{}{}})
 
+		case "update_config":
+			if err := updateConfig(req.Data); err != nil {
+				sdk.SendError(err.Error())
+				continue
+			}
+			sdk.SendResponse(sdk.Response{Result: "ok"})
+
+		case "get_config":
+			sdk.SendResponse(sdk.Response{Result: currentConfig()})
+
 		case "get_chart_config":
 			config := sdk.ChartConfig{
 				Title: "Synthetic Data (IPC)",
@@ -237,6 +264,16 @@ func handleIPC() {
 			data, storage := generateData(state, req.SeriesID, req.PreferredStorage)
 			sdk.SendBinaryData(data, storage)
 
+		case "ping":
+			sdk.HandlePing(req)
+
+		case "event":
+			// Host notifications need no reply
+			sdk.HandleEvent(req)
+
+		case "quit":
+			sdk.HandleQuit(req)
+
 		default:
 			sdk.SendError(fmt.Sprintf("Unknown method: %s", req.Method))
 		}
@@ -247,6 +284,71 @@ func handleIPC() {
 	}
 }
 
+// currentConfig returns the generation parameters in the same shape as the
+// configuration UI submits them.
+func currentConfig() ConfigResult {
+	return ConfigResult{
+		SimulationType:  state.simulationType,
+		NumPoints:       state.numPoints,
+		NumSeries:       state.numSeries,
+		Noise:           state.noise,
+		CorrelationTime: state.correlationTime,
+		Amplitude:       state.amplitude,
+		Frequency:       state.frequency,
+		StabilityIndex:  state.stabilityIndex,
+		SkewnessParam:   state.skewnessParam,
+	}
+}
+
+// updateConfig applies the parameters present in data, leaving the others
+// unchanged. The seed is kept so that the series keep their shape.
+func updateConfig(data map[string]interface{}) error {
+	cfg := currentConfig()
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	state.simulationType = cfg.SimulationType
+	state.numPoints = cfg.NumPoints
+	state.numSeries = cfg.NumSeries
+	state.noise = cfg.Noise
+	state.correlationTime = cfg.CorrelationTime
+	state.amplitude = cfg.Amplitude
+	state.frequency = cfg.Frequency
+	state.stabilityIndex = cfg.StabilityIndex
+	state.skewnessParam = cfg.SkewnessParam
+	sdk.Log("info", fmt.Sprintf("Config updated: %+v", cfg))
+	return nil
+}
+
+// validate checks the parameters, whether submitted by the UI or sent with
+// update_config.
+func (c ConfigResult) validate() error {
+	switch c.SimulationType {
+	case "Random Walk", "Gauss-Markov", "Sinusoidal", "Levy Stable":
+	default:
+		return fmt.Errorf("unknown simulation type: %s", c.SimulationType)
+	}
+	if c.NumPoints < 1 || c.NumSeries < 1 {
+		return fmt.Errorf("numPoints and numSeries must be positive")
+	}
+	if !(c.StabilityIndex > 0 && c.StabilityIndex <= 2) {
+		return fmt.Errorf("stabilityIndex must be in (0, 2]")
+	}
+	if !(c.SkewnessParam >= -1 && c.SkewnessParam <= 1) {
+		return fmt.Errorf("skewnessParam must be in [-1, 1]")
+	}
+	return nil
+}
+
 func generateData(st *pluginState, seriesID string, preferredStorage string) ([]float64, string) {
 	simType := st.simulationType
 	numPoints := st.numPoints
@@ -255,6 +357,8 @@ func generateData(st *pluginState, seriesID string, preferredStorage string) ([]
 	correlationTime := st.correlationTime
 	amplitude := st.amplitude
 	frequency := st.frequency
+	alpha := st.stabilityIndex
+	beta := st.skewnessParam
 
 	// Parse series index from ID to create unique seed per series
 	var seriesIdx int
@@ -297,6 +401,10 @@ func generateData(st *pluginState, seriesID string, preferredStorage string) ([]
 			whiteNoise := rng.NormFloat64() * 0.1
 			phase := float64(seriesIdx) * 0.5
 			y = amplitude*math.Sin(2*math.Pi*frequency*t+phase) + whiteNoise
+		case "Levy Stable":
+			// Stable increments scale with dt^(1/α), as Gaussian ones do
+			// with sqrt(dt)
+			y += levyStable(rng, alpha, beta) * math.Pow(dt, 1/alpha) * noise
 		default:
 			y += rng.NormFloat64() * math.Sqrt(dt)
 		}
@@ -311,3 +419,22 @@ func generateData(st *pluginState, seriesID string, preferredStorage string) ([]
 	}
 	return result, storage
 }
+
+// levyStable returns a standard Levy stable deviate with stability index
+// alpha, in (0, 2], and skewness beta, in [-1, 1], using the
+// Chambers-Mallows-Stuck method. With alpha = 2 it is Gaussian with
+// variance 2; below that its variance is infinite.
+func levyStable(rng *rand.Rand, alpha, beta float64) float64 {
+	v := math.Pi * (rng.Float64() - 0.5) // Uniform on (-π/2, π/2)
+	w := rng.ExpFloat64()
+
+	if alpha == 1 {
+		h := math.Pi/2 + beta*v
+		return 2 / math.Pi * (h*math.Tan(v) - beta*math.Log(math.Pi/2*w*math.Cos(v)/h))
+	}
+	t := beta * math.Tan(math.Pi*alpha/2)
+	b := math.Atan(t) / alpha
+	s := math.Pow(1+t*t, 1/(2*alpha))
+	return s * math.Sin(alpha*(v+b)) / math.Pow(math.Cos(v), 1/alpha) *
+		math.Pow(math.Cos(v-alpha*(v+b))/w, (1-alpha)/alpha)
+}
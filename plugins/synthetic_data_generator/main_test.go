@@ -0,0 +1,101 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// sampleVariance returns the variance of n deviates from levyStable.
+func sampleVariance(alpha, beta float64, n int, seed int64) float64 {
+	rng := rand.New(rand.NewSource(seed))
+	var sum, sumSq float64
+	for i := 0; i < n; i++ {
+		x := levyStable(rng, alpha, beta)
+		sum += x
+		sumSq += x * x
+	}
+	mean := sum / float64(n)
+	return sumSq/float64(n) - mean*mean
+}
+
+func TestLevyStableGaussian(t *testing.T) {
+	// With α = 2 the distribution is Gaussian with variance 2
+	if v := sampleVariance(2, 0, 100000, 1); math.Abs(v-2) > 0.1 {
+		t.Errorf("variance = %v, want about 2", v)
+	}
+}
+
+func TestLevyStableVarianceDiverges(t *testing.T) {
+	for _, alpha := range []float64{1.5, 1, 0.5} {
+		// Heavy tails make the sample variance grow with the sample size
+		// rather than settle at 2, as it does for α = 2
+		small := sampleVariance(alpha, 0, 1000, 1)
+		large := sampleVariance(alpha, 0, 1000000, 1)
+		if !(large > 2*small) || large < 20 {
+			t.Errorf("α = %v: variance of 10^3 samples = %v, of 10^6 = %v, want it to grow without bound",
+				alpha, small, large)
+		}
+	}
+}
+
+func TestLevyStableSkewness(t *testing.T) {
+	// With α < 1 and β = 1 the distribution is supported on [0, ∞)
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		if x := levyStable(rng, 0.5, 1); x < 0 {
+			t.Fatalf("deviate %d = %v, want non-negative", i, x)
+		}
+	}
+}
+
+func TestUpdateConfigLevyStable(t *testing.T) {
+	state = &pluginState{simulationType: "Random Walk", numPoints: 10, numSeries: 1, stabilityIndex: 2}
+
+	if err := updateConfig(map[string]interface{}{
+		"simulationType": "Levy Stable", "stabilityIndex": 1.2, "skewnessParam": -0.5,
+	}); err != nil {
+		t.Fatalf("updateConfig failed: %v", err)
+	}
+	if state.simulationType != "Levy Stable" || state.stabilityIndex != 1.2 || state.skewnessParam != -0.5 {
+		t.Errorf("state = %+v", *state)
+	}
+
+	for _, data := range []map[string]interface{}{
+		{"stabilityIndex": 0.0},
+		{"stabilityIndex": 2.5},
+		{"skewnessParam": 1.5},
+	} {
+		if err := updateConfig(data); err == nil {
+			t.Errorf("updateConfig(%v) succeeded, want error", data)
+		}
+	}
+
+	data, _ := generateData(state, "synthetic_0", "interleaved")
+	for i, v := range data {
+		if math.IsNaN(v) {
+			t.Fatalf("data[%d] is NaN", i)
+		}
+	}
+}
+
+func TestSubmitValidates(t *testing.T) {
+	s := NewSyntheticService()
+	for _, alpha := range []float64{0, -1, 2.5, math.NaN()} {
+		if err := s.Submit("Levy Stable", 100, 1, 1, 1, 1, 1, alpha, 0); err == nil {
+			t.Errorf("Submit with stabilityIndex %v succeeded, want error", alpha)
+		}
+	}
+	select {
+	case result := <-s.resultChan:
+		t.Fatalf("invalid parameters reached initialize: %+v", result)
+	default:
+	}
+
+	if err := s.Submit("Levy Stable", 100, 1, 1, 1, 1, 1, 2, 0); err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	if result := <-s.resultChan; result.StabilityIndex != 2 {
+		t.Errorf("result = %+v", result)
+	}
+}
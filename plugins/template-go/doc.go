@@ -0,0 +1,16 @@
+// Test harness mode
+//
+// Run with --test-harness, the plugin answers every request without user
+// interaction, so tools can exercise the whole protocol unattended:
+//
+//   - initialize replies {} at once instead of showing the configuration
+//     form, keeping the default frequency and amplitude
+//   - get_chart_config returns the single axis group of chartConfig
+//   - get_series_config returns the one series, "sine"
+//   - get_series_data returns 100 points of the sine wave
+//
+// For example:
+//
+//	printf '{"method":"initialize"}\n{"method":"get_series_data","series_id":"sine"}\n' | ./template-go --test-harness
+
+package main
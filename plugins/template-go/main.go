@@ -1,105 +1,229 @@
+// Template IPC Plugin - A complete, minimal example of an OlicanaPlot plugin
+// that generates a sine wave. Copy this directory to start a new plugin.
+//
+// Protocol:
+//   - The host starts the plugin and writes JSON requests to its stdin, one
+//     per line
+//   - The plugin writes one JSON response per request to stdout, one per line
+//   - Series data is sent as a JSON header followed by raw float64 bytes
+//   - Anything else written to stdout breaks the protocol; use sdk.Log or
+//     stderr for diagnostics
+//
+// See docs/IPC_PROTOCOL.md for the full protocol.
+
 package main
 
 import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
-	"strings"
 
 	sdk "olicanaplot/sdk/go"
-
-	"github.com/wailsapp/wails/v3/pkg/application"
 )
 
 const (
 	pluginName    = "Template IPC"
-	pluginVersion = 1
+	pluginVersion = 1 // The plugin API version, not the plugin's release
 )
 
-var (
-	app        *application.App
-	mainWindow application.Window
-)
+// capabilities lists the methods the plugin implements. It is reported by
+// both --metadata and info. Only plugins listing "event" are sent events, and
+// only those listing "ping" are pinged and restarted when they stop answering.
+var capabilities = append(sdk.BaseCapabilities(), "event", "ping")
+
+// numSamples is how many points the sine wave has.
+const numSamples = 1000
+
+// harnessSamples is how many points the sine wave has with --test-harness.
+const harnessSamples = 100
+
+// sineConfig holds the parameters chosen in the configuration form. The JSON
+// names are the form's field names.
+type sineConfig struct {
+	Frequency float64 `json:"frequency"` // Cycles over the whole series
+	Amplitude float64 `json:"amplitude"`
+}
+
+// config is the plugin's state. Requests are handled one at a time, so it
+// needs no locking.
+var config = sineConfig{Frequency: 2, Amplitude: 1}
 
 func main() {
-	// Check for --file-patterns flag
-	for _, arg := range os.Args {
-		if arg == "--file-patterns" {
-			fmt.Println("[]")
-			os.Exit(0)
+	harness := false
+	for _, arg := range os.Args[1:] {
+		switch arg {
+		case "--metadata":
+			// Discovery: the host runs the executable with --metadata once to
+			// learn its name, the file patterns it opens and the methods it
+			// implements, without starting it. A generator opens no files.
+			sdk.SendMetadata(sdk.Metadata{Name: pluginName, APIVersion: pluginVersion, Capabilities: capabilities})
+			return
+		case "--test-harness":
+			// See doc.go
+			harness = true
 		}
 	}
 
-	app = application.New(application.Options{
-		Name: pluginName,
-	})
-
-	// Run IPC handler in a goroutine
-	go handleIPC()
+	handleIPC(harness)
+}
 
-	if err := app.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "failed to run application: %v\n", err)
-		os.Exit(1)
+// handleIPC answers the host's requests until it closes stdin. In harness
+// mode initialize succeeds without showing the form and the sine wave has
+// harnessSamples points.
+func handleIPC(harness bool) {
+	samples := numSamples
+	if harness {
+		samples = harnessSamples
 	}
-}
 
-func handleIPC() {
-	reader := bufio.NewReader(os.Stdin)
-	for {
-		line, err := reader.ReadString('\n')
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		req, err := sdk.ParseRequest(scanner.Bytes())
 		if err != nil {
-			app.Quit()
-			return
-		}
-
-		var req sdk.Request
-		if err := json.Unmarshal([]byte(strings.TrimSpace(line)), &req); err != nil {
-			sdk.SendError("failed to parse request")
+			sdk.SendError(fmt.Sprintf("invalid JSON: %v", err))
 			continue
 		}
 
 		switch req.Method {
 		case "info":
+			// Step 1: the host may ask who the plugin is, as --metadata told it
 			sdk.SendResponse(sdk.Response{
-				Name:    pluginName,
-				Version: pluginVersion,
+				Name:         pluginName,
+				Version:      pluginVersion,
+				Capabilities: capabilities,
 			})
 
 		case "initialize":
-			// Show configuration UI here
-			if mainWindow == nil {
-				mainWindow = app.Window.NewWithOptions(application.WebviewWindowOptions{
-					Title:     pluginName,
-					Width:     400,
-					Height:    300,
-					Frameless: true,
-				})
+			// Step 2: the user selected the plugin. Ask for the parameters
+			// with a form shown by the host, then reply to initialize.
+			if harness {
+				sdk.SendResponse(sdk.Response{Result: struct{}{}})
+			} else if err := handleInitialize(scanner); err != nil {
+				sdk.SendError(err.Error())
+			} else {
+				sdk.SendResponse(sdk.Response{Result: "initialized"})
 			}
-			mainWindow.Show()
-			sdk.SendResponse(sdk.Response{Result: "initialized"})
 
 		case "get_chart_config":
-			sdk.SendResponse(sdk.Response{
-				Result: sdk.ChartConfig{
-					Title:      "Template Data",
-					AxisLabels: []string{"Time", "Value"},
-				},
-			})
+			// Step 3: the host asks for the chart's title and axes
+			sdk.SendResponse(sdk.Response{Result: chartConfig()})
 
 		case "get_series_config":
+			// Step 4: the host asks which series there are
 			sdk.SendResponse(sdk.Response{
 				Result: []sdk.SeriesConfig{
-					{ID: "series1", Name: "Series 1"},
+					{ID: "sine", Name: fmt.Sprintf("Sine (f = %g, A = %g)", config.Frequency, config.Amplitude)},
 				},
 			})
 
 		case "get_series_data":
-			data := []float64{0, 0, 1, 1, 2, 0, 3, 1}
-			sdk.SendBinaryData(data, "interleaved")
+			// Step 5: the host asks for each series' points by ID, in the
+			// layout it prefers; SendBinaryData writes the header and bytes
+			if req.SeriesID != "sine" {
+				sdk.SendError(fmt.Sprintf("series not found: %s", req.SeriesID))
+				continue
+			}
+			data, storage := generateSine(config, samples, req.PreferredStorage)
+			sdk.SendBinaryData(data, storage)
+
+		case "ping":
+			sdk.HandlePing(req)
+
+		case "event":
+			// Host notifications, e.g. theme changes, need no reply
+			sdk.HandleEvent(req)
+
+		case "quit":
+			sdk.HandleQuit(req)
 
 		default:
-			sdk.SendError("unknown method: " + req.Method)
+			// Reply to every request, even unknown ones, so the host is not
+			// left waiting
+			sdk.SendError(fmt.Sprintf("unknown method: %s", req.Method))
 		}
 	}
 }
+
+// handleInitialize shows the configuration form and reads the user's answer.
+// While the form is open the host writes the result to stdin, so it is read
+// here rather than by the request loop.
+func handleInitialize(scanner *bufio.Scanner) error {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"frequency": map[string]interface{}{
+				"type":    "number",
+				"title":   "Frequency (cycles)",
+				"minimum": 0,
+			},
+			"amplitude": map[string]interface{}{
+				"type":  "number",
+				"title": "Amplitude",
+			},
+		},
+	}
+	uiSchema := map[string]interface{}{
+		"ui:order": []string{"frequency", "amplitude"},
+	}
+	sdk.SendShowForm("Sine Wave", schema, uiSchema, map[string]interface{}{
+		"frequency": config.Frequency,
+		"amplitude": config.Amplitude,
+	})
+
+	if !scanner.Scan() {
+		return fmt.Errorf("failed to read form response")
+	}
+	var resp struct {
+		Result sineConfig `json:"result"`
+		Error  string     `json:"error"` // e.g. "cancelled"
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+		return fmt.Errorf("failed to parse form response: %v", err)
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("configuration cancelled: %s", resp.Error)
+	}
+	if resp.Result.Frequency < 0 {
+		return fmt.Errorf("frequency must not be negative")
+	}
+
+	config = resp.Result
+	sdk.Log("info", fmt.Sprintf("Sine configured: frequency %g, amplitude %g", config.Frequency, config.Amplitude))
+	return nil
+}
+
+// chartConfig returns the chart's title and axes.
+func chartConfig() sdk.ChartConfig {
+	return sdk.ChartConfig{
+		Title: "Sine Wave",
+		Axes: []sdk.AxisGroupConfig{
+			{
+				XAxes: []sdk.AxisConfig{{Title: "Sample"}},
+				YAxes: []sdk.AxisConfig{{Title: "Value"}},
+			},
+		},
+	}
+}
+
+// generateSine returns n samples of c's sine wave against the sample index.
+// With preferredStorage "arrays" the X values come first, then the Y values
+// ([x0 x1 ... y0 y1 ...]); otherwise the points are interleaved
+// ([x0 y0 x1 y1 ...]). It also returns the storage used.
+func generateSine(c sineConfig, n int, preferredStorage string) ([]float64, string) {
+	data := make([]float64, n*2)
+	for i := 0; i < n; i++ {
+		x := float64(i)
+		y := c.Amplitude * math.Sin(2*math.Pi*c.Frequency*x/float64(n))
+		if preferredStorage == "arrays" {
+			data[i], data[n+i] = x, y
+		} else {
+			data[i*2], data[i*2+1] = x, y
+		}
+	}
+	if preferredStorage == "arrays" {
+		return data, "arrays"
+	}
+	return data, "interleaved"
+}
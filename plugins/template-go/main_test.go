@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	sdk "olicanaplot/sdk/go"
+	"olicanaplot/sdk/go/sdktest"
+)
+
+func TestGenerateSine(t *testing.T) {
+	c := sineConfig{Frequency: 1, Amplitude: 2}
+
+	data, storage := generateSine(c, 4, "")
+	if storage != "interleaved" {
+		t.Errorf("expected interleaved storage, got %s", storage)
+	}
+	// One cycle over 4 samples: 0, peak, 0, trough
+	expected := []float64{0, 0, 1, 2, 2, 0, 3, -2}
+	for i := range expected {
+		if math.Abs(data[i]-expected[i]) > 1e-12 {
+			t.Fatalf("expected %v, got %v", expected, data)
+		}
+	}
+
+	data, storage = generateSine(c, 4, "arrays")
+	if storage != "arrays" {
+		t.Errorf("expected arrays storage, got %s", storage)
+	}
+	if data[1] != 1 || math.Abs(data[5]-2) > 1e-12 {
+		t.Errorf("expected X values then Y values, got %v", data)
+	}
+}
+
+func TestHarnessMode(t *testing.T) {
+	in, out := sdktest.Serve(t, func() { handleIPC(true) })
+	go func() {
+		io.WriteString(in, strings.Join([]string{
+			`{"method":"initialize"}`,
+			`{"method":"get_chart_config"}`,
+			`{"method":"get_series_config"}`,
+			`{"method":"get_series_data","series_id":"sine"}`,
+		}, "\n")+"\n")
+		in.Close()
+	}()
+
+	readResponse := func() sdk.Response {
+		t.Helper()
+		line, err := out.ReadBytes('\n')
+		if err != nil {
+			t.Fatalf("failed to read response: %v", err)
+		}
+		var resp sdk.Response
+		if err := json.Unmarshal(line, &resp); err != nil {
+			t.Fatalf("invalid response %q: %v", line, err)
+		}
+		if resp.Error != "" {
+			t.Fatalf("unexpected error: %s", resp.Error)
+		}
+		return resp
+	}
+
+	// No form is shown: the first reply answers initialize
+	if resp := readResponse(); resp.Type != "" || resp.Method != "" {
+		t.Fatalf("expected initialize reply, got %+v", resp)
+	}
+	readResponse()
+	if resp := readResponse(); len(resp.Result.([]interface{})) != 1 {
+		t.Errorf("expected one series, got %v", resp.Result)
+	}
+	header := readResponse()
+	if header.Type != "binary" || header.Length != harnessSamples*2*8 {
+		t.Fatalf("unexpected data header %+v", header)
+	}
+	if _, err := io.ReadFull(out, make([]byte, header.Length)); err != nil {
+		t.Fatalf("failed to read data: %v", err)
+	}
+}
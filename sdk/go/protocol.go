@@ -2,17 +2,53 @@ package sdk
 
 import (
 	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"math"
 	"os"
+	"sync"
+	"sync/atomic"
 	"unsafe"
 )
 
 // Request represents an IPC request from the host.
 type Request struct {
 	Method           string                 `json:"method"`
+	Event            string                 `json:"event,omitempty"` // Event name when Method is "event"
 	Args             string                 `json:"args,omitempty"`
 	SeriesID         string                 `json:"series_id,omitempty"`
 	PreferredStorage string                 `json:"preferred_storage,omitempty"` // interleaved or arrays
-	Data             map[string]interface{} `json:"data,omitempty"`              // For form_change
+	Data             map[string]interface{} `json:"data,omitempty"`              // For form_change, update_config and event
+	TraceID          string                 `json:"trace_id,omitempty"`          // Same for every request after initialize
+	RequestID        string                 `json:"request_id,omitempty"`        // New for each request, echo it in Response.RequestID
+	Locale           string                 `json:"locale,omitempty"`            // User's locale, e.g. "fr-FR", sent with every request
+	Field            string                 `json:"field,omitempty"`             // Form field for form_autocomplete
+	Query            string                 `json:"query,omitempty"`             // Text typed for form_autocomplete
+	Series           []SeriesPoints         `json:"series,omitempty"`            // For save
+}
+
+var currentLocale atomic.Value // string
+
+// ParseRequest decodes a request line from the host, and records its locale
+// for CurrentLocale.
+func ParseRequest(line []byte) (Request, error) {
+	var req Request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return req, err
+	}
+	if req.Locale != "" {
+		currentLocale.Store(req.Locale)
+	}
+	return req, nil
+}
+
+// CurrentLocale returns the user's locale, e.g. "fr-FR", from the most
+// recent request read by ParseRequest that included one, so plugins can
+// localize the titles and labels of their forms. It is "" until the host
+// sends a locale.
+func CurrentLocale() string {
+	locale, _ := currentLocale.Load().(string)
+	return locale
 }
 
 // Response represents an IPC response to the host.
@@ -22,7 +58,8 @@ type Response struct {
 	Error            string                 `json:"error,omitempty"`
 	Type             string                 `json:"type,omitempty"`
 	Length           int                    `json:"length,omitempty"`
-	Storage          string                 `json:"storage,omitempty"` // interleaved or arrays
+	Storage          string                 `json:"storage,omitempty"`  // interleaved or arrays
+	Checksum         *uint32                `json:"checksum,omitempty"` // CRC-32 (IEEE) of binary data
 	Name             string                 `json:"name,omitempty"`
 	Version          uint32                 `json:"version,omitempty"`
 	Title            string                 `json:"title,omitempty"`    // For show_form
@@ -30,6 +67,15 @@ type Response struct {
 	UISchema         interface{}            `json:"uiSchema,omitempty"` // For form updates
 	Data             map[string]interface{} `json:"data,omitempty"`     // For form updates
 	HandleFormChange bool                   `json:"handle_form_change,omitempty"`
+	InitSchema       interface{}            `json:"init_schema,omitempty"`      // For get_schema
+	SeriesIDSchema   interface{}            `json:"series_id_schema,omitempty"` // For get_schema
+	Icon             string                 `json:"icon,omitempty"`             // For show_form, base64 PNG
+	Accept           string                 `json:"accept,omitempty"`           // For show_file_dialog, e.g. "*.cal"
+	PluginVersion    string                 `json:"plugin_version,omitempty"`   // For info
+	BuildDate        string                 `json:"build_date,omitempty"`       // For info
+	CommitHash       string                 `json:"commit_hash,omitempty"`      // For info
+	Capabilities     []string               `json:"capabilities,omitempty"`     // For info
+	RequestID        string                 `json:"request_id,omitempty"`       // Request.RequestID of the request answered
 }
 
 // IMPORTANT: The following structs are intentionally duplicated from internal/plugins
@@ -48,6 +94,25 @@ type ChartConfig struct {
 	Axes  []AxisGroupConfig `json:"axes,omitempty"`
 	LinkX *bool             `json:"link_x,omitempty"`
 	LinkY *bool             `json:"link_y,omitempty"`
+
+	// LineWidthDefault is the line width for series that do not set their
+	// own. When nil the host's default line width is used.
+	LineWidthDefault *float64 `json:"line_width_default,omitempty"`
+
+	// SubplotTitles titles the subplots in row-major order, overriding the
+	// Title of their axis groups, so simple grids need no Axes. Empty
+	// entries leave a subplot's title unchanged.
+	SubplotTitles []string `json:"subplot_titles,omitempty"`
+
+	// Colorscale names the continuous color scale, e.g. "viridis", "plasma"
+	// or "hot", that colors the points of series with a ColorScaleColumn.
+	// Empty uses viridis.
+	Colorscale string `json:"colorscale,omitempty"`
+
+	// Deprecated: Rows and Cols are kept for plugins written before Grid
+	// existed. Use Grid instead; the host migrates these when Grid is nil.
+	Rows int `json:"rows,omitempty"`
+	Cols int `json:"cols,omitempty"`
 }
 
 // GridConfig describes the subplot grid layout.
@@ -64,6 +129,13 @@ type AxisConfig struct {
 	Type     string   `json:"type,omitempty"` // "linear", "log", "date"
 	Min      *float64 `json:"min,omitempty"`
 	Max      *float64 `json:"max,omitempty"`
+
+	// ScaleFactor and ScaleOffset convert the raw values plotted against
+	// the axis for display, as value*ScaleFactor + ScaleOffset, e.g. from
+	// pascals to kilopascals. A zero ScaleFactor means 1. The data itself
+	// is not changed.
+	ScaleFactor float64 `json:"scale_factor,omitempty"`
+	ScaleOffset float64 `json:"scale_offset,omitempty"`
 }
 
 // SubPlot describes a cell in the chart grid.
@@ -93,7 +165,24 @@ type SeriesConfig struct {
 	MarkerFill string   `json:"marker_fill,omitempty"` // "empty" or "solid"
 	Unit       string   `json:"unit,omitempty"`
 	Visible    *bool    `json:"visible"`
-	YAxis      string   `json:"y_axis,omitempty"` // references Y axis title
+	YAxis      string   `json:"y_axis,omitempty"`  // references Y axis title
+	Opacity    float64  `json:"opacity,omitempty"` // 0.0-1.0, defaults to 1.0
+
+	// Description is free text about the series, shown in its tooltip.
+	Description string `json:"description,omitempty"`
+
+	// LoadPriority orders data fetches: higher priorities load first. Series
+	// default to 0.
+	LoadPriority int `json:"load_priority,omitempty"`
+
+	// RefreshIntervalMs is how often /ws/series_stream polls the series for
+	// new data, in milliseconds. 0 uses the default of one second.
+	RefreshIntervalMs int `json:"refresh_interval_ms,omitempty"`
+
+	// ColorScaleColumn is the ID of another series whose Y values, one per
+	// point, color this series' points on the chart's Colorscale. They are
+	// served by /api/colorscale_data.
+	ColorScaleColumn string `json:"color_scale_column,omitempty"`
 }
 
 // FilePattern describes a file type supported by a plugin.
@@ -102,6 +191,82 @@ type FilePattern struct {
 	Patterns    []string `json:"patterns"`
 }
 
+// TimeRange is the X extent reported by a get_time_range request.
+type TimeRange struct {
+	XMin float64 `json:"x_min"`
+	XMax float64 `json:"x_max"`
+}
+
+// FileInfo describes the file a plugin loaded, as reported by a get_file_info
+// request.
+type FileInfo struct {
+	Path         string `json:"path"`
+	SizeBytes    int64  `json:"size_bytes"`
+	RowCount     int    `json:"row_count"`
+	ModifiedUnix int64  `json:"modified_unix"`
+}
+
+// SeriesPoints is a series' points, sent with a save request. NaN values
+// are sent as null.
+type SeriesPoints struct {
+	ID string    `json:"id"`
+	X  []float64 `json:"x"`
+	Y  []float64 `json:"y"`
+}
+
+// MarshalJSON encodes non-finite values, such as the NaN of a gap, as null,
+// since JSON has no number for them.
+func (s SeriesPoints) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonSeriesPoints{ID: s.ID, X: nullableFloats(s.X), Y: nullableFloats(s.Y)})
+}
+
+// UnmarshalJSON decodes null values as NaN.
+func (s *SeriesPoints) UnmarshalJSON(data []byte) error {
+	var js jsonSeriesPoints
+	if err := json.Unmarshal(data, &js); err != nil {
+		return err
+	}
+	*s = SeriesPoints{ID: js.ID, X: nanFloats(js.X), Y: nanFloats(js.Y)}
+	return nil
+}
+
+// jsonSeriesPoints is SeriesPoints as encoded, with null for NaN.
+type jsonSeriesPoints struct {
+	ID string     `json:"id"`
+	X  []*float64 `json:"x"`
+	Y  []*float64 `json:"y"`
+}
+
+// nullableFloats returns v with nil in place of non-finite values.
+func nullableFloats(v []float64) []*float64 {
+	if v == nil {
+		return nil
+	}
+	out := make([]*float64, len(v))
+	for i := range v {
+		if !math.IsNaN(v[i]) && !math.IsInf(v[i], 0) {
+			out[i] = &v[i]
+		}
+	}
+	return out
+}
+
+// nanFloats returns v with NaN in place of nil values.
+func nanFloats(v []*float64) []float64 {
+	if v == nil {
+		return nil
+	}
+	out := make([]float64, len(v))
+	for i, p := range v {
+		if p == nil {
+			out[i] = math.NaN()
+		} else {
+			out[i] = *p
+		}
+	}
+	return out
+}
+
 // SendResponse sends a JSON response to stdout.
 func SendResponse(resp Response) {
 	respJSON, _ := json.Marshal(resp)
@@ -115,13 +280,31 @@ func SendError(msg string) {
 	SendResponse(Response{Error: msg})
 }
 
-// SendBinaryData sends binary float64 data following a JSON header.
+// SendBinaryData sends binary float64 data following a JSON header. It is
+// SendBinaryDataChecked, so the host verifies the data.
 func SendBinaryData(data []float64, storage string) {
+	SendBinaryDataChecked(data, storage)
+}
+
+// SendBinaryDataChecked sends binary float64 data following a JSON header
+// that includes its CRC-32 checksum, which the host verifies to detect data
+// corrupted in transit.
+func SendBinaryDataChecked(data []float64, storage string) {
+	SendBinaryReply("", data, storage)
+}
+
+// SendBinaryReply is SendBinaryDataChecked with the request ID of the
+// get_series_data request answered in the header, so that the host can
+// match replies sent out of order to their requests.
+func SendBinaryReply(requestID string, data []float64, storage string) {
 	binaryData := floatsToBytes(data)
+	checksum := crc32.ChecksumIEEE(binaryData)
 	headerJSON, _ := json.Marshal(Response{
-		Type:    "binary",
-		Length:  len(binaryData),
-		Storage: storage,
+		Type:      "binary",
+		Length:    len(binaryData),
+		Storage:   storage,
+		Checksum:  &checksum,
+		RequestID: requestID,
 	})
 
 	os.Stdout.Write(headerJSON)
@@ -132,19 +315,41 @@ func SendBinaryData(data []float64, storage string) {
 	os.Stdout.Sync()
 }
 
-// Log sends an asynchronous log message to the host.
-func Log(level, message string) {
-	msg := map[string]string{
+// Log sends an asynchronous log message to the host. Optional args are
+// key/value pairs sent as structured attributes, e.g. "trace_id", req.TraceID.
+func Log(level, message string, args ...interface{}) {
+	msg := map[string]interface{}{
 		"method":  "log",
 		"level":   level,
 		"message": message,
 	}
+	if len(args) > 0 {
+		attrs := make(map[string]interface{}, len(args)/2)
+		for i := 0; i+1 < len(args); i += 2 {
+			attrs[fmt.Sprint(args[i])] = args[i+1]
+		}
+		msg["attrs"] = attrs
+	}
 	bytes, _ := json.Marshal(msg)
 	os.Stdout.Write(bytes)
 	os.Stdout.Write([]byte("\n"))
 	os.Stdout.Sync()
 }
 
+// SendProgress reports how far a long request, such as initialize loading a
+// large file, has got. pct is from 0 to 100 and message describes the step,
+// e.g. "Reading rows". The host shows it while waiting for the response.
+func SendProgress(pct float64, message string) {
+	bytes, _ := json.Marshal(map[string]interface{}{
+		"method":  "progress",
+		"pct":     pct,
+		"message": message,
+	})
+	os.Stdout.Write(bytes)
+	os.Stdout.Write([]byte("\n"))
+	os.Stdout.Sync()
+}
+
 // SendFormUpdate sends an updated form configuration.
 func SendFormUpdate(schema, uiSchema interface{}, data map[string]interface{}) {
 	resp := Response{
@@ -155,7 +360,20 @@ func SendFormUpdate(schema, uiSchema interface{}, data map[string]interface{}) {
 	SendResponse(resp)
 }
 
+// SendAutocomplete answers a form_autocomplete request with the candidate
+// values for the field. The host waits at most 200ms for them.
+func SendAutocomplete(candidates []string) {
+	if candidates == nil {
+		candidates = []string{}
+	}
+	SendResponse(Response{Result: candidates})
+}
+
 // SendShowForm requests the host to show a form with initial data.
+//
+// Besides per-field hints, uiSchema may set "ui:order" to a list of field
+// names and "ui:layout" to "columns-2" or "columns-3" to lay long forms out
+// in that many columns, filled row by row.
 func SendShowForm(title string, schema, uiSchema interface{}, data map[string]interface{}) {
 	resp := Response{
 		Method:   "show_form",
@@ -167,12 +385,198 @@ func SendShowForm(title string, schema, uiSchema interface{}, data map[string]in
 	SendResponse(resp)
 }
 
+// SendShowFileDialog requests the host to let the user choose a file, such as
+// a calibration file for the loaded data. accept is a pattern like "*.cal",
+// several separated by semicolons, or "" for any file. The host answers on
+// stdin with the chosen path as the result, or an error if the user
+// cancelled.
+func SendShowFileDialog(title, accept string) {
+	SendResponse(Response{
+		Method: "show_file_dialog",
+		Title:  title,
+		Accept: accept,
+	})
+}
+
+// SendShowFormWithIcon requests the host to show a form whose window uses the
+// given icon. iconBase64 is a base64 encoded 32x32 PNG.
+func SendShowFormWithIcon(title string, iconBase64 string, schema, uiSchema interface{}, data map[string]interface{}) {
+	resp := Response{
+		Method:   "show_form",
+		Title:    title,
+		Icon:     iconBase64,
+		Schema:   schema,
+		UISchema: uiSchema,
+		Data:     data,
+	}
+	SendResponse(resp)
+}
+
+// Metadata is what a plugin prints when run with the --metadata flag. The
+// host reads it when it discovers the plugin, without starting an IPC
+// session, so it should carry the plugin's capabilities and init schema.
+type Metadata struct {
+	Name           string        `json:"name"`
+	Patterns       []FilePattern `json:"patterns"`
+	Concurrent     bool          `json:"concurrent,omitempty"`
+	MinHostVersion uint32        `json:"minHostVersion,omitempty"`
+	APIVersion     uint32        `json:"apiVersion,omitempty"`
+	PluginVersion  string        `json:"pluginVersion,omitempty"`
+	BuildDate      string        `json:"buildDate,omitempty"`
+	CommitHash     string        `json:"commitHash,omitempty"`
+	Capabilities   []string      `json:"capabilities,omitempty"`
+	InitSchema     interface{}   `json:"initSchema,omitempty"`
+}
+
+// SendMetadata prints m as the answer to --metadata.
+func SendMetadata(m Metadata) {
+	if m.Patterns == nil {
+		m.Patterns = []FilePattern{}
+	}
+	json.NewEncoder(os.Stdout).Encode(m)
+}
+
+// SendSchema answers a get_schema request with the JSON Schema for the
+// initialize args and for series IDs.
+func SendSchema(initSchema, seriesIDSchema interface{}) {
+	SendResponse(Response{
+		Method:         "get_schema",
+		InitSchema:     initSchema,
+		SeriesIDSchema: seriesIDSchema,
+	})
+}
+
+// SendTimeRange sends the response to a get_time_range request.
+func SendTimeRange(xMin, xMax float64) {
+	SendResponse(Response{
+		Result: TimeRange{XMin: xMin, XMax: xMax},
+	})
+}
+
+// NewFileInfo stats path and returns its FileInfo with the given number of
+// data rows.
+func NewFileInfo(path string, rowCount int) (FileInfo, error) {
+	st, err := os.Stat(path)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	return FileInfo{
+		Path:         path,
+		SizeBytes:    st.Size(),
+		RowCount:     rowCount,
+		ModifiedUnix: st.ModTime().Unix(),
+	}, nil
+}
+
+// SendFileInfo sends the response to a get_file_info request.
+func SendFileInfo(info FileInfo) {
+	SendResponse(Response{Result: info})
+}
+
 // SendNoUpdate indicates no UI change is needed.
 func SendNoUpdate() {
 	os.Stdout.Write([]byte("{}\n"))
 	os.Stdout.Sync()
 }
 
+var (
+	eventMu       sync.RWMutex
+	eventHandlers = make(map[string][]func(data map[string]interface{}))
+)
+
+// OnEvent registers a handler for host notifications with the given event
+// name, e.g. "themeChanged". Handlers run from HandleEvent on the dispatch
+// loop, so they should return quickly.
+func OnEvent(name string, handler func(data map[string]interface{})) {
+	eventMu.Lock()
+	defer eventMu.Unlock()
+	eventHandlers[name] = append(eventHandlers[name], handler)
+}
+
+// BaseCapabilities returns the methods every plugin implements. A plugin that
+// declares Capabilities in its Metadata and info response lists these plus
+// its optional methods, e.g. append(sdk.BaseCapabilities(), "event") if it
+// dispatches events to HandleEvent; the host only sends events to such
+// plugins.
+func BaseCapabilities() []string {
+	return []string{"get_chart_config", "get_series_config", "get_series_data"}
+}
+
+// HandleEvent runs the handlers registered for an "event" request. Events
+// are fire-and-forget: nothing is sent back to the host, even for events
+// without a handler. It reports whether req was an event.
+func HandleEvent(req Request) bool {
+	if req.Method != "event" {
+		return false
+	}
+	eventMu.RLock()
+	handlers := eventHandlers[req.Event]
+	eventMu.RUnlock()
+	for _, h := range handlers {
+		h(req.Data)
+	}
+	return true
+}
+
+// EmitEvent sends a named event, e.g. "dataUpdated", to the host, which
+// forwards it to the frontend if it subscribed to it. data must encode to a
+// JSON object or be nil. Like Log it may be called at any time except during
+// a binary transfer, but the host only reads it with the next response.
+func EmitEvent(name string, data interface{}) {
+	msg := map[string]interface{}{
+		"method": "event",
+		"event":  name,
+	}
+	if data != nil {
+		msg["data"] = data
+	}
+	bytes, _ := json.Marshal(msg)
+	os.Stdout.Write(bytes)
+	os.Stdout.Write([]byte("\n"))
+	os.Stdout.Sync()
+}
+
+var (
+	quitMu       sync.Mutex
+	quitHandlers []func()
+)
+
+// OnQuit registers a cleanup handler, e.g. to flush buffered output, that
+// runs when the host asks the plugin to quit. Handlers run in registration
+// order from HandleQuit.
+func OnQuit(handler func()) {
+	quitMu.Lock()
+	defer quitMu.Unlock()
+	quitHandlers = append(quitHandlers, handler)
+}
+
+// HandleQuit runs the handlers registered with OnQuit for a "quit" request
+// and acknowledges it. The host then closes stdin, which ends the plugin's
+// request loop. It reports whether req was a quit request.
+func HandleQuit(req Request) bool {
+	if req.Method != "quit" {
+		return false
+	}
+	quitMu.Lock()
+	handlers := quitHandlers
+	quitMu.Unlock()
+	for _, h := range handlers {
+		h()
+	}
+	SendResponse(Response{Result: "ok"})
+	return true
+}
+
+// HandlePing answers a "ping" request from the host's watchdog, which
+// restarts plugins that stop answering. It reports whether req was a ping.
+func HandlePing(req Request) bool {
+	if req.Method != "ping" {
+		return false
+	}
+	SendResponse(Response{Result: "pong"})
+	return true
+}
+
 // FloatsToBytes converts a float64 slice to little-endian bytes without copying.
 func floatsToBytes(data []float64) []byte {
 	if len(data) == 0 {
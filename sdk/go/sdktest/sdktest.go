@@ -0,0 +1,45 @@
+// Package sdktest runs a plugin's IPC loop in tests, with pipes in place of
+// the stdin and stdout it talks to the host over.
+package sdktest
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"testing"
+)
+
+// Serve replaces os.Stdin and os.Stdout with pipes and runs loop, a
+// plugin's IPC loop, in a goroutine. Requests written to in are read by the
+// loop, and its replies are read from out, which ends once the loop has
+// returned. Closing in ends the loop's input. At the end of the test Serve
+// waits for the loop to return and restores os.Stdin and os.Stdout.
+func Serve(t testing.TB, loop func()) (in io.WriteCloser, out *bufio.Reader) {
+	t.Helper()
+
+	inR, inW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	outR, outW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldIn, oldOut := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = inR, outW
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		loop()
+		outW.Close()
+		inR.Close()
+	}()
+	t.Cleanup(func() {
+		inW.Close()
+		outR.Close()
+		<-done
+		os.Stdin, os.Stdout = oldIn, oldOut
+	})
+	return inW, bufio.NewReader(outR)
+}
@@ -6,6 +6,7 @@ import json
 import os
 import struct
 import sys
+import zlib
 from typing import TYPE_CHECKING, Any
 
 if TYPE_CHECKING:
@@ -29,8 +30,14 @@ def send_show_form(
     ui_schema: dict[str, Any],
     data: dict[str, Any] | None = None,
     handle_form_change: bool = False,
+    icon: str | None = None,
 ) -> None:
-    """Request the host to show an interactive form."""
+    """Request the host to show an interactive form.
+
+    icon is an optional base64 encoded 32x32 PNG for the dialog window.
+    ui_schema may set "ui:layout" to "columns-2" or "columns-3" to lay long
+    forms out in that many columns, filled row by row.
+    """
     resp = {
         "method": "show_form",
         "title": title,
@@ -40,6 +47,8 @@ def send_show_form(
     }
     if data:
         resp["data"] = data
+    if icon:
+        resp["icon"] = icon
     send_response(resp)
 
 
@@ -59,13 +68,25 @@ def send_form_update(
     send_response(resp)
 
 
+def send_autocomplete(candidates: list[str]) -> None:
+    """Answer a form_autocomplete request with the candidate field values."""
+    send_response({"result": candidates})
+
+
 def send_binary_data(values: list[float], storage: str = "interleaved") -> None:
-    """Send binary float64 data following a JSON header."""
+    """Send binary float64 data following a JSON header.
+
+    The header includes the CRC-32 checksum of the data, which the host verifies.
+    """
+    # '<' for little-endian, 'd' for float64
+    payload = struct.pack(f"<{len(values)}d", *values)
+
     # JSON header
     header = {
         "type": "binary",
-        "length": len(values) * 8,  # bytes
+        "length": len(payload),  # bytes
         "storage": storage,
+        "checksum": zlib.crc32(payload),
     }
     sys.stdout.write(json.dumps(header) + "\n")
     sys.stdout.flush()
@@ -76,8 +97,6 @@ def send_binary_data(values: list[float], storage: str = "interleaved") -> None:
 
         msvcrt.setmode(sys.stdout.fileno(), os.O_BINARY)
 
-    # '<' for little-endian, 'd' for float64
-    payload = struct.pack(f"<{len(values)}d", *values)
     sys.stdout.buffer.write(payload)
     sys.stdout.buffer.flush()
 
@@ -87,9 +106,20 @@ def send_binary_data(values: list[float], storage: str = "interleaved") -> None:
         msvcrt.setmode(sys.stdout.fileno(), os.O_TEXT)
 
 
-def log(level: str, message: str) -> None:
-    """Send an asynchronous log message to the host."""
-    send_response({"method": "log", "level": level, "message": message})
+def log(level: str, message: str, **attrs: Any) -> None:
+    """Send an asynchronous log message to the host.
+
+    Keyword arguments are sent as structured attributes, e.g. trace_id.
+    """
+    msg: dict[str, Any] = {"method": "log", "level": level, "message": message}
+    if attrs:
+        msg["attrs"] = attrs
+    send_response(msg)
+
+
+def send_progress(pct: float, message: str = "") -> None:
+    """Report how far a long request, such as initialize, has got (0 to 100)."""
+    send_response({"method": "progress", "pct": pct, "message": message})
 
 
 def read_request() -> dict[str, Any] | None: